@@ -1,11 +1,27 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/sninjo/vera-identity-service/internal/auth"
 	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/health"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
 
 	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +31,16 @@ type App struct {
 	DB          *gorm.DB
 	Logger      *zap.Logger
 	AuthService auth.Service
+	GRPCServer  *grpc.Server
+
+	HealthService  health.Service
+	TracerProvider *sdktrace.TracerProvider
+
+	UserRetentionJob *user.RetentionJob
+	WebhookWorker    *webhook.Worker
+	OutboxDispatcher *outbox.Dispatcher
+
+	cancel context.CancelFunc
 }
 
 func NewApp(
@@ -23,6 +49,12 @@ func NewApp(
 	db *gorm.DB,
 	logger *zap.Logger,
 	authService auth.Service,
+	grpcServer *grpc.Server,
+	healthService health.Service,
+	tracerProvider *sdktrace.TracerProvider,
+	userRetentionJob *user.RetentionJob,
+	webhookWorker *webhook.Worker,
+	outboxDispatcher *outbox.Dispatcher,
 ) *App {
 	return &App{
 		Config:      config,
@@ -30,17 +62,150 @@ func NewApp(
 		DB:          db,
 		Logger:      logger,
 		AuthService: authService,
+		GRPCServer:  grpcServer,
+
+		HealthService:  healthService,
+		TracerProvider: tracerProvider,
+
+		UserRetentionJob: userRetentionJob,
+		WebhookWorker:    webhookWorker,
+		OutboxDispatcher: outboxDispatcher,
 	}
 }
 
 func (a *App) Close() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	// flushes spans that are still batched
+	if err := a.TracerProvider.Shutdown(context.Background()); err != nil {
+		a.Logger.Warn("failed to shut down tracing", zap.Error(err))
+	}
 	a.Logger.Sync()
 	d, _ := a.DB.DB()
 	d.Close()
 }
 
+// Run serves HTTP, and gRPC if GRPC_PORT is set, and runs the background
+// workers until the process gets SIGINT or SIGTERM, then shuts down
+// gracefully.
 func (a *App) Run() {
-	if err := a.Router.Run(a.Config.Domain + ":" + a.Config.Port); err != nil {
-		a.Logger.Fatal("failed to run server", zap.Error(err))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// a second signal kills the process without waiting for the drain
+	context.AfterFunc(ctx, stop)
+
+	listener, err := net.Listen("tcp", a.Config.Domain+":"+a.Config.Port)
+	if err != nil {
+		a.Logger.Fatal("failed to listen", zap.Error(err))
+	}
+	var grpcListener net.Listener
+	if a.Config.GRPCPort != "" {
+		grpcListener, err = net.Listen("tcp", a.Config.Domain+":"+a.Config.GRPCPort)
+		if err != nil {
+			a.Logger.Fatal("failed to listen for gRPC", zap.Error(err))
+		}
+	}
+	if err := a.Serve(ctx, listener, grpcListener); err != nil {
+		a.Logger.Error("server stopped with error", zap.Error(err))
+	}
+}
+
+// Serve serves HTTP on the listener, and gRPC on grpcListener unless it is
+// nil, and runs the background workers until ctx is done. It then fails
+// readiness for the drain period, stops accepting connections, and waits up to
+// the shutdown timeout for in-flight requests and then for the workers'
+// current batches. The DB pool stays open for them and is closed by Close.
+func (a *App) Serve(ctx context.Context, listener, grpcListener net.Listener) error {
+	workerCtx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){
+		a.UserRetentionJob.Run,
+		a.WebhookWorker.Run,
+		a.OutboxDispatcher.Run,
+	} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
 	}
+
+	server := &http.Server{
+		Handler:           a.Router,
+		ReadHeaderTimeout: a.Config.HTTPReadHeaderTimeout,
+		ReadTimeout:       a.Config.HTTPReadTimeout,
+		WriteTimeout:      a.Config.HTTPWriteTimeout,
+		IdleTimeout:       a.Config.HTTPIdleTimeout,
+		MaxHeaderBytes:    a.Config.HTTPMaxHeaderBytes,
+		ErrorLog:          zap.NewStdLog(a.Logger),
+	}
+	serveErr := make(chan error, 2)
+	go func() {
+		a.Logger.Info("server listening", zap.String("addr", listener.Addr().String()))
+		serveErr <- server.Serve(listener)
+	}()
+	if grpcListener != nil {
+		go func() {
+			a.Logger.Info("gRPC server listening", zap.String("addr", grpcListener.Addr().String()))
+			serveErr <- a.GRPCServer.Serve(grpcListener)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		if grpcListener != nil {
+			a.GRPCServer.Stop()
+		}
+		server.Close()
+		cancel()
+		workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	a.Logger.Info("shutting down", zap.Duration("drain_period", a.Config.ShutdownDrainPeriod))
+	a.HealthService.Drain()
+	// clients reconnect to other instances instead of reusing this one
+	server.SetKeepAlivesEnabled(false)
+	time.Sleep(a.Config.ShutdownDrainPeriod)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancelShutdown()
+	grpcStopped := make(chan struct{})
+	if grpcListener != nil {
+		go func() {
+			a.GRPCServer.GracefulStop()
+			close(grpcStopped)
+		}()
+	}
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		err = errors.Join(errors.New("in-flight requests did not finish"), err, server.Close())
+	}
+	if grpcListener != nil {
+		// GracefulStop has no deadline of its own
+		select {
+		case <-grpcStopped:
+		case <-shutdownCtx.Done():
+			a.GRPCServer.Stop()
+			err = errors.Join(err, errors.New("in-flight gRPC calls did not finish"))
+		}
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		err = errors.Join(err, errors.New("background workers did not finish"))
+	}
+
+	a.Logger.Info("server stopped")
+	return err
 }
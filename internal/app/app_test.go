@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/health"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type fakeHealthService struct {
+	draining atomic.Bool
+}
+
+func (f *fakeHealthService) Ready(ctx context.Context) *health.Report {
+	return &health.Report{Status: health.StatusOK}
+}
+func (f *fakeHealthService) Drain() {
+	f.draining.Store(true)
+}
+
+// newTestApp returns an app with the given routes whose background workers
+// are all disabled.
+func newTestApp(cfg *config.Config, routes func(r *gin.Engine)) (*App, *fakeHealthService) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes(r)
+
+	logger := zap.NewNop()
+	healthService := &fakeHealthService{}
+	return &App{
+		Config:           cfg,
+		Router:           r,
+		Logger:           logger,
+		HealthService:    healthService,
+		UserRetentionJob: user.NewRetentionJob(cfg, nil, logger),
+		WebhookWorker:    webhook.NewWorker(cfg, nil, logger),
+		OutboxDispatcher: outbox.NewDispatcher(cfg, nil, nil, logger),
+	}, healthService
+}
+
+func serve(t *testing.T, a *App) (string, context.CancelFunc, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Serve(ctx, listener, nil)
+	}()
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+func TestApp_Serve_DrainsInFlightRequests(t *testing.T) {
+	// Arrange
+	started := make(chan struct{})
+	a, healthService := newTestApp(&config.Config{
+		ShutdownDrainPeriod: 50 * time.Millisecond,
+		ShutdownTimeout:     time.Second,
+	}, func(r *gin.Engine) {
+		r.GET("/auth/callback", func(c *gin.Context) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			c.Status(http.StatusFound)
+		})
+	})
+	url, stop, done := serve(t, a)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Get(url + "/auth/callback")
+		assert.NoError(t, err)
+		responses <- resp
+	}()
+	<-started
+
+	// Act
+	stop()
+
+	// Assert
+	resp := <-responses
+	require.NotNil(t, resp)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.True(t, healthService.draining.Load())
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return")
+	}
+	_, err := client.Get(url + "/auth/callback")
+	assert.Error(t, err)
+}
+
+func TestApp_Serve_ShutdownTimeout(t *testing.T) {
+	// Arrange
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	a, _ := newTestApp(&config.Config{
+		ShutdownTimeout: 50 * time.Millisecond,
+	}, func(r *gin.Engine) {
+		r.GET("/slow", func(c *gin.Context) {
+			close(started)
+			<-release
+		})
+	})
+	url, stop, done := serve(t, a)
+	go http.Get(url + "/slow")
+	<-started
+
+	// Act
+	stop()
+
+	// Assert
+	select {
+	case err := <-done:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "in-flight requests did not finish")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return")
+	}
+}
+
+func TestApp_Serve_MaxHeaderBytes(t *testing.T) {
+	// Arrange
+	a, _ := newTestApp(&config.Config{
+		HTTPMaxHeaderBytes: 1024,
+	}, func(r *gin.Engine) {
+		r.GET("/livez", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	})
+	url, stop, done := serve(t, a)
+	defer func() {
+		stop()
+		<-done
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, url+"/livez", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Padding", strings.Repeat("a", 8<<10))
+
+	// Act
+	resp, err := http.DefaultClient.Do(req)
+
+	// Assert
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
+func TestApp_Serve_GRPC(t *testing.T) {
+	// Arrange
+	a, _ := newTestApp(&config.Config{
+		ShutdownTimeout: time.Second,
+	}, func(r *gin.Engine) {})
+	a.GRPCServer = grpc.NewServer()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Serve(ctx, listener, grpcListener)
+	}()
+
+	conn, err := grpc.NewClient(grpcListener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := authv3.NewAuthorizationClient(conn)
+
+	// Act
+	_, served := client.Check(context.Background(), &authv3.CheckRequest{})
+	stop()
+
+	// Assert
+	// no service is registered, so the server answers Unimplemented
+	assert.Equal(t, codes.Unimplemented, status.Code(served))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return")
+	}
+	_, err = client.Check(context.Background(), &authv3.CheckRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/extauthz"
+	"github.com/sninjo/vera-identity-service/internal/rpc"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"google.golang.org/grpc"
+)
+
+// NewGRPCServer registers the gRPC services, which are served only if
+// GRPC_PORT is set. Calls are logged, then authenticated, then rate
+// limited, then audited.
+func NewGRPCServer(
+	loggingInterceptor rpc.LoggingInterceptor,
+	authInterceptor rpc.AuthInterceptor,
+	rateLimitInterceptor rpc.RateLimitInterceptor,
+	auditInterceptor rpc.AuditInterceptor,
+	extAuthzServer *extauthz.Server,
+	userServer *rpc.UserServer,
+	authServer *rpc.AuthServer,
+) *grpc.Server {
+	interceptors := []rpc.Interceptor{
+		rpc.Interceptor(loggingInterceptor),
+		rpc.Interceptor(authInterceptor),
+		// limited before auditing so that a flood does not flood the audit log too
+		rpc.Interceptor(rateLimitInterceptor),
+		rpc.Interceptor(auditInterceptor),
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(rpc.UnaryInterceptor(interceptors...)),
+		grpc.StreamInterceptor(rpc.StreamInterceptor(interceptors...)),
+	)
+	authv3.RegisterAuthorizationServer(server, extAuthzServer)
+	identityv1.RegisterUserServiceServer(server, userServer)
+	identityv1.RegisterAuthServiceServer(server, authServer)
+	return server
+}
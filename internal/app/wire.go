@@ -4,33 +4,98 @@
 package app
 
 import (
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/client"
 	"github.com/sninjo/vera-identity-service/internal/config"
 	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/internal/errordoc"
+	"github.com/sninjo/vera-identity-service/internal/extauthz"
+	"github.com/sninjo/vera-identity-service/internal/health"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
 	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
 	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/pat"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
 	"github.com/sninjo/vera-identity-service/internal/router"
+	"github.com/sninjo/vera-identity-service/internal/rpc"
 	"github.com/sninjo/vera-identity-service/internal/tool"
+	"github.com/sninjo/vera-identity-service/internal/tracing"
 	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
 
 	"github.com/google/wire"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func InitApp() (*App, error) {
 	wire.Build(
 		logger.NewLogger,
 		config.NewConfig,
+		tracing.NewTracerProvider,
+		wire.Bind(new(trace.TracerProvider), new(*sdktrace.TracerProvider)),
 		db.NewDatabase,
+		metrics.NewMetrics,
+		middleware.NewTracingMiddleware,
+		middleware.NewMetricsMiddleware,
 		middleware.NewHTTPMiddleware,
 		middleware.NewCORSMiddleware,
+		middleware.NewTokenVerifier,
 		middleware.NewAuthMiddleware,
+		ratelimit.NewStore,
+		ratelimit.NewMiddleware,
 		tool.NewHandler,
 		auth.NewService,
 		auth.NewHandler,
+		audit.NewRepository,
+		audit.NewService,
+		lockout.NewRepository,
+		lockout.NewService,
+		lockout.NewHandler,
+		client.NewRepository,
+		client.NewService,
+		wire.Bind(new(middleware.ClientChecker), new(client.Service)),
+		client.NewHandler,
+		pat.NewRepository,
+		pat.NewService,
+		wire.Bind(new(middleware.PersonalAccessTokenAuthenticator), new(pat.Service)),
+		pat.NewHandler,
+		audit.NewMiddleware,
+		audit.NewHandler,
+		webhook.NewRepository,
+		webhook.NewService,
+		wire.Bind(new(outbox.WebhookSink), new(webhook.Service)),
+		webhook.NewHandler,
+		webhook.NewWorker,
+		outbox.NewRepository,
+		outbox.NewSinks,
+		outbox.NewDispatcher,
+		attribute.NewRepository,
+		attribute.NewService,
+		attribute.NewHandler,
 		user.NewRepository,
 		user.NewService,
+		wire.Bind(new(middleware.UserStatusChecker), new(user.Service)),
+		wire.Bind(new(pat.UserGetter), new(user.Service)),
 		user.NewHandler,
+		user.NewRetentionJob,
+		errordoc.NewHandler,
+		health.NewRepository,
+		health.NewService,
+		health.NewHandler,
 		router.NewRouter,
+		extauthz.NewServer,
+		rpc.NewLoggingInterceptor,
+		rpc.NewAuthInterceptor,
+		rpc.NewRateLimitInterceptor,
+		rpc.NewAuditInterceptor,
+		rpc.NewUserServer,
+		rpc.NewAuthServer,
+		NewGRPCServer,
 		NewApp,
 	)
 	return &App{}, nil
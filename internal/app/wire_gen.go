@@ -7,14 +7,28 @@
 package app
 
 import (
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/client"
 	"github.com/sninjo/vera-identity-service/internal/config"
 	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/internal/errordoc"
+	"github.com/sninjo/vera-identity-service/internal/extauthz"
+	"github.com/sninjo/vera-identity-service/internal/health"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
 	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
 	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/pat"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
 	"github.com/sninjo/vera-identity-service/internal/router"
+	"github.com/sninjo/vera-identity-service/internal/rpc"
 	"github.com/sninjo/vera-identity-service/internal/tool"
+	"github.com/sninjo/vera-identity-service/internal/tracing"
 	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
 )
 
 // Injectors from wire.go:
@@ -25,20 +39,78 @@ func InitApp() (*App, error) {
 		return nil, err
 	}
 	configConfig := config.NewConfig(zapLogger)
-	httpMiddleware := middleware.NewHTTPMiddleware(zapLogger)
+	tracerProvider, err := tracing.NewTracerProvider(configConfig, zapLogger)
+	if err != nil {
+		return nil, err
+	}
+	tracingMiddleware := middleware.NewTracingMiddleware(tracerProvider)
+	gormDB, err := db.NewDatabase(configConfig, tracerProvider)
+	if err != nil {
+		return nil, err
+	}
+	metricsMetrics, err := metrics.NewMetrics(gormDB)
+	if err != nil {
+		return nil, err
+	}
+	metricsMiddleware := middleware.NewMetricsMiddleware(metricsMetrics)
+	httpMiddleware := middleware.NewHTTPMiddleware(configConfig, zapLogger)
 	corsMiddleware := middleware.NewCORSMiddleware(configConfig)
-	authMiddleware := middleware.NewAuthMiddleware(configConfig)
+	repository := user.NewRepository(gormDB)
+	attributeRepository := attribute.NewRepository(gormDB)
+	service := attribute.NewService(attributeRepository)
+	userService := user.NewService(repository, service)
+	clientRepository := client.NewRepository(gormDB)
+	clientService := client.NewService(clientRepository)
+	patRepository := pat.NewRepository(gormDB)
+	patService := pat.NewService(configConfig, patRepository, userService)
+	tokenVerifier := middleware.NewTokenVerifier(configConfig, userService, clientService, patService)
+	authMiddleware := middleware.NewAuthMiddleware(tokenVerifier)
+	store, err := ratelimit.NewStore(configConfig, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	ratelimitMiddleware := ratelimit.NewMiddleware(configConfig, store)
 	handler := tool.NewHandler()
-	gormDB, err := db.NewDatabase(configConfig)
+	authService := auth.NewService(configConfig, userService, service, tokenVerifier, tracerProvider)
+	lockoutRepository := lockout.NewRepository(gormDB)
+	auditRepository := audit.NewRepository(gormDB)
+	auditService := audit.NewService(auditRepository)
+	lockoutService := lockout.NewService(configConfig, lockoutRepository, auditService)
+	authHandler := auth.NewHandler(configConfig, authService, userService, lockoutService, tokenVerifier, metricsMetrics)
+	userHandler := user.NewHandler(userService)
+	attributeHandler := attribute.NewHandler(service)
+	auditMiddleware := audit.NewMiddleware(auditService, zapLogger)
+	auditHandler := audit.NewHandler(auditService)
+	webhookRepository := webhook.NewRepository(gormDB)
+	webhookService := webhook.NewService(configConfig, webhookRepository, zapLogger)
+	webhookHandler := webhook.NewHandler(webhookService)
+	lockoutHandler := lockout.NewHandler(lockoutService)
+	clientHandler := client.NewHandler(configConfig, clientService, authService, metricsMetrics)
+	patHandler := pat.NewHandler(patService)
+	errordocHandler := errordoc.NewHandler()
+	healthRepository := health.NewRepository(gormDB)
+	healthService := health.NewService(configConfig, healthRepository, zapLogger)
+	healthHandler := health.NewHandler(healthService)
+	engine, err := router.NewRouter(configConfig, tracingMiddleware, metricsMiddleware, httpMiddleware, corsMiddleware, authMiddleware, ratelimitMiddleware, handler, authHandler, userHandler, attributeHandler, auditMiddleware, auditHandler, webhookHandler, lockoutHandler, clientHandler, patHandler, errordocHandler, healthHandler, metricsMetrics)
 	if err != nil {
 		return nil, err
 	}
-	repository := user.NewRepository(gormDB)
-	service := user.NewService(repository)
-	authService := auth.NewService(configConfig, service)
-	authHandler := auth.NewHandler(configConfig, authService, service)
-	userHandler := user.NewHandler(service)
-	engine := router.NewRouter(httpMiddleware, corsMiddleware, authMiddleware, handler, authHandler, userHandler)
-	app := NewApp(configConfig, engine, gormDB, zapLogger, authService)
+	loggingInterceptor := rpc.NewLoggingInterceptor(zapLogger)
+	authInterceptor := rpc.NewAuthInterceptor(tokenVerifier)
+	rateLimitInterceptor := rpc.NewRateLimitInterceptor(configConfig, store)
+	auditInterceptor := rpc.NewAuditInterceptor(auditService, zapLogger)
+	server := extauthz.NewServer(configConfig, tokenVerifier, store, zapLogger)
+	userServer := rpc.NewUserServer(userService)
+	authServer := rpc.NewAuthServer(authService, tokenVerifier, metricsMetrics)
+	grpcServer := NewGRPCServer(loggingInterceptor, authInterceptor, rateLimitInterceptor, auditInterceptor, server, userServer, authServer)
+	retentionJob := user.NewRetentionJob(configConfig, userService, zapLogger)
+	worker := webhook.NewWorker(configConfig, webhookService, zapLogger)
+	outboxRepository := outbox.NewRepository(gormDB)
+	sinks, err := outbox.NewSinks(configConfig, webhookService)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher := outbox.NewDispatcher(configConfig, outboxRepository, sinks, zapLogger)
+	app := NewApp(configConfig, engine, gormDB, zapLogger, authService, grpcServer, healthService, tracerProvider, retentionJob, worker, dispatcher)
 	return app, nil
 }
@@ -11,16 +11,33 @@ import (
 type Response struct {
 	Code      string    `json:"code"`
 	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// AppError is an error with a code and a message that are safe to show to
+// the client. Details that must not leave the service, such as fingerprints
+// of rejected tokens or database errors, go in Internal, which is only
+// logged.
 type AppError struct {
-	Status int `json:"-"`
+	Status   int          `json:"-"`
+	Errors   []FieldError `json:"-"`
+	Internal string       `json:"-"`
 	Response
 }
 
 func (e *AppError) Error() string {
 	timestamp := e.Timestamp.Format(time.RFC3339)
-	return fmt.Sprintf("AppError{code: \"%s\", message: \"%s\", timestamp: \"%s\"}", e.Code, e.Message, timestamp)
+	if e.Internal == "" {
+		return fmt.Sprintf("AppError{code: \"%s\", message: \"%s\", timestamp: \"%s\"}", e.Code, e.Message, timestamp)
+	}
+	return fmt.Sprintf("AppError{code: \"%s\", message: \"%s\", internal: \"%s\", timestamp: \"%s\"}", e.Code, e.Message, e.Internal, timestamp)
+}
+
+// WithInternal attaches details for the logs and returns the error.
+func (e *AppError) WithInternal(internal string) *AppError {
+	e.Internal = internal
+	return e
 }
 
 var regexpErrorCode = regexp.MustCompile(`^(\d{3})_\d{2}_\d{3}$`)
@@ -54,11 +71,13 @@ func FromError(err error) *AppError {
 	if errors.As(err, &appErr) {
 		return appErr
 	} else {
+		// unexpected errors may carry queries or connection strings
 		return &AppError{
-			Status: 500,
+			Status:   500,
+			Internal: err.Error(),
 			Response: Response{
 				Code:      "unknown_error",
-				Message:   err.Error(),
+				Message:   "internal server error",
 				Timestamp: time.Now().UTC(),
 			},
 		}
@@ -5,16 +5,68 @@ package apperror
 
 const (
 	// auth
-	CodeInvalidOAuthCode    = "500_01_002"
-	CodeInvalidOAuthIdToken = "500_01_003"
-	CodeMissingUserInfo     = "500_01_004"
-	CodeInvalidRefreshToken = "401_01_005"
-	CodeInvalidAccessToken  = "401_01_006"
-	CodeInvalidTokenIssuer  = "401_01_007"
-	CodeInvalidAuthHeader   = "401_01_008"
-	CodeUserNotAuthorized   = "403_01_011"
+	CodeInvalidOAuthCode     = "500_01_002"
+	CodeInvalidOAuthIdToken  = "500_01_003"
+	CodeMissingUserInfo      = "500_01_004"
+	CodeInvalidRefreshToken  = "401_01_005"
+	CodeInvalidAccessToken   = "401_01_006"
+	CodeInvalidTokenIssuer   = "401_01_007"
+	CodeInvalidAuthHeader    = "401_01_008"
+	CodeUserNotAuthorized    = "403_01_011"
+	CodePermissionDenied     = "403_01_014"
+	CodeUserSuspended        = "403_01_015"
+	CodeUserPending          = "403_01_016"
+	CodeInvalidClient        = "401_01_035"
+	CodeUnsupportedGrantType = "400_01_037"
+	CodeInvalidScope         = "400_01_038"
+	CodeInsufficientScope    = "403_01_039"
 
 	// user
-	CodeUserNotFound   = "404_01_001"
-	CodeUserEmailInUse = "409_01_009"
+	CodeUserNotFound      = "404_01_001"
+	CodeUserEmailInUse    = "409_01_009"
+	CodeInvalidSuspension = "400_01_017"
+
+	// client
+	CodeClientNotFound = "404_01_036"
+
+	// personal access token
+	CodeTokenNotFound      = "404_01_040"
+	CodeInvalidTokenExpiry = "400_01_041"
+
+	// attribute
+	CodeInvalidUserAttributes      = "400_01_018"
+	CodeAttributeNotFound          = "404_01_019"
+	CodeAttributeExists            = "409_01_020"
+	CodeInvalidAttributeDefinition = "400_01_021"
+
+	// audit
+	CodeInvalidCursor = "400_01_022"
+
+	// webhook
+	CodeWebhookNotFound         = "404_01_023"
+	CodeInvalidWebhook          = "400_01_024"
+	CodeWebhookDeliveryNotFound = "404_01_025"
+
+	// request
+	CodeInvalidRequest = "400_01_026"
+	CodeInternalError  = "500_01_027"
+	CodeRouteNotFound  = "404_01_028"
+
+	// validation
+	CodeValidationFailed = "400_01_029"
+	CodeInvalidFieldType = "400_01_030"
+
+	// error documentation
+	CodeErrorCodeNotFound = "404_01_031"
+
+	// rate limit
+	CodeRateLimited = "429_01_032"
+
+	// lockout
+	CodeLoginLocked     = "423_01_033"
+	CodeLockoutNotFound = "404_01_034"
+
+	// user import
+	CodeInvalidImportFile       = "400_01_012"
+	CodeUnsupportedImportFormat = "415_01_013"
 )
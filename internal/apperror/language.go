@@ -0,0 +1,25 @@
+package apperror
+
+import (
+	"golang.org/x/text/language"
+)
+
+// supportedLanguages lists the languages of the message catalogs. The first
+// one is the fallback for clients that accept none of them.
+var supportedLanguages = []language.Tag{
+	language.English,
+	language.TraditionalChinese,
+}
+
+var languageMatcher = language.NewMatcher(supportedLanguages)
+
+// MatchLanguage picks the supported language that best fits an
+// Accept-Language header.
+func MatchLanguage(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return supportedLanguages[0]
+	}
+	_, index, _ := languageMatcher.Match(tags...)
+	return supportedLanguages[index]
+}
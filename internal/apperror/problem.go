@@ -0,0 +1,55 @@
+package apperror
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 representation of an AppError. The code, request ID
+// and timestamp are extension members.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Problem describes the error as it occurred for the given request path, with
+// the title and field errors in the given language. Codes outside the
+// registry, such as unknown_error, have no documentation page and use the
+// about:blank type.
+func (e *AppError) Problem(instance string, lang language.Tag) *Problem {
+	problemType := "about:blank"
+	title := http.StatusText(e.Status)
+	if definition, ok := LookupDefinition(e.Code); ok {
+		problemType = "/errors/" + e.Code
+		title = definition.Localize(lang).Title
+	}
+
+	var fieldErrors []FieldError
+	for _, fieldError := range e.Errors {
+		fieldError.Message = fieldError.localize(lang)
+		fieldErrors = append(fieldErrors, fieldError)
+	}
+
+	return &Problem{
+		Type:      problemType,
+		Title:     title,
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: e.RequestID,
+		Timestamp: e.Timestamp,
+		Errors:    fieldErrors,
+	}
+}
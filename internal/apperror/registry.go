@@ -0,0 +1,690 @@
+package apperror
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Text is the documentation of an error code in one language.
+type Text struct {
+	Title       string
+	Description string
+	Remediation string
+}
+
+// Definition documents an error code. The ID is a readable name that stays
+// stable even if the code itself ever has to change.
+type Definition struct {
+	ID     string
+	Code   string
+	Status int
+	Text   map[language.Tag]Text
+}
+
+// Localize returns the documentation in the given language, falling back to
+// English.
+func (d *Definition) Localize(lang language.Tag) Text {
+	if text, ok := d.Text[lang]; ok {
+		return text
+	}
+	return d.Text[supportedLanguages[0]]
+}
+
+// Definitions returns every registered error code ordered by serial number.
+func Definitions() []Definition {
+	return slices.Clone(definitions)
+}
+
+// LookupDefinition returns the documentation of a registered error code.
+func LookupDefinition(code string) (*Definition, bool) {
+	for i := range definitions {
+		if definitions[i].Code == code {
+			return &definitions[i], true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	slices.SortFunc(definitions, func(a, b Definition) int {
+		return strings.Compare(serial(a.Code), serial(b.Code))
+	})
+}
+
+// serial returns the serial number part of an error code, which is unique
+// across all codes regardless of their status.
+func serial(code string) string {
+	return code[strings.LastIndex(code, "_")+1:]
+}
+
+var definitions = []Definition{
+	// auth
+	{
+		ID: "invalid_oauth_code", Code: CodeInvalidOAuthCode, Status: http.StatusInternalServerError,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid OAuth code",
+				Description: "The authorization code returned by Google could not be exchanged for tokens.",
+				Remediation: "Start the login again; authorization codes are single-use and expire quickly.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的 OAuth 授權碼",
+				Description: "無法以 Google 回傳的授權碼換取權杖。",
+				Remediation: "請重新登入；授權碼只能使用一次且很快就會過期。",
+			},
+		},
+	},
+	{
+		ID: "invalid_oauth_id_token", Code: CodeInvalidOAuthIdToken, Status: http.StatusInternalServerError,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid OAuth ID token",
+				Description: "The id_token returned by Google could not be parsed.",
+				Remediation: "Start the login again. If it keeps failing, contact the identity service team.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的 OAuth ID 權杖",
+				Description: "無法解析 Google 回傳的 id_token。",
+				Remediation: "請重新登入；若持續失敗，請聯絡身分服務團隊。",
+			},
+		},
+	},
+	{
+		ID: "missing_user_info", Code: CodeMissingUserInfo, Status: http.StatusInternalServerError,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Missing user info",
+				Description: "The Google account did not share its subject, name, email or picture.",
+				Remediation: "Grant the requested profile and email scopes when signing in with Google.",
+			},
+			language.TraditionalChinese: {
+				Title:       "缺少使用者資訊",
+				Description: "Google 帳號未提供識別碼、名稱、電子郵件或頭像。",
+				Remediation: "以 Google 登入時，請同意提供個人資料與電子郵件的權限。",
+			},
+		},
+	},
+	{
+		ID: "invalid_refresh_token", Code: CodeInvalidRefreshToken, Status: http.StatusUnauthorized,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid refresh token",
+				Description: "The refresh token cookie is missing, expired or was not issued by this service.",
+				Remediation: "Log in again to obtain a new refresh token.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的更新權杖",
+				Description: "更新權杖 Cookie 不存在、已過期，或不是由本服務核發。",
+				Remediation: "請重新登入以取得新的更新權杖。",
+			},
+		},
+	},
+	{
+		ID: "invalid_access_token", Code: CodeInvalidAccessToken, Status: http.StatusUnauthorized,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid access token",
+				Description: "The bearer token is malformed, expired, revoked or has an invalid signature.",
+				Remediation: "Obtain a new access token with POST /auth/refresh and retry the request, or create a new personal access token.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的存取權杖",
+				Description: "Bearer 權杖格式錯誤、已過期、已撤銷或簽章無效。",
+				Remediation: "請透過 POST /auth/refresh 取得新的存取權杖後重試，或建立新的個人存取權杖。",
+			},
+		},
+	},
+	{
+		ID: "invalid_token_issuer", Code: CodeInvalidTokenIssuer, Status: http.StatusUnauthorized,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid token issuer",
+				Description: "The token was not issued by the Vera identity service.",
+				Remediation: "Use an access token issued by this service rather than one from another provider.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的權杖核發者",
+				Description: "此權杖並非由 Vera 身分服務核發。",
+				Remediation: "請使用本服務核發的存取權杖，而非其他提供者的權杖。",
+			},
+		},
+	},
+	{
+		ID: "invalid_auth_header", Code: CodeInvalidAuthHeader, Status: http.StatusUnauthorized,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid authorization header",
+				Description: "The Authorization header is missing or does not use the Bearer scheme.",
+				Remediation: "Send the access token as \"Authorization: Bearer <token>\".",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的授權標頭",
+				Description: "缺少 Authorization 標頭，或未使用 Bearer 格式。",
+				Remediation: "請以「Authorization: Bearer <token>」傳送存取權杖。",
+			},
+		},
+	},
+	{
+		ID: "user_not_authorized", Code: CodeUserNotAuthorized, Status: http.StatusForbidden,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "User not authorized",
+				Description: "No account exists for this Google identity; users must be created by an administrator first.",
+				Remediation: "Ask an administrator to create an account for your email address.",
+			},
+			language.TraditionalChinese: {
+				Title:       "使用者未獲授權",
+				Description: "此 Google 身分沒有對應的帳號；使用者必須先由管理員建立。",
+				Remediation: "請聯絡管理員為您的電子郵件地址建立帳號。",
+			},
+		},
+	},
+	{
+		ID: "permission_denied", Code: CodePermissionDenied, Status: http.StatusForbidden,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Permission denied",
+				Description: "The caller does not hold any of the roles the endpoint requires.",
+				Remediation: "Ask an administrator to grant the required role, then log in again to refresh your token.",
+			},
+			language.TraditionalChinese: {
+				Title:       "權限不足",
+				Description: "呼叫者不具備此端點所需的任何角色。",
+				Remediation: "請管理員授予所需角色後，重新登入以更新權杖。",
+			},
+		},
+	},
+	{
+		ID: "user_suspended", Code: CodeUserSuspended, Status: http.StatusForbidden,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "User suspended",
+				Description: "The account has been suspended by an administrator.",
+				Remediation: "Wait for the suspension to expire or ask an administrator to reactivate the account.",
+			},
+			language.TraditionalChinese: {
+				Title:       "使用者已停權",
+				Description: "此帳號已被管理員停權。",
+				Remediation: "請等待停權期滿，或請管理員重新啟用帳號。",
+			},
+		},
+	},
+	{
+		ID: "user_pending", Code: CodeUserPending, Status: http.StatusForbidden,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "User pending activation",
+				Description: "The account has not been activated yet.",
+				Remediation: "Ask an administrator to activate the account.",
+			},
+			language.TraditionalChinese: {
+				Title:       "使用者尚未啟用",
+				Description: "此帳號尚未啟用。",
+				Remediation: "請聯絡管理員啟用帳號。",
+			},
+		},
+	},
+	{
+		ID: "invalid_client", Code: CodeInvalidClient, Status: http.StatusUnauthorized,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid client",
+				Description: "The client credentials are missing or do not match a registered client.",
+				Remediation: "Send the client ID and secret with HTTP Basic authentication, or as client_id and client_secret form parameters.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的用戶端",
+				Description: "缺少用戶端憑證，或與已註冊的用戶端不符。",
+				Remediation: "請以 HTTP Basic 驗證，或以 client_id 與 client_secret 表單參數傳送用戶端 ID 與密鑰。",
+			},
+		},
+	},
+
+	{
+		ID: "unsupported_grant_type", Code: CodeUnsupportedGrantType, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Unsupported grant type",
+				Description: "The token endpoint only issues tokens with the client credentials grant.",
+				Remediation: "Send grant_type=client_credentials; users get their tokens by logging in.",
+			},
+			language.TraditionalChinese: {
+				Title:       "不支援的授權類型",
+				Description: "權杖端點只以用戶端憑證授權核發權杖。",
+				Remediation: "請傳送 grant_type=client_credentials；使用者的權杖須透過登入取得。",
+			},
+		},
+	},
+	{
+		ID: "invalid_scope", Code: CodeInvalidScope, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid scope",
+				Description: "A scope is malformed, unknown or not allowed for the client.",
+				Remediation: "Request only scopes registered for the client, or omit scope to get all of them. Scopes may not contain spaces, quotes or backslashes. Personal access tokens take users:read, users:write and admin.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的範圍",
+				Description: "範圍格式錯誤、不存在，或此用戶端不得使用該範圍。",
+				Remediation: "請只要求為此用戶端註冊的範圍，或省略 scope 以取得全部範圍。範圍不得包含空白、引號或反斜線。個人存取權杖可使用 users:read、users:write 與 admin。",
+			},
+		},
+	},
+	{
+		ID: "insufficient_scope", Code: CodeInsufficientScope, Status: http.StatusForbidden,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Insufficient scope",
+				Description: "The access token of the client, or the personal access token, does not carry the scope the endpoint requires.",
+				Remediation: "Ask an administrator to allow the scope for the client, then request a new token with it, or create a personal access token with the scope.",
+			},
+			language.TraditionalChinese: {
+				Title:       "範圍不足",
+				Description: "用戶端的存取權杖或個人存取權杖不具備此端點所需的範圍。",
+				Remediation: "請管理員為此用戶端加入該範圍，再以該範圍取得新的權杖，或建立具備該範圍的個人存取權杖。",
+			},
+		},
+	},
+
+	// user
+	{
+		ID: "user_not_found", Code: CodeUserNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "User not found",
+				Description: "No user exists with the given ID, or it has been deleted.",
+				Remediation: "Check the user ID; deleted users are only listed with ?deleted=true.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到使用者",
+				Description: "指定 ID 的使用者不存在或已被刪除。",
+				Remediation: "請確認使用者 ID；已刪除的使用者只會在 ?deleted=true 時列出。",
+			},
+		},
+	},
+	{
+		ID: "user_email_in_use", Code: CodeUserEmailInUse, Status: http.StatusConflict,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "User email already in use",
+				Description: "Another active user already has this email address; emails are compared case-insensitively.",
+				Remediation: "Use a different email address or update the existing user instead.",
+			},
+			language.TraditionalChinese: {
+				Title:       "電子郵件已被使用",
+				Description: "已有其他使用中的帳號使用此電子郵件地址；比對時不區分大小寫。",
+				Remediation: "請改用其他電子郵件地址，或更新現有的使用者。",
+			},
+		},
+	},
+	{
+		ID: "invalid_suspension", Code: CodeInvalidSuspension, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid suspension",
+				Description: "The user cannot be suspended or reactivated in its current state, or the expiry is in the past.",
+				Remediation: "Check the user status and give an expiry in the future, if any.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的停權操作",
+				Description: "使用者目前的狀態無法停權或重新啟用，或停權期限已是過去的時間。",
+				Remediation: "請確認使用者狀態；若指定停權期限，必須是未來的時間。",
+			},
+		},
+	},
+
+	// client
+	{
+		ID: "client_not_found", Code: CodeClientNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Client not found",
+				Description: "No service client with the given client ID exists.",
+				Remediation: "List the registered clients with GET /clients.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到用戶端",
+				Description: "沒有此用戶端 ID 的服務用戶端。",
+				Remediation: "請以 GET /clients 列出已註冊的用戶端。",
+			},
+		},
+	},
+
+	// personal access token
+	{
+		ID: "token_not_found", Code: CodeTokenNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Token not found",
+				Description: "The caller has no personal access token with the given ID, or it has been revoked.",
+				Remediation: "List your tokens with GET /me/tokens.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到權杖",
+				Description: "呼叫者沒有此 ID 的個人存取權杖，或該權杖已被撤銷。",
+				Remediation: "請以 GET /me/tokens 列出您的權杖。",
+			},
+		},
+	},
+	{
+		ID: "invalid_token_expiry", Code: CodeInvalidTokenExpiry, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid token expiry",
+				Description: "The expiry of a personal access token is in the past or beyond the longest allowed lifetime.",
+				Remediation: "Choose an expiry in the future and within PERSONAL_ACCESS_TOKEN_MAX_TTL from now.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的權杖到期時間",
+				Description: "個人存取權杖的到期時間已過，或超過允許的最長效期。",
+				Remediation: "請選擇未來且在 PERSONAL_ACCESS_TOKEN_MAX_TTL 以內的到期時間。",
+			},
+		},
+	},
+
+	// attribute
+	{
+		ID: "invalid_user_attributes", Code: CodeInvalidUserAttributes, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid user attributes",
+				Description: "An attribute is not defined in the registry or its value does not match the definition.",
+				Remediation: "List the definitions with GET /attributes and send values of the defined type.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的使用者屬性",
+				Description: "屬性未在登錄表中定義，或其值不符合定義。",
+				Remediation: "請以 GET /attributes 查看定義，並傳送符合型別的值。",
+			},
+		},
+	},
+	{
+		ID: "attribute_not_found", Code: CodeAttributeNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Attribute not found",
+				Description: "No attribute is defined with the given key.",
+				Remediation: "Check the key against GET /attributes.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到屬性",
+				Description: "沒有以此鍵值定義的屬性。",
+				Remediation: "請以 GET /attributes 確認鍵值。",
+			},
+		},
+	},
+	{
+		ID: "attribute_exists", Code: CodeAttributeExists, Status: http.StatusConflict,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Attribute already defined",
+				Description: "An attribute with the given key is already defined.",
+				Remediation: "Update the existing definition with PUT /attributes/{key} instead.",
+			},
+			language.TraditionalChinese: {
+				Title:       "屬性已定義",
+				Description: "已有以此鍵值定義的屬性。",
+				Remediation: "請改以 PUT /attributes/{key} 更新現有定義。",
+			},
+		},
+	},
+	{
+		ID: "invalid_attribute_definition", Code: CodeInvalidAttributeDefinition, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid attribute definition",
+				Description: "The key or claim name is not allowed, or the options do not fit the attribute type.",
+				Remediation: "Use lowercase keys and claims, and only give enum and max_length for string attributes.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的屬性定義",
+				Description: "鍵值或宣告名稱不被允許，或選項與屬性型別不符。",
+				Remediation: "鍵值與宣告名稱請使用小寫，且僅字串屬性可設定 enum 與 max_length。",
+			},
+		},
+	},
+
+	// audit
+	{
+		ID: "invalid_cursor", Code: CodeInvalidCursor, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid cursor",
+				Description: "The pagination cursor is malformed.",
+				Remediation: "Pass the next_cursor value of the previous page unchanged.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的分頁游標",
+				Description: "分頁游標格式錯誤。",
+				Remediation: "請原封不動地傳入上一頁的 next_cursor。",
+			},
+		},
+	},
+
+	// webhook
+	{
+		ID: "webhook_not_found", Code: CodeWebhookNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Webhook not found",
+				Description: "No webhook subscription exists with the given ID.",
+				Remediation: "Check the ID against GET /webhooks.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到 Webhook",
+				Description: "指定 ID 的 Webhook 訂閱不存在。",
+				Remediation: "請以 GET /webhooks 確認 ID。",
+			},
+		},
+	},
+	{
+		ID: "invalid_webhook", Code: CodeInvalidWebhook, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid webhook",
+				Description: "The URL is not an absolute http(s) URL, an event type is unknown, or the secret is too short.",
+				Remediation: "Subscribe an absolute URL to known event types and use a secret of at least 16 characters.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的 Webhook",
+				Description: "網址不是完整的 http(s) 網址、事件類型不存在，或密鑰太短。",
+				Remediation: "請以完整網址訂閱已知的事件類型，並使用至少 16 個字元的密鑰。",
+			},
+		},
+	},
+	{
+		ID: "webhook_delivery_not_found", Code: CodeWebhookDeliveryNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Webhook delivery not found",
+				Description: "The webhook has no delivery with the given ID.",
+				Remediation: "Check the ID against GET /webhooks/{id}/deliveries.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到 Webhook 傳送紀錄",
+				Description: "此 Webhook 沒有指定 ID 的傳送紀錄。",
+				Remediation: "請以 GET /webhooks/{id}/deliveries 確認 ID。",
+			},
+		},
+	},
+
+	// request
+	{
+		ID: "invalid_request", Code: CodeInvalidRequest, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid request",
+				Description: "The request body, path or query could not be parsed.",
+				Remediation: "Send well-formed JSON with the Content-Type application/json and check the path parameters.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的請求",
+				Description: "無法解析請求的內容、路徑或查詢參數。",
+				Remediation: "請以 application/json 傳送格式正確的 JSON，並確認路徑參數。",
+			},
+		},
+	},
+	{
+		ID: "internal_error", Code: CodeInternalError, Status: http.StatusInternalServerError,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Internal error",
+				Description: "The service failed unexpectedly while handling the request.",
+				Remediation: "Retry later. If it persists, report the request_id to the identity service team.",
+			},
+			language.TraditionalChinese: {
+				Title:       "內部錯誤",
+				Description: "服務在處理請求時發生非預期的錯誤。",
+				Remediation: "請稍後重試；若持續發生，請將 request_id 提供給身分服務團隊。",
+			},
+		},
+	},
+	{
+		ID: "route_not_found", Code: CodeRouteNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Route not found",
+				Description: "No endpoint matches the method and path of the request.",
+				Remediation: "Check the method and path against the API documentation at /docs.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到路由",
+				Description: "沒有符合請求方法與路徑的端點。",
+				Remediation: "請對照 /docs 的 API 文件確認方法與路徑。",
+			},
+		},
+	},
+
+	// validation
+	{
+		ID: "validation_failed", Code: CodeValidationFailed, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Validation failed",
+				Description: "One or more request fields failed validation; they are listed in errors.",
+				Remediation: "Correct the fields listed in errors and retry.",
+			},
+			language.TraditionalChinese: {
+				Title:       "驗證失敗",
+				Description: "一個或多個請求欄位未通過驗證，詳列於 errors。",
+				Remediation: "請修正 errors 中列出的欄位後重試。",
+			},
+		},
+	},
+	{
+		ID: "invalid_field_type", Code: CodeInvalidFieldType, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid field type",
+				Description: "A JSON value has the wrong type, e.g. a string where a number is expected.",
+				Remediation: "Send the field listed in errors with the expected type.",
+			},
+			language.TraditionalChinese: {
+				Title:       "欄位型別錯誤",
+				Description: "JSON 值的型別錯誤，例如應為數字卻傳入字串。",
+				Remediation: "請以預期的型別傳送 errors 中列出的欄位。",
+			},
+		},
+	},
+
+	// error documentation
+	{
+		ID: "error_code_not_found", Code: CodeErrorCodeNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Error code not found",
+				Description: "No error code is registered under the given code.",
+				Remediation: "List all codes with GET /errors.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到錯誤代碼",
+				Description: "沒有以此代碼登錄的錯誤。",
+				Remediation: "請以 GET /errors 列出所有錯誤代碼。",
+			},
+		},
+	},
+
+	// rate limit
+	{
+		ID: "rate_limited", Code: CodeRateLimited, Status: http.StatusTooManyRequests,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Too many requests",
+				Description: "The client, or the user it acts for, has used up its request quota for this endpoint.",
+				Remediation: "Wait for the number of seconds in the Retry-After header before trying again.",
+			},
+			language.TraditionalChinese: {
+				Title:       "請求過於頻繁",
+				Description: "用戶端或其代表的使用者已用完此端點的請求配額。",
+				Remediation: "請等待 Retry-After 標頭所示的秒數後再試。",
+			},
+		},
+	},
+
+	// lockout
+	{
+		ID: "login_locked", Code: CodeLoginLocked, Status: http.StatusLocked,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Login temporarily locked",
+				Description: "Too many logins for this email address or from this network failed, so logins are locked for a while; every further lockout lasts twice as long.",
+				Remediation: "Wait for the number of seconds in the Retry-After header, or ask an administrator to unlock the login.",
+			},
+			language.TraditionalChinese: {
+				Title:       "登入暫時鎖定",
+				Description: "此電子郵件地址或此網路的登入失敗次數過多，登入已暫時鎖定；每次再被鎖定的時間都會加倍。",
+				Remediation: "請等待 Retry-After 標頭所示的秒數，或請管理員解除鎖定。",
+			},
+		},
+	},
+	{
+		ID: "lockout_not_found", Code: CodeLockoutNotFound, Status: http.StatusNotFound,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Lockout not found",
+				Description: "No failed logins are recorded for the given email address or IP.",
+				Remediation: "List the current lockouts with GET /lockouts.",
+			},
+			language.TraditionalChinese: {
+				Title:       "找不到登入鎖定",
+				Description: "此電子郵件地址或 IP 沒有登入失敗的紀錄。",
+				Remediation: "請以 GET /lockouts 列出目前的登入鎖定。",
+			},
+		},
+	},
+
+	// user import
+	{
+		ID: "invalid_import_file", Code: CodeInvalidImportFile, Status: http.StatusBadRequest,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Invalid import file",
+				Description: "The import file could not be parsed, e.g. a CSV without an email column.",
+				Remediation: "Upload a CSV with an email header or newline-delimited JSON objects.",
+			},
+			language.TraditionalChinese: {
+				Title:       "無效的匯入檔案",
+				Description: "無法解析匯入檔案，例如 CSV 缺少 email 欄位。",
+				Remediation: "請上傳含 email 標題列的 CSV，或以換行分隔的 JSON 物件。",
+			},
+		},
+	},
+	{
+		ID: "unsupported_import_format", Code: CodeUnsupportedImportFormat, Status: http.StatusUnsupportedMediaType,
+		Text: map[language.Tag]Text{
+			language.English: {
+				Title:       "Unsupported import format",
+				Description: "The Content-Type of the import is neither CSV nor NDJSON.",
+				Remediation: "Send the file as text/csv or application/x-ndjson.",
+			},
+			language.TraditionalChinese: {
+				Title:       "不支援的匯入格式",
+				Description: "匯入內容的 Content-Type 不是 CSV 或 NDJSON。",
+				Remediation: "請以 text/csv 或 application/x-ndjson 傳送檔案。",
+			},
+		},
+	},
+}
@@ -0,0 +1,131 @@
+package apperror
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+// declaredCodes returns the error code constants declared in code.go by name.
+func declaredCodes(t *testing.T) map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), "code.go", nil, 0)
+	require.NoError(t, err)
+
+	codes := map[string]string{}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, name := range valueSpec.Names {
+				literal, ok := valueSpec.Values[i].(*ast.BasicLit)
+				require.True(t, ok, "%s is not a string literal", name.Name)
+				code, err := strconv.Unquote(literal.Value)
+				require.NoError(t, err)
+				codes[name.Name] = code
+			}
+		}
+	}
+	return codes
+}
+
+func TestRegistry_Codes_WellFormed(t *testing.T) {
+	for name, code := range declaredCodes(t) {
+		// Act
+		matches := regexpErrorCode.FindStringSubmatch(code)
+
+		// Assert
+		require.Len(t, matches, 2, "%s has a malformed code %q", name, code)
+		assert.Equal(t, "01", strings.Split(code, "_")[1], "%s has a foreign service ID", name)
+	}
+}
+
+func TestRegistry_Codes_UniqueSerials(t *testing.T) {
+	// Arrange
+	names := map[string]string{}
+
+	for name, code := range declaredCodes(t) {
+		// Act
+		existing, ok := names[serial(code)]
+
+		// Assert
+		assert.False(t, ok, "%s and %s share the serial number %s", name, existing, serial(code))
+		names[serial(code)] = name
+	}
+}
+
+func TestRegistry_Codes_AllRegistered(t *testing.T) {
+	// Arrange
+	codes := declaredCodes(t)
+
+	for name, code := range codes {
+		// Act
+		_, ok := LookupDefinition(code)
+
+		// Assert
+		assert.True(t, ok, "%s (%s) is not in the registry", name, code)
+	}
+	assert.Len(t, Definitions(), len(codes))
+}
+
+func TestRegistry_Definitions_Valid(t *testing.T) {
+	// Arrange
+	ids := map[string]bool{}
+
+	for _, definition := range Definitions() {
+		// Assert
+		assert.Equal(t, New(definition.Code, "").Status, definition.Status, "%s has a status that does not match its code", definition.ID)
+		assert.Regexp(t, `^[a-z]+(_[a-z]+)*$`, definition.ID)
+		assert.False(t, ids[definition.ID], "%s is registered twice", definition.ID)
+		ids[definition.ID] = true
+
+		for _, lang := range supportedLanguages {
+			text, ok := definition.Text[lang]
+			if assert.True(t, ok, "%s has no %s text", definition.ID, lang) {
+				assert.NotEmpty(t, text.Title, "%s has no %s title", definition.ID, lang)
+				assert.NotEmpty(t, text.Description, "%s has no %s description", definition.ID, lang)
+				assert.NotEmpty(t, text.Remediation, "%s has no %s remediation", definition.ID, lang)
+			}
+		}
+	}
+}
+
+func TestRegistry_Definitions_OrderedBySerial(t *testing.T) {
+	// Act
+	definitions := Definitions()
+
+	// Assert
+	require.NotEmpty(t, definitions)
+	assert.Equal(t, CodeUserNotFound, definitions[0].Code)
+	for i := 1; i < len(definitions); i++ {
+		assert.Less(t, serial(definitions[i-1].Code), serial(definitions[i].Code))
+	}
+}
+
+func TestRegistry_Localize_Fallback(t *testing.T) {
+	// Arrange
+	definition, ok := LookupDefinition(CodeUserNotFound)
+	require.True(t, ok)
+
+	// Act & Assert
+	assert.Equal(t, "User not found", definition.Localize(language.English).Title)
+	assert.Equal(t, "找不到使用者", definition.Localize(language.TraditionalChinese).Title)
+	assert.Equal(t, "User not found", definition.Localize(language.Japanese).Title)
+}
+
+func TestRegistry_LookupDefinition_NotFound(t *testing.T) {
+	// Act
+	_, ok := LookupDefinition("404_01_999")
+
+	// Assert
+	assert.False(t, ok)
+}
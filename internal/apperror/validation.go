@@ -0,0 +1,160 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+)
+
+// FieldError points at the request field that failed validation. Rule is the
+// validation tag that failed, e.g. required or max, and Param its argument.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+
+	kind reflect.Kind
+}
+
+// fieldMessages holds the message templates per language and rule. Rules
+// whose message depends on the kind of the field, such as min, have a
+// variant for strings and one for lists.
+var fieldMessages = map[language.Tag]map[string]string{
+	language.English: {
+		"":                 "{field} is invalid",
+		"required":         "{field} is required",
+		"required_without": "{field} is required when {param} is not given",
+		"email":            "{field} must be a valid email address",
+		"url":              "{field} must be a valid URL",
+		"oneof":            "{field} must be one of: {param}",
+		"type":             "{field} must be of type {param}",
+		"min":              "{field} must be at least {param}",
+		"min.string":       "{field} must be at least {param} characters long",
+		"min.list":         "{field} must contain at least {param} items",
+		"max":              "{field} must be at most {param}",
+		"max.string":       "{field} must be at most {param} characters long",
+		"max.list":         "{field} must contain at most {param} items",
+	},
+	language.TraditionalChinese: {
+		"":                 "{field} 格式不正確",
+		"required":         "{field} 為必填欄位",
+		"required_without": "未提供 {param} 時，{field} 為必填欄位",
+		"email":            "{field} 必須是有效的電子郵件地址",
+		"url":              "{field} 必須是有效的網址",
+		"oneof":            "{field} 必須是下列其中之一：{param}",
+		"type":             "{field} 必須是 {param} 型別",
+		"min":              "{field} 不可小於 {param}",
+		"min.string":       "{field} 長度至少需 {param} 個字元",
+		"min.list":         "{field} 至少需包含 {param} 個項目",
+		"max":              "{field} 不可大於 {param}",
+		"max.string":       "{field} 長度不可超過 {param} 個字元",
+		"max.list":         "{field} 最多只能包含 {param} 個項目",
+	},
+}
+
+func init() {
+	// report fields by the names clients send rather than the Go field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+// embeddedFieldName names embedded structs, whose fields JSON and form
+// binding treat as fields of the outer struct.
+const embeddedFieldName = "_"
+
+func fieldName(field reflect.StructField) string {
+	if field.Anonymous && field.Tag.Get("json") == "" {
+		return embeddedFieldName
+	}
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
+}
+
+func (e FieldError) localize(lang language.Tag) string {
+	messages, ok := fieldMessages[lang]
+	if !ok {
+		messages = fieldMessages[supportedLanguages[0]]
+	}
+
+	key := e.Rule
+	switch e.kind {
+	case reflect.String:
+		key += ".string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		key += ".list"
+	}
+	message, ok := messages[key]
+	if !ok {
+		if message, ok = messages[e.Rule]; !ok {
+			message = messages[""]
+		}
+	}
+
+	param := e.Param
+	if e.Rule == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return strings.NewReplacer("{field}", e.Field, "{param}", param).Replace(message)
+}
+
+// fieldPath turns a validator namespace such as
+// "CreateRequestBody._.enum[0]" into the path of the field in the request,
+// "enum[0]".
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	segments = slices.DeleteFunc(segments, func(segment string) bool {
+		return segment == embeddedFieldName
+	})
+	return strings.Join(segments, ".")
+}
+
+// NewBindError wraps an error of binding the given part of the request (body,
+// uri or query). Validation failures and mistyped JSON values are listed per
+// field so that clients can point at the offending input.
+func NewBindError(source string, err error) *AppError {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		fieldErrors := make([]FieldError, len(validationErrors))
+		fields := make([]string, len(validationErrors))
+		for i, validationError := range validationErrors {
+			field := fieldPath(validationError.Namespace())
+			fieldErrors[i] = FieldError{
+				Field: field,
+				Rule:  validationError.Tag(),
+				Param: validationError.Param(),
+				kind:  validationError.Kind(),
+			}
+			fieldErrors[i].Message = fieldErrors[i].localize(supportedLanguages[0])
+			fields[i] = field
+		}
+		appErr := New(CodeValidationFailed, "request "+source+" validation failed | fields: "+strings.Join(fields, ", "))
+		appErr.Errors = fieldErrors
+		return appErr
+
+	case errors.As(err, &typeError) && typeError.Field != "":
+		fieldError := FieldError{Field: typeError.Field, Rule: "type", Param: typeError.Type.Kind().String()}
+		fieldError.Message = fieldError.localize(supportedLanguages[0])
+		appErr := New(CodeInvalidFieldType, "invalid request "+source+" | field: "+typeError.Field+" | expected: "+typeError.Type.String())
+		appErr.Errors = []FieldError{fieldError}
+		return appErr
+
+	default:
+		return New(CodeInvalidRequest, "invalid request "+source+" | "+err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package apperror
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+type testRequestBody struct {
+	Email  string   `json:"email" binding:"required,email"`
+	Status string   `json:"status" binding:"omitempty,oneof=active suspended"`
+	Roles  []string `json:"roles" binding:"omitempty,max=2,dive,max=8"`
+	Age    int      `json:"age" binding:"omitempty,min=18"`
+}
+
+func bindTestRequest(t *testing.T, body string) error {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req testRequestBody
+	err := c.ShouldBindJSON(&req)
+	require.Error(t, err)
+	return err
+}
+
+func TestValidation_NewBindError_ValidationFailed(t *testing.T) {
+	// Arrange
+	err := bindTestRequest(t, `{"email":"invalid","status":"deleted","roles":["admin","a-very-long-role","user"],"age":12}`)
+
+	// Act
+	appErr := NewBindError("body", err)
+
+	// Assert
+	assert.Equal(t, CodeValidationFailed, appErr.Code)
+	assert.Equal(t, 400, appErr.Status)
+	assert.Equal(t, "request body validation failed | fields: email, status, roles, age", appErr.Message)
+	expected := []FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "status", Rule: "oneof", Param: "active suspended", Message: "status must be one of: active, suspended"},
+		{Field: "roles", Rule: "max", Param: "2", Message: "roles must contain at most 2 items"},
+		{Field: "age", Rule: "min", Param: "18", Message: "age must be at least 18"},
+	}
+	require.Len(t, appErr.Errors, len(expected))
+	for i, fieldError := range appErr.Errors {
+		assert.Equal(t, expected[i].Field, fieldError.Field)
+		assert.Equal(t, expected[i].Rule, fieldError.Rule)
+		assert.Equal(t, expected[i].Param, fieldError.Param)
+		assert.Equal(t, expected[i].Message, fieldError.Message)
+	}
+}
+func TestValidation_NewBindError_ListItem(t *testing.T) {
+	// Arrange
+	err := bindTestRequest(t, `{"email":"user@example.com","roles":["a-very-long-role"]}`)
+
+	// Act
+	appErr := NewBindError("body", err)
+
+	// Assert
+	require.Len(t, appErr.Errors, 1)
+	assert.Equal(t, "roles[0]", appErr.Errors[0].Field)
+	assert.Equal(t, "roles[0] must be at most 8 characters long", appErr.Errors[0].Message)
+}
+func TestValidation_NewBindError_InvalidFieldType(t *testing.T) {
+	// Arrange
+	err := bindTestRequest(t, `{"email":"user@example.com","age":"old"}`)
+
+	// Act
+	appErr := NewBindError("body", err)
+
+	// Assert
+	assert.Equal(t, CodeInvalidFieldType, appErr.Code)
+	require.Len(t, appErr.Errors, 1)
+	assert.Equal(t, "age", appErr.Errors[0].Field)
+	assert.Equal(t, "type", appErr.Errors[0].Rule)
+	assert.Equal(t, "int", appErr.Errors[0].Param)
+	assert.Equal(t, "age must be of type int", appErr.Errors[0].Message)
+}
+func TestValidation_NewBindError_Malformed(t *testing.T) {
+	// Arrange
+	err := bindTestRequest(t, `{"email":`)
+
+	// Act
+	appErr := NewBindError("body", err)
+
+	// Assert
+	assert.Equal(t, CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request body")
+	assert.Empty(t, appErr.Errors)
+}
+
+func TestValidation_Problem_LocalizesFieldErrors(t *testing.T) {
+	// Arrange
+	appErr := NewBindError("body", bindTestRequest(t, `{}`))
+
+	// Act
+	problem := appErr.Problem("/users", MatchLanguage("zh-TW,zh;q=0.9,en;q=0.8"))
+
+	// Assert
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "email 為必填欄位", problem.Errors[0].Message)
+	assert.Equal(t, "email is required", appErr.Errors[0].Message)
+}
+
+func TestLanguage_MatchLanguage(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		expected       language.Tag
+	}{
+		{"", language.English},
+		{"fr-FR", language.English},
+		{"en-US,en;q=0.9", language.English},
+		{"zh-TW", language.TraditionalChinese},
+		{"fr;q=0.9, zh-Hant;q=0.8", language.TraditionalChinese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			// Act
+			actual := MatchLanguage(tt.acceptLanguage)
+
+			// Assert
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
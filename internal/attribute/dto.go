@@ -0,0 +1,55 @@
+package attribute
+
+import "time"
+
+type RequestURI struct {
+	Key string `uri:"key" binding:"required,max=64"`
+}
+
+type DefinitionBody struct {
+	Type        string   `json:"type" binding:"required,oneof=string number boolean"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Enum        []string `json:"enum" binding:"omitempty,dive,required,max=255"`
+	MaxLength   *int     `json:"max_length" binding:"omitempty,min=1"`
+	Claim       *string  `json:"claim" binding:"omitempty,max=64"`
+}
+
+type CreateRequestBody struct {
+	Key string `json:"key" binding:"required,max=64"`
+	DefinitionBody
+}
+
+func (b *DefinitionBody) toDefinition(key string) *Definition {
+	return &Definition{
+		Key:         key,
+		Type:        Type(b.Type),
+		Description: b.Description,
+		Enum:        b.Enum,
+		MaxLength:   b.MaxLength,
+		Claim:       b.Claim,
+	}
+}
+
+type DefinitionResponse struct {
+	Key         string   `json:"key"`
+	Type        string   `json:"type"`
+	Description *string  `json:"description"`
+	Enum        []string `json:"enum"`
+	MaxLength   *int     `json:"max_length"`
+	Claim       *string  `json:"claim"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+func newDefinitionResponse(d *Definition) *DefinitionResponse {
+	return &DefinitionResponse{
+		Key:         d.Key,
+		Type:        string(d.Type),
+		Description: d.Description,
+		Enum:        d.Enum,
+		MaxLength:   d.MaxLength,
+		Claim:       d.Claim,
+		CreatedAt:   d.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   d.UpdatedAt.Format(time.RFC3339),
+	}
+}
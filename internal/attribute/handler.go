@@ -0,0 +1,87 @@
+package attribute
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetDefinitions(c *gin.Context) {
+	definitions, err := h.service.GetDefinitions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	definitionResponses := make([]DefinitionResponse, len(definitions))
+	for i, definition := range definitions {
+		definitionResponses[i] = *newDefinitionResponse(&definition)
+	}
+
+	c.JSON(http.StatusOK, definitionResponses)
+}
+
+func (h *Handler) CreateDefinition(c *gin.Context) {
+	var body CreateRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	definition := body.toDefinition(body.Key)
+	err := h.service.CreateDefinition(definition)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newDefinitionResponse(definition))
+}
+
+func (h *Handler) UpdateDefinition(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var body DefinitionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	definition := body.toDefinition(uri.Key)
+	err := h.service.UpdateDefinition(definition)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newDefinitionResponse(definition))
+}
+
+func (h *Handler) DeleteDefinition(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	err := h.service.DeleteDefinition(uri.Key)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
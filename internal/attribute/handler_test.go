@@ -0,0 +1,253 @@
+package attribute
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetDefinitions() ([]Definition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Definition), args.Error(1)
+}
+func (m *MockService) CreateDefinition(definition *Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockService) UpdateDefinition(definition *Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockService) DeleteDefinition(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+func (m *MockService) Merge(current, patch map[string]any) (map[string]any, error) {
+	args := m.Called(current, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+func (m *MockService) Claims(attributes map[string]any) (map[string]any, error) {
+	args := m.Called(attributes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+
+	// Act
+	handler := NewHandler(mockService)
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+}
+
+func TestHandler_GetDefinitions_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	definitions := []Definition{
+		{
+			Key:       "department",
+			Type:      TypeString,
+			Enum:      []string{"engineering", "sales"},
+			Claim:     test.StringPtr("dept"),
+			CreatedAt: time.Unix(1, 0),
+			UpdatedAt: time.Unix(1, 0),
+		},
+	}
+
+	mockService.On("GetDefinitions").Return(definitions, nil)
+
+	// Act
+	handler.GetDefinitions(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual []DefinitionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []DefinitionResponse{
+		{
+			Key:       "department",
+			Type:      "string",
+			Enum:      []string{"engineering", "sales"},
+			Claim:     test.StringPtr("dept"),
+			CreatedAt: time.Unix(1, 0).Format(time.RFC3339),
+			UpdatedAt: time.Unix(1, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetDefinitions_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	mockService.On("GetDefinitions").Return(nil, assert.AnError)
+
+	// Act
+	handler.GetDefinitions(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateDefinition_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"key":"locale","type":"string","claim":"locale"}`))
+
+	mockService.On("CreateDefinition", &Definition{Key: "locale", Type: TypeString, Claim: test.StringPtr("locale")}).Return(nil)
+
+	// Act
+	handler.CreateDefinition(c)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var actual DefinitionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, "locale", actual.Key)
+	assert.Equal(t, "string", actual.Type)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_CreateDefinition_InvalidRequestBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		field   string
+		rule    string
+	}{
+		{
+			name:    "missing key",
+			payload: `{"type":"string"}`,
+			field:   "key",
+			rule:    "required",
+		},
+		{
+			name:    "unsupported type",
+			payload: `{"key":"locale","type":"date"}`,
+			field:   "type",
+			rule:    "oneof",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockService{}
+			handler := NewHandler(mockService)
+			c, w := test.SetupContext()
+
+			c.Request.Body = io.NopCloser(bytes.NewBufferString(tt.payload))
+
+			// Act
+			handler.CreateDefinition(c)
+
+			// Assert
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Len(t, c.Errors, 1)
+			appErr := c.Errors[0].Err.(*apperror.AppError)
+			assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+			require.Len(t, appErr.Errors, 1)
+			assert.Equal(t, tt.field, appErr.Errors[0].Field)
+			assert.Equal(t, tt.rule, appErr.Errors[0].Rule)
+		})
+	}
+}
+
+func TestHandler_UpdateDefinition_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "key", Value: "floor"}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"type":"number","description":"Office floor"}`))
+
+	mockService.On("UpdateDefinition", &Definition{Key: "floor", Type: TypeNumber, Description: test.StringPtr("Office floor")}).Return(nil)
+
+	// Act
+	handler.UpdateDefinition(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_UpdateDefinition_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "key", Value: "floor"}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"type":"number"}`))
+
+	mockService.On("UpdateDefinition", &Definition{Key: "floor", Type: TypeNumber}).Return(assert.AnError)
+
+	// Act
+	handler.UpdateDefinition(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_DeleteDefinition_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "key", Value: "floor"}}
+
+	mockService.On("DeleteDefinition", "floor").Return(nil)
+
+	// Act
+	handler.DeleteDefinition(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
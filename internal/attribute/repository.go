@@ -0,0 +1,82 @@
+package attribute
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Type string
+
+const (
+	TypeString  Type = "string"
+	TypeNumber  Type = "number"
+	TypeBoolean Type = "boolean"
+)
+
+type Definition struct {
+	Key         string    `gorm:"primaryKey;type:varchar(64)"`
+	Type        Type      `gorm:"type:varchar(16);not null"`
+	Description *string   `gorm:"type:varchar(255)"`
+	Enum        []string  `gorm:"type:jsonb;serializer:json"`
+	MaxLength   *int      `gorm:"type:integer"`
+	Claim       *string   `gorm:"type:varchar(64);uniqueIndex"`
+	CreatedAt   time.Time `gorm:"type:timestamptz;not null"`
+	UpdatedAt   time.Time `gorm:"type:timestamptz;not null"`
+}
+
+func (Definition) TableName() string {
+	return "attribute_definitions"
+}
+
+type Repository interface {
+	GetAll() ([]Definition, error)
+	GetByKey(key string) (*Definition, error)
+	Create(definition *Definition) error
+	Update(definition *Definition) error
+	Delete(key string) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]Definition, error) {
+	var definitions []Definition
+	err := r.db.Order("key").Find(&definitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func (r *repository) GetByKey(key string) (*Definition, error) {
+	var definition Definition
+	err := r.db.Where("key = ?", key).First(&definition).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *repository) Create(definition *Definition) error {
+	definition.CreatedAt = time.Now().Local()
+	definition.UpdatedAt = time.Now().Local()
+	return r.db.Create(definition).Error
+}
+
+func (r *repository) Update(definition *Definition) error {
+	definition.UpdatedAt = time.Now().Local()
+	return r.db.Save(definition).Error
+}
+
+func (r *repository) Delete(key string) error {
+	return r.db.Where("key = ?", key).Delete(&Definition{}).Error
+}
@@ -0,0 +1,163 @@
+package attribute
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Definition{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_Create_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	maxLength := 16
+	definition := &Definition{
+		Key:         "employee_id",
+		Type:        TypeString,
+		Description: test.StringPtr("HR employee ID"),
+		MaxLength:   &maxLength,
+		Claim:       test.StringPtr("eid"),
+	}
+
+	// Act
+	err = repo.Create(definition)
+
+	// Assert
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), definition.CreatedAt, time.Second)
+
+	savedDefinition, err := repo.GetByKey(definition.Key)
+	require.NoError(t, err)
+	assert.Equal(t, definition.Type, savedDefinition.Type)
+	assert.Equal(t, definition.MaxLength, savedDefinition.MaxLength)
+	assert.Equal(t, definition.Claim, savedDefinition.Claim)
+}
+
+func TestRepository_GetAll_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&[]Definition{
+		{Key: "locale", Type: TypeString, Enum: []string{"en-US", "zh-TW"}},
+		{Key: "department", Type: TypeString},
+	}).Error
+	require.NoError(t, err)
+
+	// Act
+	definitions, err := repo.GetAll()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, definitions, 2)
+	assert.Equal(t, "department", definitions[0].Key)
+	assert.Equal(t, "locale", definitions[1].Key)
+	assert.Equal(t, []string{"en-US", "zh-TW"}, definitions[1].Enum)
+}
+
+func TestRepository_GetByKey_NonExistent(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	definition, err := repo.GetByKey("locale")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, definition)
+}
+
+func TestRepository_Update_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	definition := &Definition{Key: "floor", Type: TypeString}
+	err = repo.Create(definition)
+	require.NoError(t, err)
+
+	// Act
+	definition.Type = TypeNumber
+	err = repo.Update(definition)
+
+	// Assert
+	require.NoError(t, err)
+	savedDefinition, err := repo.GetByKey("floor")
+	require.NoError(t, err)
+	assert.Equal(t, TypeNumber, savedDefinition.Type)
+}
+
+func TestRepository_Delete_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = repo.Create(&Definition{Key: "floor", Type: TypeNumber})
+	require.NoError(t, err)
+
+	// Act
+	err = repo.Delete("floor")
+
+	// Assert
+	require.NoError(t, err)
+	definition, err := repo.GetByKey("floor")
+	require.NoError(t, err)
+	assert.Nil(t, definition)
+}
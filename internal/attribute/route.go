@@ -0,0 +1,19 @@
+package attribute
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware) {
+	g := r.Group("/attributes")
+	g.Use(gin.HandlerFunc(authMiddleware))
+	{
+		g.GET("", handler.GetDefinitions)
+		g.POST("", middleware.RequireRole(role.Admin), handler.CreateDefinition)
+		g.PUT("/:key", middleware.RequireRole(role.Admin), handler.UpdateDefinition)
+		g.DELETE("/:key", middleware.RequireRole(role.Admin), handler.DeleteDefinition)
+	}
+}
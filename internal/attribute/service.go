@@ -0,0 +1,200 @@
+package attribute
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+)
+
+type Service interface {
+	GetDefinitions() ([]Definition, error)
+	CreateDefinition(definition *Definition) error
+	UpdateDefinition(definition *Definition) error
+	DeleteDefinition(key string) error
+	Merge(current, patch map[string]any) (map[string]any, error)
+	Claims(attributes map[string]any) (map[string]any, error)
+}
+
+var regexpKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) validateDefinition(definition *Definition, existing []Definition) error {
+	if !regexpKey.MatchString(definition.Key) {
+		return apperror.New(apperror.CodeInvalidAttributeDefinition, "invalid attribute key | key: "+definition.Key)
+	}
+	if definition.Type != TypeString && (len(definition.Enum) > 0 || definition.MaxLength != nil) {
+		return apperror.New(apperror.CodeInvalidAttributeDefinition, "enum and max_length only apply to string attributes | key: "+definition.Key)
+	}
+	if definition.Claim != nil {
+		if !regexpKey.MatchString(*definition.Claim) {
+			return apperror.New(apperror.CodeInvalidAttributeDefinition, "invalid claim name | claim: "+*definition.Claim)
+		}
+		for _, other := range existing {
+			if other.Key != definition.Key && other.Claim != nil && *other.Claim == *definition.Claim {
+				return apperror.New(apperror.CodeInvalidAttributeDefinition, "claim already mapped | claim: "+*definition.Claim+" | key: "+other.Key)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *service) GetDefinitions() ([]Definition, error) {
+	return s.repo.GetAll()
+}
+
+func (s *service) CreateDefinition(definition *Definition) error {
+	existing, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(existing, func(d Definition) bool { return d.Key == definition.Key }) {
+		return apperror.New(apperror.CodeAttributeExists, "attribute already defined | key: "+definition.Key)
+	}
+	if err := s.validateDefinition(definition, existing); err != nil {
+		return err
+	}
+
+	return s.repo.Create(definition)
+}
+
+func (s *service) UpdateDefinition(definition *Definition) error {
+	existing, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(existing, func(d Definition) bool { return d.Key == definition.Key })
+	if index < 0 {
+		return apperror.New(apperror.CodeAttributeNotFound, "attribute not found | key: "+definition.Key)
+	}
+	if err := s.validateDefinition(definition, existing); err != nil {
+		return err
+	}
+
+	definition.CreatedAt = existing[index].CreatedAt
+	return s.repo.Update(definition)
+}
+
+func (s *service) DeleteDefinition(key string) error {
+	definition, err := s.repo.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if definition == nil {
+		return apperror.New(apperror.CodeAttributeNotFound, "attribute not found | key: "+key)
+	}
+
+	return s.repo.Delete(key)
+}
+
+func validateValue(definition *Definition, value any) string {
+	switch definition.Type {
+	case TypeString:
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if definition.MaxLength != nil && utf8.RuneCountInString(str) > *definition.MaxLength {
+			return "must be at most " + strconv.Itoa(*definition.MaxLength) + " characters"
+		}
+		if len(definition.Enum) > 0 && !slices.Contains(definition.Enum, str) {
+			return "must be one of " + strings.Join(definition.Enum, ", ")
+		}
+	case TypeNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	}
+	return ""
+}
+
+// Merge applies patch to current with JSON merge patch semantics: a null value
+// removes the attribute. Every patched attribute must be defined in the
+// registry, and values whose definition has since been deleted are dropped.
+func (s *service) Merge(current, patch map[string]any) (map[string]any, error) {
+	definitions, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	registry := make(map[string]*Definition, len(definitions))
+	for i := range definitions {
+		registry[definitions[i].Key] = &definitions[i]
+	}
+
+	merged := map[string]any{}
+	for key, value := range current {
+		if _, ok := registry[key]; ok {
+			merged[key] = value
+		}
+	}
+
+	var problems []string
+	for key, value := range patch {
+		definition, ok := registry[key]
+		if !ok {
+			problems = append(problems, key+": not defined")
+			continue
+		}
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if problem := validateValue(definition, value); problem != "" {
+			problems = append(problems, key+": "+problem)
+			continue
+		}
+		merged[key] = value
+	}
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, apperror.New(apperror.CodeInvalidUserAttributes, "invalid user attributes | "+strings.Join(problems, " | "))
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
+
+// Claims picks the attributes whose definition maps them into access token
+// claims, keyed by claim name.
+func (s *service) Claims(attributes map[string]any) (map[string]any, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	definitions, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	for _, definition := range definitions {
+		if definition.Claim == nil {
+			continue
+		}
+		value, ok := attributes[definition.Key]
+		if !ok {
+			continue
+		}
+		if claims == nil {
+			claims = map[string]any{}
+		}
+		claims[*definition.Claim] = value
+	}
+	return claims, nil
+}
@@ -0,0 +1,309 @@
+package attribute
+
+import (
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetAll() ([]Definition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Definition), args.Error(1)
+}
+func (m *MockRepository) GetByKey(key string) (*Definition, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Definition), args.Error(1)
+}
+func (m *MockRepository) Create(definition *Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockRepository) Update(definition *Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockRepository) Delete(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func newTestDefinitions() []Definition {
+	maxLength := 8
+	return []Definition{
+		{Key: "department", Type: TypeString, Enum: []string{"engineering", "sales"}, Claim: test.StringPtr("dept")},
+		{Key: "employee_id", Type: TypeString, MaxLength: &maxLength},
+		{Key: "floor", Type: TypeNumber},
+		{Key: "remote", Type: TypeBoolean, Claim: test.StringPtr("remote")},
+	}
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+
+	// Act
+	s := NewService(mockRepo)
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+}
+
+func TestService_CreateDefinition_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	definition := &Definition{Key: "locale", Type: TypeString, Claim: test.StringPtr("locale")}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+	mockRepo.On("Create", definition).Return(nil)
+
+	// Act
+	err := service.CreateDefinition(definition)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_CreateDefinition_AlreadyExists(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	definition := &Definition{Key: "department", Type: TypeString}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	err := service.CreateDefinition(definition)
+
+	// Assert
+	assert.Equal(t, apperror.CodeAttributeExists, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Create", definition)
+}
+func TestService_CreateDefinition_InvalidDefinition(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition *Definition
+	}{
+		{
+			name:       "invalid key",
+			definition: &Definition{Key: "Employee-ID", Type: TypeString},
+		},
+		{
+			name:       "enum on number",
+			definition: &Definition{Key: "level", Type: TypeNumber, Enum: []string{"1"}},
+		},
+		{
+			name:       "claim already mapped",
+			definition: &Definition{Key: "team", Type: TypeString, Claim: test.StringPtr("dept")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRepo := &MockRepository{}
+			service := &service{repo: mockRepo}
+
+			mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+			// Act
+			err := service.CreateDefinition(tt.definition)
+
+			// Assert
+			assert.Equal(t, apperror.CodeInvalidAttributeDefinition, err.(*apperror.AppError).Code)
+			mockRepo.AssertNotCalled(t, "Create", tt.definition)
+		})
+	}
+}
+
+func TestService_UpdateDefinition_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	definition := &Definition{Key: "department", Type: TypeString, Claim: test.StringPtr("dept")}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+	mockRepo.On("Update", definition).Return(nil)
+
+	// Act
+	err := service.UpdateDefinition(definition)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_UpdateDefinition_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	definition := &Definition{Key: "locale", Type: TypeString}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	err := service.UpdateDefinition(definition)
+
+	// Assert
+	assert.Equal(t, apperror.CodeAttributeNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Update", definition)
+}
+
+func TestService_DeleteDefinition_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	key := "department"
+
+	mockRepo.On("GetByKey", key).Return(&Definition{Key: key}, nil)
+	mockRepo.On("Delete", key).Return(nil)
+
+	// Act
+	err := service.DeleteDefinition(key)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeleteDefinition_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	key := "locale"
+
+	mockRepo.On("GetByKey", key).Return(nil, nil)
+
+	// Act
+	err := service.DeleteDefinition(key)
+
+	// Assert
+	assert.Equal(t, apperror.CodeAttributeNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Merge_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	current := map[string]any{"department": "sales", "floor": float64(3), "retired": "value"}
+	patch := map[string]any{"department": "engineering", "floor": nil, "remote": true}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	merged, err := service.Merge(current, patch)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"department": "engineering", "remote": true}, merged)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_Merge_InvalidValues(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	patch := map[string]any{
+		"department":  "marketing",
+		"employee_id": "E-123456789",
+		"floor":       "third",
+		"remote":      "yes",
+		"unknown":     "value",
+	}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	merged, err := service.Merge(nil, patch)
+
+	// Assert
+	assert.Nil(t, merged)
+	appErr := err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidUserAttributes, appErr.Code)
+	assert.Contains(t, appErr.Message, "department: must be one of engineering, sales")
+	assert.Contains(t, appErr.Message, "employee_id: must be at most 8 characters")
+	assert.Contains(t, appErr.Message, "floor: must be a number")
+	assert.Contains(t, appErr.Message, "remote: must be a boolean")
+	assert.Contains(t, appErr.Message, "unknown: not defined")
+}
+func TestService_Merge_RemoveAll(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	merged, err := service.Merge(map[string]any{"remote": true}, map[string]any{"remote": nil})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, merged)
+}
+
+func TestService_Claims_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	attributes := map[string]any{"department": "engineering", "employee_id": "E-1", "remote": false}
+
+	mockRepo.On("GetAll").Return(newTestDefinitions(), nil)
+
+	// Act
+	claims, err := service.Claims(attributes)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"dept": "engineering", "remote": false}, claims)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_Claims_NoAttributes(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	// Act
+	claims, err := service.Claims(nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, claims)
+	mockRepo.AssertNotCalled(t, "GetAll")
+}
+func TestService_Claims_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	mockRepo.On("GetAll").Return(nil, assert.AnError)
+
+	// Act
+	claims, err := service.Claims(map[string]any{"remote": true})
+
+	// Assert
+	assert.Nil(t, claims)
+	assert.Equal(t, assert.AnError, err)
+}
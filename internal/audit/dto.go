@@ -0,0 +1,78 @@
+package audit
+
+import "time"
+
+type GetEventsQuery struct {
+	Type     string     `form:"type" binding:"omitempty,max=64"`
+	Outcome  string     `form:"outcome" binding:"omitempty,oneof=success failure"`
+	Reason   string     `form:"reason" binding:"omitempty,max=255"`
+	ActorID  *int       `form:"actor_id" binding:"omitempty,min=1"`
+	TargetID *int       `form:"target_id" binding:"omitempty,min=1"`
+	Email    string     `form:"email" binding:"omitempty,max=255"`
+	IP       string     `form:"ip" binding:"omitempty,ip"`
+	Since    *time.Time `form:"since"`
+	Until    *time.Time `form:"until"`
+	Cursor   string     `form:"cursor"`
+	Limit    int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+func (q *GetEventsQuery) toFilter() Filter {
+	return Filter{
+		Type:     q.Type,
+		Outcome:  Outcome(q.Outcome),
+		Reason:   q.Reason,
+		ActorID:  q.ActorID,
+		TargetID: q.TargetID,
+		Email:    q.Email,
+		IP:       q.IP,
+		Since:    q.Since,
+		Until:    q.Until,
+		Limit:    q.Limit,
+	}
+}
+
+type EventResponse struct {
+	ID        int64          `json:"id"`
+	Type      string         `json:"type"`
+	Outcome   string         `json:"outcome"`
+	Reason    *string        `json:"reason"`
+	ActorID   *int           `json:"actor_id"`
+	TargetID  *int           `json:"target_id"`
+	Email     *string        `json:"email"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Metadata  map[string]any `json:"metadata"`
+	CreatedAt string         `json:"created_at"`
+}
+
+func newEventResponse(e *Event) *EventResponse {
+	return &EventResponse{
+		ID:        e.ID,
+		Type:      e.Type,
+		Outcome:   string(e.Outcome),
+		Reason:    e.Reason,
+		ActorID:   e.ActorID,
+		TargetID:  e.TargetID,
+		Email:     e.Email,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		Metadata:  e.Metadata,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type EventPageResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+func newEventPageResponse(page *Page) *EventPageResponse {
+	res := &EventPageResponse{Events: make([]EventResponse, len(page.Events))}
+	for i, event := range page.Events {
+		res.Events[i] = *newEventResponse(&event)
+	}
+	if page.NextCursor != "" {
+		res.NextCursor = &page.NextCursor
+	}
+	return res
+}
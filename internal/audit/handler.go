@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetEvents(c *gin.Context) {
+	var query GetEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	page, err := h.service.GetEvents(query.toFilter(), query.Cursor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newEventPageResponse(page))
+}
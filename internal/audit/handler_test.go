@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Record(event *Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+func (m *MockService) GetEvents(filter Filter, cursor string) (*Page, error) {
+	args := m.Called(filter, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Page), args.Error(1)
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+
+	// Act
+	handler := NewHandler(mockService)
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+}
+
+func TestHandler_GetEvents_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/audit-events?type=auth.login&outcome=failure&target_id=2&since=1970-01-01T00:00:00Z&limit=1&cursor=abc", nil)
+
+	targetID := 2
+	since := time.Unix(0, 0).UTC()
+	page := &Page{
+		Events: []Event{
+			{
+				ID:        7,
+				Type:      EventLogin,
+				Outcome:   OutcomeFailure,
+				Reason:    test.StringPtr(apperror.CodeUserNotAuthorized),
+				TargetID:  &targetID,
+				Email:     test.StringPtr("user@example.com"),
+				IP:        "192.0.2.1",
+				UserAgent: "curl/8.0",
+				CreatedAt: time.Unix(1, 0),
+			},
+		},
+		NextCursor: "next",
+	}
+
+	mockService.On("GetEvents", Filter{
+		Type:     EventLogin,
+		Outcome:  OutcomeFailure,
+		TargetID: &targetID,
+		Since:    &since,
+		Limit:    1,
+	}, "abc").Return(page, nil)
+
+	// Act
+	handler.GetEvents(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual EventPageResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := EventPageResponse{
+		Events: []EventResponse{
+			{
+				ID:        7,
+				Type:      EventLogin,
+				Outcome:   "failure",
+				Reason:    test.StringPtr(apperror.CodeUserNotAuthorized),
+				TargetID:  &targetID,
+				Email:     test.StringPtr("user@example.com"),
+				IP:        "192.0.2.1",
+				UserAgent: "curl/8.0",
+				CreatedAt: time.Unix(1, 0).Format(time.RFC3339),
+			},
+		},
+		NextCursor: test.StringPtr("next"),
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetEvents_InvalidRequestQuery(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/audit-events?outcome=maybe", nil)
+
+	// Act
+	handler.GetEvents(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	assert.Contains(t, appErr.Message, "request query validation failed")
+	require.Len(t, appErr.Errors, 1)
+	assert.Equal(t, "outcome", appErr.Errors[0].Field)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetEvents_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/audit-events", nil)
+
+	mockService.On("GetEvents", Filter{}, "").Return(nil, assert.AnError)
+
+	// Act
+	handler.GetEvents(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	contextKeyActorID  = "audit_actor_id"
+	contextKeyTargetID = "audit_target_id"
+	contextKeyEmail    = "audit_email"
+	contextKeyMetadata = "audit_metadata"
+)
+
+// Middleware records one audit event of the given type after the handler has
+// run. Handlers add what only they know through SetActor, SetTarget, SetEmail
+// and SetMetadata.
+type Middleware func(eventType string) gin.HandlerFunc
+
+func SetActor(c *gin.Context, id int) {
+	c.Set(contextKeyActorID, id)
+}
+
+func SetTarget(c *gin.Context, id int) {
+	c.Set(contextKeyTargetID, id)
+}
+
+func SetEmail(c *gin.Context, email string) {
+	c.Set(contextKeyEmail, email)
+}
+
+func SetMetadata(c *gin.Context, key string, value any) {
+	metadata, _ := c.Get(contextKeyMetadata)
+	m, ok := metadata.(map[string]any)
+	if !ok {
+		m = map[string]any{}
+		c.Set(contextKeyMetadata, m)
+	}
+	m[key] = value
+}
+
+func newEvent(c *gin.Context, eventType string) *Event {
+	event := &Event{
+		Type:      eventType,
+		Outcome:   OutcomeSuccess,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	// the authenticated caller wins over an actor named by the handler
+	if id, ok := c.Get("user_id"); ok {
+		actorID := id.(int)
+		event.ActorID = &actorID
+	} else if id, ok := c.Get(contextKeyActorID); ok {
+		actorID := id.(int)
+		event.ActorID = &actorID
+	}
+
+	if id, ok := c.Get(contextKeyTargetID); ok {
+		targetID := id.(int)
+		event.TargetID = &targetID
+	} else if id, err := strconv.Atoi(c.Param("id")); err == nil {
+		event.TargetID = &id
+	}
+
+	if email := c.GetString(contextKeyEmail); email != "" {
+		event.Email = &email
+	}
+	if metadata, ok := c.Get(contextKeyMetadata); ok {
+		event.Metadata = metadata.(map[string]any)
+	}
+	// service clients are no users, so they are named in the metadata
+	if clientID := c.GetString("client_id"); clientID != "" {
+		if event.Metadata == nil {
+			event.Metadata = map[string]any{}
+		}
+		event.Metadata["actor_client_id"] = clientID
+	}
+	// the user acts through the personal access token, which is named too
+	if tokenID, ok := c.Get("token_id"); ok {
+		if event.Metadata == nil {
+			event.Metadata = map[string]any{}
+		}
+		event.Metadata["actor_token_id"] = tokenID
+	}
+
+	if err := c.Errors.Last(); err != nil {
+		appErr := apperror.FromError(err)
+		reason := appErr.Code
+		if reason == "" {
+			reason = http.StatusText(appErr.Status)
+		}
+		event.Outcome = OutcomeFailure
+		event.Reason = &reason
+	} else if c.Writer.Status() >= http.StatusBadRequest {
+		event.Outcome = OutcomeFailure
+		reason := http.StatusText(c.Writer.Status())
+		event.Reason = &reason
+	}
+	return event
+}
+
+func NewMiddleware(service Service, logger *zap.Logger) Middleware {
+	return func(eventType string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Next()
+
+			event := newEvent(c, eventType)
+			if err := service.Record(event); err != nil {
+				logger.Error("failed to record audit event", zap.String("type", eventType), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(mockService *MockService, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	auditMiddleware := NewMiddleware(mockService, zap.NewNop())
+	r := gin.New()
+	r.POST("/users/:id", auditMiddleware(EventUserUpdate), handler)
+	return r
+}
+
+func TestMiddleware_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		c.Set("user_id", 1)
+		SetEmail(c, "user@example.com")
+		SetMetadata(c, "hard", false)
+		c.Status(http.StatusNoContent)
+	})
+
+	var recorded *Event
+	mockService.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*Event)
+	}).Return(nil)
+
+	req := httptest.NewRequest("POST", "/users/2", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NotNil(t, recorded)
+	assert.Equal(t, EventUserUpdate, recorded.Type)
+	assert.Equal(t, OutcomeSuccess, recorded.Outcome)
+	assert.Nil(t, recorded.Reason)
+	assert.Equal(t, 1, *recorded.ActorID)
+	assert.Equal(t, 2, *recorded.TargetID)
+	assert.Equal(t, "user@example.com", *recorded.Email)
+	assert.Equal(t, "192.0.2.1", recorded.IP)
+	assert.Equal(t, "curl/8.0", recorded.UserAgent)
+	assert.Equal(t, map[string]any{"hard": false}, recorded.Metadata)
+}
+func TestMiddleware_Client(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		c.Set("client_id", "svc_batch")
+		c.Status(http.StatusNoContent)
+	})
+
+	var recorded *Event
+	mockService.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*Event)
+	}).Return(nil)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/2", nil))
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NotNil(t, recorded)
+	assert.Nil(t, recorded.ActorID)
+	assert.Equal(t, map[string]any{"actor_client_id": "svc_batch"}, recorded.Metadata)
+}
+
+func TestMiddleware_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Set("token_id", 7)
+		c.Status(http.StatusNoContent)
+	})
+
+	var recorded *Event
+	mockService.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*Event)
+	}).Return(nil)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/2", nil))
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NotNil(t, recorded)
+	require.NotNil(t, recorded.ActorID)
+	assert.Equal(t, 1, *recorded.ActorID)
+	assert.Equal(t, map[string]any{"actor_token_id": 7}, recorded.Metadata)
+}
+
+func TestMiddleware_AppError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		SetActor(c, 3)
+		SetTarget(c, 3)
+		c.Error(apperror.New(apperror.CodeUserSuspended, "user suspended"))
+	})
+
+	var recorded *Event
+	mockService.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*Event)
+	}).Return(nil)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/2", nil))
+
+	// Assert
+	require.NotNil(t, recorded)
+	assert.Equal(t, OutcomeFailure, recorded.Outcome)
+	assert.Equal(t, apperror.CodeUserSuspended, *recorded.Reason)
+	assert.Equal(t, 3, *recorded.ActorID)
+	assert.Equal(t, 3, *recorded.TargetID)
+}
+func TestMiddleware_BadRequest(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	})
+
+	var recorded *Event
+	mockService.On("Record", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*Event)
+	}).Return(nil)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/2", nil))
+
+	// Assert
+	require.NotNil(t, recorded)
+	assert.Equal(t, OutcomeFailure, recorded.Outcome)
+	assert.Equal(t, "Bad Request", *recorded.Reason)
+	assert.Nil(t, recorded.ActorID)
+}
+func TestMiddleware_RecordError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	r := newTestRouter(mockService, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	mockService.On("Record", mock.Anything).Return(assert.AnError)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users/2", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+const (
+	EventLogin           = "auth.login"
+	EventRefresh         = "auth.refresh"
+	EventLoginLockout    = "auth.lockout"
+	EventLoginUnlock     = "auth.unlock"
+	EventUserCreate      = "user.create"
+	EventUserUpdate      = "user.update"
+	EventUserDelete      = "user.delete"
+	EventUserRestore     = "user.restore"
+	EventUserSuspend     = "user.suspend"
+	EventUserReactivate  = "user.reactivate"
+	EventUserRolesChange = "user.roles_change"
+	EventUserImport      = "user.import"
+
+	EventClientToken        = "client.token"
+	EventClientCreate       = "client.create"
+	EventClientUpdate       = "client.update"
+	EventClientDelete       = "client.delete"
+	EventClientSecretRotate = "client.secret_rotate"
+
+	EventTokenCreate = "token.create"
+	EventTokenRevoke = "token.revoke"
+)
+
+type Event struct {
+	ID        int64          `gorm:"primaryKey;autoIncrement"`
+	Type      string         `gorm:"type:varchar(64);not null;index"`
+	Outcome   Outcome        `gorm:"type:varchar(16);not null"`
+	Reason    *string        `gorm:"type:varchar(255)"`
+	ActorID   *int           `gorm:"index"`
+	TargetID  *int           `gorm:"index"`
+	Email     *string        `gorm:"type:varchar(255)"`
+	IP        string         `gorm:"type:varchar(64);not null"`
+	UserAgent string         `gorm:"type:varchar(512);not null"`
+	Metadata  map[string]any `gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time      `gorm:"type:timestamptz;not null;index"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+type Filter struct {
+	Type     string
+	Outcome  Outcome
+	Reason   string
+	ActorID  *int
+	TargetID *int
+	Email    string
+	IP       string
+	Since    *time.Time
+	Until    *time.Time
+	BeforeID int64
+	Limit    int
+}
+
+type Repository interface {
+	Create(event *Event) error
+	Find(filter Filter) ([]Event, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(event *Event) error {
+	event.ID = 0
+	event.CreatedAt = time.Now().Local()
+	return r.db.Create(event).Error
+}
+
+// Find returns the newest events first. BeforeID is the keyset cursor: only
+// events older than it are returned.
+func (r *repository) Find(filter Filter) ([]Event, error) {
+	query := r.db.Model(&Event{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.Email != "" {
+		query = query.Where("lower(email) = lower(?)", filter.Email)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var events []Event
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Event{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_Create_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	event := &Event{
+		Type:      EventLogin,
+		Outcome:   OutcomeSuccess,
+		ActorID:   intPtr(1),
+		TargetID:  intPtr(1),
+		Email:     test.StringPtr("user@example.com"),
+		IP:        "192.0.2.1",
+		UserAgent: "curl/8.0",
+		Metadata:  map[string]any{"provider": "google"},
+	}
+
+	// Act
+	err = repo.Create(event)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), event.ID)
+	assert.WithinDuration(t, time.Now(), event.CreatedAt, time.Second)
+
+	events, err := repo.Find(Filter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.Email, events[0].Email)
+	assert.Equal(t, event.Metadata, events[0].Metadata)
+}
+
+func TestRepository_Find_Filters(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	events := []Event{
+		{Type: EventLogin, Outcome: OutcomeFailure, Reason: test.StringPtr("403_01_011"), Email: test.StringPtr("Intruder@Example.com"), IP: "192.0.2.1", CreatedAt: time.Unix(1, 0)},
+		{Type: EventLogin, Outcome: OutcomeSuccess, ActorID: intPtr(1), TargetID: intPtr(1), IP: "192.0.2.2", CreatedAt: time.Unix(2, 0)},
+		{Type: EventUserDelete, Outcome: OutcomeSuccess, ActorID: intPtr(1), TargetID: intPtr(2), CreatedAt: time.Unix(3, 0)},
+	}
+	err = d.Create(&events).Error
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []int64
+	}{
+		{name: "type", filter: Filter{Type: EventLogin}, expected: []int64{2, 1}},
+		{name: "outcome", filter: Filter{Outcome: OutcomeFailure}, expected: []int64{1}},
+		{name: "reason", filter: Filter{Reason: "403_01_011"}, expected: []int64{1}},
+		{name: "actor", filter: Filter{ActorID: intPtr(1)}, expected: []int64{3, 2}},
+		{name: "target", filter: Filter{TargetID: intPtr(2)}, expected: []int64{3}},
+		{name: "email ignoring case", filter: Filter{Email: "intruder@example.com"}, expected: []int64{1}},
+		{name: "ip", filter: Filter{IP: "192.0.2.2"}, expected: []int64{2}},
+		{name: "time range", filter: Filter{Since: test.TimePtr(time.Unix(2, 0)), Until: test.TimePtr(time.Unix(3, 0))}, expected: []int64{2}},
+		{name: "cursor", filter: Filter{BeforeID: 3}, expected: []int64{2, 1}},
+		{name: "limit", filter: Filter{Limit: 1}, expected: []int64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.Limit == 0 {
+				tt.filter.Limit = 10
+			}
+
+			// Act
+			result, err := repo.Find(tt.filter)
+
+			// Assert
+			require.NoError(t, err)
+			ids := make([]int64, len(result))
+			for i, event := range result {
+				ids[i] = event.ID
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
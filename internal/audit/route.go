@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware) {
+	g := r.Group("/audit-events")
+	g.Use(gin.HandlerFunc(authMiddleware), middleware.RequireRole(role.Admin))
+	{
+		g.GET("", handler.GetEvents)
+	}
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// The column sizes of audit_events; longer values are cut to fit.
+const (
+	maxReasonLength    = 255
+	maxEmailLength     = 255
+	maxIPLength        = 64
+	maxUserAgentLength = 512
+)
+
+type Page struct {
+	Events     []Event
+	NextCursor string
+}
+
+type Service interface {
+	Record(event *Event) error
+	GetEvents(filter Filter, cursor string) (*Page, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(raw), 10, 64)
+}
+
+// truncate cuts the value to limit characters, which is how Postgres measures
+// varchar sizes, after replacing invalid UTF-8 that it would refuse.
+func truncate(value string, limit int) string {
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+	return string([]rune(value)[:limit])
+}
+
+func truncatePtr(value *string, limit int) *string {
+	if value == nil {
+		return nil
+	}
+	truncated := truncate(*value, limit)
+	return &truncated
+}
+
+// Record stores the event. Client-supplied fields such as the user agent are
+// truncated, since a failed audit insert must not hinge on a long header.
+func (s *service) Record(event *Event) error {
+	event.Reason = truncatePtr(event.Reason, maxReasonLength)
+	event.Email = truncatePtr(event.Email, maxEmailLength)
+	event.IP = truncate(event.IP, maxIPLength)
+	event.UserAgent = truncate(event.UserAgent, maxUserAgentLength)
+	return s.repo.Create(event)
+}
+
+func (s *service) GetEvents(filter Filter, cursor string) (*Page, error) {
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil || id <= 0 {
+			return nil, apperror.New(apperror.CodeInvalidCursor, "invalid pagination cursor | cursor: "+cursor)
+		}
+		filter.BeforeID = id
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	// fetch one extra event to learn whether another page exists
+	pageSize := filter.Limit
+	filter.Limit++
+	events, err := s.repo.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Events: events}
+	if len(events) > pageSize {
+		page.Events = events[:pageSize]
+		page.NextCursor = encodeCursor(page.Events[pageSize-1].ID)
+	}
+	return page, nil
+}
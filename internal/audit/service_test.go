@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(event *Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+func (m *MockRepository) Find(filter Filter) ([]Event, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Event), args.Error(1)
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+
+	// Act
+	s := NewService(mockRepo)
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+}
+
+func TestService_Record_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	event := &Event{Type: EventLogin, Outcome: OutcomeSuccess}
+
+	mockRepo.On("Create", event).Return(nil)
+
+	// Act
+	err := service.Record(event)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Record_TruncatesFields(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	reason := strings.Repeat("r", 300)
+	email := strings.Repeat("é", 300)
+	event := &Event{
+		Type:      EventLogin,
+		Outcome:   OutcomeFailure,
+		Reason:    &reason,
+		Email:     &email,
+		IP:        "127.0.0.1",
+		UserAgent: strings.Repeat("a", 1000) + "\xff",
+	}
+
+	mockRepo.On("Create", mock.MatchedBy(func(e *Event) bool {
+		return *e.Reason == strings.Repeat("r", 255) &&
+			*e.Email == strings.Repeat("é", 255) &&
+			e.IP == "127.0.0.1" &&
+			e.UserAgent == strings.Repeat("a", 512)
+	})).Return(nil)
+
+	// Act
+	err := service.Record(event)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("r", 300), reason)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetEvents_FirstPage(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	events := []Event{{ID: 5}, {ID: 4}, {ID: 3}}
+
+	mockRepo.On("Find", Filter{Type: EventLogin, Limit: 3}).Return(events, nil)
+
+	// Act
+	page, err := service.GetEvents(Filter{Type: EventLogin, Limit: 2}, "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, events[:2], page.Events)
+	assert.Equal(t, encodeCursor(4), page.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_GetEvents_LastPage(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	events := []Event{{ID: 3}}
+
+	mockRepo.On("Find", Filter{BeforeID: 4, Limit: 3}).Return(events, nil)
+
+	// Act
+	page, err := service.GetEvents(Filter{Limit: 2}, encodeCursor(4))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, events, page.Events)
+	assert.Empty(t, page.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_GetEvents_DefaultLimit(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	mockRepo.On("Find", Filter{Limit: defaultPageSize + 1}).Return([]Event{}, nil)
+
+	// Act
+	page, err := service.GetEvents(Filter{}, "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, page.Events)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_GetEvents_InvalidCursor(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	// Act
+	page, err := service.GetEvents(Filter{}, "not-a-cursor")
+
+	// Assert
+	assert.Nil(t, page)
+	assert.Equal(t, apperror.CodeInvalidCursor, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Find", mock.Anything)
+}
+func TestService_GetEvents_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	mockRepo.On("Find", mock.Anything).Return(nil, assert.AnError)
+
+	// Act
+	page, err := service.GetEvents(Filter{}, "")
+
+	// Assert
+	assert.Nil(t, page)
+	assert.Equal(t, assert.AnError, err)
+}
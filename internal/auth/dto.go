@@ -12,12 +12,69 @@ type OAuthIDTokenClaims struct {
 }
 
 type TokenClaims struct {
-	Name    string `json:"name,omitempty"`
-	Email   string `json:"email,omitempty"`
-	Picture string `json:"picture,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	Email      string         `json:"email,omitempty"`
+	Picture    string         `json:"picture,omitempty"`
+	Roles      []string       `json:"roles,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// SessionID is shared by the refresh token of a login and every access
+	// token refreshed from it.
+	SessionID string `json:"sid,omitempty"`
+	// ClientID and Scope are only set in the tokens of service clients, whose
+	// subject is the client ID instead of a user ID.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 }
+
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+type IntrospectRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectionResponse follows RFC 7662; an inactive token has no other
+// members.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Subject   string   `json:"sub,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+}
+
+func newIntrospectionResponse(claims *TokenClaims) *IntrospectionResponse {
+	if claims == nil {
+		return &IntrospectionResponse{Active: false}
+	}
+	response := &IntrospectionResponse{
+		Active:    true,
+		Subject:   claims.Subject,
+		Issuer:    claims.Issuer,
+		Email:     claims.Email,
+		Roles:     claims.Roles,
+		SessionID: claims.SessionID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return response
+}
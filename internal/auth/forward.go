@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identity headers of verified requests, for the proxy to pass upstream
+const (
+	HeaderUserID = "X-Auth-User-Id"
+	HeaderEmail  = "X-Auth-Email"
+	HeaderRoles  = "X-Auth-Roles"
+	// sent instead of the user headers for the tokens of service clients
+	HeaderClientID = "X-Auth-Client-Id"
+	HeaderScopes   = "X-Auth-Scopes"
+)
+
+// returnToCookie keeps the page a login was started from until the callback.
+const returnToCookie = "return_to"
+
+// ForwardAuth lets /auth/verify take the access token from the forward auth
+// cookie when there is no Authorization header, and sends browsers that fail
+// to authenticate to the login, which brings them back to the page they asked
+// the proxy for. It must run before AuthMiddleware.
+func (h *Handler) ForwardAuth(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		if token, err := c.Cookie(h.config.ForwardAuthCookie); err == nil && token != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	c.Next()
+
+	err := c.Errors.Last()
+	if err == nil || !strings.Contains(c.GetHeader("Accept"), "text/html") || apperror.FromError(err).Status != http.StatusUnauthorized {
+		return
+	}
+	// the redirect replaces the error response
+	c.Errors = c.Errors[:0]
+	loginURL := h.config.BaseURL + "/auth/login"
+	if returnTo, ok := h.returnURL(forwardedURL(c)); ok {
+		loginURL += "?return_to=" + url.QueryEscape(returnTo)
+	}
+	c.Redirect(http.StatusFound, loginURL)
+}
+
+// forwardedURL rebuilds the URL the proxy is asking about from X-Original-URL,
+// as nginx is usually configured to send, or from the X-Forwarded-Proto,
+// X-Forwarded-Host and X-Forwarded-Uri headers that Traefik sends.
+func forwardedURL(c *gin.Context) string {
+	if originalURL := c.GetHeader("X-Original-URL"); originalURL != "" {
+		return originalURL
+	}
+	host := c.GetHeader("X-Forwarded-Host")
+	if host == "" {
+		return ""
+	}
+	proto := c.GetHeader("X-Forwarded-Proto")
+	if proto == "" {
+		proto = "https"
+	}
+	return proto + "://" + host + c.GetHeader("X-Forwarded-Uri")
+}
+
+// returnURL accepts only absolute http(s) URLs on the hosts that
+// FORWARD_AUTH_RETURN_HOSTS allows, so that logins cannot redirect anywhere.
+func (h *Handler) returnURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range h.config.ForwardAuthReturnHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newForwardAuthRouter(next gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(NewMockConfig(""), &MockAuthService{}, &MockUserService{}, &MockLockoutService{}, middleware.NewTokenVerifier(NewMockConfig(""), &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	r := gin.New()
+	r.GET("/auth/verify", handler.ForwardAuth, next)
+	return r
+}
+
+func unauthorized(c *gin.Context) {
+	c.Error(apperror.New(apperror.CodeInvalidAccessToken, "invalid access token"))
+	c.Abort()
+}
+
+func TestHandler_ForwardAuth_TokenFromCookie(t *testing.T) {
+	// Arrange
+	var authorization string
+	r := newForwardAuthRouter(func(c *gin.Context) {
+		authorization = c.GetHeader("Authorization")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/auth/verify", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: "mock-access-token"})
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "Bearer mock-access-token", authorization)
+}
+func TestHandler_ForwardAuth_HeaderWins(t *testing.T) {
+	// Arrange
+	var authorization string
+	r := newForwardAuthRouter(func(c *gin.Context) {
+		authorization = c.GetHeader("Authorization")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/auth/verify", nil)
+	req.Header.Set("Authorization", "Bearer header-token")
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: "cookie-token"})
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, "Bearer header-token", authorization)
+}
+
+func TestHandler_ForwardAuth_RedirectsBrowser(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		returnTo string
+	}{
+		{
+			name:     "nginx",
+			headers:  map[string]string{"X-Original-URL": "https://grafana.example.com/d/1?orgId=1"},
+			returnTo: "https://grafana.example.com/d/1?orgId=1",
+		},
+		{
+			name:     "traefik",
+			headers:  map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "grafana.example.com", "X-Forwarded-Uri": "/d/1"},
+			returnTo: "https://grafana.example.com/d/1",
+		},
+		{
+			name:     "host not allowed",
+			headers:  map[string]string{"X-Original-URL": "https://evil.test/"},
+			returnTo: "",
+		},
+		{
+			name:     "no forwarded URL",
+			headers:  map[string]string{},
+			returnTo: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var errors []*gin.Error
+			r := gin.New()
+			handler := NewHandler(NewMockConfig(""), &MockAuthService{}, &MockUserService{}, &MockLockoutService{}, middleware.NewTokenVerifier(NewMockConfig(""), &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+			r.GET("/auth/verify", func(c *gin.Context) {
+				c.Next()
+				errors = c.Errors
+			}, handler.ForwardAuth, unauthorized)
+
+			req := httptest.NewRequest("GET", "/auth/verify", nil)
+			req.Header.Set("Accept", "text/html,application/xhtml+xml")
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			// Act
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			// Assert
+			require.Equal(t, http.StatusFound, w.Code)
+			assert.Empty(t, errors)
+			location, err := url.Parse(w.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, "/auth/login", location.Path)
+			assert.Equal(t, tt.returnTo, location.Query().Get("return_to"))
+		})
+	}
+}
+func TestHandler_ForwardAuth_APIClientGetsError(t *testing.T) {
+	// Arrange
+	var errors []*gin.Error
+	r := gin.New()
+	handler := NewHandler(NewMockConfig(""), &MockAuthService{}, &MockUserService{}, &MockLockoutService{}, middleware.NewTokenVerifier(NewMockConfig(""), &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	r.GET("/auth/verify", func(c *gin.Context) {
+		c.Next()
+		errors = c.Errors
+	}, handler.ForwardAuth, unauthorized)
+
+	req := httptest.NewRequest("GET", "/auth/verify", nil)
+	req.Header.Set("Accept", "application/json")
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Empty(t, w.Header().Get("Location"))
+	require.Len(t, errors, 1)
+	assert.Equal(t, apperror.CodeInvalidAccessToken, errors[0].Err.(*apperror.AppError).Code)
+}
+func TestHandler_ForwardAuth_ForbiddenNotRedirected(t *testing.T) {
+	// Arrange
+	r := newForwardAuthRouter(func(c *gin.Context) {
+		c.Error(apperror.New(apperror.CodePermissionDenied, "permission denied"))
+	})
+
+	req := httptest.NewRequest("GET", "/auth/verify", nil)
+	req.Header.Set("Accept", "text/html")
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Empty(t, w.Header().Get("Location"))
+}
+
+func TestHandler_returnURL(t *testing.T) {
+	handler := NewHandler(NewMockConfig(""), &MockAuthService{}, &MockUserService{}, &MockLockoutService{}, middleware.NewTokenVerifier(NewMockConfig(""), &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	tests := []struct {
+		raw      string
+		expected bool
+	}{
+		{raw: "https://grafana.example.com/d/1", expected: true},
+		{raw: "http://a.b.EXAMPLE.com", expected: true},
+		{raw: "https://example.com.evil.test/", expected: false},
+		{raw: "https://notexample.com/", expected: false},
+		{raw: "https://user@grafana.example.com/", expected: false},
+		{raw: "javascript:alert(1)", expected: false},
+		{raw: "//grafana.example.com/", expected: false},
+		{raw: "/relative", expected: false},
+		{raw: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			// Act
+			_, ok := handler.returnURL(tt.raw)
+
+			// Assert
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+}
@@ -1,96 +1,216 @@
 package auth
 
 import (
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
 	"github.com/sninjo/vera-identity-service/internal/user"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type Handler struct {
-	config      *config.Config
-	authService Service
-	userService user.Service
+	config         *config.Config
+	authService    Service
+	userService    user.Service
+	lockoutService lockout.Service
+	verifier       middleware.TokenVerifier
+	metrics        *metrics.Metrics
 }
 
-func NewHandler(config *config.Config, authService Service, userService user.Service) *Handler {
-	return &Handler{config: config, authService: authService, userService: userService}
+func NewHandler(config *config.Config, authService Service, userService user.Service, lockoutService lockout.Service, verifier middleware.TokenVerifier, metrics *metrics.Metrics) *Handler {
+	return &Handler{config: config, authService: authService, userService: userService, lockoutService: lockoutService, verifier: verifier, metrics: metrics}
 }
 
 func (h *Handler) Login(c *gin.Context) {
+	if returnTo, ok := h.returnURL(c.Query("return_to")); ok {
+		c.SetCookie(returnToCookie, returnTo, 600, "/auth", "", true, true)
+	}
 	c.Redirect(http.StatusFound, h.authService.GetOAuthLoginURL())
 }
 
 func (h *Handler) Callback(c *gin.Context) {
+	defer h.metrics.LoginAttempt(metrics.ProviderGoogle, c)
 	code := c.Query("code")
 
-	idTokenClaims, err := h.authService.GetOAuthIDTokenClaims(code)
+	idTokenClaims, err := h.authService.GetOAuthIDTokenClaims(c.Request.Context(), code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	audit.SetEmail(c, idTokenClaims.Email)
+
+	locked, err := h.lockoutService.GetActiveLockout(idTokenClaims.Email, c.ClientIP())
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	if locked != nil {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*locked.LockedUntil).Seconds()))))
+		c.Error(apperror.New(apperror.CodeLoginLocked, "login temporarily locked").WithInternal(locked.Kind + ": " + locked.Value))
+		return
+	}
 
-	user, err := h.userService.GetUserByEmail(idTokenClaims.Email)
+	user, err := h.userService.WithContext(c.Request.Context()).GetUserByEmail(idTokenClaims.Email)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 	if user == nil {
-		c.Error(apperror.New(apperror.CodeUserNotAuthorized, "user not authorized | email: "+idTokenClaims.Email))
+		h.recordFailure(c, idTokenClaims.Email)
+		c.Error(apperror.New(apperror.CodeUserNotAuthorized, "user not authorized").WithInternal("email: " + redact.Fingerprint(idTokenClaims.Email)))
+		return
+	}
+	audit.SetActor(c, user.ID)
+	audit.SetTarget(c, user.ID)
+	if err = user.CheckStatus(); err != nil {
+		h.recordFailure(c, idTokenClaims.Email)
+		c.Error(err)
 		return
 	}
 
-	if err = h.userService.RecordUserLogin(user.ID, idTokenClaims.Name, idTokenClaims.Picture, idTokenClaims.Subject); err != nil {
+	if err = h.userService.WithContext(c.Request.Context()).RecordUserLogin(user.ID, idTokenClaims.Name, idTokenClaims.Picture, idTokenClaims.Subject); err != nil {
 		c.Error(err)
 		return
 	}
 
-	accessToken, err := h.authService.NewAccessToken(user.ID, idTokenClaims.Name, idTokenClaims.Email, idTokenClaims.Picture)
+	// a login starts a session that every refresh of its refresh token continues
+	sessionID := uuid.NewString()
+	accessToken, err := h.authService.NewAccessToken(user.ID, sessionID, idTokenClaims.Name, idTokenClaims.Email, idTokenClaims.Picture, user.Roles, user.Attributes)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	refreshToken, err := h.authService.NewRefreshToken(user.ID)
+	h.metrics.TokenIssued(metrics.TokenTypeAccess)
+	refreshToken, err := h.authService.NewRefreshToken(user.ID, sessionID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	h.metrics.TokenIssued(metrics.TokenTypeRefresh)
+
+	if err = h.lockoutService.Reset(idTokenClaims.Email); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to reset failed logins", zap.Error(err))
+	}
 
 	c.SetCookie("refresh_token", refreshToken, int(h.config.RefreshTokenTTL.Seconds()), "/", "", true, true)
+	// a login started by forward auth returns to the proxied page, which
+	// reads the access token from a cookie
+	if returnTo, err := c.Cookie(returnToCookie); err == nil {
+		c.SetCookie(returnToCookie, "", -1, "/auth", "", true, true)
+		if returnTo, ok := h.returnURL(returnTo); ok {
+			c.SetCookie(h.config.ForwardAuthCookie, accessToken, int(h.config.AccessTokenTTL.Seconds()), "/", h.config.ForwardAuthCookieDomain, true, true)
+			c.Redirect(http.StatusFound, returnTo)
+			return
+		}
+	}
 	c.Redirect(http.StatusFound, h.config.SiteURL+"?access_token="+accessToken)
 }
 
+// recordFailure counts a refused login towards the lockout of the email and
+// the client IP. The login has failed either way, so errors are only logged.
+func (h *Handler) recordFailure(c *gin.Context, email string) {
+	err := h.lockoutService.RecordFailure(lockout.Attempt{Email: email, IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to record failed login", zap.Error(err))
+	}
+}
+
 func (h *Handler) Refresh(c *gin.Context) {
+	defer h.metrics.Refresh(c)
 	refreshToken, _ := c.Cookie("refresh_token")
 	claims, err := h.authService.ParseRefreshToken(refreshToken)
 	if err != nil {
-		c.Error(apperror.New(apperror.CodeInvalidRefreshToken, "invalid refresh token | refresh token: "+refreshToken))
+		c.Error(apperror.New(apperror.CodeInvalidRefreshToken, "invalid refresh token").WithInternal("refresh token: " + redact.Fingerprint(refreshToken)))
 		return
 	}
 
 	userID, _ := strconv.Atoi(claims.Subject)
-	user, err := h.userService.GetUserByID(userID)
+	audit.SetActor(c, userID)
+	audit.SetTarget(c, userID)
+	accessToken, err := h.authService.RefreshAccessToken(c.Request.Context(), claims)
 	if err != nil {
 		c.Error(err)
 		return
-	} else if user == nil {
-		c.Error(apperror.New(apperror.CodeUserNotAuthorized, "user not authorized | id: "+strconv.Itoa(userID)))
+	}
+	h.metrics.TokenIssued(metrics.TokenTypeAccess)
+
+	c.JSON(http.StatusOK, TokenResponse{AccessToken: accessToken})
+}
+
+// Verify answers direct calls and the forward auth requests of reverse
+// proxies with the identity of the caller. The roles query parameter, e.g.
+// ?roles=admin,ops, requires one of the roles, so that every proxied route can
+// have its own policy.
+func (h *Handler) Verify(c *gin.Context) {
+	if roles := c.Query("roles"); roles != "" {
+		if !slices.ContainsFunc(strings.Split(roles, ","), func(role string) bool {
+			return middleware.HasRole(c, strings.TrimSpace(role))
+		}) {
+			c.Error(apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+roles))
+			return
+		}
+	}
+
+	if middleware.IsClient(c) {
+		c.Header(HeaderClientID, c.GetString("client_id"))
+		c.Header(HeaderScopes, strings.Join(c.GetStringSlice("token_scopes"), " "))
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Header(HeaderUserID, strconv.Itoa(c.GetInt("user_id")))
+	c.Header(HeaderEmail, c.GetString("user_email"))
+	c.Header(HeaderRoles, strings.Join(c.GetStringSlice("user_roles"), ","))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Introspect(c *gin.Context) {
+	if err := h.authenticateClient(c); err != nil {
+		if apperror.FromError(err).Code == apperror.CodeInvalidClient {
+			c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		}
+		c.Error(err)
+		return
+	}
+
+	var request IntrospectRequest
+	if err := c.ShouldBindWith(&request, binding.FormPost); err != nil {
+		c.Error(apperror.NewBindError("body", err))
 		return
 	}
 
-	accessToken, err := h.authService.NewAccessToken(user.ID, *user.Name, user.Email, *user.Picture)
+	claims, err := h.authService.Introspect(c.Request.Context(), request.Token, request.TokenTypeHint)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, TokenResponse{AccessToken: accessToken})
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, newIntrospectionResponse(claims))
 }
 
-func (h *Handler) Verify(c *gin.Context) {
-	c.Status(http.StatusNoContent)
+// authenticateClient checks the client credentials of the request, sent with
+// HTTP Basic authentication or as form parameters.
+func (h *Handler) authenticateClient(c *gin.Context) error {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	return h.verifier.VerifyClientCredentials(clientID, clientSecret)
 }
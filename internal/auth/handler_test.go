@@ -1,14 +1,22 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
 	"github.com/sninjo/vera-identity-service/internal/user"
 	"github.com/sninjo/vera-identity-service/test"
 
@@ -26,27 +34,42 @@ func (m *MockAuthService) GetOAuthLoginURL() string {
 	args := m.Called()
 	return args.String(0)
 }
-func (m *MockAuthService) GetOAuthIDTokenClaims(code string) (*OAuthIDTokenClaims, error) {
-	args := m.Called(code)
+func (m *MockAuthService) GetOAuthIDTokenClaims(ctx context.Context, code string) (*OAuthIDTokenClaims, error) {
+	args := m.Called(ctx, code)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*OAuthIDTokenClaims), args.Error(1)
 }
-func (m *MockAuthService) NewAccessToken(id int, name, email, picture string) (string, error) {
-	args := m.Called(id, name, email, picture)
+func (m *MockAuthService) NewAccessToken(id int, sessionID, name, email, picture string, roles []string, attributes map[string]any) (string, error) {
+	args := m.Called(id, sessionID, name, email, picture, roles, attributes)
 	if args.Get(0) == nil {
 		return "", args.Error(1)
 	}
 	return args.String(0), args.Error(1)
 }
-func (m *MockAuthService) NewRefreshToken(id int) (string, error) {
-	args := m.Called(id)
+func (m *MockAuthService) NewRefreshToken(id int, sessionID string) (string, error) {
+	args := m.Called(id, sessionID)
 	if args.Get(0) == nil {
 		return "", args.Error(1)
 	}
 	return args.String(0), args.Error(1)
 }
+func (m *MockAuthService) RefreshAccessToken(ctx context.Context, claims *TokenClaims) (string, error) {
+	args := m.Called(ctx, claims)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) NewClientAccessToken(clientID string, scopes []string) (string, error) {
+	args := m.Called(clientID, scopes)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) Introspect(ctx context.Context, token, tokenTypeHint string) (*TokenClaims, error) {
+	args := m.Called(ctx, token, tokenTypeHint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenClaims), args.Error(1)
+}
 func (m *MockAuthService) ParseAccessToken(token string) (*TokenClaims, error) {
 	args := m.Called(token)
 	if args.Get(0) == nil {
@@ -62,28 +85,37 @@ func (m *MockAuthService) ParseRefreshToken(token string) (*TokenClaims, error)
 	return args.Get(0).(*TokenClaims), args.Error(1)
 }
 
+func scrapeMetrics(m *metrics.Metrics) string {
+	c, w := test.SetupContext()
+	m.Handler()(c)
+	return w.Body.String()
+}
+
 func TestHandler_NewHandler_Success(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
 
 	// Act
-	h := NewHandler(config, mockAuthService, mockUserService)
+	h := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
 
 	// Assert
 	assert.IsType(t, &Handler{}, h)
 	assert.Equal(t, config, h.config)
 	assert.Equal(t, mockAuthService, h.authService)
 	assert.Equal(t, mockUserService, h.userService)
+	assert.Equal(t, mockLockoutService, h.lockoutService)
 }
 
 func TestHandler_Login_Success(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
 	c, w := test.SetupContext()
 
 	loginURL := "http://mock-oauth-url/auth"
@@ -99,14 +131,41 @@ func TestHandler_Login_Success(t *testing.T) {
 
 	require.Equal(t, http.StatusFound, w.Code)
 	assert.Equal(t, loginURL, w.Header().Get("Location"))
+	assert.Empty(t, w.Result().Cookies())
+}
+func TestHandler_Login_ReturnTo(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/auth/login?return_to="+url.QueryEscape("https://grafana.example.com/d/1"), nil)
+
+	mockAuthService.On("GetOAuthLoginURL").Return("http://mock-oauth-url/auth")
+
+	// Act
+	handler.Login(c)
+
+	// Assert
+	require.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "return_to", cookies[0].Name)
+	assert.Equal(t, url.QueryEscape("https://grafana.example.com/d/1"), cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
 }
 
 func TestHandler_Callback_Success(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
 	c, w := test.SetupContext()
 
 	code := "mock-code"
@@ -123,24 +182,31 @@ func TestHandler_Callback_Success(t *testing.T) {
 		Name:    test.StringPtr("mock-name2"),
 		Email:   "mock-email2",
 		Picture: test.StringPtr("mock-picture2"),
+		Roles:   []string{"admin"},
 	}
 	mockAccessToken := "mock-access-token"
 	mockRefreshToken := "mock-refresh-token"
 
 	c.Request.URL.RawQuery = fmt.Sprintf("code=%s", code)
 
-	mockAuthService.On("GetOAuthIDTokenClaims", code).Return(idTokenClaims, nil)
+	mockAuthService.On("GetOAuthIDTokenClaims", mock.Anything, code).Return(idTokenClaims, nil)
+	mockLockoutService.On("GetActiveLockout", idTokenClaims.Email, mock.Anything).Return(nil, nil)
 	mockUserService.On("GetUserByEmail", idTokenClaims.Email).Return(user, nil)
+	mockLockoutService.On("Reset", idTokenClaims.Email).Return(nil)
 	mockUserService.On("RecordUserLogin", user.ID, idTokenClaims.Name, idTokenClaims.Picture, idTokenClaims.Subject).Return(nil)
-	mockAuthService.On("NewAccessToken", user.ID, idTokenClaims.Name, idTokenClaims.Email, idTokenClaims.Picture).Return(mockAccessToken, nil)
-	mockAuthService.On("NewRefreshToken", user.ID).Return(mockRefreshToken, nil)
+	mockAuthService.On("NewAccessToken", user.ID, mock.AnythingOfType("string"), idTokenClaims.Name, idTokenClaims.Email, idTokenClaims.Picture, user.Roles, user.Attributes).Return(mockAccessToken, nil)
+	mockAuthService.On("NewRefreshToken", user.ID, mock.AnythingOfType("string")).Return(mockRefreshToken, nil)
 
 	// Act
 	handler.Callback(c)
 
 	// Assert
 	mockAuthService.AssertExpectations(t)
+	sessionID := mockAuthService.Calls[1].Arguments.String(1)
+	assert.NotEmpty(t, sessionID)
+	assert.Equal(t, sessionID, mockAuthService.Calls[2].Arguments.String(1))
 	mockUserService.AssertExpectations(t)
+	mockLockoutService.AssertExpectations(t)
 	require.Equal(t, http.StatusFound, w.Code)
 
 	cookies := w.Result().Cookies()
@@ -164,13 +230,64 @@ func TestHandler_Callback_Success(t *testing.T) {
 	expected, err := url.Parse(config.SiteURL + "?access_token=" + mockAccessToken)
 	require.NoError(t, err)
 	assert.Equal(t, expected, actual)
+
+	scraped := scrapeMetrics(m)
+	assert.Contains(t, scraped, `vera_identity_login_attempts_total{outcome="success",provider="google",reason=""} 1`)
+	assert.Contains(t, scraped, `vera_identity_tokens_issued_total{type="access"} 1`)
+	assert.Contains(t, scraped, `vera_identity_tokens_issued_total{type="refresh"} 1`)
+}
+
+func TestHandler_Callback_ReturnTo(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	idTokenClaims := &OAuthIDTokenClaims{Name: "mock-name", Email: "mock-email", Picture: "mock-picture"}
+	user := &user.User{ID: 1, Name: test.StringPtr("mock-name"), Email: "mock-email", Picture: test.StringPtr("mock-picture")}
+
+	c.Request.URL.RawQuery = "code=mock-code"
+	c.Request.AddCookie(&http.Cookie{Name: "return_to", Value: url.QueryEscape("https://grafana.example.com/d/1")})
+
+	mockAuthService.On("GetOAuthIDTokenClaims", mock.Anything, "mock-code").Return(idTokenClaims, nil)
+	mockLockoutService.On("GetActiveLockout", idTokenClaims.Email, mock.Anything).Return(nil, nil)
+	mockUserService.On("GetUserByEmail", idTokenClaims.Email).Return(user, nil)
+	mockLockoutService.On("Reset", idTokenClaims.Email).Return(nil)
+	mockUserService.On("RecordUserLogin", user.ID, idTokenClaims.Name, idTokenClaims.Picture, idTokenClaims.Subject).Return(nil)
+	mockAuthService.On("NewAccessToken", user.ID, mock.AnythingOfType("string"), idTokenClaims.Name, idTokenClaims.Email, idTokenClaims.Picture, user.Roles, user.Attributes).Return("mock-access-token", nil)
+	mockAuthService.On("NewRefreshToken", user.ID, mock.AnythingOfType("string")).Return("mock-refresh-token", nil)
+
+	// Act
+	handler.Callback(c)
+
+	// Assert
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://grafana.example.com/d/1", w.Header().Get("Location"))
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	require.Contains(t, cookies, "access_token")
+	assert.Equal(t, "mock-access-token", cookies["access_token"].Value)
+	assert.Equal(t, "example.com", cookies["access_token"].Domain)
+	assert.Equal(t, int(config.AccessTokenTTL.Seconds()), cookies["access_token"].MaxAge)
+	assert.True(t, cookies["access_token"].HttpOnly)
+	require.Contains(t, cookies, "return_to")
+	assert.Negative(t, cookies["return_to"].MaxAge)
 }
+
 func TestHandler_Callback_UserNotFound(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
 	c, w := test.SetupContext()
 
 	code := "mock-code"
@@ -185,8 +302,59 @@ func TestHandler_Callback_UserNotFound(t *testing.T) {
 
 	c.Request.URL.RawQuery = fmt.Sprintf("code=%s", code)
 
-	mockAuthService.On("GetOAuthIDTokenClaims", code).Return(idTokenClaims, nil)
+	mockAuthService.On("GetOAuthIDTokenClaims", mock.Anything, code).Return(idTokenClaims, nil)
+	mockLockoutService.On("GetActiveLockout", idTokenClaims.Email, mock.Anything).Return(nil, nil)
 	mockUserService.On("GetUserByEmail", idTokenClaims.Email).Return(nil, nil)
+	mockLockoutService.On("RecordFailure", mock.MatchedBy(func(attempt lockout.Attempt) bool {
+		return attempt.Email == idTokenClaims.Email
+	})).Return(nil)
+
+	// Act
+	handler.Callback(c)
+
+	// Assert
+	mockAuthService.AssertExpectations(t)
+	mockUserService.AssertExpectations(t)
+	mockLockoutService.AssertExpectations(t)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeUserNotAuthorized, appErr.Code)
+	assert.Equal(t, "user not authorized", appErr.Message)
+	assert.Equal(t, "email: "+redact.Fingerprint(idTokenClaims.Email), appErr.Internal)
+
+	assert.Contains(t, scrapeMetrics(m), `vera_identity_login_attempts_total{outcome="failure",provider="google",reason="user_not_authorized"} 1`)
+}
+
+func TestHandler_Callback_UserSuspended(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	code := "mock-code"
+	idTokenClaims := &OAuthIDTokenClaims{
+		Name:    "mock-name",
+		Email:   "mock-email",
+		Picture: "mock-picture",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "mock-subject",
+		},
+	}
+	user := &user.User{ID: 1, Email: "mock-email", Status: user.StatusSuspended}
+
+	c.Request.URL.RawQuery = fmt.Sprintf("code=%s", code)
+
+	mockAuthService.On("GetOAuthIDTokenClaims", mock.Anything, code).Return(idTokenClaims, nil)
+	mockLockoutService.On("GetActiveLockout", idTokenClaims.Email, mock.Anything).Return(nil, nil)
+	mockUserService.On("GetUserByEmail", idTokenClaims.Email).Return(user, nil)
+	mockLockoutService.On("RecordFailure", mock.MatchedBy(func(attempt lockout.Attempt) bool {
+		return attempt.Email == idTokenClaims.Email
+	})).Return(nil)
 
 	// Act
 	handler.Callback(c)
@@ -194,18 +362,62 @@ func TestHandler_Callback_UserNotFound(t *testing.T) {
 	// Assert
 	mockAuthService.AssertExpectations(t)
 	mockUserService.AssertExpectations(t)
+	mockLockoutService.AssertExpectations(t)
+	mockUserService.AssertNotCalled(t, "RecordUserLogin", user.ID, idTokenClaims.Name, idTokenClaims.Picture, idTokenClaims.Subject)
 
 	require.Equal(t, http.StatusOK, w.Code)
 	assert.Len(t, c.Errors, 1)
-	assert.Equal(t, apperror.CodeUserNotAuthorized, c.Errors[0].Err.(*apperror.AppError).Code)
+	assert.Equal(t, apperror.CodeUserSuspended, c.Errors[0].Err.(*apperror.AppError).Code)
+}
+
+func TestHandler_Callback_Locked(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	code := "mock-code"
+	idTokenClaims := &OAuthIDTokenClaims{Email: "mock-email"}
+	lockedUntil := time.Now().Add(90 * time.Second)
+	locked := &lockout.Lockout{Kind: lockout.KindIP, Value: "192.0.2.1", Failures: 20, LockedUntil: &lockedUntil}
+
+	c.Request.URL.RawQuery = fmt.Sprintf("code=%s", code)
+
+	mockAuthService.On("GetOAuthIDTokenClaims", mock.Anything, code).Return(idTokenClaims, nil)
+	mockLockoutService.On("GetActiveLockout", idTokenClaims.Email, "192.0.2.1").Return(locked, nil)
+
+	// Act
+	handler.Callback(c)
+
+	// Assert
+	mockAuthService.AssertExpectations(t)
+	mockLockoutService.AssertExpectations(t)
+	mockUserService.AssertNotCalled(t, "GetUserByEmail", mock.Anything)
+	mockLockoutService.AssertNotCalled(t, "RecordFailure", mock.Anything)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeLoginLocked, appErr.Code)
+	assert.NotContains(t, appErr.Message, "192.0.2.1")
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+
+	assert.Contains(t, scrapeMetrics(m), `vera_identity_login_attempts_total{outcome="failure",provider="google",reason="login_locked"} 1`)
 }
 
 func TestHandler_Refresh_Success(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
 	c, w := test.SetupContext()
 
 	mockAccessToken := "mock-access-token"
@@ -217,6 +429,7 @@ func TestHandler_Refresh_Success(t *testing.T) {
 		Picture: test.StringPtr("mock-picture"),
 	}
 	claims := &TokenClaims{
+		SessionID: "mock-session-id",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject: strconv.Itoa(user.ID),
 		},
@@ -225,8 +438,7 @@ func TestHandler_Refresh_Success(t *testing.T) {
 	c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: mockRefreshToken})
 
 	mockAuthService.On("ParseRefreshToken", mockRefreshToken).Return(claims, nil)
-	mockUserService.On("GetUserByID", user.ID).Return(user, nil)
-	mockAuthService.On("NewAccessToken", user.ID, *user.Name, user.Email, *user.Picture).Return(mockAccessToken, nil)
+	mockAuthService.On("RefreshAccessToken", mock.Anything, claims).Return(mockAccessToken, nil)
 
 	// Act
 	handler.Refresh(c)
@@ -240,13 +452,20 @@ func TestHandler_Refresh_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
 	assert.Equal(t, mockAccessToken, resp.AccessToken)
+
+	scraped := scrapeMetrics(m)
+	assert.Contains(t, scraped, `vera_identity_token_refreshes_total{outcome="success",reason=""} 1`)
+	assert.Contains(t, scraped, `vera_identity_tokens_issued_total{type="access"} 1`)
 }
+
 func TestHandler_Refresh_InvalidRefreshToken(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
 	c, w := test.SetupContext()
 
 	mockRefreshToken := "mock-refresh-token"
@@ -264,28 +483,37 @@ func TestHandler_Refresh_InvalidRefreshToken(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, w.Code)
 	assert.Len(t, c.Errors, 1)
-	assert.Equal(t, apperror.CodeInvalidRefreshToken, c.Errors[0].Err.(*apperror.AppError).Code)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRefreshToken, appErr.Code)
+	assert.NotContains(t, appErr.Error(), mockRefreshToken)
+	assert.Contains(t, appErr.Internal, redact.Fingerprint(mockRefreshToken))
+
+	scraped := scrapeMetrics(m)
+	assert.Contains(t, scraped, `vera_identity_token_refreshes_total{outcome="failure",reason="invalid_refresh_token"} 1`)
+	assert.NotContains(t, scraped, `vera_identity_tokens_issued_total`)
 }
-func TestHandler_Refresh_UserNotFound(t *testing.T) {
+
+func TestHandler_Refresh_UserPending(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	m := metrics.New()
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), m)
 	c, w := test.SetupContext()
 
 	mockRefreshToken := "mock-refresh-token"
-	user := &user.User{ID: 1}
 	claims := &TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject: strconv.Itoa(user.ID),
+			Subject: "1",
 		},
 	}
 
 	c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: mockRefreshToken})
 
 	mockAuthService.On("ParseRefreshToken", mockRefreshToken).Return(claims, nil)
-	mockUserService.On("GetUserByID", user.ID).Return(nil, nil)
+	mockAuthService.On("RefreshAccessToken", mock.Anything, claims).Return("", apperror.New(apperror.CodeUserPending, "user pending | id: 1"))
 
 	// Act
 	handler.Refresh(c)
@@ -296,17 +524,27 @@ func TestHandler_Refresh_UserNotFound(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, w.Code)
 	assert.Len(t, c.Errors, 1)
-	assert.Equal(t, apperror.CodeUserNotAuthorized, c.Errors[0].Err.(*apperror.AppError).Code)
+	assert.Equal(t, apperror.CodeUserPending, c.Errors[0].Err.(*apperror.AppError).Code)
+
+	scraped := scrapeMetrics(m)
+	assert.Contains(t, scraped, `vera_identity_token_refreshes_total{outcome="failure",reason="user_pending"} 1`)
+	assert.NotContains(t, scraped, `vera_identity_tokens_issued_total`)
 }
 
 func TestHandler_Verify_Success(t *testing.T) {
 	// Arrange
 	mockAuthService := &MockAuthService{}
 	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
 	config := NewMockConfig("")
-	handler := NewHandler(config, mockAuthService, mockUserService)
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
 	c, w := test.SetupContext()
 
+	c.Request = httptest.NewRequest("GET", "/auth/verify?roles=ops,%20admin", nil)
+	c.Set("user_id", 1)
+	c.Set("user_email", "user@example.com")
+	c.Set("user_roles", []string{"admin", "auditor"})
+
 	// Act
 	handler.Verify(c)
 	c.Writer.WriteHeaderNow()
@@ -314,5 +552,195 @@ func TestHandler_Verify_Success(t *testing.T) {
 	// Assert
 	mockAuthService.AssertExpectations(t)
 	mockUserService.AssertExpectations(t)
+	require.Empty(t, c.Errors)
 	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderUserID))
+	assert.Equal(t, "user@example.com", w.Header().Get(HeaderEmail))
+	assert.Equal(t, "admin,auditor", w.Header().Get(HeaderRoles))
+}
+func TestHandler_Verify_Client(t *testing.T) {
+	// Arrange
+	handler := NewHandler(NewMockConfig(""), &MockAuthService{}, &MockUserService{}, &MockLockoutService{}, middleware.NewTokenVerifier(NewMockConfig(""), &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/auth/verify", nil)
+	c.Set("client_id", "svc_batch")
+	c.Set("token_scopes", []string{"users:read", "reports:read"})
+
+	// Act
+	handler.Verify(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Empty(t, c.Errors)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "svc_batch", w.Header().Get(HeaderClientID))
+	assert.Equal(t, "users:read reports:read", w.Header().Get(HeaderScopes))
+	assert.Empty(t, w.Header().Get(HeaderUserID))
+}
+func TestHandler_Verify_MissingRole(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/auth/verify?roles=admin", nil)
+	c.Set("user_id", 1)
+	c.Set("user_roles", []string{"auditor"})
+
+	// Act
+	handler.Verify(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodePermissionDenied, c.Errors[0].Err.(*apperror.AppError).Code)
+	assert.Empty(t, w.Header().Get(HeaderUserID))
+}
+
+func TestHandler_Introspect_Active(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/oauth2/introspect", strings.NewReader("token=mock-access-token&token_type_hint=access_token"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Request.SetBasicAuth("mock-gateway", "mock-gateway-secret")
+	claims := &TokenClaims{
+		Email:     "user@example.com",
+		Roles:     []string{"admin"},
+		SessionID: "mock-session-id",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			Issuer:    "identity@vera.sninjo.com",
+			IssuedAt:  jwt.NewNumericDate(time.Unix(1, 0)),
+			ExpiresAt: jwt.NewNumericDate(time.Unix(3601, 0)),
+		},
+	}
+
+	mockAuthService.On("Introspect", mock.Anything, "mock-access-token", TokenTypeHintAccessToken).Return(claims, nil)
+
+	// Act
+	handler.Introspect(c)
+
+	// Assert
+	mockAuthService.AssertExpectations(t)
+	require.Empty(t, c.Errors)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"active":true,"sub":"1","iss":"identity@vera.sninjo.com","exp":3601,"iat":1,"email":"user@example.com","roles":["admin"],"sid":"mock-session-id"}`, w.Body.String())
+}
+func TestHandler_Introspect_Inactive(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/oauth2/introspect", strings.NewReader("token=revoked&client_id=mock-gateway&client_secret=mock-gateway-secret"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	mockAuthService.On("Introspect", mock.Anything, "revoked", "").Return(nil, nil)
+
+	// Act
+	handler.Introspect(c)
+
+	// Assert
+	mockAuthService.AssertExpectations(t)
+	require.Empty(t, c.Errors)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+}
+func TestHandler_Introspect_RegisteredClient(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockClientChecker := &MockClientChecker{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, &MockLockoutService{}, middleware.NewTokenVerifier(config, mockUserService, mockClientChecker, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/oauth2/introspect", strings.NewReader("token=revoked"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Request.SetBasicAuth("svc_gateway", "svc-secret")
+
+	mockClientChecker.On("CheckClientSecret", "svc_gateway", "svc-secret").Return(nil)
+	mockAuthService.On("Introspect", mock.Anything, "revoked", "").Return(nil, nil)
+
+	// Act
+	handler.Introspect(c)
+
+	// Assert
+	mockClientChecker.AssertExpectations(t)
+	mockAuthService.AssertExpectations(t)
+	require.Empty(t, c.Errors)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+func TestHandler_Introspect_InvalidClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+	}{
+		{name: "wrong secret", username: "mock-gateway", password: "wrong-secret"},
+		{name: "unknown client", username: "unknown", password: "mock-gateway-secret"},
+		{name: "empty secret", username: "mock-gateway", password: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockAuthService := &MockAuthService{}
+			mockUserService := &MockUserService{}
+			mockClientChecker := &MockClientChecker{}
+			config := NewMockConfig("")
+			handler := NewHandler(config, mockAuthService, mockUserService, &MockLockoutService{}, middleware.NewTokenVerifier(config, mockUserService, mockClientChecker, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+			c, w := test.SetupContext()
+
+			c.Request = httptest.NewRequest("POST", "/oauth2/introspect", strings.NewReader("token=mock-access-token"))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			c.Request.SetBasicAuth(tt.username, tt.password)
+
+			// clients that are not configured are looked up among the registered ones
+			mockClientChecker.On("CheckClientSecret", "unknown", "mock-gateway-secret").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+			// Act
+			handler.Introspect(c)
+
+			// Assert
+			mockAuthService.AssertNotCalled(t, "Introspect", mock.Anything, mock.Anything, mock.Anything)
+			require.Len(t, c.Errors, 1)
+			appErr := c.Errors[0].Err.(*apperror.AppError)
+			assert.Equal(t, apperror.CodeInvalidClient, appErr.Code)
+			assert.Equal(t, `Basic realm="introspect"`, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+func TestHandler_Introspect_MissingToken(t *testing.T) {
+	// Arrange
+	mockAuthService := &MockAuthService{}
+	mockUserService := &MockUserService{}
+	mockLockoutService := &MockLockoutService{}
+	config := NewMockConfig("")
+	handler := NewHandler(config, mockAuthService, mockUserService, mockLockoutService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), metrics.New())
+	c, _ := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/oauth2/introspect", strings.NewReader(""))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Request.SetBasicAuth("mock-gateway", "mock-gateway-secret")
+
+	// Act
+	handler.Introspect(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeValidationFailed, c.Errors[0].Err.(*apperror.AppError).Code)
 }
@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"context"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/attribute"
 	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
 	"github.com/sninjo/vera-identity-service/internal/user"
 
 	"github.com/stretchr/testify/mock"
@@ -30,6 +34,12 @@ func NewMockConfig(oauthURL string) *config.Config {
 		RefreshTokenSecret: []byte("mock-refresh-token-secret"),
 		AccessTokenTTL:     time.Hour,
 		RefreshTokenTTL:    2 * time.Hour,
+
+		IntrospectionClients: map[string]string{"mock-gateway": "mock-gateway-secret"},
+
+		ForwardAuthCookie:       "access_token",
+		ForwardAuthCookieDomain: ".example.com",
+		ForwardAuthReturnHosts:  []string{".example.com"},
 	}
 }
 
@@ -62,11 +72,46 @@ func (m *MockUserService) GetUsers() ([]user.User, error) {
 	}
 	return args.Get(0).([]user.User), args.Error(1)
 }
-func (m *MockUserService) UpdateUser(id int, email string) error {
-	args := m.Called(id, email)
+func (m *MockUserService) GetDeletedUsers() ([]user.User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.User), args.Error(1)
+}
+func (m *MockUserService) UpdateUser(id int, email string, attributes map[string]any, allowPrivileged bool) error {
+	args := m.Called(id, email, attributes, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockUserService) DeleteUser(id int, allowPrivileged bool) error {
+	args := m.Called(id, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockUserService) RestoreUser(id int, allowPrivileged bool) error {
+	args := m.Called(id, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockUserService) HardDeleteUser(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockUserService) PurgeDeletedUsers(retention time.Duration) (int64, error) {
+	args := m.Called(retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockUserService) SetUserRoles(id int, roles []string) error {
+	args := m.Called(id, roles)
+	return args.Error(0)
+}
+func (m *MockUserService) SuspendUser(id int, reason *string, until *time.Time) error {
+	args := m.Called(id, reason, until)
+	return args.Error(0)
+}
+func (m *MockUserService) ReactivateUser(id int) error {
+	args := m.Called(id)
 	return args.Error(0)
 }
-func (m *MockUserService) DeleteUser(id int) error {
+func (m *MockUserService) CheckUserStatus(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
@@ -74,3 +119,111 @@ func (m *MockUserService) RecordUserLogin(id int, name, picture, loginSub string
 	args := m.Called(id, name, picture, loginSub)
 	return args.Error(0)
 }
+func (m *MockUserService) ImportUsers(entries []user.ImportEntry, dryRun, allowRoles bool) ([]user.ImportResult, error) {
+	args := m.Called(entries, dryRun, allowRoles)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.ImportResult), args.Error(1)
+}
+func (m *MockUserService) ExportUsers(fn func(users []user.User) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+func (m *MockUserService) WithContext(ctx context.Context) user.Service {
+	return m
+}
+
+type MockAttributeService struct {
+	mock.Mock
+}
+
+func (m *MockAttributeService) GetDefinitions() ([]attribute.Definition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]attribute.Definition), args.Error(1)
+}
+func (m *MockAttributeService) CreateDefinition(definition *attribute.Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockAttributeService) UpdateDefinition(definition *attribute.Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockAttributeService) DeleteDefinition(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+func (m *MockAttributeService) Merge(current, patch map[string]any) (map[string]any, error) {
+	args := m.Called(current, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+func (m *MockAttributeService) Claims(attributes map[string]any) (map[string]any, error) {
+	args := m.Called(attributes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+
+type MockLockoutService struct {
+	mock.Mock
+}
+
+func (m *MockLockoutService) GetActiveLockout(email, ip string) (*lockout.Lockout, error) {
+	args := m.Called(email, ip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lockout.Lockout), args.Error(1)
+}
+func (m *MockLockoutService) RecordFailure(attempt lockout.Attempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+func (m *MockLockoutService) Reset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+func (m *MockLockoutService) GetLockouts() ([]lockout.Lockout, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]lockout.Lockout), args.Error(1)
+}
+func (m *MockLockoutService) Unlock(kind, value string) error {
+	args := m.Called(kind, value)
+	return args.Error(0)
+}
+
+type MockClientChecker struct {
+	mock.Mock
+}
+
+func (m *MockClientChecker) CheckClient(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+func (m *MockClientChecker) CheckClientSecret(clientID, secret string) error {
+	args := m.Called(clientID, secret)
+	return args.Error(0)
+}
+
+type MockPersonalAccessTokenAuthenticator struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenAuthenticator) AuthenticatePersonalAccessToken(token string) (*middleware.PersonalAccessToken, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*middleware.PersonalAccessToken), args.Error(1)
+}
@@ -1,14 +1,20 @@
 package auth
 
 import (
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware) {
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware, auditMiddleware audit.Middleware, rateLimit ratelimit.Middleware) {
 	r.GET("/auth/login", handler.Login)
-	r.GET("/auth/callback", handler.Callback)
-	r.POST("/auth/refresh", handler.Refresh)
-	r.POST("/auth/verify", gin.HandlerFunc(authMiddleware), handler.Verify)
+	// limited before auditing so that a flood does not flood the audit log too
+	r.GET("/auth/callback", rateLimit(ratelimit.PolicyCallback), auditMiddleware(audit.EventLogin), handler.Callback)
+	r.POST("/auth/refresh", rateLimit(ratelimit.PolicyRefresh), auditMiddleware(audit.EventRefresh), handler.Refresh)
+	// nginx sends forward auth requests with the method of the request it
+	// forwards, so any method is accepted
+	r.Any("/auth/verify", rateLimit(ratelimit.PolicyVerify), handler.ForwardAuth, gin.HandlerFunc(authMiddleware), rateLimit(ratelimit.PolicyVerify), handler.Verify)
+	r.POST("/oauth2/introspect", handler.Introspect)
 }
@@ -3,33 +3,69 @@ package auth
 import (
 	"context"
 	"errors"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/attribute"
 	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/requestid"
+	"github.com/sninjo/vera-identity-service/internal/tracing"
 	"github.com/sninjo/vera-identity-service/internal/user"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 )
 
 type Service interface {
 	GetOAuthLoginURL() string
-	GetOAuthIDTokenClaims(code string) (*OAuthIDTokenClaims, error)
-	NewAccessToken(id int, name, email, picture string) (string, error)
-	NewRefreshToken(id int) (string, error)
+	GetOAuthIDTokenClaims(ctx context.Context, code string) (*OAuthIDTokenClaims, error)
+	NewAccessToken(id int, sessionID, name, email, picture string, roles []string, attributes map[string]any) (string, error)
+	NewRefreshToken(id int, sessionID string) (string, error)
+	// RefreshAccessToken returns a new access token for the session of the
+	// parsed refresh token, if its user may still sign in.
+	RefreshAccessToken(ctx context.Context, claims *TokenClaims) (string, error)
+	// NewClientAccessToken returns an access token of a service client, which
+	// acts as itself rather than as a user.
+	NewClientAccessToken(clientID string, scopes []string) (string, error)
 	ParseAccessToken(token string) (*TokenClaims, error)
 	ParseRefreshToken(token string) (*TokenClaims, error)
+	// Introspect returns the claims of a token that is valid and whose user
+	// may still use it, or nil for any other token. Tokens of service clients
+	// are active until they expire or the client is deleted, and personal
+	// access tokens until they expire or are revoked. Only errors
+	// looking up the user or client are returned.
+	Introspect(ctx context.Context, token, tokenTypeHint string) (*TokenClaims, error)
 }
 
 type service struct {
-	config      *config.Config
-	userService user.Service
+	config           *config.Config
+	userService      user.Service
+	attributeService attribute.Service
+	verifier         middleware.TokenVerifier
+	tracer           trace.Tracer
+	// sends the request ID and trace context along to Google
+	oauthClient *http.Client
 }
 
-func NewService(config *config.Config, userService user.Service) Service {
-	return &service{config: config, userService: userService}
+func NewService(config *config.Config, userService user.Service, attributeService attribute.Service, verifier middleware.TokenVerifier, tracerProvider trace.TracerProvider) Service {
+	return &service{
+		config:           config,
+		userService:      userService,
+		attributeService: attributeService,
+		verifier:         verifier,
+		tracer:           tracerProvider.Tracer("github.com/sninjo/vera-identity-service/internal/auth"),
+		oauthClient: &http.Client{
+			Transport: &tracing.Transport{Base: &requestid.Transport{Base: http.DefaultTransport}},
+		},
+	}
 }
 
 func (s *service) GetOAuthLoginURL() string {
@@ -37,35 +73,69 @@ func (s *service) GetOAuthLoginURL() string {
 	return s.config.OAuth2.AuthCodeURL("state", oauth2.AccessTypeOffline)
 }
 
-func (s *service) GetOAuthIDTokenClaims(code string) (*OAuthIDTokenClaims, error) {
-	oauthToken, err := s.config.OAuth2.Exchange(context.Background(), code)
+// exchange trades the authorization code for tokens at Google, which is the
+// slowest step of a login and therefore has its own span.
+func (s *service) exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	ctx, span := s.tracer.Start(ctx, "oauth2.Exchange", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	oauthToken, err := s.config.OAuth2.Exchange(context.WithValue(ctx, oauth2.HTTPClient, s.oauthClient), code)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to exchange OAuth code")
+		return nil, err
+	}
+	return oauthToken, nil
+}
+
+func (s *service) GetOAuthIDTokenClaims(ctx context.Context, code string) (*OAuthIDTokenClaims, error) {
+	oauthToken, err := s.exchange(ctx, code)
 	if err != nil {
-		return nil, apperror.New(apperror.CodeInvalidOAuthCode, "failed to exchange OAuth code | code: "+code)
+		// the raw error may quote the response body, which can echo the code
+		reason := err.Error()
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			reason = retrieveErr.ErrorCode + " " + retrieveErr.ErrorDescription
+		}
+		return nil, apperror.New(apperror.CodeInvalidOAuthCode, "failed to exchange OAuth code").
+			WithInternal("code: " + redact.Fingerprint(code) + " | error: " + strings.TrimSpace(reason))
 	}
 
 	idToken, _ := oauthToken.Extra("id_token").(string)
 	claims := &OAuthIDTokenClaims{}
 	_, _, err = new(jwt.Parser).ParseUnverified(idToken, claims)
 	if err != nil {
-		return nil, apperror.New(apperror.CodeInvalidOAuthIdToken, "failed to parse id_token | id_token: "+idToken)
+		return nil, apperror.New(apperror.CodeInvalidOAuthIdToken, "failed to parse id_token").
+			WithInternal("id_token: " + redact.Fingerprint(idToken) + " | error: " + err.Error())
 	}
 
-	if claims.Subject == "" || claims.Name == "" || claims.Email == "" || claims.Picture == "" {
-		return nil, apperror.New(
-			apperror.CodeMissingUserInfo,
-			"missing sub, name, email, or picture "+
-				"| subject: "+claims.Subject+" | name: "+claims.Name+" | email: "+claims.Email+" | picture: "+claims.Picture,
-		)
+	var missing []string
+	for claim, value := range map[string]string{"sub": claims.Subject, "name": claims.Name, "email": claims.Email, "picture": claims.Picture} {
+		if value == "" {
+			missing = append(missing, claim)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, apperror.New(apperror.CodeMissingUserInfo, "missing sub, name, email, or picture").
+			WithInternal("missing claims: " + strings.Join(missing, ", "))
 	}
 
 	return claims, nil
 }
 
-func (s *service) NewAccessToken(id int, name, email, picture string) (string, error) {
+func (s *service) NewAccessToken(id int, sessionID, name, email, picture string, roles []string, attributes map[string]any) (string, error) {
+	attributeClaims, err := s.attributeService.Claims(attributes)
+	if err != nil {
+		return "", err
+	}
+
 	claims := TokenClaims{
-		Name:    name,
-		Email:   email,
-		Picture: picture,
+		Name:       name,
+		Email:      email,
+		Picture:    picture,
+		Roles:      roles,
+		Attributes: attributeClaims,
+		SessionID:  sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.Itoa(id),
 			Issuer:    "identity@vera.sninjo.com",
@@ -76,8 +146,9 @@ func (s *service) NewAccessToken(id int, name, email, picture string) (string, e
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.AccessTokenSecret)
 }
 
-func (s *service) NewRefreshToken(id int) (string, error) {
+func (s *service) NewRefreshToken(id int, sessionID string) (string, error) {
 	claims := TokenClaims{
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.Itoa(id),
 			Issuer:    "identity@vera.sninjo.com",
@@ -88,6 +159,35 @@ func (s *service) NewRefreshToken(id int) (string, error) {
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.RefreshTokenSecret)
 }
 
+func (s *service) RefreshAccessToken(ctx context.Context, claims *TokenClaims) (string, error) {
+	userID, _ := strconv.Atoi(claims.Subject)
+	user, err := s.userService.WithContext(ctx).GetUserByID(userID)
+	if err != nil {
+		return "", err
+	} else if user == nil {
+		return "", apperror.New(apperror.CodeUserNotAuthorized, "user not authorized | id: "+strconv.Itoa(userID))
+	}
+	if err = user.CheckStatus(); err != nil {
+		return "", err
+	}
+
+	return s.NewAccessToken(user.ID, claims.SessionID, *user.Name, user.Email, *user.Picture, user.Roles, user.Attributes)
+}
+
+func (s *service) NewClientAccessToken(clientID string, scopes []string) (string, error) {
+	claims := TokenClaims{
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   clientID,
+			Issuer:    "identity@vera.sninjo.com",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.AccessTokenTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.AccessTokenSecret)
+}
+
 func (s *service) ParseAccessToken(token string) (*TokenClaims, error) {
 	claims := &TokenClaims{}
 	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
@@ -119,3 +219,87 @@ func (s *service) ParseRefreshToken(token string) (*TokenClaims, error) {
 
 	return claims, nil
 }
+
+func (s *service) Introspect(ctx context.Context, token, tokenTypeHint string) (*TokenClaims, error) {
+	// personal access tokens are told apart by their prefix, whatever the hint
+	if strings.HasPrefix(token, middleware.PersonalAccessTokenPrefix) {
+		return s.introspectPersonalAccessToken(token)
+	}
+
+	// the hint only decides which kind of token is tried first
+	introspectors := []func(context.Context, string) (*TokenClaims, error){s.introspectAccessToken, s.introspectRefreshToken}
+	if tokenTypeHint == TokenTypeHintRefreshToken {
+		slices.Reverse(introspectors)
+	}
+
+	for _, introspect := range introspectors {
+		if claims, err := introspect(ctx, token); err != nil || claims != nil {
+			return claims, err
+		}
+	}
+	return nil, nil
+}
+
+// introspectAccessToken applies the rules of the shared verifier, except that
+// the status of users is checked even where it is not enforced on requests.
+func (s *service) introspectAccessToken(ctx context.Context, token string) (*TokenClaims, error) {
+	claims, err := s.ParseAccessToken(token)
+	if err != nil {
+		return nil, nil
+	}
+	identity, err := s.verifier.Verify(token)
+	if err != nil {
+		return nil, inactive(err)
+	}
+	if !identity.IsClient() && !s.config.EnforceUserStatus {
+		if err := s.userService.WithContext(ctx).CheckUserStatus(identity.UserID); err != nil {
+			return nil, inactive(err)
+		}
+	}
+	return claims, nil
+}
+
+func (s *service) introspectRefreshToken(ctx context.Context, token string) (*TokenClaims, error) {
+	claims, err := s.ParseRefreshToken(token)
+	if err != nil {
+		return nil, nil
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, nil
+	}
+	// suspended, pending and deleted users can no longer use their tokens
+	if err := s.userService.WithContext(ctx).CheckUserStatus(userID); err != nil {
+		return nil, inactive(err)
+	}
+	return claims, nil
+}
+
+// introspectPersonalAccessToken describes the token with the claims an access
+// token of its user would have, and the scopes it grants.
+func (s *service) introspectPersonalAccessToken(token string) (*TokenClaims, error) {
+	identity, err := s.verifier.Verify(token)
+	if err != nil {
+		return nil, inactive(err)
+	}
+	return &TokenClaims{
+		Email: identity.Email,
+		Roles: identity.Roles,
+		Scope: strings.Join(identity.Scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(identity.UserID),
+			Issuer:    "identity@vera.sninjo.com",
+			ExpiresAt: jwt.NewNumericDate(identity.ExpiresAt),
+		},
+	}, nil
+}
+
+// inactive turns the refusal of a token into an inactive introspection and
+// passes every other error on.
+func inactive(err error) error {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return nil
+	}
+	return err
+}
@@ -1,36 +1,50 @@
 package auth
 
 import (
+	"context"
 	"net/url"
 	"testing"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/requestid"
+	"github.com/sninjo/vera-identity-service/internal/user"
 	"github.com/sninjo/vera-identity-service/test"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestService_NewService_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
 
 	// Act
-	s := NewService(config, mockUserService)
+	s := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	// Assert
 	assert.IsType(t, &service{}, s)
 	assert.Equal(t, mockUserService, s.(*service).userService)
+	assert.Equal(t, mockAttributeService, s.(*service).attributeService)
 	assert.Equal(t, config, s.(*service).config)
 }
 
 func TestService_GetOAuthLoginURL_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("http://mock-oauth-url")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	// Act
 	actualURLStr := service.GetOAuthLoginURL()
@@ -57,34 +71,85 @@ func TestService_GetOAuthIDTokenClaims_Success(t *testing.T) {
 	// Arrange
 	oauthAPI := test.SetupOAuthAPI()
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig(oauthAPI.URL)
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	ctx := requestid.NewContext(context.Background(), "request-1")
 
 	// Act
-	claims, err := service.GetOAuthIDTokenClaims(oauthAPI.AuthorizationCode)
+	claims, err := service.GetOAuthIDTokenClaims(ctx, oauthAPI.AuthorizationCode)
 
 	// Assert
 	mockUserService.AssertExpectations(t)
 	require.NoError(t, err)
+	assert.Equal(t, "request-1", oauthAPI.LastRequestID)
 
 	assert.Equal(t, oauthAPI.IDTokenClaims.Subject, claims.Subject)
 	assert.Equal(t, oauthAPI.IDTokenClaims.Name, claims.Name)
 	assert.Equal(t, oauthAPI.IDTokenClaims.Email, claims.Email)
 	assert.Equal(t, oauthAPI.IDTokenClaims.Picture, claims.Picture)
 }
+func TestService_GetOAuthIDTokenClaims_Traced(t *testing.T) {
+	// Arrange
+	oauthAPI := test.SetupOAuthAPI()
+	config := NewMockConfig(oauthAPI.URL)
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	service := NewService(config, &MockUserService{}, &MockAttributeService{}, middleware.NewTokenVerifier(config, &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), tracerProvider)
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "GET /auth/callback")
+
+	// Act
+	_, err := service.GetOAuthIDTokenClaims(ctx, oauthAPI.AuthorizationCode)
+	parent.End()
+
+	// Assert
+	require.NoError(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "oauth2.Exchange", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01", oauthAPI.LastTraceparent)
+}
+func TestService_GetOAuthIDTokenClaims_InvalidCode(t *testing.T) {
+	// Arrange
+	oauthAPI := test.SetupOAuthAPI()
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig(oauthAPI.URL)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	// Act
+	claims, err := service.GetOAuthIDTokenClaims(context.Background(), "leaked-code")
+
+	// Assert
+	assert.Nil(t, claims)
+	appErr := err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidOAuthCode, appErr.Code)
+	assert.Equal(t, "failed to exchange OAuth code", appErr.Message)
+	assert.Contains(t, appErr.Internal, redact.Fingerprint("leaked-code"))
+	assert.NotContains(t, appErr.Error(), "leaked-code")
+}
 
 func TestService_NewAccessToken_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	attributes := map[string]any{"department": "engineering", "employee_id": "E-1"}
+	mockAttributeService.On("Claims", attributes).Return(map[string]any{"dept": "engineering"}, nil)
 
 	// Act
-	token, err := service.NewAccessToken(1, "Jo Liao", "user@example.com", "https://example.com/picture.jpg")
+	token, err := service.NewAccessToken(1, "session-1", "Jo Liao", "user@example.com", "https://example.com/picture.jpg", []string{"admin"}, attributes)
 	require.NoError(t, err)
 
 	// Assert
 	mockUserService.AssertExpectations(t)
+	mockAttributeService.AssertExpectations(t)
 
 	actual := &TokenClaims{}
 	_, err = jwt.ParseWithClaims(token, actual, func(token *jwt.Token) (interface{}, error) {
@@ -92,9 +157,12 @@ func TestService_NewAccessToken_Success(t *testing.T) {
 	})
 	require.NoError(t, err)
 	expected := &TokenClaims{
-		Name:    "Jo Liao",
-		Email:   "user@example.com",
-		Picture: "https://example.com/picture.jpg",
+		Name:       "Jo Liao",
+		Email:      "user@example.com",
+		Picture:    "https://example.com/picture.jpg",
+		Roles:      []string{"admin"},
+		Attributes: map[string]any{"dept": "engineering"},
+		SessionID:  "session-1",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   "1",
 			Issuer:    "identity@vera.sninjo.com",
@@ -110,11 +178,12 @@ func TestService_NewAccessToken_Success(t *testing.T) {
 func TestService_NewRefreshToken_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	// Act
-	token, err := service.NewRefreshToken(1)
+	token, err := service.NewRefreshToken(1, "session-1")
 	require.NoError(t, err)
 
 	// Assert
@@ -126,6 +195,7 @@ func TestService_NewRefreshToken_Success(t *testing.T) {
 	})
 	require.NoError(t, err)
 	expected := &TokenClaims{
+		SessionID: "session-1",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   "1",
 			Issuer:    "identity@vera.sninjo.com",
@@ -141,8 +211,9 @@ func TestService_NewRefreshToken_Success(t *testing.T) {
 func TestService_ParseAccessToken_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		Email:   "user@example.com",
@@ -168,8 +239,9 @@ func TestService_ParseAccessToken_Success(t *testing.T) {
 func TestService_ParseAccessToken_InvalidToken(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		Email:   "user@example.com",
@@ -195,8 +267,9 @@ func TestService_ParseAccessToken_InvalidToken(t *testing.T) {
 func TestService_ParseAccessToken_InvalidIssuer(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		Email:   "user@example.com",
@@ -222,8 +295,9 @@ func TestService_ParseAccessToken_InvalidIssuer(t *testing.T) {
 func TestService_ParseAccessToken_ExpiredToken(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		Email:   "user@example.com",
@@ -250,8 +324,9 @@ func TestService_ParseAccessToken_ExpiredToken(t *testing.T) {
 func TestService_ParseRefreshToken_Success(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -274,8 +349,9 @@ func TestService_ParseRefreshToken_Success(t *testing.T) {
 func TestService_ParseRefreshToken_InvalidToken(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -298,8 +374,9 @@ func TestService_ParseRefreshToken_InvalidToken(t *testing.T) {
 func TestService_ParseRefreshToken_InvalidIssuer(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -322,8 +399,9 @@ func TestService_ParseRefreshToken_InvalidIssuer(t *testing.T) {
 func TestService_ParseRefreshToken_ExpiredToken(t *testing.T) {
 	// Arrange
 	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
 	config := NewMockConfig("")
-	service := NewService(config, mockUserService)
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
 
 	expectedClaims := &TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -343,3 +421,288 @@ func TestService_ParseRefreshToken_ExpiredToken(t *testing.T) {
 	require.Error(t, err)
 	assert.Nil(t, claims)
 }
+
+func TestService_RefreshAccessToken_Success(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	mockUserService.On("GetUserByID", 1).Return(&user.User{ID: 1, Name: test.StringPtr("Jo Liao"), Email: "user@example.com", Picture: test.StringPtr(""), Roles: []string{"admin"}, Status: user.StatusActive}, nil)
+	mockAttributeService.On("Claims", map[string]any(nil)).Return(nil, nil)
+	claims := &TokenClaims{SessionID: "session-1", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
+
+	// Act
+	token, err := service.RefreshAccessToken(context.Background(), claims)
+
+	// Assert
+	require.NoError(t, err)
+	mockUserService.AssertExpectations(t)
+	actual, err := service.ParseAccessToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", actual.Subject)
+	assert.Equal(t, "session-1", actual.SessionID)
+	assert.Equal(t, "user@example.com", actual.Email)
+	assert.Equal(t, []string{"admin"}, actual.Roles)
+}
+
+func TestService_RefreshAccessToken_UserNotFound(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	mockUserService.On("GetUserByID", 1).Return(nil, nil)
+
+	// Act
+	token, err := service.RefreshAccessToken(context.Background(), &TokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}})
+
+	// Assert
+	mockUserService.AssertExpectations(t)
+	assert.Empty(t, token)
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserNotAuthorized, apperror.FromError(err).Code)
+}
+
+func TestService_RefreshAccessToken_UserPending(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	mockUserService.On("GetUserByID", 1).Return(&user.User{ID: 1, Status: user.StatusPending}, nil)
+
+	// Act
+	token, err := service.RefreshAccessToken(context.Background(), &TokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}})
+
+	// Assert
+	mockUserService.AssertExpectations(t)
+	mockAttributeService.AssertNotCalled(t, "Claims", mock.Anything)
+	assert.Empty(t, token)
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserPending, apperror.FromError(err).Code)
+}
+
+func TestService_NewClientAccessToken_Success(t *testing.T) {
+	// Arrange
+	config := NewMockConfig("")
+	service := NewService(config, &MockUserService{}, &MockAttributeService{}, middleware.NewTokenVerifier(config, &MockUserService{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	// Act
+	token, err := service.NewClientAccessToken("svc_batch", []string{"users:read", "users:write"})
+	require.NoError(t, err)
+
+	// Assert
+	actual := &TokenClaims{}
+	_, err = jwt.ParseWithClaims(token, actual, func(token *jwt.Token) (interface{}, error) {
+		return config.AccessTokenSecret, nil
+	})
+	require.NoError(t, err)
+	expected := &TokenClaims{
+		ClientID: "svc_batch",
+		Scope:    "users:read users:write",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "svc_batch",
+			Issuer:    "identity@vera.sninjo.com",
+			IssuedAt:  actual.IssuedAt,
+			ExpiresAt: actual.ExpiresAt,
+		},
+	}
+	assert.Equal(t, expected, actual)
+	assert.WithinDuration(t, time.Now().Add(config.AccessTokenTTL), actual.ExpiresAt.Time, time.Second)
+}
+
+func TestService_Introspect_AccessToken(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	mockAttributeService.On("Claims", map[string]any(nil)).Return(map[string]any(nil), nil)
+	token, err := service.NewAccessToken(1, "session-1", "Jo Liao", "user@example.com", "https://example.com/picture.jpg", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(nil)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, "")
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, claims)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	mockUserService.AssertExpectations(t)
+}
+func TestService_Introspect_RefreshToken(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := service.NewRefreshToken(1, "session-1")
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(nil)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, TokenTypeHintRefreshToken)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, claims)
+	assert.Equal(t, "session-1", claims.SessionID)
+}
+func TestService_Introspect_ClientToken(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockClientChecker := &MockClientChecker{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, &MockAttributeService{}, middleware.NewTokenVerifier(config, mockUserService, mockClientChecker, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := service.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	mockClientChecker.On("CheckClient", "svc_batch").Return(nil)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, "")
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, claims)
+	assert.Equal(t, "svc_batch", claims.ClientID)
+	assert.Equal(t, "users:read", claims.Scope)
+	assert.Empty(t, mockUserService.Calls)
+	mockClientChecker.AssertExpectations(t)
+}
+func TestService_Introspect_ClientDeleted(t *testing.T) {
+	// Arrange
+	mockClientChecker := &MockClientChecker{}
+	config := NewMockConfig("")
+	service := NewService(config, &MockUserService{}, &MockAttributeService{}, middleware.NewTokenVerifier(config, &MockUserService{}, mockClientChecker, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := service.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	mockClientChecker.On("CheckClient", "svc_batch").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, claims)
+	mockClientChecker.AssertExpectations(t)
+}
+func TestService_Introspect_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	mockAuthenticator := &MockPersonalAccessTokenAuthenticator{}
+	config := NewMockConfig("")
+	service := NewService(config, &MockUserService{}, &MockAttributeService{}, middleware.NewTokenVerifier(config, &MockUserService{}, &MockClientChecker{}, mockAuthenticator), noop.NewTracerProvider())
+
+	expiresAt := time.Unix(1700000000, 0)
+	pat := &middleware.PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Roles: []string{"admin", "ops"}, Scopes: []string{"users:read"}, ExpiresAt: expiresAt}
+	mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(pat, nil)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), "pat_token", TokenTypeHintRefreshToken)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, claims)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, []string{"ops"}, claims.Roles)
+	assert.Equal(t, "users:read", claims.Scope)
+	assert.Equal(t, expiresAt.Unix(), claims.ExpiresAt.Unix())
+	mockAuthenticator.AssertExpectations(t)
+}
+func TestService_Introspect_PersonalAccessTokenRevoked(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"revoked", apperror.New(apperror.CodeInvalidAccessToken, "invalid access token")},
+		{"user suspended", apperror.New(apperror.CodeUserSuspended, "user suspended | id: 1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockAuthenticator := &MockPersonalAccessTokenAuthenticator{}
+			config := NewMockConfig("")
+			service := NewService(config, &MockUserService{}, &MockAttributeService{}, middleware.NewTokenVerifier(config, &MockUserService{}, &MockClientChecker{}, mockAuthenticator), noop.NewTracerProvider())
+
+			mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(nil, tt.err)
+
+			// Act
+			claims, err := service.Introspect(context.Background(), "pat_token", "")
+
+			// Assert
+			require.NoError(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+func TestService_Introspect_InvalidToken(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "1", Issuer: "identity@vera.sninjo.com"}).SignedString([]byte("other-secret"))
+	require.NoError(t, err)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, TokenTypeHintAccessToken)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, claims)
+	mockUserService.AssertNotCalled(t, "CheckUserStatus", 1)
+}
+func TestService_Introspect_UserSuspended(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := service.NewRefreshToken(1, "")
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(apperror.New(apperror.CodeUserSuspended, "user suspended | id: 1"))
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, claims)
+}
+func TestService_Introspect_DatabaseError(t *testing.T) {
+	// Arrange
+	mockUserService := &MockUserService{}
+	mockAttributeService := &MockAttributeService{}
+	config := NewMockConfig("")
+	service := NewService(config, mockUserService, mockAttributeService, middleware.NewTokenVerifier(config, mockUserService, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{}), noop.NewTracerProvider())
+
+	token, err := service.NewRefreshToken(1, "")
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(assert.AnError)
+
+	// Act
+	claims, err := service.Introspect(context.Background(), token, "")
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	assert.Nil(t, claims)
+}
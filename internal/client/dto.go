@@ -0,0 +1,76 @@
+package client
+
+import "time"
+
+const GrantTypeClientCredentials = "client_credentials"
+
+type RequestURI struct {
+	ClientID string `uri:"client_id" binding:"required,max=64"`
+}
+
+type ClientBody struct {
+	Name   string   `json:"name" binding:"required,max=255"`
+	Scopes []string `json:"scopes" binding:"required,dive,required,max=64"`
+}
+
+func (b *ClientBody) toClient(clientID string) *Client {
+	return &Client{
+		ClientID: clientID,
+		Name:     b.Name,
+		Scopes:   b.Scopes,
+	}
+}
+
+type ClientResponse struct {
+	ClientID   string   `json:"client_id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+func newClientResponse(c *Client) *ClientResponse {
+	var lastUsedAt *string
+	if c.LastUsedAt != nil {
+		t := c.LastUsedAt.Format(time.RFC3339)
+		lastUsedAt = &t
+	}
+	return &ClientResponse{
+		ClientID:   c.ClientID,
+		Name:       c.Name,
+		Scopes:     c.Scopes,
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  c.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// CreatedClientResponse is the only response that carries the secret, after
+// the client is created or its secret is rotated.
+type CreatedClientResponse struct {
+	ClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+func newCreatedClientResponse(c *Client, secret string) *CreatedClientResponse {
+	return &CreatedClientResponse{
+		ClientResponse: *newClientResponse(c),
+		ClientSecret:   secret,
+	}
+}
+
+type TokenRequest struct {
+	GrantType string `form:"grant_type" binding:"required"`
+	// Scope is space-separated, as in RFC 6749.
+	Scope string `form:"scope"`
+}
+
+// TokenResponse follows RFC 6749, so that OAuth 2.0 client libraries can use
+// it.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
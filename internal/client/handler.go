@@ -0,0 +1,184 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type Handler struct {
+	config      *config.Config
+	service     Service
+	authService auth.Service
+	metrics     *metrics.Metrics
+}
+
+func NewHandler(config *config.Config, service Service, authService auth.Service, metrics *metrics.Metrics) *Handler {
+	return &Handler{config: config, service: service, authService: authService, metrics: metrics}
+}
+
+func (h *Handler) GetClients(c *gin.Context) {
+	clients, err := h.service.GetClients()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	clientResponses := make([]ClientResponse, len(clients))
+	for i, client := range clients {
+		clientResponses[i] = *newClientResponse(&client)
+	}
+
+	c.JSON(http.StatusOK, clientResponses)
+}
+
+func (h *Handler) GetClient(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	client, err := h.service.GetClientByClientID(uri.ClientID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newClientResponse(client))
+}
+
+func (h *Handler) CreateClient(c *gin.Context) {
+	var body ClientBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	client := body.toClient("")
+	secret, err := h.service.CreateClient(client)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	audit.SetMetadata(c, "client_id", client.ClientID)
+	audit.SetMetadata(c, "scopes", client.Scopes)
+
+	c.JSON(http.StatusCreated, newCreatedClientResponse(client, secret))
+}
+
+func (h *Handler) UpdateClient(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	audit.SetMetadata(c, "client_id", uri.ClientID)
+	var body ClientBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+	audit.SetMetadata(c, "scopes", body.Scopes)
+
+	client := body.toClient(uri.ClientID)
+	if err := h.service.UpdateClient(client); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newClientResponse(client))
+}
+
+func (h *Handler) DeleteClient(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	audit.SetMetadata(c, "client_id", uri.ClientID)
+
+	if err := h.service.DeleteClient(uri.ClientID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) RotateSecret(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	audit.SetMetadata(c, "client_id", uri.ClientID)
+
+	client, secret, err := h.service.RotateSecret(uri.ClientID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newCreatedClientResponse(client, secret))
+}
+
+// Token issues access tokens with the client credentials grant of RFC 6749.
+// The client authenticates with HTTP Basic or with client_id and
+// client_secret form parameters, like at /oauth2/introspect.
+func (h *Handler) Token(c *gin.Context) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	audit.SetMetadata(c, "client_id", clientID)
+
+	client, err := h.service.Authenticate(clientID, clientSecret)
+	if err != nil {
+		if apperror.FromError(err).Code == apperror.CodeInvalidClient {
+			c.Header("WWW-Authenticate", `Basic realm="token"`)
+		}
+		c.Error(err)
+		return
+	}
+
+	var request TokenRequest
+	if err := c.ShouldBindWith(&request, binding.FormPost); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+	if request.GrantType != GrantTypeClientCredentials {
+		c.Error(apperror.New(apperror.CodeUnsupportedGrantType, "unsupported grant type | grant_type: "+request.GrantType))
+		return
+	}
+
+	scopes, err := client.GrantScopes(strings.Fields(request.Scope))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	audit.SetMetadata(c, "scopes", scopes)
+
+	accessToken, err := h.authService.NewClientAccessToken(client.ClientID, scopes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.metrics.TokenIssued(metrics.TokenTypeAccess)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(h.config.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
@@ -0,0 +1,422 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetClients() ([]Client, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Client), args.Error(1)
+}
+func (m *MockService) GetClientByClientID(clientID string) (*Client, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+func (m *MockService) CreateClient(client *Client) (string, error) {
+	args := m.Called(client)
+	return args.String(0), args.Error(1)
+}
+func (m *MockService) UpdateClient(client *Client) error {
+	args := m.Called(client)
+	return args.Error(0)
+}
+func (m *MockService) RotateSecret(clientID string) (*Client, string, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*Client), args.String(1), args.Error(2)
+}
+func (m *MockService) DeleteClient(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+func (m *MockService) Authenticate(clientID, secret string) (*Client, error) {
+	args := m.Called(clientID, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+func (m *MockService) CheckClient(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+func (m *MockService) CheckClientSecret(clientID, secret string) error {
+	args := m.Called(clientID, secret)
+	return args.Error(0)
+}
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) GetOAuthLoginURL() string {
+	args := m.Called()
+	return args.String(0)
+}
+func (m *MockAuthService) GetOAuthIDTokenClaims(ctx context.Context, code string) (*auth.OAuthIDTokenClaims, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.OAuthIDTokenClaims), args.Error(1)
+}
+func (m *MockAuthService) NewAccessToken(id int, sessionID, name, email, picture string, roles []string, attributes map[string]any) (string, error) {
+	args := m.Called(id, sessionID, name, email, picture, roles, attributes)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) NewRefreshToken(id int, sessionID string) (string, error) {
+	args := m.Called(id, sessionID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) RefreshAccessToken(ctx context.Context, claims *auth.TokenClaims) (string, error) {
+	args := m.Called(ctx, claims)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) NewClientAccessToken(clientID string, scopes []string) (string, error) {
+	args := m.Called(clientID, scopes)
+	return args.String(0), args.Error(1)
+}
+func (m *MockAuthService) ParseAccessToken(token string) (*auth.TokenClaims, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.TokenClaims), args.Error(1)
+}
+func (m *MockAuthService) ParseRefreshToken(token string) (*auth.TokenClaims, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.TokenClaims), args.Error(1)
+}
+func (m *MockAuthService) Introspect(ctx context.Context, token, tokenTypeHint string) (*auth.TokenClaims, error) {
+	args := m.Called(ctx, token, tokenTypeHint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.TokenClaims), args.Error(1)
+}
+
+func newTestHandler() (*Handler, *MockService, *MockAuthService) {
+	mockService := &MockService{}
+	mockAuthService := &MockAuthService{}
+	return NewHandler(auth.NewMockConfig(""), mockService, mockAuthService, metrics.New()), mockService, mockAuthService
+}
+
+func newTokenRequest(form url.Values) *http.Request {
+	req := httptest.NewRequest("POST", "/oauth2/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	mockAuthService := &MockAuthService{}
+
+	// Act
+	handler := NewHandler(auth.NewMockConfig(""), mockService, mockAuthService, metrics.New())
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+	assert.Equal(t, mockAuthService, handler.authService)
+}
+
+func TestHandler_GetClients_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, w := test.SetupContext()
+
+	lastUsedAt := time.Unix(2, 0)
+	clients := []Client{
+		{ID: 1, ClientID: "svc_batch", Name: "Batch", SecretHash: "secret-hash", Scopes: []string{"users:read"}, LastUsedAt: &lastUsedAt, CreatedAt: time.Unix(1, 0), UpdatedAt: time.Unix(1, 0)},
+	}
+
+	mockService.On("GetClients").Return(clients, nil)
+
+	// Act
+	handler.GetClients(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret-hash")
+
+	var actual []ClientResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	lastUsedAtString := lastUsedAt.Format(time.RFC3339)
+	expected := []ClientResponse{
+		{
+			ClientID:   "svc_batch",
+			Name:       "Batch",
+			Scopes:     []string{"users:read"},
+			LastUsedAt: &lastUsedAtString,
+			CreatedAt:  time.Unix(1, 0).Format(time.RFC3339),
+			UpdatedAt:  time.Unix(1, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetClient_NotFound(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, _ := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "client_id", Value: "svc_missing"}}
+	appErr := apperror.New(apperror.CodeClientNotFound, "client not found | client_id: svc_missing")
+
+	mockService.On("GetClientByClientID", "svc_missing").Return(nil, appErr)
+
+	// Act
+	handler.GetClient(c)
+
+	// Assert
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, appErr, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateClient_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"name":"Batch","scopes":["users:read"]}`))
+
+	mockService.On("CreateClient", &Client{Name: "Batch", Scopes: []string{"users:read"}}).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*Client).ClientID = "svc_batch"
+		}).
+		Return("csec_generated", nil)
+
+	// Act
+	handler.CreateClient(c)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var actual CreatedClientResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, "svc_batch", actual.ClientID)
+	assert.Equal(t, "csec_generated", actual.ClientSecret)
+	assert.Equal(t, []string{"users:read"}, actual.Scopes)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateClient_InvalidBody(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, _ := test.SetupContext()
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"scopes":["users:read"]}`))
+
+	// Act
+	handler.CreateClient(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeValidationFailed, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockService.AssertNotCalled(t, "CreateClient", mock.Anything)
+}
+
+func TestHandler_UpdateClient_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "client_id", Value: "svc_batch"}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"name":"Batch jobs","scopes":["users:read","users:write"]}`))
+
+	mockService.On("UpdateClient", &Client{ClientID: "svc_batch", Name: "Batch jobs", Scopes: []string{"users:read", "users:write"}}).Return(nil)
+
+	// Act
+	handler.UpdateClient(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_DeleteClient_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "client_id", Value: "svc_batch"}}
+
+	mockService.On("DeleteClient", "svc_batch").Return(nil)
+
+	// Act
+	handler.DeleteClient(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RotateSecret_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, _ := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "client_id", Value: "svc_batch"}}
+
+	mockService.On("RotateSecret", "svc_batch").Return(&Client{ClientID: "svc_batch", Scopes: []string{}}, "csec_rotated", nil)
+
+	// Act
+	handler.RotateSecret(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var actual CreatedClientResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, "csec_rotated", actual.ClientSecret)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Token_Success(t *testing.T) {
+	// Arrange
+	handler, mockService, mockAuthService := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"users:read"}})
+	c.Request.SetBasicAuth("svc_batch", "csec_secret")
+
+	mockService.On("Authenticate", "svc_batch", "csec_secret").Return(&Client{ClientID: "svc_batch", Scopes: []string{"users:read", "users:write"}}, nil)
+	mockAuthService.On("NewClientAccessToken", "svc_batch", []string{"users:read"}).Return("access-token", nil)
+
+	// Act
+	handler.Token(c)
+
+	// Assert
+	require.Empty(t, c.Errors)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var actual TokenResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := TokenResponse{
+		AccessToken: "access-token",
+		TokenType:   "Bearer",
+		ExpiresIn:   int(auth.NewMockConfig("").AccessTokenTTL.Seconds()),
+		Scope:       "users:read",
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestHandler_Token_FormCredentials(t *testing.T) {
+	// Arrange
+	handler, mockService, mockAuthService := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "client_id": {"svc_batch"}, "client_secret": {"csec_secret"}})
+
+	mockService.On("Authenticate", "svc_batch", "csec_secret").Return(&Client{ClientID: "svc_batch", Scopes: []string{"users:read"}}, nil)
+	mockAuthService.On("NewClientAccessToken", "svc_batch", []string{"users:read"}).Return("access-token", nil)
+
+	// Act
+	handler.Token(c)
+
+	// Assert
+	require.Empty(t, c.Errors)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Token_InvalidClient(t *testing.T) {
+	// Arrange
+	handler, mockService, mockAuthService := newTestHandler()
+	c, w := test.SetupContext()
+
+	c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}})
+	c.Request.SetBasicAuth("svc_batch", "csec_wrong")
+
+	mockService.On("Authenticate", "svc_batch", "csec_wrong").Return(nil, apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+	// Act
+	handler.Token(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeInvalidClient, c.Errors[0].Err.(*apperror.AppError).Code)
+	assert.Equal(t, `Basic realm="token"`, w.Header().Get("WWW-Authenticate"))
+	mockAuthService.AssertNotCalled(t, "NewClientAccessToken", mock.Anything, mock.Anything)
+}
+
+func TestHandler_Token_UnsupportedGrantType(t *testing.T) {
+	// Arrange
+	handler, mockService, mockAuthService := newTestHandler()
+	c, _ := test.SetupContext()
+
+	c.Request = newTokenRequest(url.Values{"grant_type": {"password"}})
+	c.Request.SetBasicAuth("svc_batch", "csec_secret")
+
+	mockService.On("Authenticate", "svc_batch", "csec_secret").Return(&Client{ClientID: "svc_batch"}, nil)
+
+	// Act
+	handler.Token(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeUnsupportedGrantType, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockAuthService.AssertNotCalled(t, "NewClientAccessToken", mock.Anything, mock.Anything)
+}
+
+func TestHandler_Token_ScopeNotAllowed(t *testing.T) {
+	// Arrange
+	handler, mockService, mockAuthService := newTestHandler()
+	c, _ := test.SetupContext()
+
+	c.Request = newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"users:read users:write"}})
+	c.Request.SetBasicAuth("svc_batch", "csec_secret")
+
+	mockService.On("Authenticate", "svc_batch", "csec_secret").Return(&Client{ClientID: "svc_batch", Scopes: []string{"users:read"}}, nil)
+
+	// Act
+	handler.Token(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeInvalidScope, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockAuthService.AssertNotCalled(t, "NewClientAccessToken", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Client is a service that authenticates as itself, with the client
+// credentials grant, rather than on behalf of a user.
+type Client struct {
+	ID       int    `gorm:"primaryKey;autoIncrement"`
+	ClientID string `gorm:"type:varchar(64);not null;uniqueIndex:idx_service_clients_client_id"`
+	Name     string `gorm:"type:varchar(255);not null"`
+	// SecretHash is the hex SHA-256 of the secret; the secret itself is only
+	// known to the client.
+	SecretHash string     `gorm:"type:varchar(64);not null"`
+	Scopes     []string   `gorm:"type:jsonb;serializer:json;not null"`
+	LastUsedAt *time.Time `gorm:"type:timestamptz"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;not null"`
+	UpdatedAt  time.Time  `gorm:"type:timestamptz;not null"`
+}
+
+func (Client) TableName() string {
+	return "service_clients"
+}
+
+type Repository interface {
+	GetClients() ([]Client, error)
+	GetClientByClientID(clientID string) (*Client, error)
+	CreateClient(client *Client) error
+	UpdateClient(client *Client) error
+	DeleteClient(clientID string) error
+	// UpdateLastUsedAt records when the client last got a token without
+	// touching the rest of the row.
+	UpdateLastUsedAt(clientID string, lastUsedAt time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetClients() ([]Client, error) {
+	var clients []Client
+	err := r.db.Order("id").Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (r *repository) GetClientByClientID(clientID string) (*Client, error) {
+	var client Client
+	err := r.db.Where("client_id = ?", clientID).Take(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *repository) CreateClient(client *Client) error {
+	return r.db.Create(client).Error
+}
+
+func (r *repository) UpdateClient(client *Client) error {
+	return r.db.Save(client).Error
+}
+
+func (r *repository) DeleteClient(clientID string) error {
+	return r.db.Where("client_id = ?", clientID).Delete(&Client{}).Error
+}
+
+func (r *repository) UpdateLastUsedAt(clientID string, lastUsedAt time.Time) error {
+	return r.db.Model(&Client{}).Where("client_id = ?", clientID).UpdateColumn("last_used_at", lastUsedAt).Error
+}
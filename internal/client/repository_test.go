@@ -0,0 +1,167 @@
+package client
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Client{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func createClient(t *testing.T, repo Repository, clientID string) *Client {
+	client := &Client{ClientID: clientID, Name: "Batch", SecretHash: "hash", Scopes: []string{"users:read"}}
+	err := repo.CreateClient(client)
+	require.NoError(t, err)
+	return client
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_CreateClient_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	client := createClient(t, repo, "svc_batch")
+
+	// Assert
+	assert.NotZero(t, client.ID)
+	actual, err := repo.GetClientByClientID("svc_batch")
+	require.NoError(t, err)
+	require.NotNil(t, actual)
+	assert.Equal(t, "Batch", actual.Name)
+	assert.Equal(t, "hash", actual.SecretHash)
+	assert.Equal(t, []string{"users:read"}, actual.Scopes)
+	assert.Nil(t, actual.LastUsedAt)
+}
+
+func TestRepository_CreateClient_DuplicateClientID(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	createClient(t, repo, "svc_batch")
+
+	// Act
+	err = repo.CreateClient(&Client{ClientID: "svc_batch", Name: "Other", SecretHash: "hash", Scopes: []string{}})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestRepository_GetClientByClientID_NotFound(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	client, err := repo.GetClientByClientID("svc_missing")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, client)
+}
+
+func TestRepository_GetClients_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	createClient(t, repo, "svc_a")
+	createClient(t, repo, "svc_b")
+
+	// Act
+	clients, err := repo.GetClients()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	assert.Equal(t, "svc_a", clients[0].ClientID)
+	assert.Equal(t, "svc_b", clients[1].ClientID)
+}
+
+func TestRepository_UpdateLastUsedAt_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	client := createClient(t, repo, "svc_batch")
+	lastUsedAt := time.Now().Truncate(time.Microsecond)
+
+	// Act
+	err = repo.UpdateLastUsedAt("svc_batch", lastUsedAt)
+
+	// Assert
+	require.NoError(t, err)
+	actual, err := repo.GetClientByClientID("svc_batch")
+	require.NoError(t, err)
+	require.NotNil(t, actual.LastUsedAt)
+	assert.True(t, lastUsedAt.Equal(*actual.LastUsedAt))
+	assert.WithinDuration(t, client.UpdatedAt, actual.UpdatedAt, time.Millisecond)
+}
+
+func TestRepository_DeleteClient_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	createClient(t, repo, "svc_batch")
+
+	// Act
+	err = repo.DeleteClient("svc_batch")
+
+	// Assert
+	require.NoError(t, err)
+	client, err := repo.GetClientByClientID("svc_batch")
+	require.NoError(t, err)
+	assert.Nil(t, client)
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware, auditMiddleware audit.Middleware) {
+	r.POST("/oauth2/token", auditMiddleware(audit.EventClientToken), handler.Token)
+
+	g := r.Group("/clients")
+	g.Use(gin.HandlerFunc(authMiddleware), middleware.RequireRole(role.Admin))
+	{
+		g.GET("", handler.GetClients)
+		g.POST("", auditMiddleware(audit.EventClientCreate), handler.CreateClient)
+		g.GET("/:client_id", handler.GetClient)
+		g.PUT("/:client_id", auditMiddleware(audit.EventClientUpdate), handler.UpdateClient)
+		g.DELETE("/:client_id", auditMiddleware(audit.EventClientDelete), handler.DeleteClient)
+		g.POST("/:client_id/secret", auditMiddleware(audit.EventClientSecretRotate), handler.RotateSecret)
+	}
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+)
+
+const (
+	clientIDPrefix = "svc_"
+	secretPrefix   = "csec_"
+)
+
+// lastUsedResolution is how stale the last use of a client may get before
+// it is written again, as gateways authenticate on every introspection.
+const lastUsedResolution = time.Minute
+
+// regexpScope matches a scope token of RFC 6749, which may not contain spaces
+// as scopes are sent space-separated.
+var regexpScope = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]{1,64}$`)
+
+type Service interface {
+	GetClients() ([]Client, error)
+	GetClientByClientID(clientID string) (*Client, error)
+	// CreateClient generates the client ID and secret of a new client and
+	// returns the secret, which is not stored and cannot be shown again.
+	CreateClient(client *Client) (string, error)
+	UpdateClient(client *Client) error
+	// RotateSecret replaces the secret of a client and returns the new one.
+	// The old secret stops working at once.
+	RotateSecret(clientID string) (*Client, string, error)
+	DeleteClient(clientID string) error
+	// Authenticate returns the client the credentials belong to and records
+	// its use, at most once per lastUsedResolution.
+	Authenticate(clientID, secret string) (*Client, error)
+	CheckClient(clientID string) error
+	CheckClientSecret(clientID, secret string) error
+}
+
+type service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo, now: time.Now}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret returns a secret and its hash. The secrets are random enough that
+// a plain hash protects them as well as a slow one would.
+func newSecret() (string, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	secret = secretPrefix + secret
+	return secret, hashSecret(secret), nil
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !regexpScope.MatchString(scope) {
+			return apperror.New(apperror.CodeInvalidScope, "invalid scope | scope: "+scope)
+		}
+	}
+	return nil
+}
+
+// GrantScopes returns the scopes a token of the client gets for the requested
+// ones: all of its scopes if none are requested, or else the requested ones,
+// which the client must all be allowed.
+func (c *Client) GrantScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return c.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !slices.Contains(c.Scopes, scope) {
+			return nil, apperror.New(apperror.CodeInvalidScope, "scope not allowed | scope: "+scope).WithInternal("client_id: " + c.ClientID)
+		}
+	}
+	return requested, nil
+}
+
+func (s *service) GetClients() ([]Client, error) {
+	return s.repo.GetClients()
+}
+
+func (s *service) GetClientByClientID(clientID string) (*Client, error) {
+	client, err := s.repo.GetClientByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, apperror.New(apperror.CodeClientNotFound, "client not found | client_id: "+clientID)
+	}
+	return client, nil
+}
+
+func (s *service) CreateClient(client *Client) (string, error) {
+	if err := validateScopes(client.Scopes); err != nil {
+		return "", err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", err
+	}
+	secret, secretHash, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	client.ClientID = clientIDPrefix + id
+	client.SecretHash = secretHash
+	if err := s.repo.CreateClient(client); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// UpdateClient replaces the name and scopes of an existing client. Tokens it
+// already got keep their scopes until they expire.
+func (s *service) UpdateClient(client *Client) error {
+	if err := validateScopes(client.Scopes); err != nil {
+		return err
+	}
+	existing, err := s.GetClientByClientID(client.ClientID)
+	if err != nil {
+		return err
+	}
+
+	existing.Name = client.Name
+	existing.Scopes = client.Scopes
+	if err := s.repo.UpdateClient(existing); err != nil {
+		return err
+	}
+
+	*client = *existing
+	return nil
+}
+
+func (s *service) RotateSecret(clientID string) (*Client, string, error) {
+	client, err := s.GetClientByClientID(clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, secretHash, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client.SecretHash = secretHash
+	if err := s.repo.UpdateClient(client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+func (s *service) DeleteClient(clientID string) error {
+	if _, err := s.GetClientByClientID(clientID); err != nil {
+		return err
+	}
+	return s.repo.DeleteClient(clientID)
+}
+
+func (s *service) Authenticate(clientID, secret string) (*Client, error) {
+	invalid := apperror.New(apperror.CodeInvalidClient, "invalid client").WithInternal("client_id: " + clientID)
+	if clientID == "" || !strings.HasPrefix(secret, secretPrefix) {
+		return nil, invalid
+	}
+
+	client, err := s.repo.GetClientByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, invalid
+	}
+
+	now := s.now()
+	if client.LastUsedAt == nil || now.Sub(*client.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.UpdateLastUsedAt(clientID, now); err != nil {
+			return nil, err
+		}
+		client.LastUsedAt = &now
+	}
+	return client, nil
+}
+
+// CheckClient refuses the tokens of clients that have been deleted since the
+// tokens were issued.
+func (s *service) CheckClient(clientID string) error {
+	client, err := s.repo.GetClientByClientID(clientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return apperror.New(apperror.CodeInvalidClient, "invalid client").WithInternal("client_id: " + clientID + " | client deleted")
+	}
+	return nil
+}
+
+func (s *service) CheckClientSecret(clientID, secret string) error {
+	_, err := s.Authenticate(clientID, secret)
+	return err
+}
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetClients() ([]Client, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Client), args.Error(1)
+}
+func (m *MockRepository) GetClientByClientID(clientID string) (*Client, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Client), args.Error(1)
+}
+func (m *MockRepository) CreateClient(client *Client) error {
+	args := m.Called(client)
+	return args.Error(0)
+}
+func (m *MockRepository) UpdateClient(client *Client) error {
+	args := m.Called(client)
+	return args.Error(0)
+}
+func (m *MockRepository) DeleteClient(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+func (m *MockRepository) UpdateLastUsedAt(clientID string, lastUsedAt time.Time) error {
+	args := m.Called(clientID, lastUsedAt)
+	return args.Error(0)
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+
+	// Act
+	s := NewService(mockRepo)
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+}
+
+func TestService_CreateClient_GeneratesCredentials(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	client := &Client{Name: "Batch", Scopes: []string{"users:read"}}
+
+	mockRepo.On("CreateClient", client).Return(nil)
+
+	// Act
+	secret, err := service.CreateClient(client)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(client.ClientID, clientIDPrefix))
+	assert.Len(t, client.ClientID, len(clientIDPrefix)+16)
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.Len(t, secret, len(secretPrefix)+64)
+	assert.Equal(t, hashSecret(secret), client.SecretHash)
+	assert.NotContains(t, client.SecretHash, secret)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CreateClient_InvalidScope(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	// Act
+	_, err := service.CreateClient(&Client{Name: "Batch", Scopes: []string{"users read"}})
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeInvalidScope, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "CreateClient", mock.Anything)
+}
+
+func TestService_UpdateClient_KeepsSecret(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	existing := &Client{ID: 1, ClientID: "svc_batch", Name: "Batch", SecretHash: "hash", Scopes: []string{"users:read"}}
+	client := &Client{ClientID: "svc_batch", Name: "Batch jobs", Scopes: []string{"users:read", "users:write"}}
+
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(existing, nil)
+	mockRepo.On("UpdateClient", existing).Return(nil)
+
+	// Act
+	err := service.UpdateClient(client)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.ID)
+	assert.Equal(t, "Batch jobs", client.Name)
+	assert.Equal(t, []string{"users:read", "users:write"}, client.Scopes)
+	assert.Equal(t, "hash", client.SecretHash)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateClient_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetClientByClientID", "svc_missing").Return(nil, nil)
+
+	// Act
+	err := service.UpdateClient(&Client{ClientID: "svc_missing", Name: "Missing", Scopes: []string{}})
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeClientNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RotateSecret_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	existing := &Client{ID: 1, ClientID: "svc_batch", SecretHash: "old-hash"}
+
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(existing, nil)
+	mockRepo.On("UpdateClient", existing).Return(nil)
+
+	// Act
+	client, secret, err := service.RotateSecret("svc_batch")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, hashSecret(secret), client.SecretHash)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_DeleteClient_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetClientByClientID", "svc_missing").Return(nil, nil)
+
+	// Act
+	err := service.DeleteClient("svc_missing")
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeClientNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "DeleteClient", mock.Anything)
+}
+
+func TestService_Authenticate_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	s := NewService(mockRepo).(*service)
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	secret := secretPrefix + "secret"
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(&Client{ClientID: "svc_batch", SecretHash: hashSecret(secret)}, nil)
+	mockRepo.On("UpdateLastUsedAt", "svc_batch", now).Return(nil)
+
+	// Act
+	client, err := s.Authenticate("svc_batch", secret)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "svc_batch", client.ClientID)
+	assert.Equal(t, now, *client.LastUsedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Authenticate_RecentlyUsed(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	s := NewService(mockRepo).(*service)
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	secret := secretPrefix + "secret"
+	lastUsedAt := now.Add(-30 * time.Second)
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(&Client{ClientID: "svc_batch", SecretHash: hashSecret(secret), LastUsedAt: &lastUsedAt}, nil)
+
+	// Act
+	client, err := s.Authenticate("svc_batch", secret)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, lastUsedAt, *client.LastUsedAt)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsedAt", mock.Anything, mock.Anything)
+}
+
+func TestService_Authenticate_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientID string
+		secret   string
+		client   *Client
+	}{
+		{"wrong secret", "svc_batch", secretPrefix + "wrong", &Client{ClientID: "svc_batch", SecretHash: hashSecret(secretPrefix + "secret")}},
+		{"unknown client", "svc_missing", secretPrefix + "secret", nil},
+		{"malformed secret", "svc_batch", "secret", nil},
+		{"missing client ID", "", secretPrefix + "secret", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRepo := &MockRepository{}
+			service := NewService(mockRepo)
+
+			mockRepo.On("GetClientByClientID", tt.clientID).Return(tt.client, nil).Maybe()
+
+			// Act
+			client, err := service.Authenticate(tt.clientID, tt.secret)
+
+			// Assert
+			require.Error(t, err)
+			assert.Nil(t, client)
+			assert.Equal(t, apperror.CodeInvalidClient, err.(*apperror.AppError).Code)
+			mockRepo.AssertNotCalled(t, "UpdateLastUsedAt", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestService_CheckClient_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(&Client{ClientID: "svc_batch"}, nil)
+
+	// Act
+	err := service.CheckClient("svc_batch")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CheckClient_Deleted(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetClientByClientID", "svc_batch").Return(nil, nil)
+
+	// Act
+	err := service.CheckClient("svc_batch")
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeInvalidClient, err.(*apperror.AppError).Code)
+}
+
+func TestService_GrantScopes(t *testing.T) {
+	client := &Client{ClientID: "svc_batch", Scopes: []string{"users:read", "users:write"}}
+
+	tests := []struct {
+		name      string
+		requested []string
+		expected  []string
+		valid     bool
+	}{
+		{"all scopes by default", nil, []string{"users:read", "users:write"}, true},
+		{"requested scopes", []string{"users:read"}, []string{"users:read"}, true},
+		{"scope not allowed", []string{"users:read", "reports:read"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			scopes, err := client.GrantScopes(tt.requested)
+
+			// Assert
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, scopes)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, apperror.CodeInvalidScope, err.(*apperror.AppError).Code)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -10,13 +14,33 @@ import (
 	"golang.org/x/oauth2/google"
 )
 
+// RateLimit allows Requests per Period to each client IP or each user,
+// depending on Key ("ip" or "user").
+type RateLimit struct {
+	Key      string
+	Requests int
+	Period   time.Duration
+}
+
 type Config struct {
 	BaseURL     string
 	Domain      string
 	Port        string
+	GRPCPort    string
 	DatabaseURL string
 	SiteURL     string
 
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPMaxHeaderBytes    int
+	ShutdownDrainPeriod   time.Duration
+	ShutdownTimeout       time.Duration
+
+	MigrationTable           string
+	ReadinessIdPDiscoveryURL string
+
 	GoogleClientID     string
 	GoogleClientSecret string
 	OAuth2             *oauth2.Config
@@ -25,6 +49,42 @@ type Config struct {
 	AccessTokenSecret  []byte
 	RefreshTokenTTL    time.Duration
 	RefreshTokenSecret []byte
+
+	PersonalAccessTokenMaxTTL time.Duration
+
+	UserRetentionPeriod time.Duration
+	UserPurgeInterval   time.Duration
+
+	EnforceUserStatus bool
+
+	// IntrospectionClients maps the ID of each client allowed to introspect
+	// tokens to its secret.
+	IntrospectionClients map[string]string
+
+	ForwardAuthCookie       string
+	ForwardAuthCookieDomain string
+	ForwardAuthReturnHosts  []string
+
+	WebhookMaxAttempts  int
+	WebhookBackoff      time.Duration
+	WebhookPollInterval time.Duration
+
+	OutboxSinks        []string
+	OutboxPollInterval time.Duration
+
+	LogRedactFields []string
+
+	LockoutThreshold   int
+	LockoutIPThreshold int
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+	LockoutResetAfter  time.Duration
+
+	TrustedProxies []string
+	RateLimitStore string
+	RateLimits     map[string][]RateLimit
+
+	TracingExporter string
 }
 
 func NewConfig(logger *zap.Logger) *Config {
@@ -46,6 +106,62 @@ func NewConfig(logger *zap.Logger) *Config {
 		port = "8080"
 	}
 
+	// a zero timeout disables it, as with http.Server
+	httpReadHeaderTimeout := 5 * time.Second
+	if v := os.Getenv("HTTP_READ_HEADER_TIMEOUT"); v != "" {
+		httpReadHeaderTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid HTTP_READ_HEADER_TIMEOUT", zap.Error(err))
+		}
+	}
+	httpReadTimeout := 15 * time.Second
+	if v := os.Getenv("HTTP_READ_TIMEOUT"); v != "" {
+		httpReadTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid HTTP_READ_TIMEOUT", zap.Error(err))
+		}
+	}
+	// bounds the whole handler, including the code exchange with Google
+	httpWriteTimeout := 30 * time.Second
+	if v := os.Getenv("HTTP_WRITE_TIMEOUT"); v != "" {
+		httpWriteTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid HTTP_WRITE_TIMEOUT", zap.Error(err))
+		}
+	}
+	httpIdleTimeout := 120 * time.Second
+	if v := os.Getenv("HTTP_IDLE_TIMEOUT"); v != "" {
+		httpIdleTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid HTTP_IDLE_TIMEOUT", zap.Error(err))
+		}
+	}
+	httpMaxHeaderBytes := 64 << 10
+	if v := os.Getenv("HTTP_MAX_HEADER_BYTES"); v != "" {
+		httpMaxHeaderBytes, err = strconv.Atoi(v)
+		if err != nil || httpMaxHeaderBytes < 1 {
+			logger.Fatal("Invalid HTTP_MAX_HEADER_BYTES", zap.String("value", v), zap.Error(err))
+		}
+	}
+	// time between SIGTERM and closing the listener, in which /readyz fails so
+	// that load balancers stop sending new requests
+	shutdownDrainPeriod := 5 * time.Second
+	if v := os.Getenv("SHUTDOWN_DRAIN_PERIOD"); v != "" {
+		shutdownDrainPeriod, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid SHUTDOWN_DRAIN_PERIOD", zap.Error(err))
+		}
+	}
+	// time in-flight requests and background workers get to finish after the
+	// drain period; keep both below terminationGracePeriodSeconds
+	shutdownTimeout := 20 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		shutdownTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid SHUTDOWN_TIMEOUT", zap.Error(err))
+		}
+	}
+
 	accessTokenTTL, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL"))
 	if err != nil {
 		logger.Fatal("Invalid ACCESS_TOKEN_TTL", zap.Error(err))
@@ -54,6 +170,175 @@ func NewConfig(logger *zap.Logger) *Config {
 	if err != nil {
 		logger.Fatal("Invalid REFRESH_TOKEN_TTL", zap.Error(err))
 	}
+	personalAccessTokenMaxTTL := 365 * 24 * time.Hour
+	if v := os.Getenv("PERSONAL_ACCESS_TOKEN_MAX_TTL"); v != "" {
+		personalAccessTokenMaxTTL, err = time.ParseDuration(v)
+		if err != nil || personalAccessTokenMaxTTL <= 0 {
+			logger.Fatal("Invalid PERSONAL_ACCESS_TOKEN_MAX_TTL", zap.String("value", v), zap.Error(err))
+		}
+	}
+
+	// an empty retention period keeps soft-deleted users forever
+	var userRetentionPeriod time.Duration
+	if v := os.Getenv("USER_RETENTION_PERIOD"); v != "" {
+		userRetentionPeriod, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid USER_RETENTION_PERIOD", zap.Error(err))
+		}
+	}
+	userPurgeInterval := time.Hour
+	if v := os.Getenv("USER_PURGE_INTERVAL"); v != "" {
+		userPurgeInterval, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid USER_PURGE_INTERVAL", zap.Error(err))
+		}
+	}
+
+	// checking the user status on every authenticated request costs a query
+	var enforceUserStatus bool
+	if v := os.Getenv("ENFORCE_USER_STATUS"); v != "" {
+		enforceUserStatus, err = strconv.ParseBool(v)
+		if err != nil {
+			logger.Fatal("Invalid ENFORCE_USER_STATUS", zap.Error(err))
+		}
+	}
+
+	webhookMaxAttempts := 8
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		webhookMaxAttempts, err = strconv.Atoi(v)
+		if err != nil || webhookMaxAttempts < 1 {
+			logger.Fatal("Invalid WEBHOOK_MAX_ATTEMPTS", zap.String("value", v), zap.Error(err))
+		}
+	}
+	webhookBackoff := 30 * time.Second
+	if v := os.Getenv("WEBHOOK_BACKOFF"); v != "" {
+		webhookBackoff, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid WEBHOOK_BACKOFF", zap.Error(err))
+		}
+	}
+	// a zero poll interval disables webhook delivery on this instance
+	webhookPollInterval := 5 * time.Second
+	if v := os.Getenv("WEBHOOK_POLL_INTERVAL"); v != "" {
+		webhookPollInterval, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid WEBHOOK_POLL_INTERVAL", zap.Error(err))
+		}
+	}
+
+	outboxSinks := []string{"webhook"}
+	if v, ok := os.LookupEnv("OUTBOX_SINKS"); ok {
+		outboxSinks = splitList(v)
+	}
+	// a zero poll interval leaves the outbox to other instances
+	outboxPollInterval := time.Second
+	if v := os.Getenv("OUTBOX_POLL_INTERVAL"); v != "" {
+		outboxPollInterval, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Fatal("Invalid OUTBOX_POLL_INTERVAL", zap.Error(err))
+		}
+	}
+
+	// request fields whose values never reach the logs
+	logRedactFields := []string{"password", "*secret*", "*token*", "code", "email"}
+	if v, ok := os.LookupEnv("LOG_REDACT_FIELDS"); ok {
+		logRedactFields = splitList(v)
+	}
+	for _, pattern := range logRedactFields {
+		if _, err := path.Match(pattern, ""); err != nil {
+			logger.Fatal("Invalid LOG_REDACT_FIELDS", zap.String("pattern", pattern), zap.Error(err))
+		}
+	}
+
+	// the secrets are not logged, only the reason an entry is invalid
+	introspectionClients, err := parseClients(os.Getenv("INTROSPECTION_CLIENTS"))
+	if err != nil {
+		logger.Fatal("Invalid INTROSPECTION_CLIENTS", zap.Error(err))
+	}
+
+	forwardAuthCookie := "access_token"
+	if v := os.Getenv("FORWARD_AUTH_COOKIE"); v != "" {
+		forwardAuthCookie = v
+	}
+	// hosts that logins may return to, exactly or, with a leading dot, any
+	// subdomain; unset disables returning, which prevents open redirects
+	var forwardAuthReturnHosts []string
+	if v, ok := os.LookupEnv("FORWARD_AUTH_RETURN_HOSTS"); ok {
+		forwardAuthReturnHosts = splitList(v)
+	}
+
+	// a zero threshold disables the lockout of emails or IPs
+	lockoutThreshold := 5
+	if v := os.Getenv("LOCKOUT_THRESHOLD"); v != "" {
+		lockoutThreshold, err = strconv.Atoi(v)
+		if err != nil || lockoutThreshold < 0 {
+			logger.Fatal("Invalid LOCKOUT_THRESHOLD", zap.String("value", v), zap.Error(err))
+		}
+	}
+	// higher than the email threshold as offices and carriers share IPs
+	lockoutIPThreshold := 20
+	if v := os.Getenv("LOCKOUT_IP_THRESHOLD"); v != "" {
+		lockoutIPThreshold, err = strconv.Atoi(v)
+		if err != nil || lockoutIPThreshold < 0 {
+			logger.Fatal("Invalid LOCKOUT_IP_THRESHOLD", zap.String("value", v), zap.Error(err))
+		}
+	}
+	lockoutDuration := time.Minute
+	if v := os.Getenv("LOCKOUT_DURATION"); v != "" {
+		lockoutDuration, err = time.ParseDuration(v)
+		if err != nil || lockoutDuration <= 0 {
+			logger.Fatal("Invalid LOCKOUT_DURATION", zap.String("value", v), zap.Error(err))
+		}
+	}
+	lockoutMaxDuration := 24 * time.Hour
+	if v := os.Getenv("LOCKOUT_MAX_DURATION"); v != "" {
+		lockoutMaxDuration, err = time.ParseDuration(v)
+		if err != nil || lockoutMaxDuration < lockoutDuration {
+			logger.Fatal("Invalid LOCKOUT_MAX_DURATION", zap.String("value", v), zap.Error(err))
+		}
+	}
+	// failures older than this no longer count towards a lockout
+	lockoutResetAfter := 24 * time.Hour
+	if v := os.Getenv("LOCKOUT_RESET_AFTER"); v != "" {
+		lockoutResetAfter, err = time.ParseDuration(v)
+		if err != nil || lockoutResetAfter <= 0 {
+			logger.Fatal("Invalid LOCKOUT_RESET_AFTER", zap.String("value", v), zap.Error(err))
+		}
+	}
+
+	// unset trusts no proxy, so that clients cannot pick the IP that rate
+	// limits, lockouts and audit events see with X-Forwarded-For
+	trustedProxies := splitList(os.Getenv("TRUSTED_PROXIES"))
+
+	rateLimitStore := "memory"
+	if v := os.Getenv("RATE_LIMIT_STORE"); v != "" {
+		rateLimitStore = v
+	}
+	switch rateLimitStore {
+	case "memory", "postgres":
+	default:
+		logger.Fatal("Invalid RATE_LIMIT_STORE", zap.String("value", rateLimitStore))
+	}
+	// verify is only limited by user: under forward auth its client is the proxy
+	rateLimits := "callback:ip=20/1m,refresh:ip=60/1m,verify:user=300/1m"
+	if v, ok := os.LookupEnv("RATE_LIMITS"); ok {
+		rateLimits = v
+	}
+	parsedRateLimits, err := parseRateLimits(rateLimits)
+	if err != nil {
+		logger.Fatal("Invalid RATE_LIMITS", zap.String("value", rateLimits), zap.Error(err))
+	}
+
+	// otlp reads its endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables
+	tracingExporter := "none"
+	if v := os.Getenv("TRACING_EXPORTER"); v != "" {
+		tracingExporter = v
+	}
+	switch tracingExporter {
+	case "otlp", "stdout", "none":
+	default:
+		logger.Fatal("Invalid TRACING_EXPORTER", zap.String("value", tracingExporter))
+	}
 
 	baseURL := os.Getenv("BASE_URL")
 	googleClientID := os.Getenv("GOOGLE_CLIENT_ID")
@@ -70,9 +355,22 @@ func NewConfig(logger *zap.Logger) *Config {
 		BaseURL:     os.Getenv("BASE_URL"),
 		Domain:      domain,
 		Port:        port,
+		GRPCPort:    os.Getenv("GRPC_PORT"),
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		SiteURL:     os.Getenv("SITE_URL"),
 
+		HTTPReadHeaderTimeout: httpReadHeaderTimeout,
+		HTTPReadTimeout:       httpReadTimeout,
+		HTTPWriteTimeout:      httpWriteTimeout,
+		HTTPIdleTimeout:       httpIdleTimeout,
+		HTTPMaxHeaderBytes:    httpMaxHeaderBytes,
+		ShutdownDrainPeriod:   shutdownDrainPeriod,
+		ShutdownTimeout:       shutdownTimeout,
+
+		// empty values disable the corresponding readiness checks
+		MigrationTable:           os.Getenv("MIGRATION_TABLE"),
+		ReadinessIdPDiscoveryURL: os.Getenv("READINESS_IDP_DISCOVERY_URL"),
+
 		GoogleClientID:     googleClientID,
 		GoogleClientSecret: googleClientSecret,
 		OAuth2:             oauth2Config,
@@ -81,5 +379,96 @@ func NewConfig(logger *zap.Logger) *Config {
 		AccessTokenSecret:  []byte(os.Getenv("ACCESS_TOKEN_SECRET")),
 		RefreshTokenTTL:    refreshTokenTTL,
 		RefreshTokenSecret: []byte(os.Getenv("REFRESH_TOKEN_SECRET")),
+
+		PersonalAccessTokenMaxTTL: personalAccessTokenMaxTTL,
+
+		UserRetentionPeriod: userRetentionPeriod,
+		UserPurgeInterval:   userPurgeInterval,
+
+		EnforceUserStatus: enforceUserStatus,
+
+		IntrospectionClients: introspectionClients,
+
+		ForwardAuthCookie:       forwardAuthCookie,
+		ForwardAuthCookieDomain: os.Getenv("FORWARD_AUTH_COOKIE_DOMAIN"),
+		ForwardAuthReturnHosts:  forwardAuthReturnHosts,
+
+		WebhookMaxAttempts:  webhookMaxAttempts,
+		WebhookBackoff:      webhookBackoff,
+		WebhookPollInterval: webhookPollInterval,
+
+		OutboxSinks:        outboxSinks,
+		OutboxPollInterval: outboxPollInterval,
+
+		LogRedactFields: logRedactFields,
+
+		LockoutThreshold:   lockoutThreshold,
+		LockoutIPThreshold: lockoutIPThreshold,
+		LockoutDuration:    lockoutDuration,
+		LockoutMaxDuration: lockoutMaxDuration,
+		LockoutResetAfter:  lockoutResetAfter,
+
+		TrustedProxies: trustedProxies,
+		RateLimitStore: rateLimitStore,
+		RateLimits:     parsedRateLimits,
+
+		TracingExporter: tracingExporter,
+	}
+}
+
+// splitList parses a comma-separated list, ignoring empty entries.
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseRateLimits parses a comma-separated list of policy:key=requests/period
+// entries, e.g. "callback:ip=20/1m,verify:user=300/1m", by policy.
+func parseRateLimits(v string) (map[string][]RateLimit, error) {
+	limits := map[string][]RateLimit{}
+	for _, item := range splitList(v) {
+		policy, rest, ok := strings.Cut(item, ":")
+		key, quota, ok2 := strings.Cut(rest, "=")
+		requests, period, ok3 := strings.Cut(quota, "/")
+		if !ok || !ok2 || !ok3 || policy == "" {
+			return nil, errors.New("expected policy:key=requests/period | entry: " + item)
+		}
+		if key != "ip" && key != "user" {
+			return nil, errors.New("unknown key, expected ip or user | entry: " + item)
+		}
+
+		limit := RateLimit{Key: key}
+		var err error
+		if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests < 1 {
+			return nil, errors.New("invalid number of requests | entry: " + item)
+		}
+		// buckets idle for a day are discarded, so longer periods would reset early
+		if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 || limit.Period > 24*time.Hour {
+			return nil, errors.New("invalid period, expected up to 24h | entry: " + item)
+		}
+		limits[policy] = append(limits[policy], limit)
+	}
+	return limits, nil
+}
+
+// parseClients parses a comma-separated list of client_id:secret entries by
+// client ID. Errors name the client but never its secret.
+func parseClients(v string) (map[string]string, error) {
+	clients := map[string]string{}
+	for i, item := range splitList(v) {
+		id, secret, ok := strings.Cut(item, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, errors.New("expected client_id:secret | entry: " + strconv.Itoa(i+1))
+		}
+		if _, ok := clients[id]; ok {
+			return nil, errors.New("duplicate client | client_id: " + id)
+		}
+		clients[id] = secret
 	}
+	return clients, nil
 }
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_parseRateLimits_Success(t *testing.T) {
+	// Act
+	limits, err := parseRateLimits("callback:ip=20/1m, verify:ip=600/1m,verify:user=300/1m,")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]RateLimit{
+		"callback": {{Key: "ip", Requests: 20, Period: time.Minute}},
+		"verify": {
+			{Key: "ip", Requests: 600, Period: time.Minute},
+			{Key: "user", Requests: 300, Period: time.Minute},
+		},
+	}, limits)
+}
+
+func TestConfig_parseRateLimits_Empty(t *testing.T) {
+	// Act
+	limits, err := parseRateLimits("")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+}
+
+func TestConfig_parseRateLimits_Invalid(t *testing.T) {
+	for _, v := range []string{
+		"callback=20/1m",
+		"callback:ip=20",
+		":ip=20/1m",
+		"callback:email=20/1m",
+		"callback:ip=0/1m",
+		"callback:ip=many/1m",
+		"callback:ip=20/0s",
+		"callback:ip=20/48h",
+	} {
+		// Act
+		_, err := parseRateLimits(v)
+
+		// Assert
+		assert.Error(t, err, v)
+	}
+}
+
+func TestConfig_parseClients_Success(t *testing.T) {
+	// Act
+	clients, err := parseClients("gateway:s3cret:with:colons, reports:other,")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gateway": "s3cret:with:colons", "reports": "other"}, clients)
+}
+
+func TestConfig_parseClients_Invalid(t *testing.T) {
+	for _, v := range []string{
+		"gateway",
+		"gateway:",
+		":hunter2",
+		"gateway:hunter2,gateway:hunter3",
+	} {
+		// Act
+		_, err := parseClients(v)
+
+		// Assert
+		require.Error(t, err, v)
+		assert.NotContains(t, err.Error(), "hunter", v)
+	}
+}
@@ -3,17 +3,21 @@ package db
 import (
 	"github.com/sninjo/vera-identity-service/internal/config"
 
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func NewDatabase(config *config.Config) (*gorm.DB, error) {
+func NewDatabase(config *config.Config, tracerProvider trace.TracerProvider) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(config.DatabaseURL), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err = db.Use(NewTracingPlugin(tracerProvider)); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
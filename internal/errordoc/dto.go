@@ -0,0 +1,29 @@
+package errordoc
+
+import "github.com/sninjo/vera-identity-service/internal/apperror"
+
+type RequestURI struct {
+	Code string `uri:"code" binding:"required"`
+}
+
+type ErrorResponse struct {
+	ID          string `json:"id"`
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Remediation string `json:"remediation"`
+}
+
+func newErrorResponse(definition *apperror.Definition, text apperror.Text) *ErrorResponse {
+	return &ErrorResponse{
+		ID:          definition.ID,
+		Code:        definition.Code,
+		Status:      definition.Status,
+		Type:        "/errors/" + definition.Code,
+		Title:       text.Title,
+		Description: text.Description,
+		Remediation: text.Remediation,
+	}
+}
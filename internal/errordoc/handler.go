@@ -0,0 +1,54 @@
+package errordoc
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+func (h *Handler) GetErrors(c *gin.Context) {
+	lang := negotiateLanguage(c)
+
+	definitions := apperror.Definitions()
+	errorResponses := make([]ErrorResponse, len(definitions))
+	for i, definition := range definitions {
+		errorResponses[i] = *newErrorResponse(&definition, definition.Localize(lang))
+	}
+
+	c.JSON(http.StatusOK, errorResponses)
+}
+
+func (h *Handler) GetError(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	definition, ok := apperror.LookupDefinition(uri.Code)
+	if !ok {
+		c.Error(apperror.New(apperror.CodeErrorCodeNotFound, "error code not found | code: "+uri.Code))
+		return
+	}
+
+	lang := negotiateLanguage(c)
+	c.JSON(http.StatusOK, newErrorResponse(definition, definition.Localize(lang)))
+}
+
+// negotiateLanguage picks the language of the documentation and tells caches
+// that the response depends on Accept-Language.
+func negotiateLanguage(c *gin.Context) language.Tag {
+	lang := apperror.MatchLanguage(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", lang.String())
+	c.Header("Vary", "Accept-Language")
+	return lang
+}
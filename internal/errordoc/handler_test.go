@@ -0,0 +1,97 @@
+package errordoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_GetErrors_Success(t *testing.T) {
+	// Arrange
+	handler := NewHandler()
+	c, w := test.SetupContext()
+
+	// Act
+	handler.GetErrors(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+
+	var response []ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response, len(apperror.Definitions()))
+	assert.Equal(t, ErrorResponse{
+		ID:          "user_not_found",
+		Code:        apperror.CodeUserNotFound,
+		Status:      http.StatusNotFound,
+		Type:        "/errors/404_01_001",
+		Title:       "User not found",
+		Description: "No user exists with the given ID, or it has been deleted.",
+		Remediation: "Check the user ID; deleted users are only listed with ?deleted=true.",
+	}, response[0])
+}
+
+func TestHandler_GetErrors_Localized(t *testing.T) {
+	// Arrange
+	handler := NewHandler()
+	c, w := test.SetupContext()
+	c.Request.Header.Set("Accept-Language", "zh-TW,zh;q=0.9")
+
+	// Act
+	handler.GetErrors(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "zh-Hant", w.Header().Get("Content-Language"))
+
+	var response []ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "找不到使用者", response[0].Title)
+}
+
+func TestHandler_GetError_Success(t *testing.T) {
+	// Arrange
+	handler := NewHandler()
+	c, w := test.SetupContext()
+	c.Params = gin.Params{{Key: "code", Value: apperror.CodeUserEmailInUse}}
+
+	// Act
+	handler.GetError(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "user_email_in_use", response.ID)
+	assert.Equal(t, http.StatusConflict, response.Status)
+	assert.NotEmpty(t, response.Remediation)
+}
+
+func TestHandler_GetError_NotFound(t *testing.T) {
+	// Arrange
+	handler := NewHandler()
+	c, w := test.SetupContext()
+	c.Params = gin.Params{{Key: "code", Value: "404_01_999"}}
+
+	// Act
+	handler.GetError(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*apperror.AppError)
+	require.True(t, ok)
+	assert.Equal(t, apperror.CodeErrorCodeNotFound, appErr.Code)
+}
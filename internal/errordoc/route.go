@@ -0,0 +1,13 @@
+package errordoc
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes registers the error code documentation. It is public so that
+// frontend teams can build their message catalogs from it.
+func RegisterRoutes(r *gin.Engine, handler *Handler) {
+	g := r.Group("/errors")
+	{
+		g.GET("", handler.GetErrors)
+		g.GET("/:code", handler.GetError)
+	}
+}
@@ -0,0 +1,198 @@
+package extauthz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.uber.org/zap"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RolesExtension is the context extension of an Envoy route that lists the
+// roles, any one of which the user must have, e.g. "admin,ops".
+const RolesExtension = "roles"
+
+// Server answers the external authorization checks of Envoy with the same
+// rules as /auth/verify: the access token is taken from the Authorization
+// header or the forward auth cookie, and allowed requests get the identity
+// headers of the user or service client. Checks share the user limits of
+// /auth/verify.
+type Server struct {
+	authv3.UnimplementedAuthorizationServer
+	config   *config.Config
+	verifier middleware.TokenVerifier
+	store    ratelimit.Store
+	logger   *zap.Logger
+}
+
+func NewServer(config *config.Config, verifier middleware.TokenVerifier, store ratelimit.Store, logger *zap.Logger) *Server {
+	return &Server{
+		config:   config,
+		verifier: verifier,
+		store:    store,
+		logger:   logger,
+	}
+}
+
+// Check allows or denies a request. Denials are answered with the status and
+// error body the HTTP endpoint would return; only unexpected errors fail the
+// call, which Envoy treats according to its failure_mode_allow.
+func (s *Server) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	attributes := req.GetAttributes()
+	headers := attributes.GetRequest().GetHttp().GetHeaders()
+
+	identity, err := s.authorize(headers, attributes.GetContextExtensions()[RolesExtension])
+	var retryAfter time.Duration
+	if err == nil {
+		retryAfter, err = s.limit(ctx, identity)
+	}
+	if err != nil {
+		var appErr *apperror.AppError
+		if !errors.As(err, &appErr) {
+			s.logger.Error("ext_authz check failed", zap.Error(err))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		appErr.RequestID = headers["x-request-id"]
+		s.logger.Info("ext_authz check denied", zap.String("request_id", appErr.RequestID), zap.Error(appErr))
+		return denied(appErr, retryAfter), nil
+	}
+	return allowed(identity), nil
+}
+
+// limit takes the user limits of the verify policy for the caller. Limits by
+// ip are left out, as every check comes from Envoy rather than the client.
+// It returns how long the caller has to wait if a limit is exhausted.
+func (s *Server) limit(ctx context.Context, identity *middleware.Identity) (time.Duration, error) {
+	id := ratelimit.UserKeyValue(identity.UserID, identity.ClientID)
+	for _, limit := range s.config.RateLimits[ratelimit.PolicyVerify] {
+		if limit.Key != ratelimit.KeyUser {
+			continue
+		}
+		result, err := s.store.Take(ctx, ratelimit.PolicyVerify+":"+limit.Key+":"+id, limit)
+		if err != nil {
+			// an unavailable store must not lock everyone out
+			s.logger.Warn("rate limit store failed, allowing check", zap.Error(err))
+			continue
+		}
+		if !result.Allowed {
+			return result.RetryAfter, apperror.New(apperror.CodeRateLimited, "too many requests").WithInternal("policy: " + ratelimit.PolicyVerify + " | key: " + limit.Key)
+		}
+	}
+	return 0, nil
+}
+
+func (s *Server) authorize(headers map[string]string, roles string) (*middleware.Identity, error) {
+	token, err := s.token(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if roles != "" {
+		if !slices.ContainsFunc(strings.Split(roles, ","), func(role string) bool {
+			return slices.Contains(identity.Roles, strings.TrimSpace(role))
+		}) {
+			return nil, apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+roles)
+		}
+	}
+	return identity, nil
+}
+
+// token reads the bearer token, or else the forward auth cookie. Envoy passes
+// header names in lower case.
+func (s *Server) token(headers map[string]string) (string, error) {
+	if authHeader := headers["authorization"]; authHeader != "" {
+		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+			return "", apperror.New(apperror.CodeInvalidAuthHeader, "invalid authorization header").WithInternal("header: " + redact.Fingerprint(authHeader))
+		}
+		return authHeader[7:], nil
+	}
+
+	request := &http.Request{Header: http.Header{"Cookie": {headers["cookie"]}}}
+	if cookie, err := request.Cookie(s.config.ForwardAuthCookie); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	return "", apperror.New(apperror.CodeInvalidAuthHeader, "invalid authorization header")
+}
+
+func allowed(identity *middleware.Identity) *authv3.CheckResponse {
+	// the identity headers replace any the client sent itself
+	header := func(key, value string) *corev3.HeaderValueOption {
+		return &corev3.HeaderValueOption{
+			Header:       &corev3.HeaderValue{Key: key, Value: value},
+			AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		}
+	}
+	ok := &authv3.OkHttpResponse{
+		Headers: []*corev3.HeaderValueOption{
+			header(auth.HeaderUserID, strconv.Itoa(identity.UserID)),
+			header(auth.HeaderEmail, identity.Email),
+			header(auth.HeaderRoles, strings.Join(identity.Roles, ",")),
+		},
+		HeadersToRemove: []string{auth.HeaderClientID, auth.HeaderScopes},
+	}
+	// nor may a service client pass itself off as a user
+	if identity.IsClient() {
+		ok.Headers = []*corev3.HeaderValueOption{
+			header(auth.HeaderClientID, identity.ClientID),
+			header(auth.HeaderScopes, strings.Join(identity.Scopes, " ")),
+		}
+		ok.HeadersToRemove = []string{auth.HeaderUserID, auth.HeaderEmail, auth.HeaderRoles}
+	}
+	return &authv3.CheckResponse{
+		Status:       &rpcstatus.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: ok},
+	}
+}
+
+// denied answers with the error, and with a Retry-After header if retryAfter
+// is set.
+func denied(appErr *apperror.AppError, retryAfter time.Duration) *authv3.CheckResponse {
+	code := codes.PermissionDenied
+	switch appErr.Status {
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+	headers := []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: "Content-Type", Value: "application/json"}},
+	}
+	if retryAfter > 0 {
+		headers = append(headers, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "Retry-After", Value: strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))}})
+	}
+	body, _ := json.Marshal(appErr.Response)
+	return &authv3.CheckResponse{
+		Status: &rpcstatus.Status{Code: int32(code), Message: appErr.Message},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode(appErr.Status)},
+				Headers: headers,
+				Body:    string(body),
+			},
+		},
+	}
+}
@@ -0,0 +1,337 @@
+package extauthz
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupClient serves the server in-process and returns a client of it.
+func setupClient(t *testing.T, server *Server) authv3.AuthorizationClient {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	authv3.RegisterAuthorizationServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return authv3.NewAuthorizationClient(conn)
+}
+
+func setupServer(t *testing.T, cfg *config.Config) (authv3.AuthorizationClient, auth.Service, *auth.MockUserService, *auth.MockClientChecker) {
+	mockUserService := &auth.MockUserService{}
+	mockAttributeService := &auth.MockAttributeService{}
+	mockAttributeService.On("Claims", mock.Anything).Return(nil, nil)
+	mockClientChecker := &auth.MockClientChecker{}
+	verifier := middleware.NewTokenVerifier(cfg, mockUserService, mockClientChecker, &auth.MockPersonalAccessTokenAuthenticator{})
+	authService := auth.NewService(cfg, mockUserService, mockAttributeService, verifier, noop.NewTracerProvider())
+
+	server := NewServer(cfg, verifier, ratelimit.NewMemoryStore(), zap.NewNop())
+	return setupClient(t, server), authService, mockUserService, mockClientChecker
+}
+
+func checkRequest(headers map[string]string, extensions map[string]string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Method: "GET", Path: "/", Headers: headers},
+			},
+			ContextExtensions: extensions,
+		},
+	}
+}
+
+func headerValues(options []*corev3.HeaderValueOption) map[string]string {
+	values := map[string]string{}
+	for _, option := range options {
+		values[option.GetHeader().GetKey()] = option.GetHeader().GetValue()
+	}
+	return values
+}
+
+func TestServer_Check_BearerToken(t *testing.T) {
+	// Arrange
+	client, authService, _, _ := setupServer(t, auth.NewMockConfig(""))
+
+	token, err := authService.NewAccessToken(1, "", "", "user@example.com", "", []string{"admin", "ops"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), response.GetStatus().GetCode())
+	require.NotNil(t, response.GetOkResponse())
+	expected := map[string]string{
+		auth.HeaderUserID: "1",
+		auth.HeaderEmail:  "user@example.com",
+		auth.HeaderRoles:  "admin,ops",
+	}
+	assert.Equal(t, expected, headerValues(response.GetOkResponse().GetHeaders()))
+	for _, option := range response.GetOkResponse().GetHeaders() {
+		assert.Equal(t, corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD, option.GetAppendAction())
+	}
+}
+
+func TestServer_Check_ClientToken(t *testing.T) {
+	// Arrange
+	client, authService, _, mockClientChecker := setupServer(t, auth.NewMockConfig(""))
+
+	token, err := authService.NewClientAccessToken("svc_batch", []string{"users:read", "reports:read"})
+	require.NoError(t, err)
+
+	mockClientChecker.On("CheckClient", "svc_batch").Return(nil)
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), response.GetStatus().GetCode())
+	expected := map[string]string{
+		auth.HeaderClientID: "svc_batch",
+		auth.HeaderScopes:   "users:read reports:read",
+	}
+	assert.Equal(t, expected, headerValues(response.GetOkResponse().GetHeaders()))
+	assert.ElementsMatch(t, []string{auth.HeaderUserID, auth.HeaderEmail, auth.HeaderRoles}, response.GetOkResponse().GetHeadersToRemove())
+	mockClientChecker.AssertExpectations(t)
+}
+
+func TestServer_Check_ClientDeleted(t *testing.T) {
+	// Arrange
+	client, authService, _, mockClientChecker := setupServer(t, auth.NewMockConfig(""))
+
+	token, err := authService.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	mockClientChecker.On("CheckClient", "svc_batch").Return(apperror.New(apperror.CodeInvalidClient, "invalid client | client_id: svc_batch"))
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.Unauthenticated), response.GetStatus().GetCode())
+	assert.Contains(t, response.GetDeniedResponse().GetBody(), `"code":"`+apperror.CodeInvalidClient+`"`)
+	mockClientChecker.AssertExpectations(t)
+}
+
+func TestServer_Check_PersonalAccessToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		pat      *middleware.PersonalAccessToken
+		err      error
+		expected codes.Code
+	}{
+		{"active", &middleware.PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Roles: []string{"admin", "ops"}, Scopes: []string{"users:read"}}, nil, codes.OK},
+		{"revoked", nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token"), codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := auth.NewMockConfig("")
+			mockAuthenticator := &auth.MockPersonalAccessTokenAuthenticator{}
+			verifier := middleware.NewTokenVerifier(cfg, &auth.MockUserService{}, &auth.MockClientChecker{}, mockAuthenticator)
+			client := setupClient(t, NewServer(cfg, verifier, ratelimit.NewMemoryStore(), zap.NewNop()))
+
+			mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(tt.pat, tt.err)
+
+			// Act
+			response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer pat_token"}, nil))
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int32(tt.expected), response.GetStatus().GetCode())
+			if tt.expected == codes.OK {
+				// the admin role only counts if the token was granted it
+				expected := map[string]string{
+					auth.HeaderUserID: "1",
+					auth.HeaderEmail:  "user@example.com",
+					auth.HeaderRoles:  "ops",
+				}
+				assert.Equal(t, expected, headerValues(response.GetOkResponse().GetHeaders()))
+			}
+			mockAuthenticator.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_Check_Cookie(t *testing.T) {
+	// Arrange
+	client, authService, _, _ := setupServer(t, auth.NewMockConfig(""))
+
+	token, err := authService.NewAccessToken(1, "", "", "user@example.com", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"cookie": "theme=dark; access_token=" + token}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), response.GetStatus().GetCode())
+	assert.Equal(t, "1", headerValues(response.GetOkResponse().GetHeaders())[auth.HeaderUserID])
+}
+
+func TestServer_Check_Roles(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    string
+		expected codes.Code
+	}{
+		{"any role matches", "admin, ops", codes.OK},
+		{"no role matches", "admin", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client, authService, _, _ := setupServer(t, auth.NewMockConfig(""))
+
+			token, err := authService.NewAccessToken(1, "", "", "", "", []string{"ops"}, nil)
+			require.NoError(t, err)
+
+			// Act
+			response, err := client.Check(context.Background(), checkRequest(
+				map[string]string{"authorization": "Bearer " + token},
+				map[string]string{RolesExtension: tt.roles},
+			))
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int32(tt.expected), response.GetStatus().GetCode())
+		})
+	}
+}
+
+func TestServer_Check_Denied(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"no token", map[string]string{}, apperror.CodeInvalidAuthHeader},
+		{"not a bearer token", map[string]string{"authorization": "Basic dXNlcjpwYXNz"}, apperror.CodeInvalidAuthHeader},
+		{"invalid token", map[string]string{"authorization": "Bearer invalid"}, apperror.CodeInvalidAccessToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client, _, _, _ := setupServer(t, auth.NewMockConfig(""))
+			tt.headers["x-request-id"] = "request-1"
+
+			// Act
+			response, err := client.Check(context.Background(), checkRequest(tt.headers, nil))
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int32(codes.Unauthenticated), response.GetStatus().GetCode())
+			denied := response.GetDeniedResponse()
+			require.NotNil(t, denied)
+			assert.EqualValues(t, 401, denied.GetStatus().GetCode())
+			assert.Contains(t, denied.GetBody(), `"code":"`+tt.expected+`"`)
+			assert.Contains(t, denied.GetBody(), `"request_id":"request-1"`)
+		})
+	}
+}
+
+func TestServer_Check_RateLimited(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.RateLimits = map[string][]config.RateLimit{ratelimit.PolicyVerify: {
+		{Key: ratelimit.KeyIP, Requests: 1, Period: time.Minute},
+		{Key: ratelimit.KeyUser, Requests: 2, Period: time.Minute},
+	}}
+	client, authService, _, _ := setupServer(t, cfg)
+
+	token, err := authService.NewAccessToken(1, "", "", "user@example.com", "", nil, nil)
+	require.NoError(t, err)
+	other, err := authService.NewAccessToken(2, "", "", "other@example.com", "", nil, nil)
+	require.NoError(t, err)
+	for range 2 {
+		response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+		require.NoError(t, err)
+		require.NotNil(t, response.GetOkResponse())
+	}
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+	otherResponse, otherErr := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + other}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.ResourceExhausted), response.GetStatus().GetCode())
+	denied := response.GetDeniedResponse()
+	require.NotNil(t, denied)
+	assert.EqualValues(t, 429, denied.GetStatus().GetCode())
+	assert.Contains(t, denied.GetBody(), `"code":"`+apperror.CodeRateLimited+`"`)
+	assert.Equal(t, "30", headerValues(denied.GetHeaders())["Retry-After"])
+	require.NoError(t, otherErr)
+	assert.NotNil(t, otherResponse.GetOkResponse())
+}
+
+func TestServer_Check_UserSuspended(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.EnforceUserStatus = true
+	client, authService, mockUserService, _ := setupServer(t, cfg)
+
+	token, err := authService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(apperror.New(apperror.CodeUserSuspended, "user suspended"))
+
+	// Act
+	response, err := client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.PermissionDenied), response.GetStatus().GetCode())
+	assert.EqualValues(t, 403, response.GetDeniedResponse().GetStatus().GetCode())
+	mockUserService.AssertExpectations(t)
+}
+
+func TestServer_Check_InternalError(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.EnforceUserStatus = true
+	client, authService, mockUserService, _ := setupServer(t, cfg)
+
+	token, err := authService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	mockUserService.On("CheckUserStatus", 1).Return(assert.AnError)
+
+	// Act
+	_, err = client.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + token}, nil))
+
+	// Assert
+	assert.Equal(t, codes.Internal, status.Code(err))
+	mockUserService.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package health
+
+type LiveResponse struct {
+	Status string `json:"status"`
+}
+
+type CheckResponse struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type ReadyResponse struct {
+	Status string                   `json:"status"`
+	Checks map[string]CheckResponse `json:"checks"`
+}
+
+func newReadyResponse(report *Report) *ReadyResponse {
+	checks := make(map[string]CheckResponse, len(report.Checks))
+	for name, result := range report.Checks {
+		checks[name] = CheckResponse{
+			Status:     result.Status,
+			DurationMS: result.Duration.Milliseconds(),
+			Error:      result.Error,
+		}
+	}
+	return &ReadyResponse{
+		Status: report.Status,
+		Checks: checks,
+	}
+}
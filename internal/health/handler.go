@@ -0,0 +1,34 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Live reports that the process is up. It checks no dependencies, so that an
+// unreachable database never gets the pod restarted.
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, LiveResponse{Status: StatusOK})
+}
+
+// Ready reports whether the service can serve traffic. A failing check answers
+// 503 with the same per-check breakdown so that probes and the status page can
+// tell which dependency is down.
+func (h *Handler) Ready(c *gin.Context) {
+	report := h.service.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, newReadyResponse(report))
+}
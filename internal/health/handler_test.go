@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Ready(ctx context.Context) *Report {
+	args := m.Called(ctx)
+	return args.Get(0).(*Report)
+}
+func (m *MockService) Drain() {
+	m.Called()
+}
+
+func TestHandler_Live_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	// Act
+	handler.Live(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	mockService.AssertNotCalled(t, "Ready", mock.Anything)
+}
+
+func TestHandler_Ready_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	mockService.On("Ready", mock.Anything).Return(&Report{
+		Status: StatusOK,
+		Checks: map[string]Result{
+			"database": {Status: StatusOK, Duration: 3 * time.Millisecond},
+		},
+	})
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	// Act
+	handler.Ready(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{"database":{"status":"ok","duration_ms":3}}}`, w.Body.String())
+}
+
+func TestHandler_Ready_Unavailable(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	mockService.On("Ready", mock.Anything).Return(&Report{
+		Status: StatusFail,
+		Checks: map[string]Result{
+			"database": {Status: StatusOK, Duration: 2 * time.Millisecond},
+			"idp":      {Status: StatusFail, Duration: 3 * time.Second, Error: "timed out after 3s"},
+		},
+	})
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	// Act
+	handler.Ready(c)
+
+	// Assert
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response ReadyResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFail, response.Status)
+	assert.Equal(t, CheckResponse{Status: StatusFail, DurationMS: 3000, Error: "timed out after 3s"}, response.Checks["idp"])
+	assert.Equal(t, StatusOK, response.Checks["database"].Status)
+}
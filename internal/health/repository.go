@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// MigrationState is the row the migrate CLI keeps in its migration table.
+type MigrationState struct {
+	Version uint
+	Dirty   bool
+}
+
+type Repository interface {
+	Ping(ctx context.Context) error
+	GetMigrationState(ctx context.Context, table string) (*MigrationState, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *repository) GetMigrationState(ctx context.Context, table string) (*MigrationState, error) {
+	var state MigrationState
+	err := r.db.WithContext(ctx).Table(table).Select("version", "dirty").Take(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	// the table the migrate CLI creates
+	err = d.Exec("CREATE TABLE schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)").Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func TestRepository_Ping_Success(t *testing.T) {
+	// Arrange
+	repo := NewRepository(d)
+
+	// Act
+	err := repo.Ping(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestRepository_GetMigrationState_Success(t *testing.T) {
+	// Arrange
+	err := d.Exec("DELETE FROM schema_migrations").Error
+	require.NoError(t, err)
+	err = d.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (8, true)").Error
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	state, err := repo.GetMigrationState(context.Background(), "schema_migrations")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &MigrationState{Version: 8, Dirty: true}, state)
+}
+
+func TestRepository_GetMigrationState_MissingTable(t *testing.T) {
+	// Arrange
+	repo := NewRepository(d)
+
+	// Act
+	state, err := repo.GetMigrationState(context.Background(), "schema_migrations_unknown")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, state)
+}
@@ -0,0 +1,10 @@
+package health
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(r *gin.Engine, handler *Handler) {
+	r.GET("/livez", handler.Live)
+	r.GET("/readyz", handler.Ready)
+	// deprecated: kept for deployments that still probe /healthz
+	r.GET("/healthz", handler.Live)
+}
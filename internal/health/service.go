@@ -0,0 +1,241 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/migrations"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const (
+	databaseTimeout   = time.Second
+	migrationsTimeout = 2 * time.Second
+	keysTimeout       = time.Second
+	idpTimeout        = 3 * time.Second
+)
+
+// Check is one dependency the service needs to serve requests. Its error is
+// shown to anyone who can reach the probe, so it must not contain hosts,
+// credentials or raw driver errors; those are logged instead.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+type Result struct {
+	Status   string
+	Duration time.Duration
+	Error    string
+}
+
+type Report struct {
+	Status string
+	Checks map[string]Result
+}
+
+type Service interface {
+	Ready(ctx context.Context) *Report
+	// Drain makes every later readiness report fail, so that load balancers
+	// stop routing to an instance that is shutting down.
+	Drain()
+}
+
+type service struct {
+	config    *config.Config
+	repo      Repository
+	logger    *zap.Logger
+	idpClient *http.Client
+	checks    []Check
+	draining  atomic.Bool
+}
+
+func NewService(config *config.Config, repo Repository, logger *zap.Logger) Service {
+	s := &service{config: config, repo: repo, logger: logger, idpClient: &http.Client{}}
+	s.checks = []Check{
+		{Name: "database", Timeout: databaseTimeout, Run: s.checkDatabase},
+		{Name: "keys", Timeout: keysTimeout, Run: s.checkKeys},
+	}
+	// the migration table only exists where the migrate CLI manages the schema
+	if config.MigrationTable != "" {
+		s.checks = append(s.checks, Check{Name: "migrations", Timeout: migrationsTimeout, Run: s.checkMigrations})
+	}
+	if config.ReadinessIdPDiscoveryURL != "" {
+		s.checks = append(s.checks, Check{Name: "idp", Timeout: idpTimeout, Run: s.checkIdP})
+	}
+	return s
+}
+
+// Ready runs all checks concurrently, each bounded by its own timeout. The
+// service is ready only if every check passes.
+func (s *service) Ready(ctx context.Context) *Report {
+	results := make([]Result, len(s.checks))
+	done := make(chan struct{})
+	for i, check := range s.checks {
+		go func() {
+			results[i] = runCheck(ctx, check)
+			done <- struct{}{}
+		}()
+	}
+	for range s.checks {
+		<-done
+	}
+
+	report := &Report{Status: StatusOK, Checks: map[string]Result{}}
+	for i, check := range s.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	if s.draining.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = Result{Status: StatusFail, Error: "shutting down"}
+	}
+	return report
+}
+
+func (s *service) Drain() {
+	s.draining.Store(true)
+}
+
+func runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Run(ctx)
+	}()
+
+	// a check that ignores its context still cannot hold up the probe
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusOK, Duration: time.Since(start)}
+	if errors.Is(err, context.DeadlineExceeded) {
+		result.Status = StatusFail
+		result.Error = "timed out after " + check.Timeout.String()
+	} else if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (s *service) checkDatabase(ctx context.Context) error {
+	if err := s.repo.Ping(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.logger.Warn("readiness: database ping failed", zap.Error(err))
+		return errors.New("database unreachable")
+	}
+	return nil
+}
+
+func (s *service) checkMigrations(ctx context.Context) error {
+	latest, err := latestMigrationVersion(migrations.FS)
+	if err != nil {
+		s.logger.Error("readiness: failed to read embedded migrations", zap.Error(err))
+		return errors.New("migrations unreadable")
+	}
+
+	state, err := s.repo.GetMigrationState(ctx, s.config.MigrationTable)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.logger.Warn("readiness: failed to read migration state", zap.String("table", s.config.MigrationTable), zap.Error(err))
+		return errors.New("migration state unreadable")
+	}
+	if state.Dirty {
+		return fmt.Errorf("migration %d failed and left the schema dirty", state.Version)
+	}
+	if state.Version < latest {
+		return fmt.Errorf("pending migrations: schema at version %d, expected %d", state.Version, latest)
+	}
+	return nil
+}
+
+func (s *service) checkKeys(ctx context.Context) error {
+	var missing []string
+	for name, value := range map[string][]byte{
+		"ACCESS_TOKEN_SECRET":  s.config.AccessTokenSecret,
+		"REFRESH_TOKEN_SECRET": s.config.RefreshTokenSecret,
+		"GOOGLE_CLIENT_ID":     []byte(s.config.GoogleClientID),
+		"GOOGLE_CLIENT_SECRET": []byte(s.config.GoogleClientSecret),
+	} {
+		if len(value) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return errors.New("missing " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (s *service) checkIdP(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.ReadinessIdPDiscoveryURL, nil)
+	if err != nil {
+		return errors.New("invalid identity provider discovery URL")
+	}
+	resp, err := s.idpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.logger.Warn("readiness: identity provider discovery failed", zap.Error(err))
+		return errors.New("identity provider unreachable")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("identity provider discovery returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// latestMigrationVersion returns the version of the newest migration, e.g. 8
+// for 000008_create_outbox.up.sql, which a fully migrated database is at.
+func latestMigrationVersion(fsys fs.FS) (uint, error) {
+	names, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name %q: %w", name, err)
+		}
+		latest = max(latest, uint(version))
+	}
+	if latest == 0 {
+		return 0, errors.New("no migrations found")
+	}
+	return latest, nil
+}
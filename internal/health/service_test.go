@@ -0,0 +1,290 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+func (m *MockRepository) GetMigrationState(ctx context.Context, table string) (*MigrationState, error) {
+	args := m.Called(ctx, table)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MigrationState), args.Error(1)
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		GoogleClientID:     "client-id",
+		GoogleClientSecret: "client-secret",
+		AccessTokenSecret:  []byte("access-secret"),
+		RefreshTokenSecret: []byte("refresh-secret"),
+	}
+}
+
+func TestService_Ready_Success(t *testing.T) {
+	// Arrange
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer idp.Close()
+	cfg := newTestConfig()
+	cfg.MigrationTable = "schema_migrations"
+	cfg.ReadinessIdPDiscoveryURL = idp.URL
+
+	latest, err := latestMigrationVersion(migrations.FS)
+	require.NoError(t, err)
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	mockRepo.On("GetMigrationState", mock.Anything, "schema_migrations").Return(&MigrationState{Version: latest}, nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Len(t, report.Checks, 4)
+	for name, result := range report.Checks {
+		assert.Equal(t, StatusOK, result.Status, name)
+		assert.Empty(t, result.Error, name)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Ready_OptionalChecksDisabled(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	s := NewService(newTestConfig(), mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Contains(t, report.Checks, "database")
+	assert.Contains(t, report.Checks, "keys")
+	assert.NotContains(t, report.Checks, "migrations")
+	assert.NotContains(t, report.Checks, "idp")
+	mockRepo.AssertNotCalled(t, "GetMigrationState", mock.Anything, mock.Anything)
+}
+
+func TestService_Ready_DatabaseUnreachable(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(errors.New("dial tcp 10.0.0.5:5432: connect: connection refused"))
+	s := NewService(newTestConfig(), mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, Result{Status: StatusFail, Duration: report.Checks["database"].Duration, Error: "database unreachable"}, report.Checks["database"])
+	assert.Equal(t, StatusOK, report.Checks["keys"].Status)
+}
+
+func TestService_Ready_DatabaseTimeout(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Return(context.DeadlineExceeded)
+	s := NewService(newTestConfig(), mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "timed out after 1s", report.Checks["database"].Error)
+	assert.GreaterOrEqual(t, report.Checks["database"].Duration, databaseTimeout)
+}
+
+func TestService_Ready_PendingMigrations(t *testing.T) {
+	// Arrange
+	cfg := newTestConfig()
+	cfg.MigrationTable = "schema_migrations"
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	mockRepo.On("GetMigrationState", mock.Anything, "schema_migrations").Return(&MigrationState{Version: 1}, nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Regexp(t, `^pending migrations: schema at version 1, expected \d+$`, report.Checks["migrations"].Error)
+}
+
+func TestService_Ready_DirtyMigration(t *testing.T) {
+	// Arrange
+	cfg := newTestConfig()
+	cfg.MigrationTable = "schema_migrations"
+	latest, err := latestMigrationVersion(migrations.FS)
+	require.NoError(t, err)
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	mockRepo.On("GetMigrationState", mock.Anything, "schema_migrations").Return(&MigrationState{Version: latest, Dirty: true}, nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Contains(t, report.Checks["migrations"].Error, "left the schema dirty")
+}
+
+func TestService_Ready_MigrationStateUnreadable(t *testing.T) {
+	// Arrange
+	cfg := newTestConfig()
+	cfg.MigrationTable = "schema_migrations"
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	mockRepo.On("GetMigrationState", mock.Anything, "schema_migrations").Return(nil, errors.New(`relation "schema_migrations" does not exist`))
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "migration state unreadable", report.Checks["migrations"].Error)
+}
+
+func TestService_Ready_MissingKeys(t *testing.T) {
+	// Arrange
+	cfg := newTestConfig()
+	cfg.RefreshTokenSecret = nil
+	cfg.GoogleClientSecret = ""
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "missing GOOGLE_CLIENT_SECRET, REFRESH_TOKEN_SECRET", report.Checks["keys"].Error)
+}
+
+func TestService_Ready_IdPUnhealthy(t *testing.T) {
+	// Arrange
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer idp.Close()
+	cfg := newTestConfig()
+	cfg.ReadinessIdPDiscoveryURL = idp.URL
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+
+	// Act
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "identity provider discovery returned 502", report.Checks["idp"].Error)
+}
+
+func TestService_Ready_IdPTimeout(t *testing.T) {
+	// Arrange
+	release := make(chan struct{})
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer idp.Close()
+	defer close(release)
+	cfg := newTestConfig()
+	cfg.ReadinessIdPDiscoveryURL = idp.URL
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	s := NewService(cfg, mockRepo, zap.NewNop())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Act
+	report := s.Ready(ctx)
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, StatusFail, report.Checks["idp"].Status)
+	assert.Less(t, report.Checks["idp"].Duration, idpTimeout)
+	assert.Equal(t, StatusOK, report.Checks["keys"].Status)
+}
+
+func TestService_Ready_Draining(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	s := NewService(newTestConfig(), mockRepo, zap.NewNop())
+
+	// Act
+	s.Drain()
+	report := s.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, Result{Status: StatusFail, Error: "shutting down"}, report.Checks["shutdown"])
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+}
+
+func TestService_latestMigrationVersion_Success(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"000001_create_users.up.sql":    {},
+		"000001_create_users.down.sql":  {},
+		"000012_add_column.up.sql":      {},
+		"000003_create_webhooks.up.sql": {},
+	}
+
+	// Act
+	version, err := latestMigrationVersion(fsys)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, uint(12), version)
+}
+
+func TestService_latestMigrationVersion_Embedded(t *testing.T) {
+	// Act
+	version, err := latestMigrationVersion(migrations.FS)
+
+	// Assert
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, version, uint(8))
+}
+
+func TestService_latestMigrationVersion_Invalid(t *testing.T) {
+	// Act
+	_, err := latestMigrationVersion(fstest.MapFS{"init.up.sql": {}})
+
+	// Assert
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package lockout
+
+import "time"
+
+type RequestURI struct {
+	Kind  string `uri:"kind" binding:"required,oneof=email ip"`
+	Value string `uri:"value" binding:"required,max=255"`
+}
+
+type LockoutResponse struct {
+	ID            int    `json:"id"`
+	Kind          string `json:"kind"`
+	Value         string `json:"value"`
+	Failures      int    `json:"failures"`
+	LockedUntil   string `json:"locked_until"`
+	LastFailureAt string `json:"last_failure_at"`
+}
+
+func newLockoutResponse(l *Lockout) *LockoutResponse {
+	response := &LockoutResponse{
+		ID:            l.ID,
+		Kind:          l.Kind,
+		Value:         l.Value,
+		Failures:      l.Failures,
+		LastFailureAt: l.LastFailureAt.Format(time.RFC3339),
+	}
+	if l.LockedUntil != nil {
+		response.LockedUntil = l.LockedUntil.Format(time.RFC3339)
+	}
+	return response
+}
@@ -0,0 +1,54 @@
+package lockout
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.service.GetLockouts()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	lockoutResponses := make([]LockoutResponse, len(lockouts))
+	for i, lockout := range lockouts {
+		lockoutResponses[i] = *newLockoutResponse(&lockout)
+	}
+
+	c.JSON(http.StatusOK, lockoutResponses)
+}
+
+func (h *Handler) Unlock(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	audit.SetMetadata(c, "kind", uri.Kind)
+	audit.SetMetadata(c, "value", uri.Value)
+	if uri.Kind == KindEmail {
+		audit.SetEmail(c, uri.Value)
+	}
+
+	if err := h.service.Unlock(uri.Kind, uri.Value); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+package lockout
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetActiveLockout(email, ip string) (*Lockout, error) {
+	args := m.Called(email, ip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Lockout), args.Error(1)
+}
+func (m *MockService) RecordFailure(attempt Attempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+func (m *MockService) Reset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+func (m *MockService) GetLockouts() ([]Lockout, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Lockout), args.Error(1)
+}
+func (m *MockService) Unlock(kind, value string) error {
+	args := m.Called(kind, value)
+	return args.Error(0)
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+
+	// Act
+	handler := NewHandler(mockService)
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+}
+
+func TestHandler_GetLockouts_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	lockedUntil := time.Unix(120, 0)
+	lockouts := []Lockout{
+		{ID: 1, Kind: KindEmail, Value: "user@example.com", Failures: 5, LockedUntil: &lockedUntil, LastFailureAt: time.Unix(60, 0)},
+	}
+
+	mockService.On("GetLockouts").Return(lockouts, nil)
+
+	// Act
+	handler.GetLockouts(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual []LockoutResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []LockoutResponse{
+		{
+			ID:            1,
+			Kind:          KindEmail,
+			Value:         "user@example.com",
+			Failures:      5,
+			LockedUntil:   lockedUntil.Format(time.RFC3339),
+			LastFailureAt: time.Unix(60, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetLockouts_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	mockService.On("GetLockouts").Return(nil, assert.AnError)
+
+	// Act
+	handler.GetLockouts(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Unlock_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "kind", Value: KindIP}, {Key: "value", Value: "192.0.2.1"}}
+
+	mockService.On("Unlock", KindIP, "192.0.2.1").Return(nil)
+
+	// Act
+	handler.Unlock(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_Unlock_NotFound(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, _ := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "kind", Value: KindEmail}, {Key: "value", Value: "user@example.com"}}
+	appErr := apperror.New(apperror.CodeLockoutNotFound, "lockout not found | kind: email")
+
+	mockService.On("Unlock", KindEmail, "user@example.com").Return(appErr)
+
+	// Act
+	handler.Unlock(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, appErr, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_Unlock_InvalidURI(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "kind", Value: "user"}, {Key: "value", Value: "1"}}
+
+	// Act
+	handler.Unlock(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	assert.Contains(t, appErr.Message, "request uri validation failed")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package lockout
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	KindEmail = "email"
+	KindIP    = "ip"
+)
+
+// Lockout counts the failed logins of one email address or client IP and
+// holds the lock they have earned, if any.
+type Lockout struct {
+	ID            int        `gorm:"primaryKey"`
+	Kind          string     `gorm:"type:varchar(16);not null;uniqueIndex:idx_login_lockouts_kind_value"`
+	Value         string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_login_lockouts_kind_value"`
+	Failures      int        `gorm:"not null;default:0"`
+	LockedUntil   *time.Time `gorm:"type:timestamptz;index"`
+	LastFailureAt time.Time  `gorm:"type:timestamptz;not null"`
+	CreatedAt     time.Time  `gorm:"type:timestamptz;not null"`
+}
+
+func (Lockout) TableName() string {
+	return "login_lockouts"
+}
+
+// IsLocked reports whether the lock is still in force at the given time.
+func (l *Lockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil != nil && l.LockedUntil.After(now)
+}
+
+type Repository interface {
+	Find(kind, value string) (*Lockout, error)
+	FindLocked(now time.Time) ([]Lockout, error)
+	// Update applies fn to the lockout of the kind and value, creating it
+	// first if needed, while holding a lock on its row.
+	Update(kind, value string, fn func(lockout *Lockout)) (*Lockout, error)
+	Delete(kind, value string) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Find(kind, value string) (*Lockout, error) {
+	var lockout Lockout
+	err := r.db.Where("kind = ? AND value = ?", kind, value).Take(&lockout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *repository) FindLocked(now time.Time) ([]Lockout, error) {
+	var lockouts []Lockout
+	err := r.db.Where("locked_until > ?", now).Order("locked_until DESC").Find(&lockouts).Error
+	if err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+func (r *repository) Update(kind, value string, fn func(lockout *Lockout)) (*Lockout, error) {
+	var lockout Lockout
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// creates the row on first use so that there is a row to lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Lockout{Kind: kind, Value: value, LastFailureAt: now, CreatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("kind = ? AND value = ?", kind, value).Take(&lockout).Error
+		if err != nil {
+			return err
+		}
+		fn(&lockout)
+		return tx.Save(&lockout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *repository) Delete(kind, value string) (bool, error) {
+	result := r.db.Where("kind = ? AND value = ?", kind, value).Delete(&Lockout{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,169 @@
+package lockout
+
+import (
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Lockout{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_Find_NotFound(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	lockout, err := repo.Find(KindEmail, "user@example.com")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, lockout)
+}
+
+func TestRepository_Update_CreatesAndUpdates(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	for i := 0; i < 2; i++ {
+		_, err = repo.Update(KindEmail, "user@example.com", func(lockout *Lockout) {
+			lockout.Failures++
+		})
+		require.NoError(t, err)
+	}
+
+	// Assert
+	lockout, err := repo.Find(KindEmail, "user@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, lockout)
+	assert.Equal(t, 2, lockout.Failures)
+}
+
+func TestRepository_Update_Concurrent(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Update(KindIP, "192.0.2.1", func(lockout *Lockout) {
+				lockout.Failures++
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	lockout, err := repo.Find(KindIP, "192.0.2.1")
+	require.NoError(t, err)
+	require.NotNil(t, lockout)
+	assert.Equal(t, 10, lockout.Failures)
+}
+
+func TestRepository_FindLocked_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	short := now.Add(time.Minute)
+	long := now.Add(time.Hour)
+	lockouts := []Lockout{
+		{Kind: KindEmail, Value: "expired@example.com", LockedUntil: &expired, LastFailureAt: now, CreatedAt: now},
+		{Kind: KindEmail, Value: "short@example.com", LockedUntil: &short, LastFailureAt: now, CreatedAt: now},
+		{Kind: KindIP, Value: "192.0.2.1", LockedUntil: &long, LastFailureAt: now, CreatedAt: now},
+		{Kind: KindEmail, Value: "counting@example.com", Failures: 1, LastFailureAt: now, CreatedAt: now},
+	}
+	err = d.Create(&lockouts).Error
+	require.NoError(t, err)
+
+	// Act
+	locked, err := repo.FindLocked(now)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, locked, 2)
+	assert.Equal(t, "192.0.2.1", locked[0].Value)
+	assert.Equal(t, "short@example.com", locked[1].Value)
+}
+
+func TestRepository_Delete_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&Lockout{Kind: KindEmail, Value: "user@example.com", LastFailureAt: time.Now(), CreatedAt: time.Now()}).Error
+	require.NoError(t, err)
+
+	// Act
+	deleted, err := repo.Delete(KindEmail, "user@example.com")
+	require.NoError(t, err)
+	deletedAgain, err := repo.Delete(KindEmail, "user@example.com")
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, deleted)
+	assert.False(t, deletedAgain)
+}
@@ -0,0 +1,18 @@
+package lockout
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware, auditMiddleware audit.Middleware) {
+	g := r.Group("/lockouts")
+	g.Use(gin.HandlerFunc(authMiddleware), middleware.RequireRole(role.Admin))
+	{
+		g.GET("", handler.GetLockouts)
+		g.DELETE("/:kind/:value", auditMiddleware(audit.EventLoginUnlock), handler.Unlock)
+	}
+}
@@ -0,0 +1,180 @@
+package lockout
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+)
+
+// Attempt is a failed login as the audit log should see it.
+type Attempt struct {
+	Email     string
+	IP        string
+	UserAgent string
+}
+
+type Service interface {
+	// GetActiveLockout returns the lock in force for the email or the IP, the
+	// one that lasts longer if both are locked, or nil.
+	GetActiveLockout(email, ip string) (*Lockout, error)
+	// RecordFailure counts a login that was refused to an email that has no
+	// account or whose account may not log in, and locks the email and the IP
+	// once they reach their thresholds.
+	RecordFailure(attempt Attempt) error
+	// Reset forgets the failures of an email after it has logged in.
+	Reset(email string) error
+	GetLockouts() ([]Lockout, error)
+	Unlock(kind, value string) error
+}
+
+type service struct {
+	config       *config.Config
+	repo         Repository
+	auditService audit.Service
+	now          func() time.Time
+}
+
+func NewService(config *config.Config, repo Repository, auditService audit.Service) Service {
+	return &service{config: config, repo: repo, auditService: auditService, now: time.Now}
+}
+
+// normalize makes emails case-insensitive, as they are for accounts.
+func normalize(kind, value string) string {
+	if kind == KindEmail {
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+type key struct {
+	kind  string
+	value string
+}
+
+// keys returns the normalized email and IP a login is counted under,
+// skipping the ones that are unknown.
+func keys(email, ip string) []key {
+	var keys []key
+	if email != "" {
+		keys = append(keys, key{kind: KindEmail, value: normalize(KindEmail, email)})
+	}
+	if ip != "" {
+		keys = append(keys, key{kind: KindIP, value: ip})
+	}
+	return keys
+}
+
+func (s *service) thresholds() map[string]int {
+	return map[string]int{
+		KindEmail: s.config.LockoutThreshold,
+		KindIP:    s.config.LockoutIPThreshold,
+	}
+}
+
+func (s *service) GetActiveLockout(email, ip string) (*Lockout, error) {
+	now := s.now()
+	var active *Lockout
+	for _, key := range keys(email, ip) {
+		lockout, err := s.repo.Find(key.kind, key.value)
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil && lockout.IsLocked(now) && (active == nil || lockout.LockedUntil.After(*active.LockedUntil)) {
+			active = lockout
+		}
+	}
+	return active, nil
+}
+
+func (s *service) RecordFailure(attempt Attempt) error {
+	for _, key := range keys(attempt.Email, attempt.IP) {
+		threshold := s.thresholds()[key.kind]
+		if threshold == 0 {
+			continue
+		}
+
+		now := s.now()
+		locked := false
+		lockout, err := s.repo.Update(key.kind, key.value, func(lockout *Lockout) {
+			if now.Sub(lockout.LastFailureAt) > s.config.LockoutResetAfter {
+				lockout.Failures = 0
+			}
+			lockout.Failures++
+			lockout.LastFailureAt = now
+			if lockout.Failures >= threshold {
+				lockedUntil := now.Add(s.lockDuration(lockout.Failures - threshold))
+				lockout.LockedUntil = &lockedUntil
+				locked = true
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if locked {
+			if err := s.recordLockout(attempt, lockout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lockDuration doubles the lock for every failure beyond the threshold, up to
+// the maximum.
+func (s *service) lockDuration(excess int) time.Duration {
+	duration := s.config.LockoutDuration
+	for range excess {
+		if duration >= s.config.LockoutMaxDuration/2 {
+			return s.config.LockoutMaxDuration
+		}
+		duration *= 2
+	}
+	return min(duration, s.config.LockoutMaxDuration)
+}
+
+func (s *service) recordLockout(attempt Attempt, lockout *Lockout) error {
+	event := &audit.Event{
+		Type:      audit.EventLoginLockout,
+		Outcome:   audit.OutcomeSuccess,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Metadata: map[string]any{
+			"kind":         lockout.Kind,
+			"value":        lockout.Value,
+			"failures":     lockout.Failures,
+			"locked_until": lockout.LockedUntil.UTC().Format(time.RFC3339),
+		},
+	}
+	if attempt.Email != "" {
+		event.Email = &attempt.Email
+	}
+	return s.auditService.Record(event)
+}
+
+func (s *service) Reset(email string) error {
+	_, err := s.repo.Delete(KindEmail, normalize(KindEmail, email))
+	return err
+}
+
+func (s *service) GetLockouts() ([]Lockout, error) {
+	return s.repo.FindLocked(s.now())
+}
+
+func (s *service) Unlock(kind, value string) error {
+	deleted, err := s.repo.Delete(kind, normalize(kind, value))
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// the message is returned to the caller, which must not learn emails
+		if kind == KindEmail {
+			value = redact.Fingerprint(value)
+		}
+		return apperror.New(apperror.CodeLockoutNotFound, "lockout not found | kind: "+kind).WithInternal(kind + ": " + value)
+	}
+	return nil
+}
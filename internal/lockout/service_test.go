@@ -0,0 +1,389 @@
+package lockout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+	// lockouts holds the rows that Update applies its function to
+	lockouts map[string]*Lockout
+}
+
+func (m *MockRepository) Find(kind, value string) (*Lockout, error) {
+	args := m.Called(kind, value)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Lockout), args.Error(1)
+}
+func (m *MockRepository) FindLocked(now time.Time) ([]Lockout, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Lockout), args.Error(1)
+}
+func (m *MockRepository) Update(kind, value string, fn func(lockout *Lockout)) (*Lockout, error) {
+	args := m.Called(kind, value)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	if m.lockouts == nil {
+		m.lockouts = map[string]*Lockout{}
+	}
+	lockout, ok := m.lockouts[kind+":"+value]
+	if !ok {
+		lockout = &Lockout{Kind: kind, Value: value}
+		m.lockouts[kind+":"+value] = lockout
+	}
+	fn(lockout)
+	return lockout, nil
+}
+func (m *MockRepository) Delete(kind, value string) (bool, error) {
+	args := m.Called(kind, value)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(event *audit.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+func (m *MockAuditService) GetEvents(filter audit.Filter, cursor string) (*audit.Page, error) {
+	args := m.Called(filter, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit.Page), args.Error(1)
+}
+
+func newTestService(repo Repository, auditService audit.Service, now time.Time) *service {
+	return &service{
+		config: &config.Config{
+			LockoutThreshold:   3,
+			LockoutIPThreshold: 10,
+			LockoutDuration:    time.Minute,
+			LockoutMaxDuration: 10 * time.Minute,
+			LockoutResetAfter:  time.Hour,
+		},
+		repo:         repo,
+		auditService: auditService,
+		now:          func() time.Time { return now },
+	}
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	cfg := &config.Config{}
+	mockRepo := &MockRepository{}
+	mockAuditService := &MockAuditService{}
+
+	// Act
+	s := NewService(cfg, mockRepo, mockAuditService)
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, cfg, s.(*service).config)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+	assert.Equal(t, mockAuditService, s.(*service).auditService)
+}
+
+func TestService_GetActiveLockout_Longest(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, now)
+
+	emailUntil := now.Add(time.Minute)
+	ipUntil := now.Add(time.Hour)
+	ipLockout := &Lockout{Kind: KindIP, Value: "192.0.2.1", LockedUntil: &ipUntil}
+
+	mockRepo.On("Find", KindEmail, "user@example.com").Return(&Lockout{Kind: KindEmail, LockedUntil: &emailUntil}, nil)
+	mockRepo.On("Find", KindIP, "192.0.2.1").Return(ipLockout, nil)
+
+	// Act
+	lockout, err := service.GetActiveLockout("User@Example.com", "192.0.2.1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, ipLockout, lockout)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetActiveLockout_Expired(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, now)
+
+	lockedUntil := now.Add(-time.Second)
+
+	mockRepo.On("Find", KindEmail, "user@example.com").Return(&Lockout{Kind: KindEmail, LockedUntil: &lockedUntil}, nil)
+	mockRepo.On("Find", KindIP, "192.0.2.1").Return(nil, nil)
+
+	// Act
+	lockout, err := service.GetActiveLockout("user@example.com", "192.0.2.1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, lockout)
+}
+
+func TestService_GetActiveLockout_Error(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Find", KindEmail, "user@example.com").Return(nil, errors.New("db error"))
+
+	// Act
+	lockout, err := service.GetActiveLockout("user@example.com", "")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, lockout)
+}
+
+func TestService_RecordFailure_BelowThreshold(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	mockRepo := &MockRepository{}
+	mockAuditService := &MockAuditService{}
+	service := newTestService(mockRepo, mockAuditService, now)
+
+	mockRepo.On("Update", KindEmail, "user@example.com").Return(nil)
+	mockRepo.On("Update", KindIP, "192.0.2.1").Return(nil)
+
+	// Act
+	err := service.RecordFailure(Attempt{Email: "User@Example.com", IP: "192.0.2.1"})
+
+	// Assert
+	require.NoError(t, err)
+	lockout := mockRepo.lockouts[KindEmail+":user@example.com"]
+	assert.Equal(t, 1, lockout.Failures)
+	assert.Equal(t, now, lockout.LastFailureAt)
+	assert.Nil(t, lockout.LockedUntil)
+	mockAuditService.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestService_RecordFailure_Locks(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	mockRepo := &MockRepository{}
+	mockAuditService := &MockAuditService{}
+	service := newTestService(mockRepo, mockAuditService, now)
+
+	mockRepo.lockouts = map[string]*Lockout{
+		KindEmail + ":user@example.com": {Kind: KindEmail, Value: "user@example.com", Failures: 2, LastFailureAt: now.Add(-time.Minute)},
+	}
+	mockRepo.On("Update", KindEmail, "user@example.com").Return(nil)
+	mockRepo.On("Update", KindIP, "192.0.2.1").Return(nil)
+	mockAuditService.On("Record", mock.MatchedBy(func(event *audit.Event) bool {
+		return event.Type == audit.EventLoginLockout &&
+			event.Outcome == audit.OutcomeSuccess &&
+			*event.Email == "User@Example.com" &&
+			event.IP == "192.0.2.1" &&
+			event.Metadata["kind"] == KindEmail &&
+			event.Metadata["failures"] == 3
+	})).Return(nil)
+
+	// Act
+	err := service.RecordFailure(Attempt{Email: "User@Example.com", IP: "192.0.2.1"})
+
+	// Assert
+	require.NoError(t, err)
+	lockout := mockRepo.lockouts[KindEmail+":user@example.com"]
+	assert.Equal(t, 3, lockout.Failures)
+	assert.Equal(t, now.Add(time.Minute), *lockout.LockedUntil)
+	assert.Nil(t, mockRepo.lockouts[KindIP+":192.0.2.1"].LockedUntil)
+	mockAuditService.AssertNumberOfCalls(t, "Record", 1)
+}
+
+func TestService_RecordFailure_Exponential(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{name: "at threshold", failures: 2, expected: time.Minute},
+		{name: "one beyond", failures: 3, expected: 2 * time.Minute},
+		{name: "two beyond", failures: 4, expected: 4 * time.Minute},
+		{name: "capped", failures: 6, expected: 10 * time.Minute},
+		{name: "far beyond", failures: 100, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			now := time.Unix(1000, 0)
+			mockRepo := &MockRepository{}
+			mockAuditService := &MockAuditService{}
+			service := newTestService(mockRepo, mockAuditService, now)
+
+			mockRepo.lockouts = map[string]*Lockout{
+				KindEmail + ":user@example.com": {Kind: KindEmail, Value: "user@example.com", Failures: tt.failures, LastFailureAt: now},
+			}
+			mockRepo.On("Update", KindEmail, "user@example.com").Return(nil)
+			mockAuditService.On("Record", mock.Anything).Return(nil)
+
+			// Act
+			err := service.RecordFailure(Attempt{Email: "user@example.com"})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, now.Add(tt.expected), *mockRepo.lockouts[KindEmail+":user@example.com"].LockedUntil)
+		})
+	}
+}
+
+func TestService_RecordFailure_ResetAfter(t *testing.T) {
+	// Arrange
+	now := time.Unix(10000, 0)
+	mockRepo := &MockRepository{}
+	mockAuditService := &MockAuditService{}
+	service := newTestService(mockRepo, mockAuditService, now)
+
+	mockRepo.lockouts = map[string]*Lockout{
+		KindEmail + ":user@example.com": {Kind: KindEmail, Value: "user@example.com", Failures: 2, LastFailureAt: now.Add(-2 * time.Hour)},
+	}
+	mockRepo.On("Update", KindEmail, "user@example.com").Return(nil)
+
+	// Act
+	err := service.RecordFailure(Attempt{Email: "user@example.com"})
+
+	// Assert
+	require.NoError(t, err)
+	lockout := mockRepo.lockouts[KindEmail+":user@example.com"]
+	assert.Equal(t, 1, lockout.Failures)
+	assert.Nil(t, lockout.LockedUntil)
+	mockAuditService.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestService_RecordFailure_Disabled(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+	service.config.LockoutThreshold = 0
+	service.config.LockoutIPThreshold = 0
+
+	// Act
+	err := service.RecordFailure(Attempt{Email: "user@example.com", IP: "192.0.2.1"})
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestService_RecordFailure_Error(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Update", KindEmail, "user@example.com").Return(errors.New("db error"))
+
+	// Act
+	err := service.RecordFailure(Attempt{Email: "user@example.com", IP: "192.0.2.1"})
+
+	// Assert
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Update", KindIP, "192.0.2.1")
+}
+
+func TestService_Reset_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Delete", KindEmail, "user@example.com").Return(false, nil)
+
+	// Act
+	err := service.Reset("User@Example.com")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetLockouts_Success(t *testing.T) {
+	// Arrange
+	now := time.Unix(1000, 0)
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, now)
+
+	lockouts := []Lockout{{ID: 1, Kind: KindEmail, Value: "user@example.com"}}
+	mockRepo.On("FindLocked", now).Return(lockouts, nil)
+
+	// Act
+	result, err := service.GetLockouts()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, lockouts, result)
+}
+
+func TestService_Unlock_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Delete", KindEmail, "user@example.com").Return(true, nil)
+
+	// Act
+	err := service.Unlock(KindEmail, "User@Example.com")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Unlock_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Delete", KindIP, "192.0.2.1").Return(false, nil)
+
+	// Act
+	err := service.Unlock(KindIP, "192.0.2.1")
+
+	// Assert
+	require.Error(t, err)
+	appErr, ok := err.(*apperror.AppError)
+	require.True(t, ok)
+	assert.Equal(t, apperror.CodeLockoutNotFound, appErr.Code)
+	assert.Equal(t, "ip: 192.0.2.1", appErr.Internal)
+}
+
+func TestService_Unlock_NotFoundEmail(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo, nil, time.Unix(1000, 0))
+
+	mockRepo.On("Delete", KindEmail, "user@example.com").Return(false, nil)
+
+	// Act
+	err := service.Unlock(KindEmail, "user@example.com")
+
+	// Assert
+	require.Error(t, err)
+	appErr, ok := err.(*apperror.AppError)
+	require.True(t, ok)
+	assert.Equal(t, apperror.CodeLockoutNotFound, appErr.Code)
+	assert.NotContains(t, appErr.Error(), "user@example.com")
+	assert.Equal(t, "email: "+redact.Fingerprint("user@example.com"), appErr.Internal)
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 )
 
@@ -11,3 +13,20 @@ func NewLogger() (*zap.Logger, error) {
 	}
 	return logger, nil
 }
+
+type contextKey struct{}
+
+// NewContext stores a logger scoped to a request, e.g. one that already
+// carries the request ID.
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in the context, or a no-op logger if
+// there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.NewNop()
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const namespace = "vera_identity"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+const ProviderGoogle = "google"
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Metrics holds the collectors of the service on a registry of its own, so
+// that tests can create as many as they need.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	loginAttempts       *prometheus.CounterVec
+	refreshes           *prometheus.CounterVec
+	tokensIssued        *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_attempts_total",
+			Help:      "OAuth login attempts by provider and outcome; failures carry the error ID as reason.",
+		}, []string{"provider", "outcome", "reason"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Access token refreshes by outcome; failures carry the error ID as reason.",
+		}, []string{"outcome", "reason"}),
+		tokensIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_issued_total",
+			Help:      "Tokens issued by type.",
+		}, []string{"type"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.loginAttempts,
+		m.refreshes,
+		m.tokensIssued,
+	)
+	return m
+}
+
+// NewMetrics also reports the connection pool of the database, as the
+// go_sql_* gauges and counters of sql.DBStats.
+func NewMetrics(db *gorm.DB) (*Metrics, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	m := New()
+	if err = m.registry.Register(collectors.NewDBStatsCollector(sqlDB, "identity")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveRequest records a finished request. Requests that match no route
+// share one route label so that scans of random paths cannot blow up the
+// number of series.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpRequestDuration.With(labels).Observe(duration.Seconds())
+}
+
+func (m *Metrics) LoginAttempt(provider string, c *gin.Context) {
+	outcome, reason := Outcome(c)
+	m.loginAttempts.WithLabelValues(provider, outcome, reason).Inc()
+}
+
+func (m *Metrics) Refresh(c *gin.Context) {
+	outcome, reason := Outcome(c)
+	m.refreshes.WithLabelValues(outcome, reason).Inc()
+}
+
+// RefreshResult records a refresh that was not served over HTTP, e.g. one
+// over gRPC, by the error it ended with.
+func (m *Metrics) RefreshResult(err error) {
+	outcome, reason := ErrorOutcome(err)
+	m.refreshes.WithLabelValues(outcome, reason).Inc()
+}
+
+func (m *Metrics) TokenIssued(tokenType string) {
+	m.tokensIssued.WithLabelValues(tokenType).Inc()
+}
+
+// Outcome tells whether the request succeeded and, if not, why. The reason is
+// the stable ID of the error code, which keeps the label values bounded.
+func Outcome(c *gin.Context) (outcome string, reason string) {
+	err := c.Errors.Last()
+	if err == nil {
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return OutcomeFailure, http.StatusText(c.Writer.Status())
+		}
+		return OutcomeSuccess, ""
+	}
+
+	return ErrorOutcome(err)
+}
+
+// ErrorOutcome is Outcome for callers without a gin context, where a nil
+// error means success.
+func ErrorOutcome(err error) (outcome string, reason string) {
+	if err == nil {
+		return OutcomeSuccess, ""
+	}
+
+	appErr := apperror.FromError(err)
+	if definition, ok := apperror.LookupDefinition(appErr.Code); ok {
+		return OutcomeFailure, definition.ID
+	}
+	return OutcomeFailure, appErr.Code
+}
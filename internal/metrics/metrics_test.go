@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_ObserveRequest_Success(t *testing.T) {
+	// Arrange
+	m := New()
+
+	// Act
+	m.ObserveRequest("GET", "/users/:id", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest("GET", "/users/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest("GET", "", http.StatusNotFound, time.Millisecond)
+
+	// Assert
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/users/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpRequestDuration))
+}
+
+func TestMetrics_Outcome(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		status          int
+		expectedOutcome string
+		expectedReason  string
+	}{
+		{"success", nil, http.StatusOK, OutcomeSuccess, ""},
+		{"registered error", apperror.New(apperror.CodeUserSuspended, "user suspended"), http.StatusOK, OutcomeFailure, "user_suspended"},
+		{"unexpected error", errors.New("connection refused"), http.StatusOK, OutcomeFailure, "unknown_error"},
+		{"error status", nil, http.StatusBadRequest, OutcomeFailure, "Bad Request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			c, _ := test.SetupContext()
+			c.Status(tt.status)
+			if tt.err != nil {
+				c.Error(tt.err)
+			}
+
+			// Act
+			outcome, reason := Outcome(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedOutcome, outcome)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestMetrics_LoginAttempt_ByOutcome(t *testing.T) {
+	// Arrange
+	m := New()
+	succeeded, _ := test.SetupContext()
+	failed, _ := test.SetupContext()
+	failed.Error(apperror.New(apperror.CodeUserNotAuthorized, "user not authorized"))
+
+	// Act
+	m.LoginAttempt(ProviderGoogle, succeeded)
+	m.LoginAttempt(ProviderGoogle, failed)
+	m.LoginAttempt(ProviderGoogle, failed)
+
+	// Assert
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.loginAttempts.WithLabelValues(ProviderGoogle, OutcomeSuccess, "")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.loginAttempts.WithLabelValues(ProviderGoogle, OutcomeFailure, "user_not_authorized")))
+}
+
+func TestMetrics_Handler_Exposition(t *testing.T) {
+	// Arrange
+	m := New()
+	m.TokenIssued(TokenTypeAccess)
+	c, w := test.SetupContext()
+
+	// Act
+	m.Handler()(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `vera_identity_tokens_issued_total{type="access"} 1`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
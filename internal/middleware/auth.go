@@ -1,50 +1,72 @@
 package middleware
 
 import (
-	"strconv"
+	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
-	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/redact"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthMiddleware gin.HandlerFunc
 
-func NewAuthMiddleware(config *config.Config) AuthMiddleware {
+// UserStatusChecker reports whether a user may still act with a valid token,
+// e.g. because they have been suspended since the token was issued.
+type UserStatusChecker interface {
+	CheckUserStatus(id int) error
+}
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells
+// them apart from the signed access tokens.
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is what a personal access token authenticates: the user
+// it acts for, with the user's current roles, and the scopes it grants.
+type PersonalAccessToken struct {
+	ID        int
+	UserID    int
+	Email     string
+	Roles     []string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// PersonalAccessTokenAuthenticator looks personal access tokens up, as they
+// are kept in the database rather than signed.
+type PersonalAccessTokenAuthenticator interface {
+	AuthenticatePersonalAccessToken(token string) (*PersonalAccessToken, error)
+}
+
+func NewAuthMiddleware(verifier TokenVerifier) AuthMiddleware {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-			c.Error(apperror.New(apperror.CodeInvalidAuthHeader, "invalid authorization header | header: "+authHeader))
+			c.Error(apperror.New(apperror.CodeInvalidAuthHeader, "invalid authorization header").WithInternal("header: " + redact.Fingerprint(authHeader)))
 			c.Abort()
 			return
 		}
 
-		token := authHeader[7:]
-		claims := &jwt.RegisteredClaims{}
-		_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AccessTokenSecret), nil
-		})
+		identity, err := verifier.Verify(authHeader[7:])
 		if err != nil {
-			c.Error(apperror.New(apperror.CodeInvalidAccessToken, "invalid access token | token: "+token))
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		if claims.Issuer != "identity@vera.sninjo.com" {
-			c.Error(apperror.New(apperror.CodeInvalidTokenIssuer, "invalid token issuer | token: "+token))
-			c.Abort()
+		if identity.IsClient() {
+			c.Set("client_id", identity.ClientID)
+			c.Set("token_scopes", identity.Scopes)
+			c.Next()
 			return
 		}
-
-		userID, err := strconv.Atoi(claims.Subject)
-		if err != nil {
-			c.Error(apperror.New(apperror.CodeInvalidAccessToken, "invalid access token | token: "+token))
-			c.Abort()
-			return
+		if identity.IsPersonalAccessToken() {
+			c.Set("token_id", identity.TokenID)
+			c.Set("token_scopes", identity.Scopes)
 		}
-		c.Set("user_id", userID)
+		c.Set("user_id", identity.UserID)
+		c.Set("user_email", identity.Email)
+		c.Set("user_roles", identity.Roles)
 		c.Next()
 	}
 }
@@ -17,7 +17,8 @@ func NewCORSMiddleware(config *config.Config) CORSMiddleware {
 		if config.SiteURL == origin {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token, X-Request-ID")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID")
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -2,56 +2,159 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/requestid"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type HTTPMiddleware gin.HandlerFunc
 
-func logRequest(c *gin.Context, logger *zap.Logger, requestID string) {
+func logRequest(c *gin.Context, logger *zap.Logger, rules *redact.Rules) {
 	method := c.Request.Method
 	path := c.Request.URL.Path
-	query := c.Request.URL.RawQuery
+	query := rules.Query(c.Request.URL.RawQuery)
 
 	cookies := c.Request.Cookies()
 	cookieStrs := make([]string, len(cookies))
 	for i, c := range c.Request.Cookies() {
-		cookieStrs[i] = c.Name + "=***REDACTED***"
+		cookieStrs[i] = c.Name + "=" + redact.Placeholder
 	}
 
 	authHeader := c.GetHeader("Authorization")
 	if authHeader != "" {
-		authHeader = "***REDACTED***"
+		authHeader = redact.Placeholder
 	}
 
-	var bodyBytes []byte
-	if c.Request.Body != nil {
-		bodyBytes, _ = io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	}
 	fields := []zap.Field{
-		zap.String("request_id", requestID),
 		zap.String("method", method),
 		zap.String("path", path),
 		zap.String("query", query),
-		zap.String("body", string(bodyBytes)),
+		zap.String("body", logBody(c, rules)),
 		zap.Strings("cookies", cookieStrs),
 		zap.String("auth_header", authHeader),
 	}
 	logger.Info("request started", fields...)
 }
 
-func NewHTTPMiddleware(logger *zap.Logger) HTTPMiddleware {
+// maxLoggedBody bounds how much of a request body is read to be logged.
+const maxLoggedBody = 64 << 10
+
+type bodyReader struct {
+	io.Reader
+	io.Closer
+}
+
+// logBody returns the redacted body of a JSON or form request. Other bodies,
+// like multipart uploads and imports, are not logged, so they are not read
+// either; neither are JSON and form bodies beyond maxLoggedBody, which are
+// left for the handler to reject.
+func logBody(c *gin.Context, rules *redact.Rules) string {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return ""
+	}
+
+	switch c.ContentType() {
+	case gin.MIMEJSON, gin.MIMEPOSTForm:
+	default:
+		if c.Request.ContentLength < 0 {
+			return "[body omitted]"
+		}
+		return "[" + strconv.FormatInt(c.Request.ContentLength, 10) + " bytes omitted]"
+	}
+
+	body := c.Request.Body
+	buffered, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody+1))
+	// the handler reads what was buffered, then the rest of the body
+	c.Request.Body = bodyReader{io.MultiReader(bytes.NewReader(buffered), body), body}
+	if len(buffered) > maxLoggedBody {
+		return "[more than " + strconv.Itoa(maxLoggedBody) + " bytes omitted]"
+	}
+	return rules.Body(c.ContentType(), buffered)
+}
+
+// writeError renders the error as application/problem+json unless the client
+// only accepts plain JSON, which keeps the legacy {code, message, timestamp}
+// shape for older clients.
+func writeError(c *gin.Context, appErr *apperror.AppError) {
+	if c.NegotiateFormat(apperror.ProblemContentType, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(appErr.Status, appErr.Response)
+		return
+	}
+
+	body, err := json.Marshal(appErr.Problem(c.Request.URL.Path, apperror.MatchLanguage(c.GetHeader("Accept-Language"))))
+	if err != nil {
+		c.JSON(appErr.Status, appErr.Response)
+		return
+	}
+	c.Data(appErr.Status, apperror.ProblemContentType, body)
+}
+
+// RecoverPanic turns a panic in a handler into an internal error that the
+// HTTP middleware renders like any other error.
+func RecoverPanic(c *gin.Context, recovered any) {
+	passAbort(recovered)
+	c.Error(apperror.New(apperror.CodeInternalError, "internal server error"))
+	c.Abort()
+}
+
+// RecoverMiddlewarePanic answers a panic outside the HTTP middleware, which
+// cannot render an error body, with a bare 500 like gin.Recovery.
+func RecoverMiddlewarePanic(c *gin.Context, recovered any) {
+	passAbort(recovered)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// passAbort panics again with http.ErrAbortHandler, which a handler raises
+// when it cannot finish a response it has started, so that net/http drops the
+// connection instead of ending the response as if it were complete.
+func passAbort(recovered any) {
+	if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(err)
+	}
+}
+
+// RouteNotFound reports requests that match no route.
+func RouteNotFound(c *gin.Context) {
+	c.Error(apperror.New(apperror.CodeRouteNotFound, "route not found | method: "+c.Request.Method+" | path: "+c.Request.URL.Path))
+}
+
+// NewHTTPMiddleware logs requests and renders their errors. Every request gets
+// an ID, taken from the X-Request-ID or traceparent header of the caller if
+// present, which is echoed in the X-Request-ID response header, added to
+// errors and to the request-scoped logger, and sent along with outbound calls
+// made with the request context. The logger also carries the trace ID of the
+// span started by TracingMiddleware.
+func NewHTTPMiddleware(config *config.Config, baseLogger *zap.Logger) HTTPMiddleware {
+	rules := redact.NewRules(config)
 	return func(c *gin.Context) {
 		start := time.Now()
-		requestID := uuid.New().String()
-		logRequest(c, logger, requestID)
+		requestID := requestid.Resolve(c.GetHeader(requestid.Header), c.GetHeader(requestid.TraceparentHeader))
+		requestLogger := baseLogger.With(zap.String("request_id", requestID))
+		if span := trace.SpanFromContext(c.Request.Context()); span.SpanContext().IsValid() {
+			span.SetAttributes(attribute.String("request.id", requestID))
+			requestLogger = requestLogger.With(zap.String("trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		c.Set("request_id", requestID)
+		c.Set("logger", requestLogger)
+		ctx := requestid.NewContext(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(logger.NewContext(ctx, requestLogger))
+		c.Header(requestid.Header, requestID)
+		logRequest(c, requestLogger, rules)
 
 		c.Next()
 
@@ -59,15 +162,15 @@ func NewHTTPMiddleware(logger *zap.Logger) HTTPMiddleware {
 		var appErr *apperror.AppError
 		if err != nil {
 			appErr = apperror.FromError(err)
-			c.JSON(appErr.Status, appErr.Response)
+			appErr.RequestID = requestID
+			writeError(c, appErr)
 		}
 
 		f := []zap.Field{
-			zap.String("request_id", requestID),
 			zap.Int("status", c.Writer.Status()),
 			zap.Int64("duration_ms", time.Since(start).Milliseconds()),
 			zap.Error(appErr),
 		}
-		logger.Info("request completed", f...)
+		requestLogger.Info("request completed", f...)
 	}
 }
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMiddleware_LogBody(t *testing.T) {
+	large := `{"name":"` + strings.Repeat("a", maxLoggedBody) + `"}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"json", "application/json", `{"name":"User","password":"secret"}`, `{"name":"User","password":"` + redact.Placeholder + `"}`},
+		{"form", "application/x-www-form-urlencoded; charset=utf-8", "name=User&password=secret", "name=User&password=%2A%2A%2AREDACTED%2A%2A%2A"},
+		{"json too large", "application/json", large, "[more than 65536 bytes omitted]"},
+		{"multipart", "multipart/form-data; boundary=x", "--x\r\n\r\nemail\n--x--\r\n", "[20 bytes omitted]"},
+		{"ndjson", "application/x-ndjson", `{"email":"user@example.com"}`, "[28 bytes omitted]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			rules := redact.NewRules(&config.Config{LogRedactFields: []string{"password"}})
+
+			// Act
+			actual := logBody(c, rules)
+
+			// Assert
+			assert.Equal(t, tt.expected, actual)
+			body, err := io.ReadAll(c.Request.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsMiddleware gin.HandlerFunc
+
+// NewMetricsMiddleware counts requests and their latency by route. Like
+// TracingMiddleware it runs before the HTTP middleware, so the status is the
+// one sent to the client, errors included.
+func NewMetricsMiddleware(m *metrics.Metrics) MetricsMiddleware {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		m.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
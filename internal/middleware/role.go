@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopeAdmin lets a personal access token use the admin role of its user.
+const ScopeAdmin = "admin"
+
+// HasRole reports whether the authenticated caller holds the role. It relies
+// on the roles that AuthMiddleware stores in the context.
+func HasRole(c *gin.Context, role string) bool {
+	return slices.Contains(c.GetStringSlice("user_roles"), role)
+}
+
+// RequireRole aborts the request unless the caller holds at least one of the
+// roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, role := range roles {
+			if HasRole(c, role) {
+				c.Next()
+				return
+			}
+		}
+		c.Error(apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+strings.Join(roles, ", ")))
+		c.Abort()
+	}
+}
+
+// IsClient reports whether the caller is a service client rather than a user.
+func IsClient(c *gin.Context) bool {
+	return c.GetString("client_id") != ""
+}
+
+// AllowPrivileged is Identity.AllowPrivileged for the caller that
+// AuthMiddleware stored in the context.
+func AllowPrivileged(c *gin.Context, id int) bool {
+	identity := &Identity{UserID: c.GetInt("user_id"), Roles: c.GetStringSlice("user_roles"), ClientID: c.GetString("client_id")}
+	return identity.AllowPrivileged(id)
+}
+
+// IsPersonalAccessToken reports whether the caller authenticated with a
+// personal access token rather than the access token of a login.
+func IsPersonalAccessToken(c *gin.Context) bool {
+	_, ok := c.Get("token_id")
+	return ok
+}
+
+// RequireScope aborts requests of service clients and personal access tokens
+// that lack the scope. Logged-in users are not limited by scopes, only by
+// their roles. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsClient(c) && !IsPersonalAccessToken(c) || slices.Contains(c.GetStringSlice("token_scopes"), scope) {
+			c.Next()
+			return
+		}
+		err := apperror.New(apperror.CodeInsufficientScope, "insufficient scope | required scope: "+scope)
+		if IsClient(c) {
+			err = err.WithInternal("client_id: " + c.GetString("client_id"))
+		} else {
+			err = err.WithInternal("token_id: " + strconv.Itoa(c.GetInt("token_id")))
+		}
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// RequireLogin aborts requests that are not made with the access token of a
+// login, i.e. by service clients or with personal access tokens. It must run
+// after AuthMiddleware.
+func RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsClient(c) || IsPersonalAccessToken(c) {
+			c.Error(apperror.New(apperror.CodePermissionDenied, "permission denied | login required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func setupTracedRouter(handler gin.HandlerFunc) (*gin.Engine, *tracetest.SpanRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := gin.New()
+	r.Use(
+		gin.HandlerFunc(NewTracingMiddleware(tracerProvider)),
+		gin.HandlerFunc(NewHTTPMiddleware(&config.Config{}, zap.NewNop())),
+	)
+	r.GET("/users/:id", handler)
+	return r, recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestTracingMiddleware_Request_ServerSpan(t *testing.T) {
+	// Arrange
+	r, recorder := setupTracedRouter(func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("X-Request-ID", "request-1")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.False(t, span.Parent().IsValid())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	attributes := spanAttributes(span)
+	assert.Equal(t, "GET", attributes["http.request.method"].AsString())
+	assert.Equal(t, "/users/:id", attributes["http.route"].AsString())
+	assert.Equal(t, "/users/1", attributes["url.path"].AsString())
+	assert.Equal(t, int64(http.StatusNoContent), attributes["http.response.status_code"].AsInt64())
+	assert.Equal(t, "request-1", attributes["request.id"].AsString())
+}
+
+func TestTracingMiddleware_Traceparent_ContinuesTrace(t *testing.T) {
+	// Arrange
+	r, recorder := setupTracedRouter(func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.True(t, spans[0].Parent().IsRemote())
+}
+
+func TestTracingMiddleware_InternalError_ErrorStatus(t *testing.T) {
+	// Arrange
+	r, recorder := setupTracedRouter(func(c *gin.Context) {
+		c.Error(apperror.New(apperror.CodeInternalError, "internal server error"))
+	})
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+	// Assert
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+	attributes := spanAttributes(spans[0])
+	assert.Equal(t, int64(http.StatusInternalServerError), attributes["http.response.status_code"].AsInt64())
+	assert.Equal(t, apperror.CodeInternalError, attributes["error.type"].AsString())
+}
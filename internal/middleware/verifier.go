@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type accessTokenClaims struct {
+	Email    string   `json:"email,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ClientChecker looks service clients up, as they are kept in the database.
+type ClientChecker interface {
+	// CheckClient reports whether the client still exists, so that the tokens
+	// of a deleted client stop working before they expire.
+	CheckClient(clientID string) error
+	// CheckClientSecret reports whether the secret belongs to the client.
+	CheckClientSecret(clientID, secret string) error
+}
+
+// Identity is the caller a token authenticates: a user or a service client.
+type Identity struct {
+	UserID int
+	Email  string
+	Roles  []string
+	// ClientID is set for service clients, which act as themselves
+	ClientID string
+	// TokenID is set for personal access tokens, which act for their user
+	TokenID int
+	// Scopes limit what a service client or personal access token may do
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// IsClient reports whether the caller is a service client rather than a user.
+func (i *Identity) IsClient() bool {
+	return i.ClientID != ""
+}
+
+// IsPersonalAccessToken reports whether the caller is a user acting through a
+// personal access token rather than the access token of a login.
+func (i *Identity) IsPersonalAccessToken() bool {
+	return i.TokenID != 0
+}
+
+// AllowPrivileged reports whether the caller may manage the user even if they
+// hold roles: admins may, and so may users acting on themselves.
+func (i *Identity) AllowPrivileged(id int) bool {
+	return slices.Contains(i.Roles, role.Admin) || !i.IsClient() && i.UserID == id
+}
+
+// TokenVerifier holds the rules for access tokens and client credentials that
+// the HTTP API, the gRPC API and the Envoy external authorization share.
+type TokenVerifier interface {
+	// Verify returns the caller of an access token or personal access token.
+	// Token problems are returned as app errors; other errors come from
+	// looking the caller up.
+	Verify(token string) (*Identity, error)
+	// VerifyClientCredentials checks the credentials of an introspection
+	// client, configured or registered.
+	VerifyClientCredentials(clientID, secret string) error
+}
+
+type tokenVerifier struct {
+	config        *config.Config
+	checker       UserStatusChecker
+	clients       ClientChecker
+	authenticator PersonalAccessTokenAuthenticator
+}
+
+func NewTokenVerifier(config *config.Config, checker UserStatusChecker, clients ClientChecker, authenticator PersonalAccessTokenAuthenticator) TokenVerifier {
+	return &tokenVerifier{
+		config:        config,
+		checker:       checker,
+		clients:       clients,
+		authenticator: authenticator,
+	}
+}
+
+func (v *tokenVerifier) Verify(token string) (*Identity, error) {
+	if strings.HasPrefix(token, PersonalAccessTokenPrefix) {
+		return v.verifyPersonalAccessToken(token)
+	}
+
+	claims := &accessTokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(v.config.AccessTokenSecret), nil
+	})
+	if err != nil {
+		return nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token").WithInternal("token: " + redact.Fingerprint(token) + " | error: " + err.Error())
+	}
+
+	if claims.Issuer != "identity@vera.sninjo.com" {
+		return nil, apperror.New(apperror.CodeInvalidTokenIssuer, "invalid token issuer").WithInternal("token: " + redact.Fingerprint(token) + " | issuer: " + claims.Issuer)
+	}
+
+	// service clients act as themselves, so there is no user to check
+	if claims.ClientID != "" {
+		if err := v.clients.CheckClient(claims.ClientID); err != nil {
+			return nil, err
+		}
+		return &Identity{ClientID: claims.ClientID, Scopes: strings.Fields(claims.Scope), ExpiresAt: expiresAt(claims)}, nil
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token").WithInternal("token: " + redact.Fingerprint(token))
+	}
+	if v.config.EnforceUserStatus {
+		if err := v.checker.CheckUserStatus(userID); err != nil {
+			return nil, err
+		}
+	}
+	return &Identity{UserID: userID, Email: claims.Email, Roles: claims.Roles, ExpiresAt: expiresAt(claims)}, nil
+}
+
+// verifyPersonalAccessToken looks the token up, which always checks the status
+// of its user.
+func (v *tokenVerifier) verifyPersonalAccessToken(token string) (*Identity, error) {
+	pat, err := v.authenticator.AuthenticatePersonalAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// the admin role of the user only counts if the token was granted it
+	roles := pat.Roles
+	if !slices.Contains(pat.Scopes, ScopeAdmin) {
+		roles = slices.DeleteFunc(slices.Clone(roles), func(r string) bool { return r == role.Admin })
+	}
+	return &Identity{
+		UserID:    pat.UserID,
+		Email:     pat.Email,
+		Roles:     roles,
+		TokenID:   pat.ID,
+		Scopes:    pat.Scopes,
+		ExpiresAt: pat.ExpiresAt,
+	}, nil
+}
+
+func expiresAt(claims *accessTokenClaims) time.Time {
+	if claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
+
+func (v *tokenVerifier) VerifyClientCredentials(clientID, secret string) error {
+	if expected, found := v.config.IntrospectionClients[clientID]; found {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+			return apperror.New(apperror.CodeInvalidClient, "invalid client").WithInternal("client_id: " + clientID)
+		}
+		return nil
+	}
+	return v.clients.CheckClientSecret(clientID, secret)
+}
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserStatusChecker struct {
+	mock.Mock
+}
+
+func (m *MockUserStatusChecker) CheckUserStatus(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockClientChecker struct {
+	mock.Mock
+}
+
+func (m *MockClientChecker) CheckClient(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+func (m *MockClientChecker) CheckClientSecret(clientID, secret string) error {
+	args := m.Called(clientID, secret)
+	return args.Error(0)
+}
+
+type MockPersonalAccessTokenAuthenticator struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenAuthenticator) AuthenticatePersonalAccessToken(token string) (*PersonalAccessToken, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PersonalAccessToken), args.Error(1)
+}
+
+func newVerifierConfig() *config.Config {
+	return &config.Config{
+		AccessTokenSecret:    []byte("mock-access-token-secret"),
+		IntrospectionClients: map[string]string{"mock-gateway": "mock-gateway-secret"},
+	}
+}
+
+func signAccessToken(t *testing.T, claims *accessTokenClaims) string {
+	claims.Issuer = "identity@vera.sninjo.com"
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("mock-access-token-secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func TestTokenVerifier_Verify_User(t *testing.T) {
+	// Arrange
+	cfg := newVerifierConfig()
+	cfg.EnforceUserStatus = true
+	mockChecker := &MockUserStatusChecker{}
+	verifier := NewTokenVerifier(cfg, mockChecker, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{})
+	claims := &accessTokenClaims{Email: "user@example.com", Roles: []string{"admin"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
+	token := signAccessToken(t, claims)
+
+	mockChecker.On("CheckUserStatus", 1).Return(nil)
+
+	// Act
+	identity, err := verifier.Verify(token)
+
+	// Assert
+	require.NoError(t, err)
+	assert.WithinDuration(t, claims.ExpiresAt.Time, identity.ExpiresAt, 0)
+	assert.Equal(t, &Identity{UserID: 1, Email: "user@example.com", Roles: []string{"admin"}, ExpiresAt: identity.ExpiresAt}, identity)
+	assert.False(t, identity.IsClient())
+	mockChecker.AssertExpectations(t)
+}
+
+func TestTokenVerifier_Verify_UserSuspended(t *testing.T) {
+	// Arrange
+	cfg := newVerifierConfig()
+	cfg.EnforceUserStatus = true
+	mockChecker := &MockUserStatusChecker{}
+	verifier := NewTokenVerifier(cfg, mockChecker, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{})
+	token := signAccessToken(t, &accessTokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}})
+
+	mockChecker.On("CheckUserStatus", 1).Return(apperror.New(apperror.CodeUserSuspended, "user suspended | id: 1"))
+
+	// Act
+	identity, err := verifier.Verify(token)
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, identity)
+	assert.Equal(t, apperror.CodeUserSuspended, err.(*apperror.AppError).Code)
+}
+
+func TestTokenVerifier_Verify_Client(t *testing.T) {
+	// Arrange
+	mockChecker := &MockUserStatusChecker{}
+	mockClients := &MockClientChecker{}
+	verifier := NewTokenVerifier(newVerifierConfig(), mockChecker, mockClients, &MockPersonalAccessTokenAuthenticator{})
+	claims := &accessTokenClaims{ClientID: "svc_batch", Scope: "users:read users:write", RegisteredClaims: jwt.RegisteredClaims{Subject: "svc_batch"}}
+	token := signAccessToken(t, claims)
+
+	mockClients.On("CheckClient", "svc_batch").Return(nil)
+
+	// Act
+	identity, err := verifier.Verify(token)
+
+	// Assert
+	require.NoError(t, err)
+	assert.WithinDuration(t, claims.ExpiresAt.Time, identity.ExpiresAt, 0)
+	assert.Equal(t, &Identity{ClientID: "svc_batch", Scopes: []string{"users:read", "users:write"}, ExpiresAt: identity.ExpiresAt}, identity)
+	assert.True(t, identity.IsClient())
+	mockClients.AssertExpectations(t)
+	mockChecker.AssertNotCalled(t, "CheckUserStatus", mock.Anything)
+}
+
+func TestTokenVerifier_Verify_ClientDeleted(t *testing.T) {
+	// Arrange
+	mockClients := &MockClientChecker{}
+	verifier := NewTokenVerifier(newVerifierConfig(), &MockUserStatusChecker{}, mockClients, &MockPersonalAccessTokenAuthenticator{})
+	token := signAccessToken(t, &accessTokenClaims{ClientID: "svc_batch", RegisteredClaims: jwt.RegisteredClaims{Subject: "svc_batch"}})
+
+	mockClients.On("CheckClient", "svc_batch").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+	// Act
+	identity, err := verifier.Verify(token)
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, identity)
+	assert.Equal(t, apperror.CodeInvalidClient, err.(*apperror.AppError).Code)
+}
+
+func TestTokenVerifier_Verify_PersonalAccessToken(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		scopes   []string
+		expected []string
+	}{
+		{"admin role granted", []string{"users:read", ScopeAdmin}, []string{"admin", "ops"}},
+		{"admin role not granted", []string{"users:read"}, []string{"ops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockAuthenticator := &MockPersonalAccessTokenAuthenticator{}
+			verifier := NewTokenVerifier(newVerifierConfig(), &MockUserStatusChecker{}, &MockClientChecker{}, mockAuthenticator)
+
+			pat := &PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Roles: []string{"admin", "ops"}, Scopes: tt.scopes, ExpiresAt: expiresAt}
+			mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(pat, nil)
+
+			// Act
+			identity, err := verifier.Verify("pat_token")
+
+			// Assert
+			require.NoError(t, err)
+			expected := &Identity{UserID: 1, Email: "user@example.com", Roles: tt.expected, TokenID: 7, Scopes: tt.scopes, ExpiresAt: expiresAt}
+			assert.Equal(t, expected, identity)
+			assert.True(t, identity.IsPersonalAccessToken())
+			assert.Equal(t, []string{"admin", "ops"}, pat.Roles)
+			mockAuthenticator.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTokenVerifier_Verify_PersonalAccessTokenInvalid(t *testing.T) {
+	// Arrange
+	mockAuthenticator := &MockPersonalAccessTokenAuthenticator{}
+	verifier := NewTokenVerifier(newVerifierConfig(), &MockUserStatusChecker{}, &MockClientChecker{}, mockAuthenticator)
+
+	mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_revoked").Return(nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token"))
+
+	// Act
+	identity, err := verifier.Verify("pat_revoked")
+
+	// Assert
+	require.Error(t, err)
+	assert.Nil(t, identity)
+	assert.Equal(t, apperror.CodeInvalidAccessToken, err.(*apperror.AppError).Code)
+}
+
+func TestTokenVerifier_Verify_InvalidToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    func(t *testing.T) string
+		expected string
+	}{
+		{"malformed", func(t *testing.T) string { return "invalid" }, apperror.CodeInvalidAccessToken},
+		{"subject not a user id", func(t *testing.T) string {
+			return signAccessToken(t, &accessTokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "svc_batch"}})
+		}, apperror.CodeInvalidAccessToken},
+		{"other issuer", func(t *testing.T) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "1", Issuer: "other"}).SignedString([]byte("mock-access-token-secret"))
+			require.NoError(t, err)
+			return token
+		}, apperror.CodeInvalidTokenIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			verifier := NewTokenVerifier(newVerifierConfig(), &MockUserStatusChecker{}, &MockClientChecker{}, &MockPersonalAccessTokenAuthenticator{})
+
+			// Act
+			identity, err := verifier.Verify(tt.token(t))
+
+			// Assert
+			require.Error(t, err)
+			assert.Nil(t, identity)
+			assert.Equal(t, tt.expected, err.(*apperror.AppError).Code)
+		})
+	}
+}
+
+func TestTokenVerifier_VerifyClientCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientID string
+		secret   string
+		expected string
+	}{
+		{"configured client", "mock-gateway", "mock-gateway-secret", ""},
+		{"configured client with wrong secret", "mock-gateway", "wrong", apperror.CodeInvalidClient},
+		{"configured client with empty secret", "mock-gateway", "", apperror.CodeInvalidClient},
+		{"registered client", "svc_gateway", "svc-secret", ""},
+		{"registered client with wrong secret", "svc_gateway", "wrong", apperror.CodeInvalidClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClients := &MockClientChecker{}
+			verifier := NewTokenVerifier(newVerifierConfig(), &MockUserStatusChecker{}, mockClients, &MockPersonalAccessTokenAuthenticator{})
+
+			mockClients.On("CheckClientSecret", "svc_gateway", "svc-secret").Return(nil)
+			mockClients.On("CheckClientSecret", "svc_gateway", "wrong").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+			// Act
+			err := verifier.VerifyClientCredentials(tt.clientID, tt.secret)
+
+			// Assert
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.expected, err.(*apperror.AppError).Code)
+		})
+	}
+}
+
+func TestIdentity_AllowPrivileged(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *Identity
+		expected bool
+	}{
+		{"admin", &Identity{UserID: 1, Roles: []string{"admin"}}, true},
+		{"self", &Identity{UserID: 2, Roles: []string{"ops"}}, true},
+		{"other user", &Identity{UserID: 1, Roles: []string{"ops"}}, false},
+		{"client", &Identity{ClientID: "svc_batch"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			actual := tt.identity.AllowPrivileged(2)
+
+			// Assert
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
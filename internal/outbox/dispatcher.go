@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+const (
+	dispatchBatchSize = 50
+	dispatchLease     = time.Minute
+	dispatchBackoff   = time.Second
+	maxDispatchDelay  = 5 * time.Minute
+)
+
+// Dispatcher drains the outbox into the configured sinks. A message is
+// removed only after every sink accepted it; when one sink fails the message
+// is retried for all of them, which is why sinks must be idempotent.
+type Dispatcher struct {
+	repo     Repository
+	sinks    Sinks
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+func NewDispatcher(config *config.Config, repo Repository, sinks Sinks, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		sinks:    sinks,
+		logger:   logger,
+		interval: config.OutboxPollInterval,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.interval <= 0 {
+		d.logger.Info("outbox dispatcher disabled")
+		return
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		d.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		count, err := d.Dispatch()
+		if err != nil {
+			d.logger.Error("failed to dispatch outbox", zap.Error(err))
+			return
+		}
+		if count < dispatchBatchSize {
+			return
+		}
+	}
+}
+
+// Dispatch publishes one batch of due messages and returns how many were
+// claimed.
+func (d *Dispatcher) Dispatch() (int, error) {
+	messages, err := d.repo.ClaimDue(time.Now(), dispatchLease, dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		d.publish(&messages[i])
+	}
+	return len(messages), nil
+}
+
+func (d *Dispatcher) publish(message *Message) {
+	event := message.Event()
+	var errs []error
+	for name, sink := range d.sinks {
+		if err := sink.Publish(event); err != nil {
+			errs = append(errs, errors.New(name+": "+err.Error()))
+		}
+	}
+
+	if len(errs) == 0 {
+		if err := d.repo.Delete(message.ID); err != nil {
+			d.logger.Error("failed to remove published outbox message", zap.Int64("message_id", message.ID), zap.Error(err))
+		}
+		return
+	}
+
+	message.Attempts++
+	lastError := errors.Join(errs...).Error()
+	message.LastError = &lastError
+	message.NextAttemptAt = time.Now().Add(retryDelay(message.Attempts))
+	d.logger.Warn(
+		"failed to publish outbox message",
+		zap.Int64("message_id", message.ID),
+		zap.String("type", message.Type),
+		zap.Int("attempts", message.Attempts),
+		zap.String("error", lastError),
+	)
+	if err := d.repo.Update(message); err != nil {
+		d.logger.Error("failed to update outbox message", zap.Int64("message_id", message.ID), zap.Error(err))
+	}
+}
+
+// retryDelay doubles the backoff after every failed attempt. Messages are
+// never given up on; a sink that stays down shows up in the logs instead.
+func retryDelay(attempts int) time.Duration {
+	delay := dispatchBackoff
+	for i := 1; i < attempts && delay < maxDispatchDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDispatchDelay)
+}
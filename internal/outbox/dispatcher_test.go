@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	args := m.Called(now, lease, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Message), args.Error(1)
+}
+
+func (m *MockRepository) Update(message *Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockSink struct {
+	mock.Mock
+}
+
+func (m *MockSink) Publish(event Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func newTestMessage(id int64) Message {
+	return Message{
+		ID:             id,
+		IdempotencyKey: "0b6f3c1e-8d5a-4b8e-9f3a-2d1c0e9b8a7f",
+		Type:           EventUserCreated,
+		Data:           json.RawMessage(`{"id":7}`),
+		NextAttemptAt:  time.Now(),
+		CreatedAt:      time.Now(),
+	}
+}
+
+func TestDispatcher_NewDispatcher_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	sinks := Sinks{SinkWebhook: &MockSink{}}
+	config := &config.Config{OutboxPollInterval: time.Second}
+	logger := zap.NewNop()
+
+	// Act
+	dispatcher := NewDispatcher(config, mockRepo, sinks, logger)
+
+	// Assert
+	assert.Equal(t, mockRepo, dispatcher.repo)
+	assert.Equal(t, sinks, dispatcher.sinks)
+	assert.Equal(t, logger, dispatcher.logger)
+	assert.Equal(t, time.Second, dispatcher.interval)
+}
+
+func TestDispatcher_Dispatch_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	webhookSink := &MockSink{}
+	stdoutSink := &MockSink{}
+	dispatcher := NewDispatcher(&config.Config{}, mockRepo, Sinks{SinkWebhook: webhookSink, SinkStdout: stdoutSink}, zap.NewNop())
+
+	message := newTestMessage(1)
+	mockRepo.On("ClaimDue", mock.Anything, dispatchLease, dispatchBatchSize).Return([]Message{message}, nil)
+	webhookSink.On("Publish", message.Event()).Return(nil)
+	stdoutSink.On("Publish", message.Event()).Return(nil)
+	mockRepo.On("Delete", int64(1)).Return(nil)
+
+	// Act
+	count, err := dispatcher.Dispatch()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockRepo.AssertExpectations(t)
+	webhookSink.AssertExpectations(t)
+	stdoutSink.AssertExpectations(t)
+}
+func TestDispatcher_Dispatch_SinkError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	webhookSink := &MockSink{}
+	stdoutSink := &MockSink{}
+	dispatcher := NewDispatcher(&config.Config{}, mockRepo, Sinks{SinkWebhook: webhookSink, SinkStdout: stdoutSink}, zap.NewNop())
+
+	message := newTestMessage(1)
+	message.Attempts = 2
+	var updated *Message
+	mockRepo.On("ClaimDue", mock.Anything, dispatchLease, dispatchBatchSize).Return([]Message{message}, nil)
+	webhookSink.On("Publish", message.Event()).Return(assert.AnError)
+	stdoutSink.On("Publish", message.Event()).Return(nil)
+	mockRepo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(0).(*Message)
+	}).Return(nil)
+
+	// Act
+	count, err := dispatcher.Dispatch()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.NotNil(t, updated)
+	assert.Equal(t, 3, updated.Attempts)
+	assert.Equal(t, "webhook: "+assert.AnError.Error(), *updated.LastError)
+	assert.WithinDuration(t, time.Now().Add(4*time.Second), updated.NextAttemptAt, time.Second)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+func TestDispatcher_Dispatch_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	dispatcher := NewDispatcher(&config.Config{}, mockRepo, Sinks{}, zap.NewNop())
+
+	mockRepo.On("ClaimDue", mock.Anything, dispatchLease, dispatchBatchSize).Return(nil, assert.AnError)
+
+	// Act
+	count, err := dispatcher.Dispatch()
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, 0, count)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDispatcher_Run_Disabled(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	dispatcher := NewDispatcher(&config.Config{}, mockRepo, Sinks{}, zap.NewNop())
+
+	// Act
+	dispatcher.Run(context.Background())
+
+	// Assert
+	mockRepo.AssertNotCalled(t, "ClaimDue", mock.Anything, mock.Anything, mock.Anything)
+}
+func TestDispatcher_Run_DrainsFullBatches(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	sink := &MockSink{}
+	dispatcher := NewDispatcher(&config.Config{OutboxPollInterval: time.Hour}, mockRepo, Sinks{SinkWebhook: sink}, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	batch := make([]Message, dispatchBatchSize)
+	for i := range batch {
+		batch[i] = newTestMessage(int64(i + 1))
+	}
+	mockRepo.On("ClaimDue", mock.Anything, dispatchLease, dispatchBatchSize).Return(batch, nil).Once()
+	mockRepo.On("ClaimDue", mock.Anything, dispatchLease, dispatchBatchSize).Return([]Message{}, nil).Once().Run(func(args mock.Arguments) {
+		cancel()
+	})
+	sink.On("Publish", mock.Anything).Return(nil)
+	mockRepo.On("Delete", mock.Anything).Return(nil)
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("outbox dispatcher did not stop after the context was canceled")
+	}
+	mockRepo.AssertNumberOfCalls(t, "ClaimDue", 2)
+	mockRepo.AssertNumberOfCalls(t, "Delete", dispatchBatchSize)
+}
+
+func TestDispatcher_retryDelay_Capped(t *testing.T) {
+	// Act & Assert
+	assert.Equal(t, time.Second, retryDelay(1))
+	assert.Equal(t, 8*time.Second, retryDelay(4))
+	assert.Equal(t, maxDispatchDelay, retryDelay(100))
+}
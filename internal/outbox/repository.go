@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	EventUserCreated     = "user.created"
+	EventUserDeleted     = "user.deleted"
+	EventUserSuspended   = "user.suspended"
+	EventUserReactivated = "user.reactivated"
+	EventUserLogin       = "user.login"
+)
+
+// EventTypes lists every event written to the outbox.
+var EventTypes = []string{
+	EventUserCreated,
+	EventUserDeleted,
+	EventUserSuspended,
+	EventUserReactivated,
+	EventUserLogin,
+}
+
+type Message struct {
+	ID             int64           `gorm:"primaryKey;autoIncrement"`
+	IdempotencyKey string          `gorm:"type:uuid;not null;uniqueIndex"`
+	Type           string          `gorm:"type:varchar(64);not null"`
+	Data           json.RawMessage `gorm:"type:jsonb;not null"`
+	Attempts       int             `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time       `gorm:"type:timestamptz;not null;index"`
+	LastError      *string         `gorm:"type:text"`
+	CreatedAt      time.Time       `gorm:"type:timestamptz;not null"`
+}
+
+func (Message) TableName() string {
+	return "outbox"
+}
+
+// Event is the form in which a message is handed to sinks. Its ID is the
+// idempotency key of the message, so it stays the same when a message is
+// published more than once.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func (m *Message) Event() Event {
+	return Event{ID: m.IdempotencyKey, Type: m.Type, CreatedAt: m.CreatedAt.UTC(), Data: m.Data}
+}
+
+// Writer appends events to the outbox. It must be bound to the transaction
+// that makes the change the events describe, so that both are committed or
+// rolled back together.
+type Writer interface {
+	Write(eventType string, data any) error
+}
+
+type writer struct {
+	db *gorm.DB
+}
+
+func NewWriter(tx *gorm.DB) Writer {
+	return &writer{db: tx}
+}
+
+func (w *writer) Write(eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message := &Message{
+		IdempotencyKey: uuid.New().String(),
+		Type:           eventType,
+		Data:           payload,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	return w.db.Create(message).Error
+}
+
+type Repository interface {
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]Message, error)
+	Update(message *Message) error
+	Delete(id int64) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ClaimDue locks the oldest due messages and pushes their next attempt back
+// by the lease, so that other instances skip them while this one publishes.
+// Messages of a dispatcher that dies mid-batch become due again once the
+// lease runs out.
+func (r *repository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	var messages []Message
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_attempt_at <= ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&Message{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *repository) Update(message *Message) error {
+	return r.db.Save(message).Error
+}
+
+func (r *repository) Delete(id int64) error {
+	return r.db.Delete(&Message{}, id).Error
+}
@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Message{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_Writer_Write(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	err = NewWriter(d).Write(EventUserCreated, map[string]any{"id": 7})
+
+	// Assert
+	require.NoError(t, err)
+	var message Message
+	require.NoError(t, d.First(&message).Error)
+	assert.Equal(t, EventUserCreated, message.Type)
+	assert.JSONEq(t, `{"id":7}`, string(message.Data))
+	assert.NotEmpty(t, message.IdempotencyKey)
+	assert.Equal(t, 0, message.Attempts)
+}
+func TestRepository_Writer_RolledBack(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	err = d.Transaction(func(tx *gorm.DB) error {
+		if err := NewWriter(tx).Write(EventUserDeleted, nil); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	var count int64
+	require.NoError(t, d.Model(&Message{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestRepository_ClaimDue_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	now := time.Now()
+	writer := NewWriter(d)
+	require.NoError(t, writer.Write(EventUserCreated, nil))
+	require.NoError(t, writer.Write(EventUserLogin, nil))
+	require.NoError(t, d.Create(&Message{
+		IdempotencyKey: "0b6f3c1e-8d5a-4b8e-9f3a-2d1c0e9b8a7f",
+		Type:           EventUserDeleted,
+		Data:           json.RawMessage(`null`),
+		NextAttemptAt:  now.Add(time.Hour),
+	}).Error)
+
+	// Act
+	messages, err := repo.ClaimDue(now.Add(time.Second), time.Minute, 10)
+	require.NoError(t, err)
+	again, err := repo.ClaimDue(now.Add(time.Second), time.Minute, 10)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, messages, 2)
+	assert.Equal(t, EventUserCreated, messages[0].Type)
+	assert.Equal(t, EventUserLogin, messages[1].Type)
+	assert.Empty(t, again)
+}
+
+func TestRepository_Delete_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	require.NoError(t, NewWriter(d).Write(EventUserCreated, nil))
+
+	// Act
+	err = repo.Delete(1)
+
+	// Assert
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, d.Model(&Message{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+)
+
+const (
+	SinkWebhook = "webhook"
+	SinkStdout  = "stdout"
+)
+
+// Sink receives the events drained from the outbox. Events are delivered at
+// least once, so a sink must tolerate seeing the same event ID again.
+type Sink interface {
+	Publish(event Event) error
+}
+
+// WebhookSink is the sink that fans events out to webhook subscriptions.
+type WebhookSink interface {
+	Sink
+}
+
+// Sinks maps the configured sink names to their implementations.
+type Sinks map[string]Sink
+
+func NewSinks(config *config.Config, webhooks WebhookSink) (Sinks, error) {
+	sinks := make(Sinks, len(config.OutboxSinks))
+	for _, name := range config.OutboxSinks {
+		switch name {
+		case SinkWebhook:
+			sinks[name] = webhooks
+		case SinkStdout:
+			sinks[name] = NewWriterSink(os.Stdout)
+		default:
+			return nil, errors.New("unknown outbox sink | sink: " + name)
+		}
+	}
+	return sinks, nil
+}
+
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink writes every event as one line of JSON.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Publish(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Publisher is the publishing half of a NATS client; *nats.Conn satisfies it
+// as do most brokers with a subject-based API.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+type natsSink struct {
+	publisher     Publisher
+	subjectPrefix string
+}
+
+// NewNATSSink publishes every event as JSON to the subject made of the prefix
+// and the event type, e.g. "vera.identity.user.created". Subscribers should
+// deduplicate on the event ID.
+func NewNATSSink(publisher Publisher, subjectPrefix string) Sink {
+	return &natsSink{publisher: publisher, subjectPrefix: subjectPrefix}
+}
+
+func (s *natsSink) Publish(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(s.subjectPrefix+event.Type, data)
+}
@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(subject string, data []byte) error {
+	args := m.Called(subject, data)
+	return args.Error(0)
+}
+
+func newTestEvent() Event {
+	return Event{
+		ID:        "0b6f3c1e-8d5a-4b8e-9f3a-2d1c0e9b8a7f",
+		Type:      EventUserCreated,
+		CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:      json.RawMessage(`{"id":7}`),
+	}
+}
+
+func TestSink_NewSinks_Success(t *testing.T) {
+	// Arrange
+	webhookSink := &MockSink{}
+	config := &config.Config{OutboxSinks: []string{SinkWebhook, SinkStdout}}
+
+	// Act
+	sinks, err := NewSinks(config, webhookSink)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	assert.Equal(t, webhookSink, sinks[SinkWebhook])
+	assert.IsType(t, &writerSink{}, sinks[SinkStdout])
+}
+func TestSink_NewSinks_UnknownSink(t *testing.T) {
+	// Arrange
+	config := &config.Config{OutboxSinks: []string{SinkWebhook, "kafka"}}
+
+	// Act
+	sinks, err := NewSinks(config, &MockSink{})
+
+	// Assert
+	assert.EqualError(t, err, "unknown outbox sink | sink: kafka")
+	assert.Nil(t, sinks)
+}
+
+func TestSink_WriterSink_Publish(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	// Act
+	err := sink.Publish(newTestEvent())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"id":"0b6f3c1e-8d5a-4b8e-9f3a-2d1c0e9b8a7f","type":"user.created","created_at":"2025-01-02T03:04:05Z","data":{"id":7}}`+"\n",
+		buf.String(),
+	)
+}
+
+func TestSink_NATSSink_Publish(t *testing.T) {
+	// Arrange
+	mockPublisher := &MockPublisher{}
+	sink := NewNATSSink(mockPublisher, "vera.identity.")
+	event := newTestEvent()
+	data, _ := json.Marshal(event)
+
+	mockPublisher.On("Publish", "vera.identity.user.created", data).Return(nil)
+
+	// Act
+	err := sink.Publish(event)
+
+	// Assert
+	require.NoError(t, err)
+	mockPublisher.AssertExpectations(t)
+}
+func TestSink_NATSSink_PublishError(t *testing.T) {
+	// Arrange
+	mockPublisher := &MockPublisher{}
+	sink := NewNATSSink(mockPublisher, "vera.identity.")
+
+	mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	// Act
+	err := sink.Publish(newTestEvent())
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+}
@@ -0,0 +1,60 @@
+package pat
+
+import "time"
+
+type RequestURI struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type TokenBody struct {
+	Name      string    `json:"name" binding:"required,max=255"`
+	Scopes    []string  `json:"scopes" binding:"required,dive,required,max=64"`
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+func (b *TokenBody) toToken(userID int) *Token {
+	return &Token{
+		UserID:    userID,
+		Name:      b.Name,
+		Scopes:    b.Scopes,
+		ExpiresAt: b.ExpiresAt,
+	}
+}
+
+type TokenResponse struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+func newTokenResponse(t *Token) *TokenResponse {
+	var lastUsedAt *string
+	if t.LastUsedAt != nil {
+		s := t.LastUsedAt.Format(time.RFC3339)
+		lastUsedAt = &s
+	}
+	return &TokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt.Format(time.RFC3339),
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  t.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// CreatedTokenResponse is the only response that carries the token.
+type CreatedTokenResponse struct {
+	TokenResponse
+	Token string `json:"token"`
+}
+
+func newCreatedTokenResponse(t *Token, value string) *CreatedTokenResponse {
+	return &CreatedTokenResponse{
+		TokenResponse: *newTokenResponse(t),
+		Token:         value,
+	}
+}
@@ -0,0 +1,74 @@
+package pat
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetTokens(c *gin.Context) {
+	tokens, err := h.service.GetTokens(c.GetInt("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokenResponses := make([]TokenResponse, len(tokens))
+	for i, token := range tokens {
+		tokenResponses[i] = *newTokenResponse(&token)
+	}
+
+	c.JSON(http.StatusOK, tokenResponses)
+}
+
+func (h *Handler) CreateToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	audit.SetTarget(c, userID)
+	var body TokenBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+	audit.SetMetadata(c, "name", body.Name)
+	audit.SetMetadata(c, "scopes", body.Scopes)
+
+	token := body.toToken(userID)
+	value, err := h.service.CreateToken(token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	audit.SetMetadata(c, "token_id", token.ID)
+
+	c.JSON(http.StatusCreated, newCreatedTokenResponse(token, value))
+}
+
+func (h *Handler) RevokeToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	// the target is the user, not the token named by the path
+	audit.SetTarget(c, userID)
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	audit.SetMetadata(c, "token_id", uri.ID)
+
+	if err := h.service.RevokeToken(userID, uri.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
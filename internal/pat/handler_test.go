@@ -0,0 +1,184 @@
+package pat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetTokens(userID int) ([]Token, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Token), args.Error(1)
+}
+func (m *MockService) CreateToken(token *Token) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
+func (m *MockService) RevokeToken(userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+func (m *MockService) AuthenticatePersonalAccessToken(token string) (*middleware.PersonalAccessToken, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*middleware.PersonalAccessToken), args.Error(1)
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+
+	// Act
+	handler := NewHandler(mockService)
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+}
+
+func TestHandler_GetTokens_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+	c.Set("user_id", 1)
+
+	tokens := []Token{
+		{ID: 7, UserID: 1, Name: "CLI", TokenHash: "token-hash", Scopes: []string{"users:read"}, ExpiresAt: time.Unix(3, 0), CreatedAt: time.Unix(1, 0), UpdatedAt: time.Unix(1, 0)},
+	}
+
+	mockService.On("GetTokens", 1).Return(tokens, nil)
+
+	// Act
+	handler.GetTokens(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "token-hash")
+
+	var actual []TokenResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []TokenResponse{
+		{
+			ID:        7,
+			Name:      "CLI",
+			Scopes:    []string{"users:read"},
+			ExpiresAt: time.Unix(3, 0).Format(time.RFC3339),
+			CreatedAt: time.Unix(1, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateToken_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+	c.Set("user_id", 1)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"name":"CLI","scopes":["users:read"],"expires_at":"2030-01-01T00:00:00Z"}`))
+
+	mockService.On("CreateToken", &Token{UserID: 1, Name: "CLI", Scopes: []string{"users:read"}, ExpiresAt: expiresAt}).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*Token).ID = 7
+		}).
+		Return("pat_generated", nil)
+
+	// Act
+	handler.CreateToken(c)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var actual CreatedTokenResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, 7, actual.ID)
+	assert.Equal(t, "pat_generated", actual.Token)
+	assert.Equal(t, "2030-01-01T00:00:00Z", actual.ExpiresAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateToken_InvalidBody(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, _ := test.SetupContext()
+	c.Set("user_id", 1)
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"name":"CLI","scopes":["users:read"]}`))
+
+	// Act
+	handler.CreateToken(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeValidationFailed, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockService.AssertNotCalled(t, "CreateToken", mock.Anything)
+}
+
+func TestHandler_RevokeToken_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+	c.Set("user_id", 1)
+
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+
+	mockService.On("RevokeToken", 1, 7).Return(nil)
+
+	// Act
+	handler.RevokeToken(c)
+
+	// Assert
+	c.Writer.WriteHeaderNow()
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RevokeToken_NotFound(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, _ := test.SetupContext()
+	c.Set("user_id", 1)
+
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	appErr := apperror.New(apperror.CodeTokenNotFound, "token not found | id: 7")
+
+	mockService.On("RevokeToken", 1, 7).Return(appErr)
+
+	// Act
+	handler.RevokeToken(c)
+
+	// Assert
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, appErr, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
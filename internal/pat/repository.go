@@ -0,0 +1,90 @@
+package pat
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Token is a personal access token, with which a user's scripts act as the
+// user, limited to the token's scopes, without a browser login.
+type Token struct {
+	ID     int    `gorm:"primaryKey;autoIncrement"`
+	UserID int    `gorm:"not null;index:idx_personal_access_tokens_user_id"`
+	Name   string `gorm:"type:varchar(255);not null"`
+	// TokenHash is the hex SHA-256 of the token; the token itself is only
+	// known to the user.
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_personal_access_tokens_token_hash"`
+	Scopes     []string   `gorm:"type:jsonb;serializer:json;not null"`
+	ExpiresAt  time.Time  `gorm:"type:timestamptz;not null"`
+	LastUsedAt *time.Time `gorm:"type:timestamptz"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;not null"`
+	UpdatedAt  time.Time  `gorm:"type:timestamptz;not null"`
+}
+
+func (Token) TableName() string {
+	return "personal_access_tokens"
+}
+
+type Repository interface {
+	GetTokensByUserID(userID int) ([]Token, error)
+	GetTokenByID(id int) (*Token, error)
+	GetTokenByHash(tokenHash string) (*Token, error)
+	CreateToken(token *Token) error
+	DeleteToken(id int) error
+	// UpdateLastUsedAt records when the token was last used without touching
+	// the rest of the row.
+	UpdateLastUsedAt(id int, lastUsedAt time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetTokensByUserID(userID int) ([]Token, error) {
+	var tokens []Token
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *repository) GetTokenByID(id int) (*Token, error) {
+	var token Token
+	err := r.db.Where("id = ?", id).Take(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *repository) GetTokenByHash(tokenHash string) (*Token, error) {
+	var token Token
+	err := r.db.Where("token_hash = ?", tokenHash).Take(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *repository) CreateToken(token *Token) error {
+	return r.db.Create(token).Error
+}
+
+func (r *repository) DeleteToken(id int) error {
+	return r.db.Where("id = ?", id).Delete(&Token{}).Error
+}
+
+func (r *repository) UpdateLastUsedAt(id int, lastUsedAt time.Time) error {
+	return r.db.Model(&Token{}).Where("id = ?", id).UpdateColumn("last_used_at", lastUsedAt).Error
+}
@@ -0,0 +1,168 @@
+package pat
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Token{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func createToken(t *testing.T, repo Repository, userID int, tokenHash string) *Token {
+	token := &Token{UserID: userID, Name: "CLI", TokenHash: tokenHash, Scopes: []string{"users:read"}, ExpiresAt: time.Now().Add(time.Hour)}
+	err := repo.CreateToken(token)
+	require.NoError(t, err)
+	return token
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_CreateToken_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	token := createToken(t, repo, 1, "hash")
+
+	// Assert
+	assert.NotZero(t, token.ID)
+	actual, err := repo.GetTokenByHash("hash")
+	require.NoError(t, err)
+	require.NotNil(t, actual)
+	assert.Equal(t, token.ID, actual.ID)
+	assert.Equal(t, 1, actual.UserID)
+	assert.Equal(t, []string{"users:read"}, actual.Scopes)
+	assert.Nil(t, actual.LastUsedAt)
+}
+
+func TestRepository_CreateToken_DuplicateHash(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	createToken(t, repo, 1, "hash")
+
+	// Act
+	err = repo.CreateToken(&Token{UserID: 2, Name: "Other", TokenHash: "hash", Scopes: []string{}, ExpiresAt: time.Now().Add(time.Hour)})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestRepository_GetTokenByHash_NotFound(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	token, err := repo.GetTokenByHash("missing")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, token)
+}
+
+func TestRepository_GetTokensByUserID_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	createToken(t, repo, 1, "hash-a")
+	createToken(t, repo, 2, "hash-b")
+	createToken(t, repo, 1, "hash-c")
+
+	// Act
+	tokens, err := repo.GetTokensByUserID(1)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "hash-a", tokens[0].TokenHash)
+	assert.Equal(t, "hash-c", tokens[1].TokenHash)
+}
+
+func TestRepository_UpdateLastUsedAt_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	token := createToken(t, repo, 1, "hash")
+	lastUsedAt := time.Now().Truncate(time.Microsecond)
+
+	// Act
+	err = repo.UpdateLastUsedAt(token.ID, lastUsedAt)
+
+	// Assert
+	require.NoError(t, err)
+	actual, err := repo.GetTokenByID(token.ID)
+	require.NoError(t, err)
+	require.NotNil(t, actual.LastUsedAt)
+	assert.True(t, lastUsedAt.Equal(*actual.LastUsedAt))
+	assert.WithinDuration(t, token.UpdatedAt, actual.UpdatedAt, time.Millisecond)
+}
+
+func TestRepository_DeleteToken_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+	token := createToken(t, repo, 1, "hash")
+
+	// Act
+	err = repo.DeleteToken(token.ID)
+
+	// Assert
+	require.NoError(t, err)
+	actual, err := repo.GetTokenByID(token.ID)
+	require.NoError(t, err)
+	assert.Nil(t, actual)
+}
@@ -0,0 +1,20 @@
+package pat
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers the token routes of the caller. They need a login,
+// so that a leaked token cannot be used to create more.
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware, auditMiddleware audit.Middleware) {
+	g := r.Group("/me/tokens")
+	g.Use(gin.HandlerFunc(authMiddleware), middleware.RequireLogin())
+	{
+		g.GET("", handler.GetTokens)
+		g.POST("", auditMiddleware(audit.EventTokenCreate), handler.CreateToken)
+		g.DELETE("/:id", auditMiddleware(audit.EventTokenRevoke), handler.RevokeToken)
+	}
+}
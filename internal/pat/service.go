@@ -0,0 +1,144 @@
+package pat
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/user"
+)
+
+// lastUsedResolution is how stale the last use of a token may get, so that
+// scripts do not cost a write on every request.
+const lastUsedResolution = time.Minute
+
+// Scopes are those a personal access token may be granted.
+var Scopes = []string{user.ScopeUsersRead, user.ScopeUsersWrite, middleware.ScopeAdmin}
+
+// UserGetter looks up the user a token acts for.
+type UserGetter interface {
+	GetUserByID(id int) (*user.User, error)
+}
+
+type Service interface {
+	GetTokens(userID int) ([]Token, error)
+	// CreateToken generates a new token for token.UserID and returns it. Only
+	// its hash is stored, so it cannot be shown again.
+	CreateToken(token *Token) (string, error)
+	RevokeToken(userID, id int) error
+	// AuthenticatePersonalAccessToken returns the user the token acts for and
+	// records its use. Tokens that are unknown, expired or whose user may not
+	// log in are refused.
+	AuthenticatePersonalAccessToken(token string) (*middleware.PersonalAccessToken, error)
+}
+
+type service struct {
+	config *config.Config
+	repo   Repository
+	users  UserGetter
+	now    func() time.Time
+}
+
+func NewService(config *config.Config, repo Repository, users UserGetter) Service {
+	return &service{config: config, repo: repo, users: users, now: time.Now}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a token and its hash. The tokens are random enough that a
+// plain hash protects them as well as a slow one would.
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := middleware.PersonalAccessTokenPrefix + hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func (s *service) GetTokens(userID int) ([]Token, error) {
+	return s.repo.GetTokensByUserID(userID)
+}
+
+func (s *service) CreateToken(token *Token) (string, error) {
+	for _, scope := range token.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return "", apperror.New(apperror.CodeInvalidScope, "invalid scope | scope: "+scope)
+		}
+	}
+	now := s.now()
+	if !token.ExpiresAt.After(now) || token.ExpiresAt.After(now.Add(s.config.PersonalAccessTokenMaxTTL)) {
+		return "", apperror.New(apperror.CodeInvalidTokenExpiry, "invalid token expiry | expires_at: "+token.ExpiresAt.Format(time.RFC3339)+" | max_ttl: "+s.config.PersonalAccessTokenMaxTTL.String())
+	}
+
+	value, tokenHash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	token.TokenHash = tokenHash
+	if err := s.repo.CreateToken(token); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// RevokeToken deletes a token of the user. Tokens of other users are reported
+// as not found, so that their IDs are not revealed.
+func (s *service) RevokeToken(userID, id int) error {
+	token, err := s.repo.GetTokenByID(id)
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserID != userID {
+		return apperror.New(apperror.CodeTokenNotFound, "token not found | id: "+strconv.Itoa(id))
+	}
+	return s.repo.DeleteToken(id)
+}
+
+func (s *service) AuthenticatePersonalAccessToken(value string) (*middleware.PersonalAccessToken, error) {
+	token, err := s.repo.GetTokenByHash(hashToken(value))
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if token == nil || !now.Before(token.ExpiresAt) {
+		return nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token").WithInternal("token: " + redact.Fingerprint(value))
+	}
+
+	// the user is looked up anyway for their current roles, so their status
+	// is always checked, as a token may be used for a year
+	u, err := s.users.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, apperror.New(apperror.CodeUserNotAuthorized, "user not authorized | id: "+strconv.Itoa(token.UserID))
+	}
+	if err := u.CheckStatus(); err != nil {
+		return nil, err
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.UpdateLastUsedAt(token.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	return &middleware.PersonalAccessToken{
+		ID:        token.ID,
+		UserID:    u.ID,
+		Email:     u.Email,
+		Roles:     u.Roles,
+		Scopes:    token.Scopes,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
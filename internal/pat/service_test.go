@@ -0,0 +1,264 @@
+package pat
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetTokensByUserID(userID int) ([]Token, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Token), args.Error(1)
+}
+func (m *MockRepository) GetTokenByID(id int) (*Token, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Token), args.Error(1)
+}
+func (m *MockRepository) GetTokenByHash(tokenHash string) (*Token, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Token), args.Error(1)
+}
+func (m *MockRepository) CreateToken(token *Token) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+func (m *MockRepository) DeleteToken(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockRepository) UpdateLastUsedAt(id int, lastUsedAt time.Time) error {
+	args := m.Called(id, lastUsedAt)
+	return args.Error(0)
+}
+
+type MockUserGetter struct {
+	mock.Mock
+}
+
+func (m *MockUserGetter) GetUserByID(id int) (*user.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+var now = time.Unix(1700000000, 0)
+
+func newTestService() (*service, *MockRepository, *MockUserGetter) {
+	mockRepo := &MockRepository{}
+	mockUsers := &MockUserGetter{}
+	s := NewService(&config.Config{PersonalAccessTokenMaxTTL: 24 * time.Hour}, mockRepo, mockUsers).(*service)
+	s.now = func() time.Time { return now }
+	return s, mockRepo, mockUsers
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockUsers := &MockUserGetter{}
+
+	// Act
+	s := NewService(&config.Config{}, mockRepo, mockUsers)
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+	assert.Equal(t, mockUsers, s.(*service).users)
+}
+
+func TestService_CreateToken_GeneratesToken(t *testing.T) {
+	// Arrange
+	service, mockRepo, _ := newTestService()
+
+	token := &Token{UserID: 1, Name: "CLI", Scopes: []string{"users:read"}, ExpiresAt: now.Add(time.Hour)}
+
+	mockRepo.On("CreateToken", token).Return(nil)
+
+	// Act
+	value, err := service.CreateToken(token)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, middleware.PersonalAccessTokenPrefix))
+	assert.Len(t, value, len(middleware.PersonalAccessTokenPrefix)+64)
+	assert.Equal(t, hashToken(value), token.TokenHash)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CreateToken_InvalidScope(t *testing.T) {
+	// Arrange
+	service, mockRepo, _ := newTestService()
+
+	// Act
+	_, err := service.CreateToken(&Token{UserID: 1, Name: "CLI", Scopes: []string{"reports:read"}, ExpiresAt: now.Add(time.Hour)})
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeInvalidScope, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "CreateToken", mock.Anything)
+}
+
+func TestService_CreateToken_InvalidExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+	}{
+		{"in the past", now.Add(-time.Minute)},
+		{"now", now},
+		{"beyond max TTL", now.Add(25 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service, mockRepo, _ := newTestService()
+
+			// Act
+			_, err := service.CreateToken(&Token{UserID: 1, Name: "CLI", Scopes: []string{}, ExpiresAt: tt.expiresAt})
+
+			// Assert
+			require.Error(t, err)
+			assert.Equal(t, apperror.CodeInvalidTokenExpiry, err.(*apperror.AppError).Code)
+			mockRepo.AssertNotCalled(t, "CreateToken", mock.Anything)
+		})
+	}
+}
+
+func TestService_RevokeToken_Success(t *testing.T) {
+	// Arrange
+	service, mockRepo, _ := newTestService()
+
+	mockRepo.On("GetTokenByID", 7).Return(&Token{ID: 7, UserID: 1}, nil)
+	mockRepo.On("DeleteToken", 7).Return(nil)
+
+	// Act
+	err := service.RevokeToken(1, 7)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RevokeToken_OtherUser(t *testing.T) {
+	// Arrange
+	service, mockRepo, _ := newTestService()
+
+	mockRepo.On("GetTokenByID", 7).Return(&Token{ID: 7, UserID: 2}, nil)
+
+	// Act
+	err := service.RevokeToken(1, 7)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeTokenNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "DeleteToken", mock.Anything)
+}
+
+func TestService_AuthenticatePersonalAccessToken_Success(t *testing.T) {
+	// Arrange
+	service, mockRepo, mockUsers := newTestService()
+
+	value := middleware.PersonalAccessTokenPrefix + "token"
+	token := &Token{ID: 7, UserID: 1, TokenHash: hashToken(value), Scopes: []string{"users:read"}, ExpiresAt: now.Add(time.Hour)}
+
+	mockRepo.On("GetTokenByHash", hashToken(value)).Return(token, nil)
+	mockUsers.On("GetUserByID", 1).Return(&user.User{ID: 1, Email: "user@example.com", Roles: []string{"admin"}, Status: user.StatusActive}, nil)
+	mockRepo.On("UpdateLastUsedAt", 7, now).Return(nil)
+
+	// Act
+	pat, err := service.AuthenticatePersonalAccessToken(value)
+
+	// Assert
+	require.NoError(t, err)
+	expected := &middleware.PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Roles: []string{"admin"}, Scopes: []string{"users:read"}, ExpiresAt: now.Add(time.Hour)}
+	assert.Equal(t, expected, pat)
+	mockRepo.AssertExpectations(t)
+	mockUsers.AssertExpectations(t)
+}
+
+func TestService_AuthenticatePersonalAccessToken_RecentlyUsed(t *testing.T) {
+	// Arrange
+	service, mockRepo, mockUsers := newTestService()
+
+	value := middleware.PersonalAccessTokenPrefix + "token"
+	lastUsedAt := now.Add(-30 * time.Second)
+	token := &Token{ID: 7, UserID: 1, ExpiresAt: now.Add(time.Hour), LastUsedAt: &lastUsedAt}
+
+	mockRepo.On("GetTokenByHash", hashToken(value)).Return(token, nil)
+	mockUsers.On("GetUserByID", 1).Return(&user.User{ID: 1, Status: user.StatusActive}, nil)
+
+	// Act
+	_, err := service.AuthenticatePersonalAccessToken(value)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsedAt", mock.Anything, mock.Anything)
+}
+
+func TestService_AuthenticatePersonalAccessToken_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *Token
+	}{
+		{"unknown token", nil},
+		{"expired token", &Token{ID: 7, UserID: 1, ExpiresAt: now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service, mockRepo, mockUsers := newTestService()
+
+			value := middleware.PersonalAccessTokenPrefix + "token"
+			mockRepo.On("GetTokenByHash", hashToken(value)).Return(tt.token, nil)
+
+			// Act
+			pat, err := service.AuthenticatePersonalAccessToken(value)
+
+			// Assert
+			require.Error(t, err)
+			assert.Nil(t, pat)
+			assert.Equal(t, apperror.CodeInvalidAccessToken, err.(*apperror.AppError).Code)
+			mockUsers.AssertNotCalled(t, "GetUserByID", mock.Anything)
+			mockRepo.AssertNotCalled(t, "UpdateLastUsedAt", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestService_AuthenticatePersonalAccessToken_UserSuspended(t *testing.T) {
+	// Arrange
+	service, mockRepo, mockUsers := newTestService()
+
+	value := middleware.PersonalAccessTokenPrefix + "token"
+	mockRepo.On("GetTokenByHash", hashToken(value)).Return(&Token{ID: 7, UserID: 1, ExpiresAt: now.Add(time.Hour)}, nil)
+	mockUsers.On("GetUserByID", 1).Return(&user.User{ID: 1, Status: user.StatusSuspended}, nil)
+
+	// Act
+	_, err := service.AuthenticatePersonalAccessToken(value)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserSuspended, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsedAt", mock.Anything, mock.Anything)
+}
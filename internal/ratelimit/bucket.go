@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+)
+
+const (
+	KeyIP   = "ip"
+	KeyUser = "user"
+)
+
+// Result is the state of a bucket after a request has tried to take a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, if the request was denied.
+	RetryAfter time.Duration
+}
+
+// Bucket is a token bucket that holds up to Requests tokens of its limit and
+// refills at Requests per Period, so that a client can burst up to the limit
+// and is then held to the average rate.
+type Bucket struct {
+	Key       string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+}
+
+func (Bucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// Take refills the bucket for the time since it was last used and takes a
+// token if there is one.
+func (b *Bucket) Take(limit config.RateLimit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed.Seconds()*rate(limit))
+	}
+	b.UpdatedAt = now
+
+	allowed := b.Tokens >= 1
+	if allowed {
+		b.Tokens--
+	}
+	return newResult(limit, b.Tokens, allowed)
+}
+
+func newResult(limit config.RateLimit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(limit.Requests) - tokens) / rate(limit)),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate(limit))
+	}
+	return result
+}
+
+// rate returns the tokens added per second.
+func rate(limit config.RateLimit) float64 {
+	return float64(limit.Requests) / limit.Period.Seconds()
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
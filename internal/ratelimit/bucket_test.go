@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket_Take_Burst(t *testing.T) {
+	// Arrange
+	limit := config.RateLimit{Key: KeyIP, Requests: 3, Period: time.Minute}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := &Bucket{}
+
+	// Act
+	results := []Result{
+		bucket.Take(limit, now),
+		bucket.Take(limit, now),
+		bucket.Take(limit, now),
+		bucket.Take(limit, now),
+	}
+
+	// Assert
+	assert.Equal(t, Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 20 * time.Second}, results[0])
+	assert.Equal(t, 0, results[2].Remaining)
+	assert.True(t, results[2].Allowed)
+	assert.Equal(t, Result{Allowed: false, Limit: 3, Remaining: 0, Reset: time.Minute, RetryAfter: 20 * time.Second}, results[3])
+}
+
+func TestBucket_Take_Refill(t *testing.T) {
+	// Arrange
+	limit := config.RateLimit{Key: KeyIP, Requests: 2, Period: time.Minute}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := &Bucket{}
+	bucket.Take(limit, now)
+	bucket.Take(limit, now)
+
+	// Act
+	denied := bucket.Take(limit, now.Add(15*time.Second))
+	allowed := bucket.Take(limit, now.Add(30*time.Second))
+	full := bucket.Take(limit, now.Add(time.Hour))
+
+	// Assert
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, 15*time.Second, denied.RetryAfter)
+	assert.True(t, allowed.Allowed)
+	assert.Equal(t, 0, allowed.Remaining)
+	assert.True(t, full.Allowed)
+	assert.Equal(t, 1, full.Remaining)
+}
+
+func TestBucket_Take_ClockSkew(t *testing.T) {
+	// Arrange
+	limit := config.RateLimit{Key: KeyIP, Requests: 2, Period: time.Minute}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := &Bucket{}
+	bucket.Take(limit, now)
+
+	// Act
+	result := bucket.Take(limit, now.Add(-time.Minute))
+
+	// Assert
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
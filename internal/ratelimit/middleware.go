@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	PolicyCallback = "callback"
+	PolicyRefresh  = "refresh"
+	PolicyVerify   = "verify"
+)
+
+// contextKeyTaken holds the limits already taken for the request and the
+// tightest result so far.
+const contextKeyTaken = "ratelimit_taken"
+
+type taken struct {
+	limits map[string]bool
+	result *Result
+	period time.Duration
+}
+
+// Middleware limits the requests to a route by the limits configured for the
+// given policy in RATE_LIMITS. Limits keyed by user only apply after
+// AuthMiddleware has authenticated the caller, so a policy with both can be
+// used before and after it; the second use skips the limits the first took.
+type Middleware func(policy string) gin.HandlerFunc
+
+func NewMiddleware(config *config.Config, store Store) Middleware {
+	return func(policy string) gin.HandlerFunc {
+		limits := config.RateLimits[policy]
+		return func(c *gin.Context) {
+			value, _ := c.Get(contextKeyTaken)
+			t, ok := value.(*taken)
+			if !ok {
+				t = &taken{limits: map[string]bool{}}
+				c.Set(contextKeyTaken, t)
+			}
+
+			for _, limit := range limits {
+				id := keyValue(c, limit.Key)
+				if id == "" || t.limits[policy+":"+limit.Key] {
+					continue
+				}
+				t.limits[policy+":"+limit.Key] = true
+
+				result, err := store.Take(c.Request.Context(), policy+":"+limit.Key+":"+id, limit)
+				if err != nil {
+					// an unavailable store must not lock everyone out of logging in
+					logger.FromContext(c.Request.Context()).Warn("rate limit store failed, allowing request", zap.String("policy", policy), zap.Error(err))
+					continue
+				}
+				if t.result == nil || tighter(result, *t.result) {
+					t.result = &result
+					t.period = limit.Period
+				}
+			}
+			if t.result == nil {
+				c.Next()
+				return
+			}
+
+			setHeaders(c, *t.result, t.period)
+			if !t.result.Allowed {
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(t.result.RetryAfter)))
+				c.Error(apperror.New(apperror.CodeRateLimited, "too many requests").WithInternal("policy: " + policy))
+				c.Abort()
+				return
+			}
+			c.Next()
+		}
+	}
+}
+
+func keyValue(c *gin.Context, key string) string {
+	switch key {
+	case KeyIP:
+		return c.ClientIP()
+	case KeyUser:
+		if id, ok := c.Get("user_id"); ok {
+			return UserKeyValue(id.(int), "")
+		}
+		if id := c.GetString("client_id"); id != "" {
+			return UserKeyValue(0, id)
+		}
+	}
+	return ""
+}
+
+// UserKeyValue returns the value that user limits are keyed by, which for a
+// service client is its client ID, so that each API shares the same buckets.
+func UserKeyValue(userID int, clientID string) string {
+	if clientID != "" {
+		return "client:" + clientID
+	}
+	return strconv.Itoa(userID)
+}
+
+// tighter reports whether a is the result the client should be told about
+// rather than b: a denial wins, then the fewest remaining requests.
+func tighter(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	return a.Remaining < b.Remaining
+}
+
+// setHeaders sets the RateLimit-* headers of the IETF draft "RateLimit header
+// fields for HTTP" for the tightest limit.
+func setHeaders(c *gin.Context, result Result, period time.Duration) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	c.Header("RateLimit-Policy", strconv.Itoa(result.Limit)+";w="+strconv.Itoa(ceilSeconds(period)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Take(ctx context.Context, key string, limit config.RateLimit) (Result, error) {
+	args := m.Called(ctx, key, limit)
+	return args.Get(0).(Result), args.Error(1)
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		RateLimits: map[string][]config.RateLimit{
+			PolicyVerify: {
+				{Key: KeyIP, Requests: 3, Period: time.Minute},
+				{Key: KeyUser, Requests: 2, Period: time.Minute},
+			},
+		},
+	}
+}
+
+func TestMiddleware_Allowed(t *testing.T) {
+	// Arrange
+	middleware := NewMiddleware(newTestConfig(), NewMemoryStore())
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, c.Errors)
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "20", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "3;w=60", w.Header().Get("RateLimit-Policy"))
+}
+
+func TestMiddleware_Denied(t *testing.T) {
+	// Arrange
+	middleware := NewMiddleware(newTestConfig(), NewMemoryStore())
+	for range 3 {
+		c, _ := test.SetupContext()
+		c.Request.RemoteAddr = "192.0.2.1:1234"
+		middleware(PolicyVerify)(c)
+	}
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	appErr, ok := c.Errors[0].Err.(*apperror.AppError)
+	require.True(t, ok)
+	assert.Equal(t, apperror.CodeRateLimited, appErr.Code)
+	assert.Equal(t, http.StatusTooManyRequests, appErr.Status)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_SeparateClients(t *testing.T) {
+	// Arrange
+	middleware := NewMiddleware(newTestConfig(), NewMemoryStore())
+	for range 3 {
+		c, _ := test.SetupContext()
+		c.Request.RemoteAddr = "192.0.2.1:1234"
+		middleware(PolicyVerify)(c)
+	}
+	c, _ := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.2:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	assert.Empty(t, c.Errors)
+}
+
+func TestMiddleware_UserLimitAfterAuth(t *testing.T) {
+	// Arrange
+	store := &MockStore{}
+	store.On("Take", mock.Anything, "verify:ip:192.0.2.1", mock.Anything).Return(Result{Allowed: true, Limit: 3, Remaining: 2}, nil).Once()
+	store.On("Take", mock.Anything, "verify:user:7", mock.Anything).Return(Result{Allowed: true, Limit: 2, Remaining: 1}, nil).Once()
+	middleware := NewMiddleware(newTestConfig(), store)
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+	c.Set("user_id", 7)
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	assert.Empty(t, c.Errors)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	store.AssertExpectations(t)
+}
+
+func TestMiddleware_ClientLimitAfterAuth(t *testing.T) {
+	// Arrange
+	store := &MockStore{}
+	store.On("Take", mock.Anything, "verify:ip:192.0.2.1", mock.Anything).Return(Result{Allowed: true, Limit: 3, Remaining: 2}, nil).Once()
+	store.On("Take", mock.Anything, "verify:user:client:svc_batch", mock.Anything).Return(Result{Allowed: true, Limit: 2, Remaining: 1}, nil).Once()
+	middleware := NewMiddleware(newTestConfig(), store)
+	c, _ := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+	c.Set("client_id", "svc_batch")
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	assert.Empty(t, c.Errors)
+	store.AssertExpectations(t)
+}
+
+func TestMiddleware_StoreError(t *testing.T) {
+	// Arrange
+	store := &MockStore{}
+	store.On("Take", mock.Anything, mock.Anything, mock.Anything).Return(Result{}, errors.New("connection refused"))
+	middleware := NewMiddleware(newTestConfig(), store)
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyVerify)(c)
+
+	// Assert
+	assert.Empty(t, c.Errors)
+	assert.False(t, c.IsAborted())
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestMiddleware_NoLimits(t *testing.T) {
+	// Arrange
+	store := &MockStore{}
+	middleware := NewMiddleware(newTestConfig(), store)
+	c, w := test.SetupContext()
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+
+	// Act
+	middleware(PolicyCallback)(c)
+
+	// Assert
+	assert.Empty(t, c.Errors)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	store.AssertNotCalled(t, "Take", mock.Anything, mock.Anything, mock.Anything)
+}
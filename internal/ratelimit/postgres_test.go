@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Bucket{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func TestPostgresStore_Take_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	limit := config.RateLimit{Key: KeyIP, Requests: 2, Period: time.Minute}
+	store := NewPostgresStore(d)
+
+	// Act
+	first, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	second, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	third, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+	assert.True(t, second.Allowed)
+	assert.False(t, third.Allowed)
+}
+
+func TestPostgresStore_Take_Concurrent(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	limit := config.RateLimit{Key: KeyIP, Requests: 5, Period: time.Hour}
+	stores := []Store{NewPostgresStore(d), NewPostgresStore(d)}
+
+	// Act
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := stores[i%2].Take(context.Background(), "refresh:ip:192.0.2.1", limit)
+			assert.NoError(t, err)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, 5, allowed)
+}
+
+func TestPostgresStore_Take_SweepsIdleBuckets(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	err = d.Create(&Bucket{Key: "callback:ip:192.0.2.1", Tokens: 0, UpdatedAt: time.Now().Add(-2 * idleTTL)}).Error
+	require.NoError(t, err)
+	limit := config.RateLimit{Key: KeyIP, Requests: 2, Period: time.Minute}
+	store := NewPostgresStore(d)
+
+	// Act
+	_, err = store.Take(context.Background(), "callback:ip:192.0.2.2", limit)
+	require.NoError(t, err)
+
+	// Assert
+	var count int64
+	err = d.Model(&Bucket{}).Where("key = ?", "callback:ip:192.0.2.1").Count(&count).Error
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
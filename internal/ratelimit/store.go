@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// idleTTL is how long an unused bucket is kept. Periods are at most a day, so
+// an idle bucket has refilled by then and dropping it changes nothing.
+const idleTTL = 24 * time.Hour
+
+// sweepInterval is how often a store drops idle buckets.
+const sweepInterval = time.Hour
+
+// Store keeps the token buckets. The in-memory store limits each instance on
+// its own; the Postgres store shares the buckets between instances.
+type Store interface {
+	Take(ctx context.Context, key string, limit config.RateLimit) (Result, error)
+}
+
+func NewStore(config *config.Config, db *gorm.DB) (Store, error) {
+	switch config.RateLimitStore {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StorePostgres:
+		return NewPostgresStore(db), nil
+	default:
+		return nil, errors.New("unknown rate limit store | store: " + config.RateLimitStore)
+	}
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: map[string]*Bucket{}, now: time.Now}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit config.RateLimit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, bucket := range s.buckets {
+			if now.Sub(bucket.UpdatedAt) > idleTTL {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &Bucket{Key: key}
+		s.buckets[key] = bucket
+	}
+	return bucket.Take(limit, now), nil
+}
+
+type postgresStore struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewPostgresStore keeps the buckets in the rate_limit_buckets table and locks
+// a bucket's row while taking a token from it.
+func NewPostgresStore(db *gorm.DB) Store {
+	return &postgresStore{db: db, now: time.Now}
+}
+
+func (s *postgresStore) Take(ctx context.Context, key string, limit config.RateLimit) (Result, error) {
+	now := s.now()
+	s.sweep(ctx, now)
+
+	var result Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// creates the row on first use so that there is a row to lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Bucket{Key: key, Tokens: float64(limit.Requests), UpdatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var bucket Bucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).Take(&bucket).Error
+		if err != nil {
+			return err
+		}
+		result = bucket.Take(limit, now)
+		return tx.Model(&bucket).Updates(map[string]any{"tokens": bucket.Tokens, "updated_at": bucket.UpdatedAt}).Error
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// sweep deletes idle buckets at most once per sweep interval per instance.
+func (s *postgresStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	// a failed sweep only leaves rows for the next one
+	s.db.WithContext(ctx).Where("updated_at < ?", now.Add(-idleTTL)).Delete(&Bucket{})
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_NewStore_Success(t *testing.T) {
+	// Act
+	memory, err := NewStore(&config.Config{RateLimitStore: StoreMemory}, nil)
+	require.NoError(t, err)
+	postgres, err := NewStore(&config.Config{RateLimitStore: StorePostgres}, nil)
+	require.NoError(t, err)
+
+	// Assert
+	assert.IsType(t, &memoryStore{}, memory)
+	assert.IsType(t, &postgresStore{}, postgres)
+}
+
+func TestStore_NewStore_Unknown(t *testing.T) {
+	// Act
+	_, err := NewStore(&config.Config{RateLimitStore: "memcached"}, nil)
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_Take_SeparateKeys(t *testing.T) {
+	// Arrange
+	limit := config.RateLimit{Key: KeyIP, Requests: 1, Period: time.Minute}
+	store := NewMemoryStore()
+
+	// Act
+	first, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	second, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	other, err := store.Take(context.Background(), "callback:ip:192.0.2.2", limit)
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, first.Allowed)
+	assert.False(t, second.Allowed)
+	assert.True(t, other.Allowed)
+}
+
+func TestMemoryStore_Take_SweepsIdleBuckets(t *testing.T) {
+	// Arrange
+	limit := config.RateLimit{Key: KeyIP, Requests: 1, Period: time.Minute}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+	_, err := store.Take(context.Background(), "callback:ip:192.0.2.1", limit)
+	require.NoError(t, err)
+
+	// Act
+	now = now.Add(idleTTL + time.Minute)
+	_, err = store.Take(context.Background(), "callback:ip:192.0.2.2", limit)
+	require.NoError(t, err)
+
+	// Assert
+	assert.NotContains(t, store.buckets, "callback:ip:192.0.2.1")
+	assert.Contains(t, store.buckets, "callback:ip:192.0.2.2")
+}
@@ -0,0 +1,126 @@
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+)
+
+const Placeholder = "***REDACTED***"
+
+// Fingerprint identifies a secret such as a token without revealing it, so
+// that log lines about the same token can be correlated. An empty secret is
+// reported as such because "no token sent" is a useful distinction.
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return "<empty>"
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// Rules decide which request fields are hidden from logs. A rule is a
+// case-insensitive pattern in path.Match syntax that is matched against field
+// names at any depth, e.g. "password" or "*token*".
+type Rules struct {
+	patterns []string
+}
+
+func NewRules(config *config.Config) *Rules {
+	patterns := make([]string, len(config.LogRedactFields))
+	for i, pattern := range config.LogRedactFields {
+		patterns[i] = strings.ToLower(pattern)
+	}
+	return &Rules{patterns: patterns}
+}
+
+func (r *Rules) Match(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Query returns the raw query with the values of matching parameters
+// replaced.
+func (r *Rules) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Placeholder
+	}
+	return r.values(values).Encode()
+}
+
+// Body returns a loggable form of a request body. JSON and form bodies are
+// logged with matching fields replaced; other bodies, such as import files,
+// are summarized by size only.
+func (r *Rules) Body(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		redacted, err := json.Marshal(r.json(value))
+		if err != nil {
+			break
+		}
+		return string(redacted)
+
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		return r.values(values).Encode()
+	}
+	return "[" + strconv.Itoa(len(body)) + " bytes omitted]"
+}
+
+func (r *Rules) values(values url.Values) url.Values {
+	for name := range values {
+		if r.Match(name) {
+			values[name] = []string{Placeholder}
+		}
+	}
+	return values
+}
+
+func (r *Rules) json(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if r.Match(key) {
+				v[key] = Placeholder
+			} else {
+				v[key] = r.json(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.json(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRules() *Rules {
+	return NewRules(&config.Config{LogRedactFields: []string{"password", "*Token*", "email"}})
+}
+
+func TestRedact_Fingerprint_Success(t *testing.T) {
+	// Act
+	fingerprint := Fingerprint("eyJhbGciOiJIUzI1NiJ9.e30.signature")
+
+	// Assert
+	assert.Regexp(t, `^sha256:[0-9a-f]{12}$`, fingerprint)
+	assert.Equal(t, fingerprint, Fingerprint("eyJhbGciOiJIUzI1NiJ9.e30.signature"))
+	assert.NotEqual(t, fingerprint, Fingerprint("another-token"))
+	assert.Equal(t, "<empty>", Fingerprint(""))
+}
+
+func TestRedact_Match_Patterns(t *testing.T) {
+	// Arrange
+	rules := newTestRules()
+
+	// Act & Assert
+	assert.True(t, rules.Match("password"))
+	assert.True(t, rules.Match("Password"))
+	assert.True(t, rules.Match("refresh_token"))
+	assert.True(t, rules.Match("tokens"))
+	assert.False(t, rules.Match("name"))
+	assert.False(t, rules.Match("new_password"))
+}
+
+func TestRedact_Body_JSON(t *testing.T) {
+	// Arrange
+	rules := newTestRules()
+	body := []byte(`{"email":"user@example.com","roles":["admin"],"profile":{"access_token":"secret","age":42},"items":[{"password":"p"}]}`)
+
+	// Act
+	actual := rules.Body("application/json; charset=utf-8", body)
+
+	// Assert
+	assert.JSONEq(
+		t,
+		`{"email":"***REDACTED***","roles":["admin"],"profile":{"access_token":"***REDACTED***","age":42},"items":[{"password":"***REDACTED***"}]}`,
+		actual,
+	)
+}
+func TestRedact_Body_Form(t *testing.T) {
+	// Arrange
+	rules := newTestRules()
+
+	// Act
+	actual := rules.Body("application/x-www-form-urlencoded", []byte("name=jo&id_token=secret"))
+
+	// Assert
+	assert.Equal(t, "id_token=%2A%2A%2AREDACTED%2A%2A%2A&name=jo", actual)
+}
+func TestRedact_Body_Omitted(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"empty", "application/json", "", ""},
+		{"csv", "text/csv", "email\nuser@example.com\n", "[23 bytes omitted]"},
+		{"malformed json", "application/json", `{"password":`, "[12 bytes omitted]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			rules := newTestRules()
+
+			// Act
+			actual := rules.Body(tt.contentType, []byte(tt.body))
+
+			// Assert
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestRedact_Query_Success(t *testing.T) {
+	// Arrange
+	rules := newTestRules()
+
+	// Act
+	actual := rules.Query("code=4/0AX&state=state&email=user@example.com")
+
+	// Assert
+	assert.Equal(t, "code=4%2F0AX&email=%2A%2A%2AREDACTED%2A%2A%2A&state=state", actual)
+}
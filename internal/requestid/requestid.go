@@ -0,0 +1,70 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID between the gateway, this service and the
+// services it calls.
+const Header = "X-Request-ID"
+
+const TraceparentHeader = "traceparent"
+
+// incoming IDs end up in logs and response headers, so only short IDs of
+// harmless characters are honored
+var regexpRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// W3C trace context: version-trace_id-parent_id-flags
+var regexpTraceparent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// Resolve picks the ID of a request from its X-Request-ID header, falling
+// back to the trace ID of its traceparent header and finally to a new UUID.
+func Resolve(requestID string, traceparent string) string {
+	if regexpRequestID.MatchString(requestID) {
+		return requestID
+	}
+	if matches := regexpTraceparent.FindStringSubmatch(strings.TrimSpace(traceparent)); len(matches) == 2 &&
+		matches[1] != strings.Repeat("0", 32) {
+		return matches[1]
+	}
+	return uuid.New().String()
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// FromContext returns the request ID stored in the context, or an empty
+// string outside of a request, e.g. in background jobs.
+func FromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// Transport sets the request ID of the request context on outbound requests.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func NewClient() *http.Client {
+	return &http.Client{Transport: &Transport{Base: http.DefaultTransport}}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := FromContext(req.Context())
+	if requestID == "" || req.Header.Get(Header) != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, requestID)
+	return t.Base.RoundTrip(req)
+}
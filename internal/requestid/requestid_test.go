@@ -0,0 +1,93 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID_Resolve_Header(t *testing.T) {
+	// Act
+	requestID := Resolve("gateway-1234", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Assert
+	assert.Equal(t, "gateway-1234", requestID)
+}
+
+func TestRequestID_Resolve_Traceparent(t *testing.T) {
+	// Act
+	requestID := Resolve("", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Assert
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestID)
+}
+
+func TestRequestID_Resolve_Generated(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+	}{
+		{"missing", "", ""},
+		{"unsafe request id", "id\nforged log line", ""},
+		{"too long request id", strings.Repeat("a", 129), ""},
+		{"malformed traceparent", "", "00-xyz-00f067aa0ba902b7-01"},
+		{"zero trace id", "", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			requestID := Resolve(tt.requestID, tt.traceparent)
+
+			// Assert
+			_, err := uuid.Parse(requestID)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRequestID_Transport_SetsHeader(t *testing.T) {
+	// Arrange
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(Header)
+	}))
+	defer server.Close()
+
+	ctx := NewContext(context.Background(), "request-1")
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, err := NewClient().Do(req)
+
+	// Assert
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "request-1", received)
+	assert.Empty(t, req.Header.Get(Header))
+}
+
+func TestRequestID_Transport_NoRequestID(t *testing.T) {
+	// Arrange
+	received := "unset"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(Header)
+	}))
+	defer server.Close()
+
+	// Act
+	resp, err := NewClient().Get(server.URL)
+
+	// Assert
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, received)
+}
@@ -0,0 +1,7 @@
+// Package role holds the roles that the service itself gives meaning to. It
+// has no dependencies, so that domain packages and the HTTP and gRPC layers
+// can share them.
+package role
+
+// Admin may assign roles, suspend users and manage the service's settings.
+const Admin = "admin"
@@ -1,40 +1,78 @@
 package router
 
 import (
-	"net/http"
-
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/client"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/errordoc"
+	"github.com/sninjo/vera-identity-service/internal/health"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
 	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/pat"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
 	"github.com/sninjo/vera-identity-service/internal/tool"
 	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 )
 
 func NewRouter(
+	config *config.Config,
+	tracingMiddleware middleware.TracingMiddleware,
+	metricsMiddleware middleware.MetricsMiddleware,
 	httpMiddleware middleware.HTTPMiddleware,
 	corsMiddleware middleware.CORSMiddleware,
 	authMiddleware middleware.AuthMiddleware,
+	rateLimitMiddleware ratelimit.Middleware,
 	toolHandler *tool.Handler,
 	authHandler *auth.Handler,
 	userHandler *user.Handler,
-) *gin.Engine {
+	attributeHandler *attribute.Handler,
+	auditMiddleware audit.Middleware,
+	auditHandler *audit.Handler,
+	webhookHandler *webhook.Handler,
+	lockoutHandler *lockout.Handler,
+	clientHandler *client.Handler,
+	patHandler *pat.Handler,
+	errordocHandler *errordoc.Handler,
+	healthHandler *health.Handler,
+	m *metrics.Metrics,
+) (*gin.Engine, error) {
 	r := gin.New()
+	// gin trusts every proxy unless told otherwise; nil trusts none
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, err
+	}
 	r.Use(
-		gin.Recovery(),
+		gin.CustomRecovery(middleware.RecoverMiddlewarePanic),
+		gin.HandlerFunc(tracingMiddleware),
+		gin.HandlerFunc(metricsMiddleware),
 		gin.HandlerFunc(httpMiddleware),
+		// recovers inside the HTTP middleware so that panics get an error body too
+		gin.CustomRecovery(middleware.RecoverPanic),
 		gin.HandlerFunc(corsMiddleware),
 	)
+	r.NoRoute(middleware.RouteNotFound)
 
-	r.GET("/healthz", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "OK"})
-	})
+	health.RegisterRoutes(r, healthHandler)
+	r.GET("/metrics", m.Handler())
 	r.StaticFile("/docs/swagger.yaml", "./api/swagger.yaml")
 	r.StaticFile("/docs", "./api/swagger.html")
 
 	tool.RegisterRoutes(r, toolHandler)
-	auth.RegisterRoutes(r, authHandler, authMiddleware)
-	user.RegisterRoutes(r, userHandler, authMiddleware)
+	auth.RegisterRoutes(r, authHandler, authMiddleware, auditMiddleware, rateLimitMiddleware)
+	user.RegisterRoutes(r, userHandler, authMiddleware, auditMiddleware)
+	attribute.RegisterRoutes(r, attributeHandler, authMiddleware)
+	audit.RegisterRoutes(r, auditHandler, authMiddleware)
+	webhook.RegisterRoutes(r, webhookHandler, authMiddleware)
+	lockout.RegisterRoutes(r, lockoutHandler, authMiddleware, auditMiddleware)
+	client.RegisterRoutes(r, clientHandler, authMiddleware, auditMiddleware)
+	pat.RegisterRoutes(r, patHandler, authMiddleware, auditMiddleware)
+	errordoc.RegisterRoutes(r, errordocHandler)
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	"github.com/sninjo/vera-identity-service/internal/role"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"google.golang.org/grpc/metadata"
+)
+
+type access int
+
+const (
+	// accessUser needs an access token
+	accessUser access = iota
+	// accessAdmin needs an access token of an admin
+	accessAdmin
+	// accessClient needs the credentials of an introspection client
+	accessClient
+	// accessPublic is authenticated by the call itself, e.g. by the token
+	// it verifies
+	accessPublic
+)
+
+// methodAccess lists the methods that do not only need an access token.
+var methodAccess = map[string]access{
+	identityv1.UserService_SetUserRoles_FullMethodName:    accessAdmin,
+	identityv1.UserService_SuspendUser_FullMethodName:     accessAdmin,
+	identityv1.UserService_ReactivateUser_FullMethodName:  accessAdmin,
+	identityv1.AuthService_VerifyToken_FullMethodName:     accessPublic,
+	identityv1.AuthService_RefreshToken_FullMethodName:    accessPublic,
+	identityv1.AuthService_IntrospectToken_FullMethodName: accessClient,
+	authv3.Authorization_Check_FullMethodName:             accessPublic,
+}
+
+// methodScopes lists the scopes that service clients and personal access
+// tokens need for the methods of the matching HTTP endpoints.
+var methodScopes = map[string]string{
+	identityv1.UserService_ListUsers_FullMethodName:   user.ScopeUsersRead,
+	identityv1.UserService_GetUser_FullMethodName:     user.ScopeUsersRead,
+	identityv1.UserService_ExportUsers_FullMethodName: user.ScopeUsersRead,
+	identityv1.UserService_CreateUser_FullMethodName:  user.ScopeUsersWrite,
+	identityv1.UserService_UpdateUser_FullMethodName:  user.ScopeUsersWrite,
+	identityv1.UserService_DeleteUser_FullMethodName:  user.ScopeUsersWrite,
+	identityv1.UserService_RestoreUser_FullMethodName: user.ScopeUsersWrite,
+	identityv1.UserService_ImportUsers_FullMethodName: user.ScopeUsersWrite,
+}
+
+type identityKey struct{}
+
+func newIdentityContext(ctx context.Context, identity *middleware.Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller that AuthInterceptor authenticated,
+// or nil for calls without an access token.
+func IdentityFromContext(ctx context.Context) *middleware.Identity {
+	identity, _ := ctx.Value(identityKey{}).(*middleware.Identity)
+	return identity
+}
+
+// hasRole reports whether the authenticated caller holds the role.
+func hasRole(ctx context.Context, role string) bool {
+	identity := IdentityFromContext(ctx)
+	return identity != nil && slices.Contains(identity.Roles, role)
+}
+
+// allowPrivileged is Identity.AllowPrivileged for the authenticated caller.
+func allowPrivileged(ctx context.Context, id int) bool {
+	identity := IdentityFromContext(ctx)
+	return identity != nil && identity.AllowPrivileged(id)
+}
+
+type AuthInterceptor Interceptor
+
+// NewAuthInterceptor authenticates calls by the authorization metadata, with
+// the rules of AuthMiddleware for access tokens and of /oauth2/introspect for
+// client credentials.
+func NewAuthInterceptor(verifier middleware.TokenVerifier) AuthInterceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		authorization := firstValue(md, "authorization")
+
+		switch methodAccess[method] {
+		case accessPublic:
+			return call(ctx)
+		case accessClient:
+			request := &http.Request{Header: http.Header{"Authorization": {authorization}}}
+			clientID, clientSecret, ok := request.BasicAuth()
+			if !ok {
+				return apperror.New(apperror.CodeInvalidClient, "invalid client")
+			}
+			if err := verifier.VerifyClientCredentials(clientID, clientSecret); err != nil {
+				return err
+			}
+			return call(ctx)
+		}
+
+		if len(authorization) < 7 || authorization[:7] != "Bearer " {
+			return apperror.New(apperror.CodeInvalidAuthHeader, "invalid authorization header").WithInternal("header: " + redact.Fingerprint(authorization))
+		}
+		identity, err := verifier.Verify(authorization[7:])
+		if err != nil {
+			return err
+		}
+		ctx = newIdentityContext(ctx, identity)
+
+		if methodAccess[method] == accessAdmin && !hasRole(ctx, role.Admin) {
+			return apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+role.Admin)
+		}
+		// tokens are limited by their scopes, logins only by their roles
+		if scope, ok := methodScopes[method]; ok && (identity.IsClient() || identity.IsPersonalAccessToken()) && !slices.Contains(identity.Scopes, scope) {
+			err := apperror.New(apperror.CodeInsufficientScope, "insufficient scope | required scope: "+scope)
+			if identity.IsClient() {
+				return err.WithInternal("client_id: " + identity.ClientID)
+			}
+			return err.WithInternal("token_id: " + strconv.Itoa(identity.TokenID))
+		}
+		return call(ctx)
+	}
+}
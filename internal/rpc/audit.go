@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// auditEvents maps the methods that are audited to their event types, which
+// are those of the matching HTTP endpoints.
+var auditEvents = map[string]string{
+	identityv1.UserService_CreateUser_FullMethodName:     audit.EventUserCreate,
+	identityv1.UserService_UpdateUser_FullMethodName:     audit.EventUserUpdate,
+	identityv1.UserService_DeleteUser_FullMethodName:     audit.EventUserDelete,
+	identityv1.UserService_RestoreUser_FullMethodName:    audit.EventUserRestore,
+	identityv1.UserService_SetUserRoles_FullMethodName:   audit.EventUserRolesChange,
+	identityv1.UserService_SuspendUser_FullMethodName:    audit.EventUserSuspend,
+	identityv1.UserService_ReactivateUser_FullMethodName: audit.EventUserReactivate,
+	identityv1.UserService_ImportUsers_FullMethodName:    audit.EventUserImport,
+	identityv1.AuthService_RefreshToken_FullMethodName:   audit.EventRefresh,
+}
+
+// auditRecord collects what only the handler knows about an audited call.
+type auditRecord struct {
+	actorID  *int
+	targetID *int
+	email    string
+	metadata map[string]any
+}
+
+type auditRecordKey struct{}
+
+func recordFromContext(ctx context.Context) *auditRecord {
+	record, _ := ctx.Value(auditRecordKey{}).(*auditRecord)
+	if record == nil {
+		// calls that are not audited write to a record nobody reads
+		return &auditRecord{}
+	}
+	return record
+}
+
+func setAuditActor(ctx context.Context, id int) {
+	recordFromContext(ctx).actorID = &id
+}
+
+func setAuditTarget(ctx context.Context, id int) {
+	recordFromContext(ctx).targetID = &id
+}
+
+func setAuditEmail(ctx context.Context, email string) {
+	recordFromContext(ctx).email = email
+}
+
+func setAuditMetadata(ctx context.Context, key string, value any) {
+	record := recordFromContext(ctx)
+	if record.metadata == nil {
+		record.metadata = map[string]any{}
+	}
+	record.metadata[key] = value
+}
+
+type AuditInterceptor Interceptor
+
+// NewAuditInterceptor records one audit event for each call of an audited
+// method, like audit.Middleware does for HTTP.
+func NewAuditInterceptor(service audit.Service, logger *zap.Logger) AuditInterceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		eventType, ok := auditEvents[method]
+		if !ok {
+			return call(ctx)
+		}
+
+		record := &auditRecord{}
+		err := call(context.WithValue(ctx, auditRecordKey{}, record))
+
+		event := newEvent(ctx, eventType, record, err)
+		if recordErr := service.Record(event); recordErr != nil {
+			logger.Error("failed to record audit event", zap.String("type", eventType), zap.Error(recordErr))
+		}
+		return err
+	}
+}
+
+func newEvent(ctx context.Context, eventType string, record *auditRecord, err error) *audit.Event {
+	md, _ := metadata.FromIncomingContext(ctx)
+	event := &audit.Event{
+		Type:      eventType,
+		Outcome:   audit.OutcomeSuccess,
+		UserAgent: firstValue(md, "user-agent"),
+		TargetID:  record.targetID,
+		Metadata:  record.metadata,
+	}
+	event.IP = peerIP(ctx)
+
+	// the authenticated caller wins over an actor named by the handler;
+	// service clients are no users, so they are named in the metadata
+	if identity := IdentityFromContext(ctx); identity != nil && identity.IsClient() {
+		if event.Metadata == nil {
+			event.Metadata = map[string]any{}
+		}
+		event.Metadata["actor_client_id"] = identity.ClientID
+	} else if identity != nil {
+		event.ActorID = &identity.UserID
+		// the user acts through the personal access token, which is named too
+		if identity.IsPersonalAccessToken() {
+			if event.Metadata == nil {
+				event.Metadata = map[string]any{}
+			}
+			event.Metadata["actor_token_id"] = identity.TokenID
+		}
+	} else {
+		event.ActorID = record.actorID
+	}
+	if record.email != "" {
+		event.Email = &record.email
+	}
+
+	if err != nil {
+		var reason string
+		if s, ok := status.FromError(err); ok {
+			reason = s.Code().String()
+		} else {
+			reason = apperror.FromError(err).Code
+		}
+		event.Outcome = audit.OutcomeFailure
+		event.Reason = &reason
+	}
+	return event
+}
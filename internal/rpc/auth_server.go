@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/redact"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+)
+
+// AuthServer serves the token operations of /auth/verify, /auth/refresh and
+// /oauth2/introspect.
+type AuthServer struct {
+	identityv1.UnimplementedAuthServiceServer
+	authService auth.Service
+	verifier    middleware.TokenVerifier
+	metrics     *metrics.Metrics
+}
+
+func NewAuthServer(authService auth.Service, verifier middleware.TokenVerifier, metrics *metrics.Metrics) *AuthServer {
+	return &AuthServer{
+		authService: authService,
+		verifier:    verifier,
+		metrics:     metrics,
+	}
+}
+
+func (s *AuthServer) VerifyToken(ctx context.Context, req *identityv1.VerifyTokenRequest) (*identityv1.VerifyTokenResponse, error) {
+	identity, err := s.verifier.Verify(req.GetAccessToken())
+	if err != nil {
+		return nil, err
+	}
+	if err := limitUser(ctx, identity); err != nil {
+		return nil, err
+	}
+	if roles := req.GetRoles(); len(roles) > 0 {
+		if !slices.ContainsFunc(roles, func(role string) bool {
+			return slices.Contains(identity.Roles, role)
+		}) {
+			return nil, apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+strings.Join(roles, ","))
+		}
+	}
+
+	if identity.IsClient() {
+		return &identityv1.VerifyTokenResponse{
+			ClientId: identity.ClientID,
+			Scopes:   identity.Scopes,
+		}, nil
+	}
+	return &identityv1.VerifyTokenResponse{
+		UserId: int64(identity.UserID),
+		Email:  identity.Email,
+		Roles:  identity.Roles,
+		Scopes: identity.Scopes,
+	}, nil
+}
+
+func (s *AuthServer) RefreshToken(ctx context.Context, req *identityv1.RefreshTokenRequest) (resp *identityv1.RefreshTokenResponse, err error) {
+	defer func() { s.metrics.RefreshResult(err) }()
+	claims, err := s.authService.ParseRefreshToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, apperror.New(apperror.CodeInvalidRefreshToken, "invalid refresh token").WithInternal("refresh token: " + redact.Fingerprint(req.GetRefreshToken()))
+	}
+
+	userID, _ := strconv.Atoi(claims.Subject)
+	setAuditActor(ctx, userID)
+	setAuditTarget(ctx, userID)
+	if err = limitUser(ctx, &middleware.Identity{UserID: userID}); err != nil {
+		return nil, err
+	}
+	accessToken, err := s.authService.RefreshAccessToken(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.TokenIssued(metrics.TokenTypeAccess)
+
+	return &identityv1.RefreshTokenResponse{AccessToken: accessToken}, nil
+}
+
+func (s *AuthServer) IntrospectToken(ctx context.Context, req *identityv1.IntrospectTokenRequest) (*identityv1.IntrospectTokenResponse, error) {
+	if err := validate(&auth.IntrospectRequest{Token: req.GetToken(), TokenTypeHint: req.GetTokenTypeHint()}); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.authService.Introspect(ctx, req.GetToken(), req.GetTokenTypeHint())
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return &identityv1.IntrospectTokenResponse{Active: false}, nil
+	}
+
+	response := &identityv1.IntrospectTokenResponse{
+		Active:   true,
+		Sub:      claims.Subject,
+		Iss:      claims.Issuer,
+		Email:    claims.Email,
+		Roles:    claims.Roles,
+		Sid:      claims.SessionID,
+		ClientId: claims.ClientID,
+		Scope:    claims.Scope,
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+	return response, nil
+}
@@ -0,0 +1,282 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func withClientCredentials() context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte("mock-gateway:mock-gateway-secret"))
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+credentials)
+}
+
+func scrapeMetrics(m *metrics.Metrics) string {
+	c, w := test.SetupContext()
+	m.Handler()(c)
+	return w.Body.String()
+}
+
+func TestAuthServer_VerifyToken_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewAccessToken(1, "", "", "user@example.com", "", []string{"ops"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	response, err := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token, Roles: []string{"admin", "ops"}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.GetUserId())
+	assert.Equal(t, "user@example.com", response.GetEmail())
+	assert.Equal(t, []string{"ops"}, response.GetRoles())
+}
+
+func TestAuthServer_VerifyToken_MissingRole(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewAccessToken(1, "", "", "user@example.com", "", []string{"ops"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token, Roles: []string{"admin"}})
+
+	// Assert
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, apperror.CodePermissionDenied, errorInfo(t, err).GetReason())
+}
+
+func TestAuthServer_VerifyToken_InvalidToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	// Act
+	_, err := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: "invalid"})
+
+	// Assert
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, apperror.CodeInvalidAccessToken, errorInfo(t, err).GetReason())
+}
+
+func TestAuthServer_VerifyToken_ClientToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	s.mockClientChecker.On("CheckClient", "svc_batch").Return(nil)
+
+	// Act
+	response, err := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "svc_batch", response.GetClientId())
+	assert.Equal(t, []string{"users:read"}, response.GetScopes())
+	assert.Zero(t, response.GetUserId())
+	s.mockClientChecker.AssertExpectations(t)
+}
+
+func TestAuthServer_VerifyToken_ClientDeleted(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	s.mockClientChecker.On("CheckClient", "svc_batch").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+
+	// Act
+	_, err = client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token})
+
+	// Assert
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, apperror.CodeInvalidClient, errorInfo(t, err).GetReason())
+}
+
+func TestAuthServer_VerifyToken_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	s.mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(&middleware.PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Roles: []string{"admin", "ops"}, Scopes: []string{"users:read"}}, nil)
+
+	// Act
+	response, err := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: "pat_token"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.GetUserId())
+	assert.Equal(t, []string{"ops"}, response.GetRoles())
+	assert.Equal(t, []string{"users:read"}, response.GetScopes())
+	s.mockAuthenticator.AssertExpectations(t)
+}
+
+func TestAuthServer_RefreshToken_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	name, picture := "User", ""
+	refreshToken, err := s.authService.NewRefreshToken(1, "session-1")
+	require.NoError(t, err)
+	s.mockUserService.On("GetUserByID", 1).Return(&user.User{ID: 1, Name: &name, Picture: &picture, Email: "user@example.com", Roles: []string{"admin"}, Status: user.StatusActive}, nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	response, err := client.RefreshToken(context.Background(), &identityv1.RefreshTokenRequest{RefreshToken: refreshToken})
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := s.authService.ParseAccessToken(response.GetAccessToken())
+	require.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	assert.Equal(t, audit.EventRefresh, event.Type)
+	assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, 1, *event.ActorID)
+	s.mockUserService.AssertExpectations(t)
+	scraped := scrapeMetrics(s.metrics)
+	assert.Contains(t, scraped, `vera_identity_token_refreshes_total{outcome="success",reason=""} 1`)
+	assert.Contains(t, scraped, `vera_identity_tokens_issued_total{type="access"} 1`)
+}
+
+func TestAuthServer_RefreshToken_InvalidToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.RefreshToken(context.Background(), &identityv1.RefreshTokenRequest{RefreshToken: "invalid"})
+
+	// Assert
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, apperror.CodeInvalidRefreshToken, errorInfo(t, err).GetReason())
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	assert.Equal(t, audit.OutcomeFailure, event.Outcome)
+	s.mockUserService.AssertNotCalled(t, "GetUserByID", mock.Anything)
+	scraped := scrapeMetrics(s.metrics)
+	assert.Contains(t, scraped, `vera_identity_token_refreshes_total{outcome="failure",reason="invalid_refresh_token"} 1`)
+	assert.NotContains(t, scraped, `vera_identity_tokens_issued_total`)
+}
+
+func TestAuthServer_IntrospectToken_Active(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewAccessToken(1, "session-1", "", "user@example.com", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+	s.mockUserService.On("CheckUserStatus", 1).Return(nil)
+
+	// Act
+	response, err := client.IntrospectToken(withClientCredentials(), &identityv1.IntrospectTokenRequest{Token: token})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.GetActive())
+	assert.Equal(t, "1", response.GetSub())
+	assert.Equal(t, "user@example.com", response.GetEmail())
+	assert.Equal(t, []string{"admin"}, response.GetRoles())
+	assert.Equal(t, "session-1", response.GetSid())
+	assert.NotZero(t, response.GetExp())
+	assert.NotZero(t, response.GetIat())
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestAuthServer_IntrospectToken_ClientToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewClientAccessToken("svc_batch", []string{"users:read"})
+	require.NoError(t, err)
+
+	s.mockClientChecker.On("CheckClient", "svc_batch").Return(nil)
+
+	// Act
+	response, err := client.IntrospectToken(withClientCredentials(), &identityv1.IntrospectTokenRequest{Token: token})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.GetActive())
+	assert.Equal(t, "svc_batch", response.GetSub())
+	assert.Equal(t, "svc_batch", response.GetClientId())
+	assert.Equal(t, "users:read", response.GetScope())
+}
+
+func TestAuthServer_IntrospectToken_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	expiresAt := time.Unix(1700000000, 0)
+	s.mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_token").Return(&middleware.PersonalAccessToken{ID: 7, UserID: 1, Email: "user@example.com", Scopes: []string{"users:read"}, ExpiresAt: expiresAt}, nil)
+
+	// Act
+	response, err := client.IntrospectToken(withClientCredentials(), &identityv1.IntrospectTokenRequest{Token: "pat_token"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.GetActive())
+	assert.Equal(t, "1", response.GetSub())
+	assert.Equal(t, "users:read", response.GetScope())
+	assert.Equal(t, expiresAt.Unix(), response.GetExp())
+}
+
+func TestAuthServer_IntrospectToken_Inactive(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	// Act
+	response, err := client.IntrospectToken(withClientCredentials(), &identityv1.IntrospectTokenRequest{Token: "invalid"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, response.GetActive())
+	assert.Empty(t, response.GetSub())
+}
+
+func TestAuthServer_IntrospectToken_MissingToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	// Act
+	_, err := client.IntrospectToken(withClientCredentials(), &identityv1.IntrospectTokenRequest{})
+
+	// Assert
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
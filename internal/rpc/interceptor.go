@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Interceptor runs around a unary or streaming call of the given full method,
+// e.g. "/vera.identity.v1.UserService/GetUser". The call runs with the
+// context the interceptor passes on.
+type Interceptor func(ctx context.Context, method string, call func(ctx context.Context) error) error
+
+// UnaryInterceptor chains the interceptors for unary calls; the first one is
+// the outermost.
+func UnaryInterceptor(interceptors ...Interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := chain(interceptors, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})(ctx)
+		return resp, err
+	}
+}
+
+// StreamInterceptor chains the interceptors for streaming calls; the first one
+// is the outermost.
+func StreamInterceptor(interceptors ...Interceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return chain(interceptors, info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})(ss.Context())
+	}
+}
+
+func chain(interceptors []Interceptor, method string, call func(ctx context.Context) error) func(ctx context.Context) error {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], call
+		call = func(ctx context.Context) error {
+			return interceptor(ctx, method, next)
+		}
+	}
+	return call
+}
+
+// serverStream hands the context of the interceptors to stream handlers.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
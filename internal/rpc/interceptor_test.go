@@ -0,0 +1,503 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/metrics"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
+	"github.com/sninjo/vera-identity-service/internal/requestid"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(event *audit.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+func (m *MockAuditService) GetEvents(filter audit.Filter, cursor string) (*audit.Page, error) {
+	args := m.Called(filter, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit.Page), args.Error(1)
+}
+
+type testServer struct {
+	conn              *grpc.ClientConn
+	authService       auth.Service
+	verifier          middleware.TokenVerifier
+	mockUserService   *auth.MockUserService
+	mockClientChecker *auth.MockClientChecker
+	mockAuthenticator *auth.MockPersonalAccessTokenAuthenticator
+	mockAuditService  *MockAuditService
+	metrics           *metrics.Metrics
+}
+
+// setupServer serves the user and auth services in-process behind the same
+// interceptors as the app.
+func setupServer(t *testing.T, cfg *config.Config) *testServer {
+	mockUserService := &auth.MockUserService{}
+	mockAttributeService := &auth.MockAttributeService{}
+	mockAttributeService.On("Claims", mock.Anything).Return(nil, nil)
+	mockClientChecker := &auth.MockClientChecker{}
+	mockAuthenticator := &auth.MockPersonalAccessTokenAuthenticator{}
+	mockAuditService := &MockAuditService{}
+	verifier := middleware.NewTokenVerifier(cfg, mockUserService, mockClientChecker, mockAuthenticator)
+	authService := auth.NewService(cfg, mockUserService, mockAttributeService, verifier, noop.NewTracerProvider())
+	m := metrics.New()
+
+	interceptors := []Interceptor{
+		Interceptor(NewLoggingInterceptor(zap.NewNop())),
+		Interceptor(NewAuthInterceptor(verifier)),
+		Interceptor(NewRateLimitInterceptor(cfg, ratelimit.NewMemoryStore())),
+		Interceptor(NewAuditInterceptor(mockAuditService, zap.NewNop())),
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryInterceptor(interceptors...)),
+		grpc.StreamInterceptor(StreamInterceptor(interceptors...)),
+	)
+	identityv1.RegisterUserServiceServer(server, NewUserServer(mockUserService))
+	identityv1.RegisterAuthServiceServer(server, NewAuthServer(authService, verifier, m))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		conn:              conn,
+		authService:       authService,
+		verifier:          verifier,
+		mockUserService:   mockUserService,
+		mockClientChecker: mockClientChecker,
+		mockAuthenticator: mockAuthenticator,
+		mockAuditService:  mockAuditService,
+		metrics:           m,
+	}
+}
+
+// withToken returns a context that calls with an access token of the user.
+func (s *testServer) withToken(t *testing.T, id int, roles ...string) context.Context {
+	token, err := s.authService.NewAccessToken(id, "", "", "user@example.com", "", roles, nil)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// withPersonalAccessToken returns a context that calls with a personal access
+// token of the user.
+func (s *testServer) withPersonalAccessToken(id, tokenID int, scopes ...string) context.Context {
+	token := middleware.PersonalAccessTokenPrefix + strconv.Itoa(tokenID)
+	s.mockAuthenticator.On("AuthenticatePersonalAccessToken", token).Return(&middleware.PersonalAccessToken{ID: tokenID, UserID: id, Email: "user@example.com", Roles: []string{"admin"}, Scopes: scopes}, nil)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// withClientToken returns a context that calls with an access token of the
+// service client, which still exists.
+func (s *testServer) withClientToken(t *testing.T, clientID string, scopes ...string) context.Context {
+	s.mockClientChecker.On("CheckClient", clientID).Return(nil)
+	token, err := s.authService.NewClientAccessToken(clientID, scopes)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return nil
+}
+
+func TestInterceptor_Chain_Order(t *testing.T) {
+	// Arrange
+	var calls []string
+	interceptor := func(name string) Interceptor {
+		return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+			calls = append(calls, name+" "+method)
+			return call(ctx)
+		}
+	}
+	unary := UnaryInterceptor(interceptor("outer"), interceptor("inner"))
+
+	// Act
+	resp, err := unary(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, func(ctx context.Context, req any) (any, error) {
+		calls = append(calls, "handler")
+		return req, nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "request", resp)
+	assert.Equal(t, []string{"outer /test/Method", "inner /test/Method", "handler"}, calls)
+}
+
+func TestLoggingInterceptor_ErrorToStatus(t *testing.T) {
+	// Arrange
+	interceptor := NewLoggingInterceptor(zap.NewNop())
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.Header, "request-1"))
+
+	// Act
+	var requestID string
+	err := interceptor(ctx, "/test/Method", func(ctx context.Context) error {
+		requestID = requestid.FromContext(ctx)
+		return apperror.New(apperror.CodeUserNotFound, "user not found | id: 1")
+	})
+
+	// Assert
+	assert.Equal(t, "request-1", requestID)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "user not found | id: 1", status.Convert(err).Message())
+	info := errorInfo(t, err)
+	assert.Equal(t, apperror.CodeUserNotFound, info.GetReason())
+	assert.Equal(t, "request-1", info.GetMetadata()["request_id"])
+}
+
+func TestLoggingInterceptor_UnexpectedError(t *testing.T) {
+	// Arrange
+	interceptor := NewLoggingInterceptor(zap.NewNop())
+
+	// Act
+	err := interceptor(context.Background(), "/test/Method", func(ctx context.Context) error {
+		return assert.AnError
+	})
+
+	// Assert
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal server error", status.Convert(err).Message())
+}
+
+func TestLoggingInterceptor_StatusPassedThrough(t *testing.T) {
+	// Arrange
+	interceptor := NewLoggingInterceptor(zap.NewNop())
+	expected := status.Error(codes.Unavailable, "try again")
+
+	// Act
+	err := interceptor(context.Background(), "/test/Method", func(ctx context.Context) error {
+		return expected
+	})
+
+	// Assert
+	assert.Equal(t, expected, err)
+}
+
+func TestAuthInterceptor_Access(t *testing.T) {
+	clientCredentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("mock-gateway:mock-gateway-secret"))
+	wrongCredentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("mock-gateway:wrong"))
+	registeredCredentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("svc_gateway:svc-secret"))
+	clientBearer := func(t *testing.T, s *testServer, clientID string, scopes ...string) string {
+		token, err := s.authService.NewClientAccessToken(clientID, scopes)
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization func(s *testServer) string
+		expected      codes.Code
+	}{
+		{"user without token", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return "" }, codes.Unauthenticated},
+		{"user with invalid token", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return "Bearer invalid" }, codes.Unauthenticated},
+		{"user with token", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return bearer(t, s, "") }, codes.OK},
+		{"admin method without role", identityv1.UserService_SuspendUser_FullMethodName, func(s *testServer) string { return bearer(t, s, "") }, codes.PermissionDenied},
+		{"admin method with role", identityv1.UserService_SuspendUser_FullMethodName, func(s *testServer) string { return bearer(t, s, "admin") }, codes.OK},
+		{"client method with credentials", identityv1.AuthService_IntrospectToken_FullMethodName, func(s *testServer) string { return clientCredentials }, codes.OK},
+		{"client method with wrong credentials", identityv1.AuthService_IntrospectToken_FullMethodName, func(s *testServer) string { return wrongCredentials }, codes.Unauthenticated},
+		{"client method with token", identityv1.AuthService_IntrospectToken_FullMethodName, func(s *testServer) string { return bearer(t, s, "admin") }, codes.Unauthenticated},
+		{"client method with registered credentials", identityv1.AuthService_IntrospectToken_FullMethodName, func(s *testServer) string { return registeredCredentials }, codes.OK},
+		{"client token with scope", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return clientBearer(t, s, "svc_batch", "users:read") }, codes.OK},
+		{"client token without scope", identityv1.UserService_CreateUser_FullMethodName, func(s *testServer) string { return clientBearer(t, s, "svc_batch", "users:read") }, codes.PermissionDenied},
+		{"client token for admin method", identityv1.UserService_SuspendUser_FullMethodName, func(s *testServer) string { return clientBearer(t, s, "svc_batch", "users:write") }, codes.PermissionDenied},
+		{"token of deleted client", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return clientBearer(t, s, "svc_deleted", "users:read") }, codes.Unauthenticated},
+		{"personal access token with scope", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return "Bearer pat_read" }, codes.OK},
+		{"personal access token without scope", identityv1.UserService_DeleteUser_FullMethodName, func(s *testServer) string { return "Bearer pat_read" }, codes.PermissionDenied},
+		{"personal access token without admin scope", identityv1.UserService_SuspendUser_FullMethodName, func(s *testServer) string { return "Bearer pat_read" }, codes.PermissionDenied},
+		{"personal access token with admin scope", identityv1.UserService_SuspendUser_FullMethodName, func(s *testServer) string { return "Bearer pat_admin" }, codes.OK},
+		{"revoked personal access token", identityv1.UserService_ListUsers_FullMethodName, func(s *testServer) string { return "Bearer pat_revoked" }, codes.Unauthenticated},
+		{"public method", identityv1.AuthService_VerifyToken_FullMethodName, func(s *testServer) string { return "" }, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := auth.NewMockConfig("")
+			s := setupServer(t, cfg)
+			s.mockClientChecker.On("CheckClientSecret", "svc_gateway", "svc-secret").Return(nil)
+			s.mockClientChecker.On("CheckClient", "svc_batch").Return(nil)
+			s.mockClientChecker.On("CheckClient", "svc_deleted").Return(apperror.New(apperror.CodeInvalidClient, "invalid client"))
+			s.mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_read").Return(&middleware.PersonalAccessToken{ID: 7, UserID: 1, Roles: []string{"admin"}, Scopes: []string{"users:read"}}, nil)
+			s.mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_admin").Return(&middleware.PersonalAccessToken{ID: 8, UserID: 1, Roles: []string{"admin"}, Scopes: []string{"admin"}}, nil)
+			s.mockAuthenticator.On("AuthenticatePersonalAccessToken", "pat_revoked").Return(nil, apperror.New(apperror.CodeInvalidAccessToken, "invalid access token"))
+			interceptor := Interceptor(NewLoggingInterceptor(zap.NewNop()))
+			authInterceptor := NewAuthInterceptor(s.verifier)
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", tt.authorization(s)))
+
+			// Act
+			called := false
+			err := interceptor(ctx, tt.method, func(ctx context.Context) error {
+				return authInterceptor(ctx, tt.method, func(ctx context.Context) error {
+					called = true
+					return nil
+				})
+			})
+
+			// Assert
+			assert.Equal(t, tt.expected, status.Code(err))
+			assert.Equal(t, tt.expected == codes.OK, called)
+		})
+	}
+}
+
+func bearer(t *testing.T, s *testServer, role string) string {
+	var roles []string
+	if role != "" {
+		roles = []string{role}
+	}
+	token, err := s.authService.NewAccessToken(1, "", "", "", "", roles, nil)
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestAuthInterceptor_UserSuspended(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.EnforceUserStatus = true
+	s := setupServer(t, cfg)
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("CheckUserStatus", 1).Return(apperror.New(apperror.CodeUserSuspended, "user suspended | id: 1"))
+
+	// Act
+	_, err := client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{})
+
+	// Assert
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, apperror.CodeUserSuspended, errorInfo(t, err).GetReason())
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestAuditInterceptor_RecordsEvent(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("SetUserRoles", 2, []string{"ops"}).Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.SetUserRoles(s.withToken(t, 1, "admin"), &identityv1.SetUserRolesRequest{Id: 2, Roles: []string{"ops"}})
+
+	// Assert
+	require.NoError(t, err)
+	s.mockAuditService.AssertNumberOfCalls(t, "Record", 1)
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	assert.Equal(t, audit.EventUserRolesChange, event.Type)
+	assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, 1, *event.ActorID)
+	require.NotNil(t, event.TargetID)
+	assert.Equal(t, 2, *event.TargetID)
+	assert.Equal(t, map[string]any{"roles": []string{"ops"}}, event.Metadata)
+	assert.Contains(t, event.UserAgent, "grpc-go")
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestAuditInterceptor_RecordsClient(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("DeleteUser", 2, false).Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.DeleteUser(s.withClientToken(t, "svc_batch", "users:write"), &identityv1.DeleteUserRequest{Id: 2})
+
+	// Assert
+	require.NoError(t, err)
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	assert.Equal(t, audit.EventUserDelete, event.Type)
+	assert.Nil(t, event.ActorID)
+	assert.Equal(t, "svc_batch", event.Metadata["actor_client_id"])
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestAuditInterceptor_RecordsPersonalAccessToken(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("DeleteUser", 2, false).Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.DeleteUser(s.withPersonalAccessToken(1, 7, "users:write"), &identityv1.DeleteUserRequest{Id: 2})
+
+	// Assert
+	require.NoError(t, err)
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, 1, *event.ActorID)
+	assert.Equal(t, 7, event.Metadata["actor_token_id"])
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestAuditInterceptor_RecordsFailure(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("RestoreUser", 2, false).Return(apperror.New(apperror.CodeUserNotFound, "user not found | id: 2"))
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.RestoreUser(s.withToken(t, 1), &identityv1.RestoreUserRequest{Id: 2})
+
+	// Assert
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	event := s.mockAuditService.Calls[0].Arguments.Get(0).(*audit.Event)
+	assert.Equal(t, audit.EventUserRestore, event.Type)
+	assert.Equal(t, audit.OutcomeFailure, event.Outcome)
+	require.NotNil(t, event.Reason)
+	assert.Equal(t, apperror.CodeUserNotFound, *event.Reason)
+}
+
+func TestAuditInterceptor_NotAudited(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("GetUsers").Return(nil, nil)
+
+	// Act
+	_, err := client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{})
+
+	// Assert
+	require.NoError(t, err)
+	s.mockAuditService.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestRateLimitInterceptor_VerifyTokenByPeer(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.RateLimits = map[string][]config.RateLimit{ratelimit.PolicyVerify: {{Key: ratelimit.KeyIP, Requests: 1, Period: time.Minute}}}
+	s := setupServer(t, cfg)
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	_, err := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: "invalid"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Act
+	var header metadata.MD
+	_, err = client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: "invalid"}, grpc.Header(&header))
+
+	// Assert
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, apperror.CodeRateLimited, errorInfo(t, err).GetReason())
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+}
+
+func TestRateLimitInterceptor_VerifyTokenByUser(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.RateLimits = map[string][]config.RateLimit{ratelimit.PolicyVerify: {{Key: ratelimit.KeyUser, Requests: 1, Period: time.Minute}}}
+	s := setupServer(t, cfg)
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	token, err := s.authService.NewAccessToken(1, "", "", "user@example.com", "", nil, nil)
+	require.NoError(t, err)
+	other, err := s.authService.NewAccessToken(2, "", "", "other@example.com", "", nil, nil)
+	require.NoError(t, err)
+
+	_, err = client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token})
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: token})
+	_, otherErr := client.VerifyToken(context.Background(), &identityv1.VerifyTokenRequest{AccessToken: other})
+
+	// Assert
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, apperror.CodeRateLimited, errorInfo(t, err).GetReason())
+	assert.NoError(t, otherErr)
+}
+
+func TestRateLimitInterceptor_RefreshTokenByUser(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.RateLimits = map[string][]config.RateLimit{ratelimit.PolicyRefresh: {{Key: ratelimit.KeyUser, Requests: 1, Period: time.Minute}}}
+	s := setupServer(t, cfg)
+	client := identityv1.NewAuthServiceClient(s.conn)
+
+	name, picture := "User", ""
+	refreshToken, err := s.authService.NewRefreshToken(1, "session-1")
+	require.NoError(t, err)
+	s.mockUserService.On("GetUserByID", 1).Return(&user.User{ID: 1, Name: &name, Picture: &picture, Email: "user@example.com", Status: user.StatusActive}, nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	_, err = client.RefreshToken(context.Background(), &identityv1.RefreshTokenRequest{RefreshToken: refreshToken})
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.RefreshToken(context.Background(), &identityv1.RefreshTokenRequest{RefreshToken: refreshToken})
+
+	// Assert
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, apperror.CodeRateLimited, errorInfo(t, err).GetReason())
+	s.mockUserService.AssertNumberOfCalls(t, "GetUserByID", 1)
+}
+
+func TestRateLimitInterceptor_NotLimited(t *testing.T) {
+	// Arrange
+	cfg := auth.NewMockConfig("")
+	cfg.RateLimits = map[string][]config.RateLimit{ratelimit.PolicyVerify: {{Key: ratelimit.KeyIP, Requests: 1, Period: time.Minute}}}
+	s := setupServer(t, cfg)
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("GetUsers").Return(nil, nil)
+
+	_, err := client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{})
+	require.NoError(t, err)
+
+	// Act
+	_, err = client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+}
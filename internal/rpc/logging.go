@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/requestid"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type LoggingInterceptor Interceptor
+
+// NewLoggingInterceptor logs calls and turns their errors into gRPC statuses,
+// like HTTPMiddleware does for HTTP. Every call gets a request ID, taken from
+// the x-request-id or traceparent metadata of the caller if present, which is
+// sent back in the x-request-id header and added to errors and to the
+// call-scoped logger.
+func NewLoggingInterceptor(baseLogger *zap.Logger) LoggingInterceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		start := time.Now()
+		md, _ := metadata.FromIncomingContext(ctx)
+		requestID := requestid.Resolve(firstValue(md, requestid.Header), firstValue(md, requestid.TraceparentHeader))
+		requestLogger := baseLogger.With(zap.String("request_id", requestID))
+
+		ctx = logger.NewContext(requestid.NewContext(ctx, requestID), requestLogger)
+		grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, requestID))
+		requestLogger.Info("rpc started", zap.String("method", method))
+
+		err := call(ctx)
+
+		// statuses returned as such, e.g. by ext_authz, are passed through
+		logged := err
+		if _, ok := status.FromError(err); !ok {
+			appErr := apperror.FromError(err)
+			appErr.RequestID = requestID
+			logged = appErr
+			err = Status(appErr)
+		}
+
+		requestLogger.Info("rpc completed",
+			zap.String("method", method),
+			zap.String("code", status.Code(err).String()),
+			zap.Int64("duration_ms", time.Since(start).Milliseconds()),
+			zap.Error(logged),
+		)
+		return err
+	}
+}
+
+// firstValue returns the first value of a metadata key, which is matched
+// case-insensitively.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
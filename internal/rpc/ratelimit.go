@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/ratelimit"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// methodPolicies maps the methods that are rate limited to the policies of
+// the matching HTTP endpoints, so that a client cannot get around a limit by
+// switching APIs.
+var methodPolicies = map[string]string{
+	identityv1.AuthService_VerifyToken_FullMethodName:  ratelimit.PolicyVerify,
+	identityv1.AuthService_RefreshToken_FullMethodName: ratelimit.PolicyRefresh,
+}
+
+// rateLimiter takes the limits of the policy of one call: those keyed by the
+// peer address before the call, and those keyed by user once the handler
+// knows who the token belongs to.
+type rateLimiter struct {
+	store  ratelimit.Store
+	policy string
+	limits []config.RateLimit
+}
+
+type rateLimiterKey struct{}
+
+func (l *rateLimiter) take(ctx context.Context, key, id string) error {
+	if id == "" {
+		return nil
+	}
+	for _, limit := range l.limits {
+		if limit.Key != key {
+			continue
+		}
+		result, err := l.store.Take(ctx, l.policy+":"+limit.Key+":"+id, limit)
+		if err != nil {
+			// an unavailable store must not lock everyone out
+			logger.FromContext(ctx).Warn("rate limit store failed, allowing call", zap.String("policy", l.policy), zap.Error(err))
+			continue
+		}
+		if !result.Allowed {
+			// like the Retry-After header of the HTTP API
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))))
+			return apperror.New(apperror.CodeRateLimited, "too many requests").WithInternal("policy: " + l.policy + " | key: " + limit.Key)
+		}
+	}
+	return nil
+}
+
+// limitUser takes the user limits of the call once its handler has verified
+// the token. Calls that are not rate limited pass.
+func limitUser(ctx context.Context, identity *middleware.Identity) error {
+	l, ok := ctx.Value(rateLimiterKey{}).(*rateLimiter)
+	if !ok {
+		return nil
+	}
+	return l.take(ctx, ratelimit.KeyUser, ratelimit.UserKeyValue(identity.UserID, identity.ClientID))
+}
+
+type RateLimitInterceptor Interceptor
+
+// NewRateLimitInterceptor limits VerifyToken and RefreshToken by the limits
+// that RATE_LIMITS configures for /auth/verify and /auth/refresh, sharing
+// their buckets. The gRPC API has no proxy in front of it, so ip limits count
+// the peer address.
+func NewRateLimitInterceptor(config *config.Config, store ratelimit.Store) RateLimitInterceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		policy, ok := methodPolicies[method]
+		if !ok {
+			return call(ctx)
+		}
+
+		l := &rateLimiter{store: store, policy: policy, limits: config.RateLimits[policy]}
+		if err := l.take(ctx, ratelimit.KeyIP, peerIP(ctx)); err != nil {
+			return err
+		}
+		return call(context.WithValue(ctx, rateLimiterKey{}, l))
+	}
+}
+
+// peerIP returns the address the call came from, without its port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details of errors, whose reason
+// is the error code of the HTTP API, e.g. 404_01_001.
+const ErrorDomain = "identity.vera.sninjo.com"
+
+// Status converts an application error to a gRPC status error with the same
+// message. The error code and request ID go in an ErrorInfo detail, and the
+// field errors of validation failures in a BadRequest detail.
+func Status(appErr *apperror.AppError) error {
+	s := status.New(code(appErr.Status), appErr.Message)
+
+	info := &errdetails.ErrorInfo{Reason: appErr.Code, Domain: ErrorDomain}
+	if appErr.RequestID != "" {
+		info.Metadata = map[string]string{"request_id": appErr.RequestID}
+	}
+	details := []protoadapt.MessageV1{info}
+	if len(appErr.Errors) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range appErr.Errors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	if withDetails, err := s.WithDetails(details...); err == nil {
+		s = withDetails
+	}
+	return s.Err()
+}
+
+// code maps the HTTP status of an error to the closest gRPC code.
+func code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if httpStatus >= 400 && httpStatus < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
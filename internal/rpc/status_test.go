@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/user"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatus_Codes(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected codes.Code
+	}{
+		{apperror.CodeValidationFailed, codes.InvalidArgument},
+		{apperror.CodeInvalidAccessToken, codes.Unauthenticated},
+		{apperror.CodePermissionDenied, codes.PermissionDenied},
+		{apperror.CodeUserNotFound, codes.NotFound},
+		{apperror.CodeUserEmailInUse, codes.AlreadyExists},
+		{apperror.CodeLoginLocked, codes.FailedPrecondition},
+		{apperror.CodeRateLimited, codes.ResourceExhausted},
+		{apperror.CodeInternalError, codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			// Act
+			err := Status(apperror.New(tt.code, "message"))
+
+			// Assert
+			assert.Equal(t, tt.expected, status.Code(err))
+			assert.Equal(t, "message", status.Convert(err).Message())
+		})
+	}
+}
+
+func TestStatus_FieldViolations(t *testing.T) {
+	// Arrange
+	bindErr := binding.Validator.ValidateStruct(&user.RequestBody{Email: "not-an-email"})
+	require.Error(t, bindErr)
+	appErr := apperror.NewBindError("message", bindErr)
+
+	// Act
+	err := Status(appErr)
+
+	// Assert
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = d
+		}
+	}
+	require.NotNil(t, badRequest)
+	require.Len(t, badRequest.GetFieldViolations(), 1)
+	assert.Equal(t, "email", badRequest.GetFieldViolations()[0].GetField())
+}
@@ -0,0 +1,286 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/role"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserServer serves the user operations of the /users HTTP endpoints, with the
+// same validation.
+type UserServer struct {
+	identityv1.UnimplementedUserServiceServer
+	service user.Service
+}
+
+func NewUserServer(service user.Service) *UserServer {
+	return &UserServer{service: service}
+}
+
+// validate checks a request with the binding rules of the HTTP request types.
+func validate(request any) error {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return apperror.NewBindError("message", err)
+	}
+	return nil
+}
+
+func validateID(id int64) error {
+	return validate(&user.RequestURI{ID: int(id)})
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *identityv1.ListUsersRequest) (*identityv1.ListUsersResponse, error) {
+	var users []user.User
+	var err error
+	if req.GetDeleted() {
+		users, err = s.service.WithContext(ctx).GetDeletedUsers()
+	} else {
+		users, err = s.service.WithContext(ctx).GetUsers()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &identityv1.ListUsersResponse{Users: make([]*identityv1.User, len(users))}
+	for i := range users {
+		if response.Users[i], err = newUser(&users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *identityv1.GetUserRequest) (*identityv1.User, error) {
+	var u *user.User
+	var lookup string
+	var err error
+	switch by := req.GetLookup().(type) {
+	case *identityv1.GetUserRequest_Id:
+		if err := validateID(by.Id); err != nil {
+			return nil, err
+		}
+		lookup = "id: " + strconv.FormatInt(by.Id, 10)
+		u, err = s.service.WithContext(ctx).GetUserByID(int(by.Id))
+	case *identityv1.GetUserRequest_Email:
+		if err := validate(&user.RequestBody{Email: by.Email}); err != nil {
+			return nil, err
+		}
+		lookup = "email: " + by.Email
+		u, err = s.service.WithContext(ctx).GetUserByEmail(by.Email)
+	default:
+		return nil, apperror.New(apperror.CodeInvalidRequest, "invalid request message | id or email required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, apperror.New(apperror.CodeUserNotFound, "user not found | "+lookup)
+	}
+	return newUser(u)
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *identityv1.CreateUserRequest) (*emptypb.Empty, error) {
+	if err := validate(&user.RequestBody{Email: req.GetEmail()}); err != nil {
+		return nil, err
+	}
+	setAuditEmail(ctx, req.GetEmail())
+
+	if err := s.service.WithContext(ctx).CreateUser(req.GetEmail()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *identityv1.UpdateUserRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	body := &user.UpdateRequestBody{Email: req.GetEmail()}
+	if req.Attributes != nil {
+		body.Attributes = req.GetAttributes().AsMap()
+	}
+	if err := validate(body); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.WithContext(ctx).UpdateUser(int(req.GetId()), body.Email, body.Attributes, allowPrivileged(ctx, int(req.GetId()))); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *identityv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	setAuditMetadata(ctx, "hard", req.GetHard())
+
+	var err error
+	if req.GetHard() {
+		if !hasRole(ctx, role.Admin) {
+			return nil, apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+role.Admin)
+		}
+		err = s.service.WithContext(ctx).HardDeleteUser(int(req.GetId()))
+	} else {
+		err = s.service.WithContext(ctx).DeleteUser(int(req.GetId()), allowPrivileged(ctx, int(req.GetId())))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) RestoreUser(ctx context.Context, req *identityv1.RestoreUserRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.WithContext(ctx).RestoreUser(int(req.GetId()), allowPrivileged(ctx, int(req.GetId()))); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) SetUserRoles(ctx context.Context, req *identityv1.SetUserRolesRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	// an empty list removes every role, which JSON sends as []
+	roles := req.GetRoles()
+	if roles == nil {
+		roles = []string{}
+	}
+	if err := validate(&user.RolesRequestBody{Roles: roles}); err != nil {
+		return nil, err
+	}
+	setAuditMetadata(ctx, "roles", roles)
+
+	if err := s.service.WithContext(ctx).SetUserRoles(int(req.GetId()), roles); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) SuspendUser(ctx context.Context, req *identityv1.SuspendUserRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	body := &user.SuspendRequestBody{Reason: req.Reason}
+	if req.Until != nil {
+		until := req.GetUntil().AsTime()
+		body.Until = &until
+	}
+	if err := validate(body); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.WithContext(ctx).SuspendUser(int(req.GetId()), body.Reason, body.Until); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) ReactivateUser(ctx context.Context, req *identityv1.ReactivateUserRequest) (*emptypb.Empty, error) {
+	setAuditTarget(ctx, int(req.GetId()))
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.WithContext(ctx).ReactivateUser(int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) ImportUsers(ctx context.Context, req *identityv1.ImportUsersRequest) (*identityv1.ImportUsersResponse, error) {
+	entries := make([]user.ImportEntry, len(req.GetEntries()))
+	for i, entry := range req.GetEntries() {
+		entries[i] = user.ImportEntry{Line: i + 1, Email: entry.GetEmail(), Name: entry.Name, Roles: entry.GetRoles(), Status: user.Status(entry.GetStatus())}
+	}
+
+	results, err := s.service.WithContext(ctx).ImportUsers(entries, req.GetDryRun(), hasRole(ctx, role.Admin))
+	if err != nil {
+		return nil, err
+	}
+
+	summary := user.SummarizeImport(results)
+	response := &identityv1.ImportUsersResponse{
+		DryRun:  req.GetDryRun(),
+		Total:   int32(len(results)),
+		Created: int32(summary.Created),
+		Valid:   int32(summary.Valid),
+		Skipped: int32(summary.Skipped),
+		Invalid: int32(summary.Invalid),
+		Rows:    make([]*identityv1.ImportUsersResponse_Row, len(results)),
+	}
+	for i, result := range results {
+		response.Rows[i] = &identityv1.ImportUsersResponse_Row{
+			Line:   int32(result.Line),
+			Email:  result.Email,
+			Status: string(result.Status),
+			Reason: result.Reason,
+		}
+	}
+	setAuditMetadata(ctx, "dry_run", response.DryRun)
+	setAuditMetadata(ctx, "created", response.Created)
+	return response, nil
+}
+
+func (s *UserServer) ExportUsers(req *identityv1.ExportUsersRequest, stream grpc.ServerStreamingServer[identityv1.User]) error {
+	return s.service.WithContext(stream.Context()).ExportUsers(func(users []user.User) error {
+		for i := range users {
+			u, err := newUser(&users[i])
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(u); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func newUser(u *user.User) (*identityv1.User, error) {
+	attributes, err := structpb.NewStruct(u.Attributes)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeInternalError, "internal server error").WithInternal("user attributes | id: " + strconv.Itoa(u.ID) + " | error: " + err.Error())
+	}
+	timestamp := func(t *time.Time) *timestamppb.Timestamp {
+		if t == nil {
+			return nil
+		}
+		return timestamppb.New(*t)
+	}
+	return &identityv1.User{
+		Id:             int64(u.ID),
+		Name:           u.Name,
+		Email:          u.Email,
+		Picture:        u.Picture,
+		Roles:          u.Roles,
+		Attributes:     attributes,
+		Status:         string(u.Status),
+		StatusReason:   u.StatusReason,
+		SuspendedUntil: timestamp(u.SuspendedUntil),
+		LastLoginAt:    timestamp(u.LastLoginAt),
+		CreatedAt:      timestamppb.New(u.CreatedAt),
+		UpdatedAt:      timestamppb.New(u.UpdatedAt),
+		DeletedAt:      timestamp(u.DeletedAt),
+	}, nil
+}
@@ -0,0 +1,332 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/user"
+	identityv1 "github.com/sninjo/vera-identity-service/pkg/identity/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestUserServer_ListUsers_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	name := "User"
+	lastLoginAt := time.Unix(60, 0)
+	users := []user.User{
+		{ID: 1, Name: &name, Email: "user@example.com", Roles: []string{"admin"}, Attributes: map[string]any{"team": "core"}, Status: user.StatusActive, LastLoginAt: &lastLoginAt, CreatedAt: time.Unix(0, 0), UpdatedAt: time.Unix(30, 0)},
+	}
+	s.mockUserService.On("GetUsers").Return(users, nil)
+
+	// Act
+	response, err := client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, response.GetUsers(), 1)
+	actual := response.GetUsers()[0]
+	assert.Equal(t, int64(1), actual.GetId())
+	assert.Equal(t, "User", actual.GetName())
+	assert.Equal(t, "user@example.com", actual.GetEmail())
+	assert.False(t, actual.Picture != nil)
+	assert.Equal(t, []string{"admin"}, actual.GetRoles())
+	assert.Equal(t, map[string]any{"team": "core"}, actual.GetAttributes().AsMap())
+	assert.Equal(t, "active", actual.GetStatus())
+	assert.Equal(t, int64(60), actual.GetLastLoginAt().GetSeconds())
+	assert.Nil(t, actual.GetSuspendedUntil())
+	assert.Nil(t, actual.GetDeletedAt())
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_ListUsers_Deleted(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("GetDeletedUsers").Return([]user.User{}, nil)
+
+	// Act
+	response, err := client.ListUsers(s.withToken(t, 1), &identityv1.ListUsersRequest{Deleted: true})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, response.GetUsers())
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_GetUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *identityv1.GetUserRequest
+		setup    func(m *auth.MockUserService)
+		expected codes.Code
+	}{
+		{
+			name:    "by id",
+			request: &identityv1.GetUserRequest{Lookup: &identityv1.GetUserRequest_Id{Id: 2}},
+			setup: func(m *auth.MockUserService) {
+				m.On("GetUserByID", 2).Return(&user.User{ID: 2, Email: "user@example.com"}, nil)
+			},
+			expected: codes.OK,
+		},
+		{
+			name:    "by email",
+			request: &identityv1.GetUserRequest{Lookup: &identityv1.GetUserRequest_Email{Email: "user@example.com"}},
+			setup: func(m *auth.MockUserService) {
+				m.On("GetUserByEmail", "user@example.com").Return(&user.User{ID: 2, Email: "user@example.com"}, nil)
+			},
+			expected: codes.OK,
+		},
+		{
+			name:    "not found",
+			request: &identityv1.GetUserRequest{Lookup: &identityv1.GetUserRequest_Id{Id: 2}},
+			setup: func(m *auth.MockUserService) {
+				m.On("GetUserByID", 2).Return(nil, nil)
+			},
+			expected: codes.NotFound,
+		},
+		{
+			name:     "invalid id",
+			request:  &identityv1.GetUserRequest{Lookup: &identityv1.GetUserRequest_Id{Id: 0}},
+			setup:    func(m *auth.MockUserService) {},
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:     "no lookup",
+			request:  &identityv1.GetUserRequest{},
+			setup:    func(m *auth.MockUserService) {},
+			expected: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			s := setupServer(t, auth.NewMockConfig(""))
+			client := identityv1.NewUserServiceClient(s.conn)
+			tt.setup(s.mockUserService)
+
+			// Act
+			actual, err := client.GetUser(s.withToken(t, 1), tt.request)
+
+			// Assert
+			assert.Equal(t, tt.expected, status.Code(err))
+			if tt.expected == codes.OK {
+				assert.Equal(t, int64(2), actual.GetId())
+			}
+			s.mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServer_CreateUser_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("CreateUser", "user@example.com").Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.CreateUser(s.withToken(t, 1), &identityv1.CreateUserRequest{Email: "user@example.com"})
+
+	// Assert
+	require.NoError(t, err)
+	s.mockUserService.AssertExpectations(t)
+	s.mockAuditService.AssertExpectations(t)
+}
+
+func TestUserServer_CreateUser_InvalidEmail(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.CreateUser(s.withToken(t, 1), &identityv1.CreateUserRequest{Email: "not-an-email"})
+
+	// Assert
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, apperror.CodeValidationFailed, errorInfo(t, err).GetReason())
+	s.mockUserService.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestUserServer_UpdateUser_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	attributes, err := structpb.NewStruct(map[string]any{"team": "core"})
+	require.NoError(t, err)
+	s.mockUserService.On("UpdateUser", 2, "", map[string]any{"team": "core"}, false).Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err = client.UpdateUser(s.withToken(t, 1), &identityv1.UpdateUserRequest{Id: 2, Attributes: attributes})
+
+	// Assert
+	require.NoError(t, err)
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_UpdateUser_NothingToUpdate(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.UpdateUser(s.withToken(t, 1), &identityv1.UpdateUserRequest{Id: 2})
+
+	// Assert
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	s.mockUserService.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserServer_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		hard     bool
+		roles    []string
+		method   string
+		args     []any
+		expected codes.Code
+	}{
+		{"soft", false, nil, "DeleteUser", []any{2, false}, codes.OK},
+		{"soft as admin", false, []string{"admin"}, "DeleteUser", []any{2, true}, codes.OK},
+		{"hard as admin", true, []string{"admin"}, "HardDeleteUser", []any{2}, codes.OK},
+		{"hard without role", true, nil, "", nil, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			s := setupServer(t, auth.NewMockConfig(""))
+			client := identityv1.NewUserServiceClient(s.conn)
+
+			if tt.method != "" {
+				s.mockUserService.On(tt.method, tt.args...).Return(nil)
+			}
+			s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+			// Act
+			_, err := client.DeleteUser(s.withToken(t, 1, tt.roles...), &identityv1.DeleteUserRequest{Id: 2, Hard: tt.hard})
+
+			// Assert
+			assert.Equal(t, tt.expected, status.Code(err))
+			s.mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServer_SuspendUser_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	reason := "on leave"
+	until := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	s.mockUserService.On("SuspendUser", 2, &reason, &until).Return(nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	_, err := client.SuspendUser(s.withToken(t, 1, "admin"), &identityv1.SuspendUserRequest{Id: 2, Reason: &reason, Until: timestamppb.New(until)})
+
+	// Assert
+	require.NoError(t, err)
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_ImportUsers_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	entries := []user.ImportEntry{
+		{Line: 1, Email: "new@example.com", Roles: []string{"ops"}, Status: user.StatusPending},
+		{Line: 2, Email: "user@example.com"},
+	}
+	results := []user.ImportResult{
+		{Line: 1, Email: "new@example.com", Status: user.ImportStatusValid},
+		{Line: 2, Email: "user@example.com", Status: user.ImportStatusSkipped, Reason: "email in use"},
+	}
+	s.mockUserService.On("ImportUsers", entries, true, false).Return(results, nil)
+	s.mockAuditService.On("Record", mock.Anything).Return(nil)
+
+	// Act
+	response, err := client.ImportUsers(s.withToken(t, 1), &identityv1.ImportUsersRequest{
+		Entries: []*identityv1.ImportUsersRequest_Entry{
+			{Email: "new@example.com", Roles: []string{"ops"}, Status: "pending"},
+			{Email: "user@example.com"},
+		},
+		DryRun: true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.GetDryRun())
+	assert.Equal(t, int32(2), response.GetTotal())
+	assert.Equal(t, int32(1), response.GetValid())
+	assert.Equal(t, int32(1), response.GetSkipped())
+	require.Len(t, response.GetRows(), 2)
+	assert.Equal(t, "email in use", response.GetRows()[1].GetReason())
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_ExportUsers_Success(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	s.mockUserService.On("ExportUsers", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		fn := args.Get(0).(func(users []user.User) error)
+		require.NoError(t, fn([]user.User{{ID: 1, Email: "a@example.com"}, {ID: 2, Email: "b@example.com"}}))
+		require.NoError(t, fn([]user.User{{ID: 3, Email: "c@example.com"}}))
+	})
+
+	// Act
+	stream, err := client.ExportUsers(s.withToken(t, 1), &identityv1.ExportUsersRequest{})
+	require.NoError(t, err)
+	var ids []int64
+	for {
+		u, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, u.GetId())
+	}
+
+	// Assert
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	s.mockUserService.AssertExpectations(t)
+}
+
+func TestUserServer_ExportUsers_Unauthenticated(t *testing.T) {
+	// Arrange
+	s := setupServer(t, auth.NewMockConfig(""))
+	client := identityv1.NewUserServiceClient(s.conn)
+
+	// Act
+	stream, err := client.ExportUsers(context.Background(), &identityv1.ExportUsersRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+
+	// Assert
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	s.mockUserService.AssertNotCalled(t, "ExportUsers", mock.Anything)
+}
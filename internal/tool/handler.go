@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -21,7 +23,7 @@ func (h *Handler) getUnixTimestampHandler(c *gin.Context) {
 func (h *Handler) generateJWTHandler(c *gin.Context) {
 	var req GenerateJWTRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		c.Error(apperror.NewBindError("body", err))
 		return
 	}
 
@@ -34,7 +36,7 @@ func (h *Handler) generateJWTHandler(c *gin.Context) {
 	}
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, req.Claims).SignedString([]byte(req.Secret))
 	if err != nil {
-		c.String(http.StatusInternalServerError, err.Error())
+		c.Error(err)
 		return
 	}
 	c.String(http.StatusOK, token)
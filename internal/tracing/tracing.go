@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap"
+)
+
+const serviceName = "vera-identity-service"
+
+// Propagator reads and writes the W3C traceparent header, so that spans of
+// this service join the traces of the gateway and of the services it calls.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// NewTracerProvider exports spans with the exporter chosen by
+// TRACING_EXPORTER. With "none" spans are still created, which keeps trace IDs
+// in the logs, but they are dropped.
+func NewTracerProvider(config *config.Config, logger *zap.Logger) (*sdktrace.TracerProvider, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	}
+
+	switch config.TracingExporter {
+	case "otlp":
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	logger.Info("tracing configured", zap.String("exporter", config.TracingExporter))
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// Transport passes the span of the request context on to outbound requests.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	Propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.Base.RoundTrip(req)
+}
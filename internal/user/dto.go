@@ -10,14 +10,50 @@ type RequestBody struct {
 	Email string `json:"email" binding:"email,max=255"`
 }
 
+type RolesRequestBody struct {
+	Roles []string `json:"roles" binding:"required,dive,max=64"`
+}
+
+type SuspendRequestBody struct {
+	Reason *string    `json:"reason" binding:"omitempty,max=255"`
+	Until  *time.Time `json:"until"`
+}
+
+type UpdateRequestBody struct {
+	Email      string         `json:"email" binding:"required_without=Attributes,omitempty,email,max=255"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+type GetUsersQuery struct {
+	Deleted bool `form:"deleted"`
+}
+
+type DeleteQuery struct {
+	Hard bool `form:"hard"`
+}
+
+type ImportQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type ExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+}
+
 type UserResponse struct {
-	ID          int     `json:"id"`
-	Name        *string `json:"name"`
-	Email       string  `json:"email"`
-	Picture     *string `json:"picture"`
-	LastLoginAt *string `json:"last_login_at"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID             int            `json:"id"`
+	Name           *string        `json:"name"`
+	Email          string         `json:"email"`
+	Picture        *string        `json:"picture"`
+	Roles          []string       `json:"roles"`
+	Attributes     map[string]any `json:"attributes"`
+	Status         string         `json:"status"`
+	StatusReason   *string        `json:"status_reason"`
+	SuspendedUntil *string        `json:"suspended_until"`
+	LastLoginAt    *string        `json:"last_login_at"`
+	CreatedAt      string         `json:"created_at"`
+	UpdatedAt      string         `json:"updated_at"`
+	DeletedAt      *string        `json:"deleted_at,omitempty"`
 }
 
 func newUserResponse(u *User) *UserResponse {
@@ -26,13 +62,96 @@ func newUserResponse(u *User) *UserResponse {
 		t := u.LastLoginAt.Format(time.RFC3339)
 		lastLoginAt = &t
 	}
+	var suspendedUntil *string
+	if u.SuspendedUntil != nil {
+		t := u.SuspendedUntil.Format(time.RFC3339)
+		suspendedUntil = &t
+	}
+	var deletedAt *string
+	if u.DeletedAt != nil {
+		t := u.DeletedAt.Format(time.RFC3339)
+		deletedAt = &t
+	}
 	return &UserResponse{
-		ID:          u.ID,
-		Name:        u.Name,
-		Email:       u.Email,
-		Picture:     u.Picture,
-		LastLoginAt: lastLoginAt,
-		CreatedAt:   u.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   u.UpdatedAt.Format(time.RFC3339),
+		ID:             u.ID,
+		Name:           u.Name,
+		Email:          u.Email,
+		Picture:        u.Picture,
+		Roles:          u.Roles,
+		Attributes:     u.Attributes,
+		Status:         string(u.Status),
+		StatusReason:   u.StatusReason,
+		SuspendedUntil: suspendedUntil,
+		LastLoginAt:    lastLoginAt,
+		CreatedAt:      u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      u.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:      deletedAt,
+	}
+}
+
+// EventData is the user payload of webhook events.
+type EventData struct {
+	ID             int      `json:"id"`
+	Email          string   `json:"email"`
+	Name           *string  `json:"name"`
+	Roles          []string `json:"roles"`
+	Status         string   `json:"status"`
+	StatusReason   *string  `json:"status_reason,omitempty"`
+	SuspendedUntil *string  `json:"suspended_until,omitempty"`
+}
+
+func (u *User) EventData() *EventData {
+	var suspendedUntil *string
+	if u.SuspendedUntil != nil {
+		t := u.SuspendedUntil.Format(time.RFC3339)
+		suspendedUntil = &t
+	}
+	return &EventData{
+		ID:             u.ID,
+		Email:          u.Email,
+		Name:           u.Name,
+		Roles:          u.Roles,
+		Status:         string(u.Status),
+		StatusReason:   u.StatusReason,
+		SuspendedUntil: suspendedUntil,
+	}
+}
+
+type ImportRowResponse struct {
+	Line   int    `json:"line"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type ImportReportResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Total   int                 `json:"total"`
+	Created int                 `json:"created"`
+	Valid   int                 `json:"valid"`
+	Skipped int                 `json:"skipped"`
+	Invalid int                 `json:"invalid"`
+	Rows    []ImportRowResponse `json:"rows"`
+}
+
+func newImportReportResponse(dryRun bool, results []ImportResult) *ImportReportResponse {
+	summary := SummarizeImport(results)
+	report := &ImportReportResponse{
+		DryRun:  dryRun,
+		Total:   len(results),
+		Created: summary.Created,
+		Valid:   summary.Valid,
+		Skipped: summary.Skipped,
+		Invalid: summary.Invalid,
+		Rows:    make([]ImportRowResponse, len(results)),
+	}
+	for i, result := range results {
+		report.Rows[i] = ImportRowResponse{
+			Line:   result.Line,
+			Email:  result.Email,
+			Status: string(result.Status),
+			Reason: result.Reason,
+		}
 	}
+	return report
 }
@@ -3,9 +3,18 @@ package user
 import (
 	"net/http"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/audit"
+	"github.com/sninjo/vera-identity-service/internal/logger"
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+const maxImportSize = 10 << 20
+
 type Handler struct {
 	service Service
 }
@@ -15,7 +24,19 @@ func NewHandler(service Service) *Handler {
 }
 
 func (h *Handler) GetUsers(c *gin.Context) {
-	users, err := h.service.GetUsers()
+	var query GetUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	var users []User
+	var err error
+	if query.Deleted {
+		users, err = h.service.WithContext(c.Request.Context()).GetDeletedUsers()
+	} else {
+		users, err = h.service.WithContext(c.Request.Context()).GetUsers()
+	}
 	if err != nil {
 		c.Error(err)
 		return
@@ -32,11 +53,12 @@ func (h *Handler) GetUsers(c *gin.Context) {
 func (h *Handler) CreateUser(c *gin.Context) {
 	var req RequestBody
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body | " + err.Error()})
+		c.Error(apperror.NewBindError("body", err))
 		return
 	}
+	audit.SetEmail(c, req.Email)
 
-	err := h.service.CreateUser(req.Email)
+	err := h.service.WithContext(c.Request.Context()).CreateUser(req.Email)
 	if err != nil {
 		c.Error(err)
 		return
@@ -48,16 +70,16 @@ func (h *Handler) CreateUser(c *gin.Context) {
 func (h *Handler) UpdateUser(c *gin.Context) {
 	var uri RequestURI
 	if err := c.ShouldBindUri(&uri); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request uri | " + err.Error()})
+		c.Error(apperror.NewBindError("uri", err))
 		return
 	}
-	var body RequestBody
+	var body UpdateRequestBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body | " + err.Error()})
+		c.Error(apperror.NewBindError("body", err))
 		return
 	}
 
-	err := h.service.UpdateUser(uri.ID, body.Email)
+	err := h.service.WithContext(c.Request.Context()).UpdateUser(uri.ID, body.Email, body.Attributes, middleware.AllowPrivileged(c, uri.ID))
 	if err != nil {
 		c.Error(err)
 		return
@@ -69,11 +91,43 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 func (h *Handler) DeleteUser(c *gin.Context) {
 	var req RequestURI
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request uri | " + err.Error()})
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var query DeleteQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	audit.SetMetadata(c, "hard", query.Hard)
+
+	var err error
+	if query.Hard {
+		if !middleware.HasRole(c, role.Admin) {
+			c.Error(apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+role.Admin))
+			return
+		}
+		err = h.service.WithContext(c.Request.Context()).HardDeleteUser(req.ID)
+	} else {
+		err = h.service.WithContext(c.Request.Context()).DeleteUser(req.ID, middleware.AllowPrivileged(c, req.ID))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) RestoreUser(c *gin.Context) {
+	var req RequestURI
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
 		return
 	}
 
-	err := h.service.DeleteUser(req.ID)
+	err := h.service.WithContext(c.Request.Context()).RestoreUser(req.ID, middleware.AllowPrivileged(c, req.ID))
 	if err != nil {
 		c.Error(err)
 		return
@@ -81,3 +135,149 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+func (h *Handler) SetUserRoles(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var body RolesRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+	audit.SetMetadata(c, "roles", body.Roles)
+
+	err := h.service.WithContext(c.Request.Context()).SetUserRoles(uri.ID, body.Roles)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) SuspendUser(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var body SuspendRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	err := h.service.WithContext(c.Request.Context()).SuspendUser(uri.ID, body.Reason, body.Until)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ReactivateUser(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	err := h.service.WithContext(c.Request.Context()).ReactivateUser(uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ImportUsers(c *gin.Context) {
+	var query ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	entries, err := decodeImport(c.ContentType(), body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.service.WithContext(c.Request.Context()).ImportUsers(entries, query.DryRun, middleware.HasRole(c, role.Admin))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report := newImportReportResponse(query.DryRun, results)
+	audit.SetMetadata(c, "dry_run", report.DryRun)
+	audit.SetMetadata(c, "created", report.Created)
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) ExportUsers(c *gin.Context) {
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		if c.NegotiateFormat(MIMETypeCSV, MIMETypeNDJSON) == MIMETypeNDJSON {
+			format = FormatNDJSON
+		} else {
+			format = FormatCSV
+		}
+	}
+
+	contentType := MIMETypeCSV
+	if format == FormatNDJSON {
+		contentType = MIMETypeNDJSON
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="users.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	encoder := newExportEncoder(format, c.Writer)
+	err := h.service.WithContext(c.Request.Context()).ExportUsers(func(users []User) error {
+		for i := range users {
+			if err := encoder.Encode(&users[i]); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err != nil {
+		abortExport(c, err)
+	}
+}
+
+// abortExport reports a failed export. Once rows have been sent the status can
+// no longer change, so the connection is dropped instead, which tells the
+// client that the file is incomplete rather than letting it end as if whole.
+func abortExport(c *gin.Context, err error) {
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+		return
+	}
+	logger.FromContext(c.Request.Context()).Error("user export failed after the response started", zap.Error(err))
+	c.Abort()
+	panic(http.ErrAbortHandler)
+}
@@ -2,13 +2,18 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/role"
 	"github.com/sninjo/vera-identity-service/test"
 
 	"github.com/gin-gonic/gin"
@@ -46,11 +51,46 @@ func (m *MockService) GetUsers() ([]User, error) {
 	}
 	return args.Get(0).([]User), args.Error(1)
 }
-func (m *MockService) UpdateUser(id int, email string) error {
-	args := m.Called(id, email)
+func (m *MockService) GetDeletedUsers() ([]User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]User), args.Error(1)
+}
+func (m *MockService) UpdateUser(id int, email string, attributes map[string]any, allowPrivileged bool) error {
+	args := m.Called(id, email, attributes, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockService) DeleteUser(id int, allowPrivileged bool) error {
+	args := m.Called(id, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockService) RestoreUser(id int, allowPrivileged bool) error {
+	args := m.Called(id, allowPrivileged)
+	return args.Error(0)
+}
+func (m *MockService) HardDeleteUser(id int) error {
+	args := m.Called(id)
 	return args.Error(0)
 }
-func (m *MockService) DeleteUser(id int) error {
+func (m *MockService) PurgeDeletedUsers(retention time.Duration) (int64, error) {
+	args := m.Called(retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockService) SetUserRoles(id int, roles []string) error {
+	args := m.Called(id, roles)
+	return args.Error(0)
+}
+func (m *MockService) SuspendUser(id int, reason *string, until *time.Time) error {
+	args := m.Called(id, reason, until)
+	return args.Error(0)
+}
+func (m *MockService) ReactivateUser(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockService) CheckUserStatus(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
@@ -58,6 +98,25 @@ func (m *MockService) RecordUserLogin(id int, name, picture, loginSub string) er
 	args := m.Called(id, name, picture, loginSub)
 	return args.Error(0)
 }
+func (m *MockService) ImportUsers(entries []ImportEntry, dryRun, allowRoles bool) ([]ImportResult, error) {
+	args := m.Called(entries, dryRun, allowRoles)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ImportResult), args.Error(1)
+}
+func (m *MockService) ExportUsers(fn func(users []User) error) error {
+	args := m.Called(fn)
+	if users, ok := args.Get(0).([]User); ok {
+		if err := fn(users); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+func (m *MockService) WithContext(ctx context.Context) Service {
+	return m
+}
 
 func TestHandler_NewHandler_Success(t *testing.T) {
 	// Arrange
@@ -179,19 +238,22 @@ func TestHandler_CreateUser_Success(t *testing.T) {
 }
 func TestHandler_CreateUser_InvalidRequestBody(t *testing.T) {
 	tests := []struct {
-		name          string
-		payload       string
-		errorContains string
+		name    string
+		payload string
+		field   string
+		rule    string
 	}{
 		{
-			name:          "missing email",
-			payload:       `{}`,
-			errorContains: "Email",
+			name:    "missing email",
+			payload: `{}`,
+			field:   "email",
+			rule:    "email",
 		},
 		{
-			name:          "invalid email format",
-			payload:       `{"email": "not-a-email"}`,
-			errorContains: "email",
+			name:    "invalid email format",
+			payload: `{"email": "not-a-email"}`,
+			field:   "email",
+			rule:    "email",
 		},
 	}
 
@@ -208,13 +270,13 @@ func TestHandler_CreateUser_InvalidRequestBody(t *testing.T) {
 			handler.CreateUser(c)
 
 			// Assert
-			require.Equal(t, http.StatusBadRequest, w.Code)
-
-			var res map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &res)
-			require.NoError(t, err)
-			assert.Contains(t, res["error"], "invalid request body")
-			assert.Contains(t, res["error"], tt.errorContains)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Len(t, c.Errors, 1)
+			appErr := c.Errors[0].Err.(*apperror.AppError)
+			assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+			require.Len(t, appErr.Errors, 1)
+			assert.Equal(t, tt.field, appErr.Errors[0].Field)
+			assert.Equal(t, tt.rule, appErr.Errors[0].Rule)
 		})
 	}
 }
@@ -258,7 +320,28 @@ func TestHandler_UpdateUser_Success(t *testing.T) {
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(requestJSON))
 	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
 
-	mockService.On("UpdateUser", id, requestBody.Email).Return(nil)
+	mockService.On("UpdateUser", id, requestBody.Email, map[string]any(nil), false).Return(nil)
+
+	// Act
+	handler.UpdateUser(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_UpdateUser_Attributes(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"attributes":{"department":"engineering","locale":null}}`))
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("UpdateUser", id, "", map[string]any{"department": "engineering", "locale": nil}, false).Return(nil)
 
 	// Act
 	handler.UpdateUser(c)
@@ -268,6 +351,32 @@ func TestHandler_UpdateUser_Success(t *testing.T) {
 	require.Equal(t, http.StatusNoContent, w.Code)
 	mockService.AssertExpectations(t)
 }
+func TestHandler_UpdateUser_AttributesOfAdmin(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, _ := test.SetupContext()
+	c.Set("user_id", 2)
+	c.Set("user_roles", []string{"ops"})
+
+	id := 1
+	patch := map[string]any{"department": "engineering"}
+	appErr := apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: admin")
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"attributes":{"department":"engineering"}}`))
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	// a non-admin acting on someone else may not change a privileged user
+	mockService.On("UpdateUser", id, "", patch, false).Return(appErr)
+
+	// Act
+	handler.UpdateUser(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, appErr, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
 func TestHandler_UpdateUser_InvalidRequestURI(t *testing.T) {
 	// Arrange
 	mockService := &MockService{}
@@ -280,30 +389,32 @@ func TestHandler_UpdateUser_InvalidRequestURI(t *testing.T) {
 	handler.UpdateUser(c)
 
 	// Assert
-	require.Equal(t, http.StatusBadRequest, w.Code)
-
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	require.NoError(t, err)
-	assert.Contains(t, response["error"], "invalid request uri")
-	assert.Contains(t, response["error"], "Int")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request uri")
+	assert.Contains(t, appErr.Message, "Int")
 }
 func TestHandler_UpdateUser_InvalidRequestBody(t *testing.T) {
 	id := 1
 	tests := []struct {
-		name          string
-		payload       string
-		errorContains string
+		name    string
+		payload string
+		field   string
+		rule    string
 	}{
 		{
-			name:          "missing email",
-			payload:       `{}`,
-			errorContains: "Email",
+			name:    "missing email",
+			payload: `{}`,
+			field:   "email",
+			rule:    "required_without",
 		},
 		{
-			name:          "invalid email format",
-			payload:       `{"email": "not-a-email"}`,
-			errorContains: "email",
+			name:    "invalid email format",
+			payload: `{"email": "not-a-email"}`,
+			field:   "email",
+			rule:    "email",
 		},
 	}
 
@@ -321,13 +432,13 @@ func TestHandler_UpdateUser_InvalidRequestBody(t *testing.T) {
 			handler.UpdateUser(c)
 
 			// Assert
-			require.Equal(t, http.StatusBadRequest, w.Code)
-
-			var res map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &res)
-			require.NoError(t, err)
-			assert.Contains(t, res["error"], "invalid request body")
-			assert.Contains(t, res["error"], tt.errorContains)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Len(t, c.Errors, 1)
+			appErr := c.Errors[0].Err.(*apperror.AppError)
+			assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+			require.Len(t, appErr.Errors, 1)
+			assert.Equal(t, tt.field, appErr.Errors[0].Field)
+			assert.Equal(t, tt.rule, appErr.Errors[0].Rule)
 		})
 	}
 }
@@ -346,7 +457,7 @@ func TestHandler_UpdateUser_ServiceError(t *testing.T) {
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(requestJSON))
 	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
 
-	mockService.On("UpdateUser", id, requestBody.Email).Return(assert.AnError)
+	mockService.On("UpdateUser", id, requestBody.Email, map[string]any(nil), false).Return(assert.AnError)
 
 	// Act
 	handler.UpdateUser(c)
@@ -368,7 +479,7 @@ func TestHandler_DeleteUser_Success(t *testing.T) {
 
 	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
 
-	mockService.On("DeleteUser", id).Return(nil)
+	mockService.On("DeleteUser", id, false).Return(nil)
 
 	// Act
 	handler.DeleteUser(c)
@@ -378,6 +489,38 @@ func TestHandler_DeleteUser_Success(t *testing.T) {
 	require.Equal(t, http.StatusNoContent, w.Code)
 	mockService.AssertExpectations(t)
 }
+func TestHandler_DeleteUser_AllowPrivileged(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int
+		roles  []string
+	}{
+		{"admin", 2, []string{role.Admin}},
+		{"self", 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockService := &MockService{}
+			handler := NewHandler(mockService)
+			c, w := test.SetupContext()
+
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			c.Set("user_id", tt.userID)
+			c.Set("user_roles", tt.roles)
+
+			mockService.On("DeleteUser", 1, true).Return(nil)
+
+			// Act
+			handler.DeleteUser(c)
+			c.Writer.WriteHeaderNow()
+
+			// Assert
+			require.Equal(t, http.StatusNoContent, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
 func TestHandler_DeleteUser_InvalidRequestURI(t *testing.T) {
 	// Arrange
 	mockService := &MockService{}
@@ -390,15 +533,268 @@ func TestHandler_DeleteUser_InvalidRequestURI(t *testing.T) {
 	handler.DeleteUser(c)
 
 	// Assert
-	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request uri")
+	assert.Contains(t, appErr.Message, "Int")
+}
+func TestHandler_DeleteUser_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
 
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("DeleteUser", id, false).Return(assert.AnError)
+
+	// Act
+	handler.DeleteUser(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ImportUsers_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	payload := "email,name,roles\nuser@example.com,User,admin;viewer\n"
+	c.Request = httptest.NewRequest("POST", "/users/import?dry_run=true", bytes.NewBufferString(payload))
+	c.Request.Header.Set("Content-Type", MIMETypeCSV)
+	c.Set("user_roles", []string{role.Admin})
+
+	entries := []ImportEntry{
+		{Line: 2, Email: "user@example.com", Name: test.StringPtr("User"), Roles: []string{"admin", "viewer"}},
+	}
+	results := []ImportResult{
+		{Line: 2, Email: "user@example.com", Status: ImportStatusValid},
+	}
+	mockService.On("ImportUsers", entries, true, true).Return(results, nil)
+
+	// Act
+	handler.ImportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual ImportReportResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "invalid request uri")
-	assert.Contains(t, response["error"], "Int")
+	expected := ImportReportResponse{
+		DryRun: true,
+		Total:  1,
+		Valid:  1,
+		Rows: []ImportRowResponse{
+			{Line: 2, Email: "user@example.com", Status: "valid"},
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
 }
-func TestHandler_DeleteUser_ServiceError(t *testing.T) {
+func TestHandler_ImportUsers_UnsupportedFormat(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/users/import", bytes.NewBufferString("<xml/>"))
+	c.Request.Header.Set("Content-Type", "application/xml")
+
+	// Act
+	handler.ImportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodeUnsupportedImportFormat, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ImportUsers_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("POST", "/users/import", bytes.NewBufferString(`{"email":"user@example.com"}`))
+	c.Request.Header.Set("Content-Type", MIMETypeNDJSON)
+
+	mockService.On("ImportUsers", []ImportEntry{{Line: 1, Email: "user@example.com"}}, false, false).Return(nil, assert.AnError)
+
+	// Act
+	handler.ImportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ExportUsers_CSV(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users/export", nil)
+	users := []User{
+		{
+			ID:        1,
+			Name:      test.StringPtr("name1"),
+			Email:     "user1@example.com",
+			Roles:     []string{"admin", "viewer"},
+			CreatedAt: time.Unix(1, 0),
+			UpdatedAt: time.Unix(1, 0),
+		},
+	}
+	mockService.On("ExportUsers", mock.Anything).Return(users, nil)
+
+	// Act
+	handler.ExportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MIMETypeCSV, w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="users.csv"`, w.Header().Get("Content-Disposition"))
+	timestamp := time.Unix(1, 0).Format(time.RFC3339)
+	expected := "id,email,name,picture,roles,last_login_at,created_at,updated_at\n" +
+		"1,user1@example.com,name1,,admin;viewer,," + timestamp + "," + timestamp + "\n"
+	assert.Equal(t, expected, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ExportUsers_NDJSON(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users/export?format=ndjson", nil)
+	users := []User{
+		{ID: 1, Email: "user1@example.com", CreatedAt: time.Unix(1, 0), UpdatedAt: time.Unix(1, 0)},
+		{ID: 2, Email: "user2@example.com", CreatedAt: time.Unix(2, 0), UpdatedAt: time.Unix(2, 0)},
+	}
+	mockService.On("ExportUsers", mock.Anything).Return(users, nil)
+
+	// Act
+	handler.ExportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MIMETypeNDJSON, w.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	for i, line := range lines {
+		var actual UserResponse
+		err := json.Unmarshal([]byte(line), &actual)
+		require.NoError(t, err)
+		assert.Equal(t, *newUserResponse(&users[i]), actual)
+	}
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ExportUsers_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users/export", nil)
+	mockService.On("ExportUsers", mock.Anything).Return(nil, assert.AnError)
+
+	// Act
+	handler.ExportUsers(c)
+
+	// Assert
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	assert.Empty(t, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ExportUsers_ErrorAfterRows(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users/export", nil)
+	users := []User{{ID: 1, Email: "user1@example.com", CreatedAt: time.Unix(1, 0), UpdatedAt: time.Unix(1, 0)}}
+	mockService.On("ExportUsers", mock.Anything).Return(users, assert.AnError)
+
+	// Act & Assert
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.ExportUsers(c) })
+	assert.Empty(t, c.Errors)
+	assert.True(t, c.IsAborted())
+	assert.Contains(t, w.Body.String(), "user1@example.com")
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ExportUsers_InvalidFormat(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users/export?format=xml", nil)
+
+	// Act
+	handler.ExportUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	assert.Contains(t, appErr.Message, "request query validation failed")
+	require.Len(t, appErr.Errors, 1)
+	assert.Equal(t, "format", appErr.Errors[0].Field)
+	assert.Equal(t, "oneof", appErr.Errors[0].Rule)
+	assert.Equal(t, "csv ndjson", appErr.Errors[0].Param)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetUsers_Deleted(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("GET", "/users?deleted=true", nil)
+	users := []User{
+		{
+			ID:        1,
+			Email:     "user1@example.com",
+			CreatedAt: time.Unix(1, 0),
+			UpdatedAt: time.Unix(1, 0),
+			DeletedAt: test.TimePtr(time.Unix(2, 0)),
+		},
+	}
+	mockService.On("GetDeletedUsers").Return(users, nil)
+
+	// Act
+	handler.GetUsers(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual []UserResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, test.StringPtr(time.Unix(2, 0).Format(time.RFC3339)), actual[0].DeletedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_DeleteUser_Hard(t *testing.T) {
 	// Arrange
 	mockService := &MockService{}
 	handler := NewHandler(mockService)
@@ -406,16 +802,250 @@ func TestHandler_DeleteUser_ServiceError(t *testing.T) {
 
 	id := 1
 
+	c.Request = httptest.NewRequest("DELETE", "/users/1?hard=true", nil)
 	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+	c.Set("user_roles", []string{role.Admin})
+
+	mockService.On("HardDeleteUser", id).Return(nil)
+
+	// Act
+	handler.DeleteUser(c)
+	c.Writer.WriteHeaderNow()
 
-	mockService.On("DeleteUser", id).Return(assert.AnError)
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_DeleteUser_HardPermissionDenied(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request = httptest.NewRequest("DELETE", "/users/1?hard=true", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("user_roles", []string{"viewer"})
 
 	// Act
 	handler.DeleteUser(c)
 
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, apperror.CodePermissionDenied, c.Errors[0].Err.(*apperror.AppError).Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RestoreUser_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("RestoreUser", id, false).Return(nil)
+
+	// Act
+	handler.RestoreUser(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_RestoreUser_InvalidRequestURI(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "invalid-id"}}
+
+	// Act
+	handler.RestoreUser(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request uri")
+}
+func TestHandler_RestoreUser_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("RestoreUser", id, false).Return(assert.AnError)
+
+	// Act
+	handler.RestoreUser(c)
+
 	// Assert
 	require.Equal(t, http.StatusOK, w.Code)
 	assert.Len(t, c.Errors, 1)
 	assert.Equal(t, assert.AnError, c.Errors[0].Err)
 	mockService.AssertExpectations(t)
 }
+
+func TestHandler_SuspendUser_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+	reason := "policy violation"
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+	body, _ := json.Marshal(SuspendRequestBody{Reason: &reason, Until: &until})
+	c.Request = httptest.NewRequest("POST", "/users/1/suspend", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("SuspendUser", id, &reason, &until).Return(nil)
+
+	// Act
+	handler.SuspendUser(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_SuspendUser_InvalidRequestBody(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request = httptest.NewRequest("POST", "/users/1/suspend", bytes.NewBufferString(`{"until":"tomorrow"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	// Act
+	handler.SuspendUser(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request body")
+}
+func TestHandler_SuspendUser_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+	c.Request = httptest.NewRequest("POST", "/users/1/suspend", bytes.NewBufferString(`{}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("SuspendUser", id, (*string)(nil), (*time.Time)(nil)).Return(assert.AnError)
+
+	// Act
+	handler.SuspendUser(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ReactivateUser_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("ReactivateUser", id).Return(nil)
+
+	// Act
+	handler.ReactivateUser(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_ReactivateUser_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+
+	mockService.On("ReactivateUser", id).Return(assert.AnError)
+
+	// Act
+	handler.ReactivateUser(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_SetUserRoles_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	id := 1
+
+	c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"roles":["admin","viewer"]}`))
+
+	mockService.On("SetUserRoles", id, []string{"admin", "viewer"}).Return(nil)
+
+	// Act
+	handler.SetUserRoles(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_SetUserRoles_InvalidRequestBody(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{}`))
+
+	// Act
+	handler.SetUserRoles(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	require.Len(t, appErr.Errors, 1)
+	assert.Equal(t, "roles", appErr.Errors[0].Field)
+	assert.Equal(t, "required", appErr.Errors[0].Rule)
+}
@@ -1,22 +1,46 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+const (
+	emailIndexName        = "idx_users_email_lower"
+	pgUniqueViolationCode = "23505"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusSuspended Status = "suspended"
+	StatusPending   Status = "pending"
+)
+
 type User struct {
-	ID           int        `gorm:"primaryKey;autoIncrement"`
-	Name         *string    `gorm:"type:varchar(255)"`
-	Email        string     `gorm:"type:varchar(255);not null"`
-	Picture      *string    `gorm:"type:varchar(255)"`
-	LastLoginSub *string    `gorm:"type:varchar(255)"`
-	LastLoginAt  *time.Time `gorm:"type:timestamptz"`
-	CreatedAt    time.Time  `gorm:"type:timestamptz;not null"`
-	UpdatedAt    time.Time  `gorm:"type:timestamptz;not null"`
-	DeletedAt    *time.Time `gorm:"type:timestamptz;index"`
+	ID             int            `gorm:"primaryKey;autoIncrement"`
+	Name           *string        `gorm:"type:varchar(255)"`
+	Email          string         `gorm:"type:varchar(255);not null;index:idx_users_email_lower,unique,expression:lower(email),where:deleted_at IS NULL"`
+	Picture        *string        `gorm:"type:varchar(255)"`
+	Roles          []string       `gorm:"type:jsonb;serializer:json"`
+	Attributes     map[string]any `gorm:"type:jsonb;serializer:json"`
+	Status         Status         `gorm:"type:varchar(16);not null;default:active"`
+	StatusReason   *string        `gorm:"type:varchar(255)"`
+	SuspendedUntil *time.Time     `gorm:"type:timestamptz"`
+	LastLoginSub   *string        `gorm:"type:varchar(255)"`
+	LastLoginAt    *time.Time     `gorm:"type:timestamptz"`
+	CreatedAt      time.Time      `gorm:"type:timestamptz;not null"`
+	UpdatedAt      time.Time      `gorm:"type:timestamptz;not null"`
+	DeletedAt      *time.Time     `gorm:"type:timestamptz;index"`
 }
 
 func (User) TableName() string {
@@ -27,9 +51,17 @@ type Repository interface {
 	GetByID(id int) (*User, error)
 	GetByEmail(email string) (*User, error)
 	GetAll() ([]User, error)
+	GetDeleted() ([]User, error)
+	GetDeletedByID(id int) (*User, error)
+	FindInBatches(batchSize int, fn func(users []User) error) error
 	Create(user *User) error
-	Update(user *User) error
+	Update(user *User, columns ...string) error
 	SoftDelete(id int) error
+	Restore(id int) error
+	HardDelete(id int) error
+	PurgeDeletedBefore(cutoff time.Time) (int64, error)
+	Transaction(fn func(repo Repository, events outbox.Writer) error) error
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -40,6 +72,16 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// translateError maps a violation of the case-insensitive email index onto
+// CodeUserEmailInUse, which closes the race left by the service-level check.
+func translateError(err error, email string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolationCode && pgErr.ConstraintName == emailIndexName {
+		return apperror.New(apperror.CodeUserEmailInUse, "user email already in use | email: "+email)
+	}
+	return err
+}
+
 func (r *repository) GetByID(id int) (*User, error) {
 	var user User
 	err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
@@ -54,7 +96,7 @@ func (r *repository) GetByID(id int) (*User, error) {
 
 func (r *repository) GetByEmail(email string) (*User, error) {
 	var user User
-	err := r.db.Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
+	err := r.db.Where("lower(email) = lower(?) AND deleted_at IS NULL", email).First(&user).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -73,26 +115,96 @@ func (r *repository) GetAll() ([]User, error) {
 	return users, nil
 }
 
+func (r *repository) GetDeleted() ([]User, error) {
+	var users []User
+	err := r.db.Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *repository) GetDeletedByID(id int) (*User, error) {
+	var user User
+	err := r.db.Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) FindInBatches(batchSize int, fn func(users []User) error) error {
+	var users []User
+	return r.db.Where("deleted_at IS NULL").Order("id").FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(users)
+	}).Error
+}
+
+// Transaction runs fn with a repository and an outbox writer that share one
+// database transaction, so a change and the events announcing it are
+// committed together.
+func (r *repository) Transaction(fn func(repo Repository, events outbox.Writer) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx}, outbox.NewWriter(tx))
+	})
+}
+
+// WithContext returns a repository whose queries belong to the request of the
+// context, which makes them show up in its trace.
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 func (r *repository) Create(user *User) error {
 	user.ID = 0
 	user.CreatedAt = time.Now().Local()
 	user.UpdatedAt = time.Now().Local()
-	return r.db.Create(user).Error
+	return translateError(r.db.Create(user).Error, user.Email)
 }
 
-func (r *repository) Update(user *User) error {
-	existingUser, err := r.GetByID(user.ID)
-	if err != nil {
-		return err
+// Update writes only the given columns of the user, and updated_at, so that
+// concurrent changes to other columns, e.g. a login during a suspension, are
+// not overwritten with stale values.
+func (r *repository) Update(user *User, columns ...string) error {
+	user.UpdatedAt = time.Now().Local()
+	result := r.db.Model(&User{}).
+		Where("id = ? AND deleted_at IS NULL", user.ID).
+		Select(append(slices.Clone(columns), "updated_at")).
+		Updates(user)
+	if result.Error != nil {
+		return translateError(result.Error, user.Email)
 	}
-	if existingUser == nil {
+	if result.RowsAffected == 0 {
 		return errors.New("user not exist")
 	}
-
-	user.UpdatedAt = time.Now().Local()
-	return r.db.Save(user).Error
+	return nil
 }
 
 func (r *repository) SoftDelete(id int) error {
 	return r.db.Model(&User{}).Where("id = ?", id).Update("deleted_at", time.Now().Local()).Error
 }
+
+func (r *repository) Restore(id int) error {
+	user, err := r.GetDeletedByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("deleted user not exist")
+	}
+
+	err = r.db.Model(&User{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil).Error
+	return translateError(err, user.Email)
+}
+
+func (r *repository) HardDelete(id int) error {
+	return r.db.Where("id = ?", id).Delete(&User{}).Error
+}
+
+func (r *repository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&User{})
+	return result.RowsAffected, result.Error
+}
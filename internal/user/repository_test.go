@@ -1,21 +1,28 @@
 package user
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/sninjo/vera-identity-service/internal/apperror"
 	"github.com/sninjo/vera-identity-service/internal/config"
 	"github.com/sninjo/vera-identity-service/internal/db"
 	"github.com/sninjo/vera-identity-service/test"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/gorm"
 )
 
 var d *gorm.DB
+var spanRecorder = tracetest.NewSpanRecorder()
 
 func TestMain(m *testing.M) {
 	// Setup
@@ -24,7 +31,7 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 
-	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL})
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -42,6 +49,34 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+func TestRepository_WithContext_TracesQueries(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	tracerProvider := sdktrace.NewTracerProvider()
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "GET /auth/callback")
+	defer parent.End()
+
+	// Act
+	user, err := repo.WithContext(ctx).GetByEmail("user@example.com")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, user)
+
+	var span sdktrace.ReadOnlySpan
+	for _, ended := range spanRecorder.Ended() {
+		if ended.Parent().SpanID() == parent.SpanContext().SpanID() {
+			span = ended
+		}
+	}
+	require.NotNil(t, span)
+	assert.Equal(t, "gorm.query", span.Name())
+	assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID())
+}
+
 func TestRepository_NewRepository_Success(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -143,6 +178,24 @@ func TestRepository_GetByEmail_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, user, result)
 }
+func TestRepository_GetByEmail_CaseInsensitive(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "Test@Example.com"}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	// Act
+	result, err := repo.GetByEmail("test@example.com")
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, user.ID, result.ID)
+}
 func TestRepository_GetByEmail_EmptyEmail(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -264,6 +317,61 @@ func TestRepository_GetAll_FilterSoftDeleted(t *testing.T) {
 	assert.Empty(t, result)
 }
 
+func TestRepository_FindInBatches_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	for i := 1; i <= 5; i++ {
+		user := &User{
+			ID:        i,
+			Email:     "test" + strconv.Itoa(i) + "@example.com",
+			Roles:     []string{"viewer"},
+			CreatedAt: time.Unix(1, 0),
+			UpdatedAt: time.Unix(1, 0),
+		}
+		if i == 3 {
+			user.DeletedAt = test.TimePtr(time.Unix(2, 0))
+		}
+		err = d.Create(user).Error
+		require.NoError(t, err)
+	}
+
+	// Act
+	var batches [][]int
+	err = repo.FindInBatches(2, func(users []User) error {
+		ids := make([]int, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+			assert.Equal(t, []string{"viewer"}, user.Roles)
+		}
+		batches = append(batches, ids)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{1, 2}, {4, 5}}, batches)
+}
+func TestRepository_FindInBatches_CallbackError(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&User{ID: 1, Email: "test@example.com"}).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.FindInBatches(2, func(users []User) error {
+		return assert.AnError
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
 func TestRepository_Create_Success(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -289,6 +397,7 @@ func TestRepository_Create_Success(t *testing.T) {
 		Picture:      test.StringPtr("https://example.com/picture.png"),
 		LastLoginSub: test.StringPtr("mock-login-sub"),
 		LastLoginAt:  test.TimePtr(time.Unix(1, 0)),
+		Status:       StatusActive,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 		DeletedAt:    nil,
@@ -348,6 +457,38 @@ func TestRepository_Create_DuplicateID(t *testing.T) {
 	assert.Nil(t, empty)
 }
 
+func TestRepository_Create_DuplicateEmail(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&User{ID: 1, Email: "test@example.com"}).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.Create(&User{Email: "TEST@example.com"})
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
+}
+func TestRepository_Create_DuplicateEmailOfSoftDeleted(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&User{ID: 1, Email: "test@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))}).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.Create(&User{Email: "test@example.com"})
+
+	// Assert
+	require.NoError(t, err)
+}
+
 func TestRepository_Update_Success(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -378,7 +519,7 @@ func TestRepository_Update_Success(t *testing.T) {
 	user.LastLoginAt = test.TimePtr(time.Unix(2, 0))
 
 	// Act
-	err = repo.Update(user)
+	err = repo.Update(user, "name", "email", "picture", "last_login_sub", "last_login_at")
 
 	// Assert
 	require.NoError(t, err)
@@ -389,6 +530,7 @@ func TestRepository_Update_Success(t *testing.T) {
 		Picture:      test.StringPtr("https://example.com/new-picture.png"),
 		LastLoginSub: test.StringPtr("new-login-sub"),
 		LastLoginAt:  test.TimePtr(time.Unix(2, 0)),
+		Status:       StatusActive,
 		CreatedAt:    time.Unix(1, 0),
 		UpdatedAt:    user.UpdatedAt,
 		DeletedAt:    nil,
@@ -401,6 +543,35 @@ func TestRepository_Update_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectedUser, savedUser)
 }
+func TestRepository_Update_OnlyGivenColumns(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&User{ID: 1, Email: "test@example.com", Status: StatusActive}).Error
+	require.NoError(t, err)
+	stale, err := repo.GetByID(1)
+	require.NoError(t, err)
+
+	suspended, err := repo.GetByID(1)
+	require.NoError(t, err)
+	suspended.Status = StatusSuspended
+	err = repo.Update(suspended, "status")
+	require.NoError(t, err)
+
+	stale.LastLoginSub = test.StringPtr("login-sub")
+
+	// Act
+	err = repo.Update(stale, "last_login_sub")
+
+	// Assert
+	require.NoError(t, err)
+	savedUser, err := repo.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSuspended, savedUser.Status)
+	assert.Equal(t, test.StringPtr("login-sub"), savedUser.LastLoginSub)
+}
 func TestRepository_Update_NonExistentUser(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -410,7 +581,7 @@ func TestRepository_Update_NonExistentUser(t *testing.T) {
 	user := &User{ID: 1}
 
 	// Act
-	err = repo.Update(user)
+	err = repo.Update(user, "email")
 
 	// Assert
 	require.Error(t, err)
@@ -420,6 +591,28 @@ func TestRepository_Update_NonExistentUser(t *testing.T) {
 	assert.Nil(t, empty)
 }
 
+func TestRepository_Update_DuplicateEmail(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	err = d.Create(&User{ID: 1, Email: "test1@example.com"}).Error
+	require.NoError(t, err)
+	user := &User{ID: 2, Email: "test2@example.com"}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	user.Email = "Test1@example.com"
+
+	// Act
+	err = repo.Update(user, "email")
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
+}
+
 func TestRepository_SoftDelete_Success(t *testing.T) {
 	// Arrange
 	err := test.CleanupTables(d)
@@ -456,6 +649,7 @@ func TestRepository_SoftDelete_Success(t *testing.T) {
 		Picture:      test.StringPtr("https://example.com/picture.png"),
 		LastLoginSub: test.StringPtr("mock-login-sub"),
 		LastLoginAt:  test.TimePtr(time.Unix(1, 0)),
+		Status:       StatusActive,
 		CreatedAt:    time.Unix(1, 0),
 		UpdatedAt:    deletedUser.UpdatedAt,
 		DeletedAt:    deletedUser.DeletedAt,
@@ -481,3 +675,180 @@ func TestRepository_SoftDelete_NonExistentNode(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), count)
 }
+
+func TestRepository_GetDeleted_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	users := []*User{
+		{ID: 1, Email: "active@example.com"},
+		{ID: 2, Email: "deleted1@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))},
+		{ID: 3, Email: "deleted2@example.com", DeletedAt: test.TimePtr(time.Unix(2, 0))},
+	}
+	for _, user := range users {
+		err = d.Create(user).Error
+		require.NoError(t, err)
+	}
+
+	// Act
+	result, err := repo.GetDeleted()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, 3, result[0].ID)
+	assert.Equal(t, 2, result[1].ID)
+}
+
+func TestRepository_GetDeletedByID_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "deleted@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	// Act
+	result, err := repo.GetDeletedByID(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, user.Email, result.Email)
+}
+func TestRepository_GetDeletedByID_ActiveUser(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "active@example.com"}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	// Act
+	result, err := repo.GetDeletedByID(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestRepository_Restore_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "deleted@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.Restore(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+
+	restoredUser, err := repo.GetByID(user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, restoredUser)
+	assert.Nil(t, restoredUser.DeletedAt)
+	assert.WithinDuration(t, time.Now(), restoredUser.UpdatedAt, time.Second)
+}
+
+func TestRepository_Restore_DuplicateEmail(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "test@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+	err = d.Create(&User{ID: 2, Email: "TEST@example.com"}).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.Restore(user.ID)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
+}
+
+func TestRepository_translateError_UniqueEmailViolation(t *testing.T) {
+	// Arrange
+	pgErr := &pgconn.PgError{Code: pgUniqueViolationCode, ConstraintName: emailIndexName}
+
+	// Act
+	err := translateError(pgErr, "test@example.com")
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
+}
+func TestRepository_translateError_OtherError(t *testing.T) {
+	// Arrange
+	pgErr := &pgconn.PgError{Code: pgUniqueViolationCode, ConstraintName: "users_pkey"}
+
+	// Act
+	err := translateError(pgErr, "test@example.com")
+
+	// Assert
+	assert.Equal(t, pgErr, err)
+}
+
+func TestRepository_HardDelete_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	user := &User{ID: 1, Email: "test@example.com"}
+	err = d.Create(user).Error
+	require.NoError(t, err)
+
+	// Act
+	err = repo.HardDelete(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+
+	count := int64(0)
+	err = d.Model(&User{}).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRepository_PurgeDeletedBefore_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	users := []*User{
+		{ID: 1, Email: "active@example.com"},
+		{ID: 2, Email: "old@example.com", DeletedAt: test.TimePtr(time.Unix(1, 0))},
+		{ID: 3, Email: "recent@example.com", DeletedAt: test.TimePtr(time.Unix(3, 0))},
+	}
+	for _, user := range users {
+		err = d.Create(user).Error
+		require.NoError(t, err)
+	}
+
+	// Act
+	count, err := repo.PurgeDeletedBefore(time.Unix(2, 0))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	var ids []int
+	err = d.Model(&User{}).Order("id").Pluck("id", &ids).Error
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, ids)
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// RetentionJob permanently removes users that have been soft-deleted for
+// longer than the configured retention period.
+type RetentionJob struct {
+	service   Service
+	logger    *zap.Logger
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewRetentionJob(config *config.Config, service Service, logger *zap.Logger) *RetentionJob {
+	return &RetentionJob{
+		service:   service,
+		logger:    logger,
+		retention: config.UserRetentionPeriod,
+		interval:  config.UserPurgeInterval,
+	}
+}
+
+func (j *RetentionJob) Run(ctx context.Context) {
+	if j.retention <= 0 || j.interval <= 0 {
+		j.logger.Info("user retention job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) purge() {
+	count, err := j.service.PurgeDeletedUsers(j.retention)
+	if err != nil {
+		j.logger.Error("failed to purge deleted users", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		j.logger.Info("purged deleted users", zap.Int64("count", count), zap.Duration("retention", j.retention))
+	}
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestRetentionJob_NewRetentionJob_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	config := &config.Config{UserRetentionPeriod: 24 * time.Hour, UserPurgeInterval: time.Hour}
+	logger := zap.NewNop()
+
+	// Act
+	job := NewRetentionJob(config, mockService, logger)
+
+	// Assert
+	assert.Equal(t, mockService, job.service)
+	assert.Equal(t, logger, job.logger)
+	assert.Equal(t, config.UserRetentionPeriod, job.retention)
+	assert.Equal(t, config.UserPurgeInterval, job.interval)
+}
+
+func TestRetentionJob_Run_Disabled(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	job := NewRetentionJob(&config.Config{UserPurgeInterval: time.Hour}, mockService, zap.NewNop())
+
+	// Act
+	job.Run(context.Background())
+
+	// Assert
+	mockService.AssertNotCalled(t, "PurgeDeletedUsers", time.Duration(0))
+}
+func TestRetentionJob_Run_PurgeUntilCanceled(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	retention := 24 * time.Hour
+	job := NewRetentionJob(&config.Config{UserRetentionPeriod: retention, UserPurgeInterval: time.Millisecond}, mockService, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	mockService.On("PurgeDeletedUsers", retention).Return(int64(1), nil).Run(func(args mock.Arguments) {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+	})
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		job.Run(ctx)
+		close(done)
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("retention job did not stop after the context was canceled")
+	}
+	mockService.AssertNumberOfCalls(t, "PurgeDeletedUsers", 2)
+}
+func TestRetentionJob_Run_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	retention := 24 * time.Hour
+	job := NewRetentionJob(&config.Config{UserRetentionPeriod: retention, UserPurgeInterval: time.Hour}, mockService, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockService.On("PurgeDeletedUsers", retention).Return(int64(0), assert.AnError).Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	// Act
+	job.Run(ctx)
+
+	// Assert
+	mockService.AssertExpectations(t)
+}
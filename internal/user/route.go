@@ -1,18 +1,26 @@
 package user
 
 import (
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware) {
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware, auditMiddleware audit.Middleware) {
 	g := r.Group("/users")
 	g.Use(gin.HandlerFunc(authMiddleware))
 	{
-		g.GET("", handler.GetUsers)
-		g.POST("", handler.CreateUser)
-		g.PATCH("/:id", handler.UpdateUser)
-		g.DELETE("/:id", handler.DeleteUser)
+		g.GET("", middleware.RequireScope(ScopeUsersRead), handler.GetUsers)
+		g.POST("", middleware.RequireScope(ScopeUsersWrite), auditMiddleware(audit.EventUserCreate), handler.CreateUser)
+		g.POST("/import", middleware.RequireScope(ScopeUsersWrite), auditMiddleware(audit.EventUserImport), handler.ImportUsers)
+		g.GET("/export", middleware.RequireScope(ScopeUsersRead), handler.ExportUsers)
+		g.PATCH("/:id", middleware.RequireScope(ScopeUsersWrite), auditMiddleware(audit.EventUserUpdate), handler.UpdateUser)
+		g.DELETE("/:id", middleware.RequireScope(ScopeUsersWrite), auditMiddleware(audit.EventUserDelete), handler.DeleteUser)
+		g.POST("/:id/restore", middleware.RequireScope(ScopeUsersWrite), auditMiddleware(audit.EventUserRestore), handler.RestoreUser)
+		g.PUT("/:id/roles", middleware.RequireRole(role.Admin), auditMiddleware(audit.EventUserRolesChange), handler.SetUserRoles)
+		g.POST("/:id/suspend", middleware.RequireRole(role.Admin), auditMiddleware(audit.EventUserSuspend), handler.SuspendUser)
+		g.POST("/:id/reactivate", middleware.RequireRole(role.Admin), auditMiddleware(audit.EventUserReactivate), handler.ReactivateUser)
 	}
 }
@@ -1,28 +1,146 @@
 package user
 
 import (
+	"context"
+	"net/mail"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/role"
 )
 
 type Service interface {
 	GetUserByID(id int) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUsers() ([]User, error)
+	GetDeletedUsers() ([]User, error)
 	CreateUser(email string) error
-	UpdateUser(id int, email string) error
-	DeleteUser(id int) error
+	// UpdateUser, DeleteUser and RestoreUser refuse to change the email or
+	// attributes of, delete or restore a user who holds roles unless
+	// allowPrivileged is set, i.e. the caller is an admin or the user
+	// themselves.
+	UpdateUser(id int, email string, attributes map[string]any, allowPrivileged bool) error
+	DeleteUser(id int, allowPrivileged bool) error
+	RestoreUser(id int, allowPrivileged bool) error
+	HardDeleteUser(id int) error
+	PurgeDeletedUsers(retention time.Duration) (int64, error)
+	SetUserRoles(id int, roles []string) error
+	SuspendUser(id int, reason *string, until *time.Time) error
+	ReactivateUser(id int) error
+	CheckUserStatus(id int) error
 	RecordUserLogin(id int, name, picture, loginSub string) error
+	// ImportUsers creates users in bulk. Only callers that may assign roles,
+	// i.e. admins, set allowRoles; otherwise rows with roles are invalid.
+	ImportUsers(entries []ImportEntry, dryRun, allowRoles bool) ([]ImportResult, error)
+	ExportUsers(fn func(users []User) error) error
+	WithContext(ctx context.Context) Service
 }
 
+// statusColumns are the columns that suspending and reactivating write.
+var statusColumns = []string{"status", "status_reason", "suspended_until"}
+
+// scopes that service clients need for the user endpoints
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+)
+
+// CheckStatus returns an error when the user may not sign in or use tokens.
+// A suspension whose expiry has passed no longer blocks the user.
+func (u *User) CheckStatus() error {
+	switch u.Status {
+	case StatusSuspended:
+		if u.SuspendedUntil != nil && !time.Now().Before(*u.SuspendedUntil) {
+			return nil
+		}
+		message := "user suspended | id: " + strconv.Itoa(u.ID)
+		if u.SuspendedUntil != nil {
+			message += " | until: " + u.SuspendedUntil.Format(time.RFC3339)
+		}
+		return apperror.New(apperror.CodeUserSuspended, message)
+	case StatusPending:
+		return apperror.New(apperror.CodeUserPending, "user pending activation | id: "+strconv.Itoa(u.ID))
+	default:
+		return nil
+	}
+}
+
+type ImportStatus string
+
+const (
+	ImportStatusCreated ImportStatus = "created"
+	ImportStatusValid   ImportStatus = "valid"
+	ImportStatusSkipped ImportStatus = "skipped"
+	ImportStatusInvalid ImportStatus = "invalid"
+)
+
+type ImportEntry struct {
+	Line  int
+	Email string
+	Name  *string
+	Roles []string
+	// Status is active (the default) or pending, for accounts that an admin
+	// activates later with ReactivateUser
+	Status Status
+}
+
+type ImportResult struct {
+	Line   int
+	Email  string
+	Status ImportStatus
+	Reason string
+}
+
+// ImportSummary counts the results of an import by their status.
+type ImportSummary struct {
+	Created int
+	Valid   int
+	Skipped int
+	Invalid int
+}
+
+func SummarizeImport(results []ImportResult) ImportSummary {
+	var summary ImportSummary
+	for _, result := range results {
+		switch result.Status {
+		case ImportStatusCreated:
+			summary.Created++
+		case ImportStatusValid:
+			summary.Valid++
+		case ImportStatusSkipped:
+			summary.Skipped++
+		case ImportStatusInvalid:
+			summary.Invalid++
+		}
+	}
+	return summary
+}
+
+const exportBatchSize = 500
+
 type service struct {
-	repo Repository
+	repo             Repository
+	attributeService attribute.Service
+}
+
+func NewService(repo Repository, attributeService attribute.Service) Service {
+	return &service{repo: repo, attributeService: attributeService}
+}
+
+// WithContext returns a service whose queries belong to the request of the
+// context.
+func (s *service) WithContext(ctx context.Context) Service {
+	return &service{repo: s.repo.WithContext(ctx), attributeService: s.attributeService}
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// normalizeEmail gives every email one canonical form so that uniqueness and
+// lookups do not depend on the casing or surrounding spaces a client sends.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 func (s *service) validateEmailUniqueness(email string, excludeID *int) error {
@@ -41,49 +159,204 @@ func (s *service) GetUserByID(id int) (*User, error) {
 }
 
 func (s *service) GetUserByEmail(email string) (*User, error) {
-	return s.repo.GetByEmail(email)
+	return s.repo.GetByEmail(normalizeEmail(email))
 }
 
 func (s *service) GetUsers() ([]User, error) {
 	return s.repo.GetAll()
 }
 
+func (s *service) GetDeletedUsers() ([]User, error) {
+	return s.repo.GetDeleted()
+}
+
 func (s *service) CreateUser(email string) error {
+	email = normalizeEmail(email)
 	if err := s.validateEmailUniqueness(email, nil); err != nil {
 		return err
 	}
 
-	user := &User{Email: email}
-	if err := s.repo.Create(user); err != nil {
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		user := &User{Email: email}
+		if err := repo.Create(user); err != nil {
+			return err
+		}
+		return events.Write(outbox.EventUserCreated, user.EventData())
+	})
+}
+
+func (s *service) UpdateUser(id int, email string, attributes map[string]any, allowPrivileged bool) error {
+	email = normalizeEmail(email)
+	if email != "" {
+		if err := s.validateEmailUniqueness(email, &id); err != nil {
+			return err
+		}
+	}
+
+	user, err := s.repo.GetByID(id)
+	if err != nil {
 		return err
 	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
+	}
+
+	var columns []string
+	if email != "" && email != user.Email {
+		if err := checkPrivilegedTarget(user, allowPrivileged); err != nil {
+			return err
+		}
+		user.Email = email
+		columns = append(columns, "email")
+	}
+	if attributes != nil {
+		// attributes end up in the user's access tokens, like the roles
+		if err := checkPrivilegedTarget(user, allowPrivileged); err != nil {
+			return err
+		}
+		merged, err := s.attributeService.Merge(user.Attributes, attributes)
+		if err != nil {
+			return err
+		}
+		user.Attributes = merged
+		columns = append(columns, "attributes")
+	}
+	if err := s.repo.Update(user, columns...); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (s *service) UpdateUser(id int, email string) error {
-	if err := s.validateEmailUniqueness(email, &id); err != nil {
+func (s *service) DeleteUser(id int, allowPrivileged bool) error {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
 		return err
 	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
+	}
+	if err := checkPrivilegedTarget(user, allowPrivileged); err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		if err := repo.SoftDelete(id); err != nil {
+			return err
+		}
+		return events.Write(outbox.EventUserDeleted, user.EventData())
+	})
+}
 
+// RestoreUser brings a soft-deleted user back. Since soft-deleted rows do not
+// reserve their email, the restore is refused when an active user has taken it.
+func (s *service) RestoreUser(id int, allowPrivileged bool) error {
+	user, err := s.repo.GetDeletedByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotFound, "deleted user not found | id: "+strconv.Itoa(id))
+	}
+	if err := checkPrivilegedTarget(user, allowPrivileged); err != nil {
+		return err
+	}
+
+	existingUser, err := s.repo.GetByEmail(user.Email)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return apperror.New(
+			apperror.CodeUserEmailInUse,
+			"user email already in use by another user | id: "+strconv.Itoa(id)+" | email: "+user.Email+" | conflicting id: "+strconv.Itoa(existingUser.ID),
+		)
+	}
+
+	return s.repo.Restore(id)
+}
+
+// checkPrivilegedTarget keeps holders of users:write from taking over or
+// removing users with roles, which only admins may manage.
+func checkPrivilegedTarget(user *User, allowPrivileged bool) error {
+	if allowPrivileged || len(user.Roles) == 0 {
+		return nil
+	}
+	return apperror.New(apperror.CodePermissionDenied, "permission denied | required roles: "+role.Admin).
+		WithInternal("target id: " + strconv.Itoa(user.ID) + " | target roles: " + strings.Join(user.Roles, ", "))
+}
+
+// HardDeleteUser announces the deletion only for active users; soft-deleted
+// users were announced when they were deleted.
+func (s *service) HardDeleteUser(id int) error {
 	user, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
 	}
+	active := user != nil
+	if user == nil {
+		user, err = s.repo.GetDeletedByID(id)
+		if err != nil {
+			return err
+		}
+	}
 	if user == nil {
 		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
 	}
 
-	if email != "" {
-		user.Email = email
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		if err := repo.HardDelete(id); err != nil {
+			return err
+		}
+		if !active {
+			return nil
+		}
+		return events.Write(outbox.EventUserDeleted, user.EventData())
+	})
+}
+
+func (s *service) PurgeDeletedUsers(retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeletedBefore(time.Now().Add(-retention))
+}
+
+func (s *service) SetUserRoles(id int, roles []string) error {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
+	}
+
+	user.Roles = normalizeRoles(roles)
+	return s.repo.Update(user, "roles")
+}
+
+func (s *service) SuspendUser(id int, reason *string, until *time.Time) error {
+	if until != nil && !until.After(time.Now()) {
+		return apperror.New(apperror.CodeInvalidSuspension, "suspension expiry must be in the future | until: "+until.Format(time.RFC3339))
 	}
-	if err := s.repo.Update(user); err != nil {
+
+	user, err := s.repo.GetByID(id)
+	if err != nil {
 		return err
 	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
+	}
 
-	return nil
+	user.Status = StatusSuspended
+	user.StatusReason = reason
+	user.SuspendedUntil = until
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		if err := repo.Update(user, statusColumns...); err != nil {
+			return err
+		}
+		return events.Write(outbox.EventUserSuspended, user.EventData())
+	})
 }
 
-func (s *service) DeleteUser(id int) error {
+func (s *service) ReactivateUser(id int) error {
 	user, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
@@ -92,7 +365,26 @@ func (s *service) DeleteUser(id int) error {
 		return apperror.New(apperror.CodeUserNotFound, "user not found | id: "+strconv.Itoa(id))
 	}
 
-	return s.repo.SoftDelete(id)
+	user.Status = StatusActive
+	user.StatusReason = nil
+	user.SuspendedUntil = nil
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		if err := repo.Update(user, statusColumns...); err != nil {
+			return err
+		}
+		return events.Write(outbox.EventUserReactivated, user.EventData())
+	})
+}
+
+func (s *service) CheckUserStatus(id int) error {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperror.New(apperror.CodeUserNotAuthorized, "user not authorized | id: "+strconv.Itoa(id))
+	}
+	return user.CheckStatus()
 }
 
 func (s *service) RecordUserLogin(id int, name, picture, loginSub string) error {
@@ -109,8 +401,124 @@ func (s *service) RecordUserLogin(id int, name, picture, loginSub string) error
 	user.LastLoginSub = &loginSub
 	now := time.Now()
 	user.LastLoginAt = &now
-	if err := s.repo.Update(user); err != nil {
-		return err
+	return s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+		if err := repo.Update(user, "name", "picture", "last_login_sub", "last_login_at"); err != nil {
+			return err
+		}
+		return events.Write(outbox.EventUserLogin, user.EventData())
+	})
+}
+
+func normalizeRoles(roles []string) []string {
+	if len(roles) == 0 {
+		return nil
 	}
-	return nil
+	normalized := make([]string, 0, len(roles))
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" || seen[role] {
+			continue
+		}
+		seen[role] = true
+		normalized = append(normalized, role)
+	}
+	return normalized
+}
+
+func validateImportEntry(entry ImportEntry) string {
+	if entry.Email == "" {
+		return "missing email"
+	}
+	if len(entry.Email) > 255 {
+		return "email exceeds 255 characters"
+	}
+	if addr, err := mail.ParseAddress(entry.Email); err != nil || addr.Address != entry.Email {
+		return "invalid email format"
+	}
+	if entry.Name != nil && len(*entry.Name) > 255 {
+		return "name exceeds 255 characters"
+	}
+	if entry.Status != "" && entry.Status != StatusActive && entry.Status != StatusPending {
+		return "invalid status | status: " + string(entry.Status)
+	}
+	return ""
+}
+
+// ImportUsers validates every entry and, unless dryRun is set, creates the
+// users that pass. Row-level problems are reported in the results instead of
+// failing the whole import; only repository errors abort it.
+func (s *service) ImportUsers(entries []ImportEntry, dryRun, allowRoles bool) ([]ImportResult, error) {
+	results := make([]ImportResult, len(entries))
+	seenLines := make(map[string]int, len(entries))
+
+	for i, entry := range entries {
+		entry.Email = normalizeEmail(entry.Email)
+		entry.Status = Status(strings.ToLower(strings.TrimSpace(string(entry.Status))))
+		result := ImportResult{Line: entry.Line, Email: entry.Email}
+
+		if reason := validateImportEntry(entry); reason != "" {
+			result.Status = ImportStatusInvalid
+			result.Reason = reason
+			results[i] = result
+			continue
+		}
+		roles := normalizeRoles(entry.Roles)
+		if len(roles) > 0 && !allowRoles {
+			result.Status = ImportStatusInvalid
+			result.Reason = "roles require the admin role"
+			results[i] = result
+			continue
+		}
+		if line, ok := seenLines[entry.Email]; ok {
+			result.Status = ImportStatusInvalid
+			result.Reason = "duplicate email in file | line: " + strconv.Itoa(line)
+			results[i] = result
+			continue
+		}
+		seenLines[entry.Email] = entry.Line
+
+		existingUser, err := s.repo.GetByEmail(entry.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existingUser != nil {
+			result.Status = ImportStatusSkipped
+			result.Reason = "user email already in use"
+			results[i] = result
+			continue
+		}
+
+		if dryRun {
+			result.Status = ImportStatusValid
+			results[i] = result
+			continue
+		}
+
+		user := &User{Email: entry.Email, Name: entry.Name, Roles: roles, Status: entry.Status}
+		err = s.repo.Transaction(func(repo Repository, events outbox.Writer) error {
+			if err := repo.Create(user); err != nil {
+				return err
+			}
+			return events.Write(outbox.EventUserCreated, user.EventData())
+		})
+		if err != nil {
+			if apperror.FromError(err).Code != apperror.CodeUserEmailInUse {
+				return nil, err
+			}
+			// created by another request since the lookup
+			result.Status = ImportStatusSkipped
+			result.Reason = "user email already in use"
+			results[i] = result
+			continue
+		}
+		result.Status = ImportStatusCreated
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+func (s *service) ExportUsers(fn func(users []User) error) error {
+	return s.repo.FindInBatches(exportBatchSize, fn)
 }
@@ -1,10 +1,14 @@
 package user
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/test"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -40,25 +44,111 @@ func (m *MockRepository) GetAll() ([]User, error) {
 	}
 	return args.Get(0).([]User), args.Error(1)
 }
-func (m *MockRepository) Update(user *User) error {
-	args := m.Called(user)
+func (m *MockRepository) GetDeleted() ([]User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]User), args.Error(1)
+}
+func (m *MockRepository) GetDeletedByID(id int) (*User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+func (m *MockRepository) FindInBatches(batchSize int, fn func(users []User) error) error {
+	args := m.Called(batchSize, fn)
+	return args.Error(0)
+}
+func (m *MockRepository) Update(user *User, columns ...string) error {
+	args := m.Called(user, columns)
 	return args.Error(0)
 }
 func (m *MockRepository) SoftDelete(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+func (m *MockRepository) Restore(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockRepository) HardDelete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockRepository) Transaction(fn func(repo Repository, events outbox.Writer) error) error {
+	args := m.Called()
+	return fn(m, args.Get(0).(outbox.Writer))
+}
+func (m *MockRepository) WithContext(ctx context.Context) Repository {
+	return m
+}
+func (m *MockRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockOutboxWriter struct {
+	mock.Mock
+}
+
+func (m *MockOutboxWriter) Write(eventType string, data any) error {
+	args := m.Called(eventType, data)
+	return args.Error(0)
+}
+
+type MockAttributeService struct {
+	mock.Mock
+}
+
+func (m *MockAttributeService) GetDefinitions() ([]attribute.Definition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]attribute.Definition), args.Error(1)
+}
+func (m *MockAttributeService) CreateDefinition(definition *attribute.Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockAttributeService) UpdateDefinition(definition *attribute.Definition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
+func (m *MockAttributeService) DeleteDefinition(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+func (m *MockAttributeService) Merge(current, patch map[string]any) (map[string]any, error) {
+	args := m.Called(current, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+func (m *MockAttributeService) Claims(attributes map[string]any) (map[string]any, error) {
+	args := m.Called(attributes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
 
 func TestService_NewService_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
+	mockAttributeService := &MockAttributeService{}
 
 	// Act
-	s := NewService(mockRepo)
+	s := NewService(mockRepo, mockAttributeService)
 
 	// Assert
 	assert.IsType(t, &service{}, s)
 	assert.Equal(t, mockRepo, s.(*service).repo)
+	assert.Equal(t, mockAttributeService, s.(*service).attributeService)
 }
 
 func TestService_validateEmailUniqueness_Success(t *testing.T) {
@@ -198,6 +288,23 @@ func TestService_GetUserByEmail_Success(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+func TestService_GetUserByEmail_NormalizeEmail(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com"}
+
+	mockRepo.On("GetByEmail", user.Email).Return(user, nil)
+
+	// Act
+	response, err := service.GetUserByEmail(" EMAIL@example.com")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, user, response)
+	mockRepo.AssertExpectations(t)
+}
 func TestService_GetUserByEmail_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
@@ -275,12 +382,15 @@ func TestService_GetUsers_RepositoryError(t *testing.T) {
 func TestService_CreateUser_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
 	service := &service{repo: mockRepo}
 
 	email := "email@example.com"
 
 	mockRepo.On("GetByEmail", email).Return(nil, nil)
 	mockRepo.On("Create", &User{Email: email}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(nil)
 
 	// Act
 	err := service.CreateUser(email)
@@ -288,7 +398,31 @@ func TestService_CreateUser_Success(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_CreateUser_NormalizeEmail(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	email := "email@example.com"
+
+	mockRepo.On("GetByEmail", email).Return(nil, nil)
+	mockRepo.On("Create", &User{Email: email}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(nil)
+
+	// Act
+	err := service.CreateUser("  Email@Example.COM ")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
 }
+
 func TestService_CreateUser_EmailAlreadyExists(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
@@ -305,6 +439,27 @@ func TestService_CreateUser_EmailAlreadyExists(t *testing.T) {
 	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
 	mockRepo.AssertExpectations(t)
 }
+func TestService_CreateUser_OutboxError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	email := "email@example.com"
+
+	mockRepo.On("GetByEmail", email).Return(nil, nil)
+	mockRepo.On("Create", &User{Email: email}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(assert.AnError)
+
+	// Act
+	err := service.CreateUser(email)
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
 func TestService_CreateUser_CreateError(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
@@ -314,6 +469,7 @@ func TestService_CreateUser_CreateError(t *testing.T) {
 
 	mockRepo.On("GetByEmail", email).Return(nil, nil)
 	mockRepo.On("Create", &User{Email: email}).Return(assert.AnError)
+	mockRepo.On("Transaction").Return(&MockOutboxWriter{})
 
 	// Act
 	err := service.CreateUser(email)
@@ -334,14 +490,76 @@ func TestService_UpdateUser_Success(t *testing.T) {
 
 	mockRepo.On("GetByEmail", newEmail).Return(nil, nil)
 	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: oldEmail}, nil)
-	mockRepo.On("Update", &User{ID: id, Email: newEmail}).Return(nil)
+	mockRepo.On("Update", &User{ID: id, Email: newEmail}, []string{"email"}).Return(nil)
+
+	// Act
+	err := service.UpdateUser(id, newEmail, nil, false)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_UpdateUser_NormalizeEmail(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	newEmail := "new-email@example.com"
+
+	mockRepo.On("GetByEmail", newEmail).Return(nil, nil)
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "old-email@example.com"}, nil)
+	mockRepo.On("Update", &User{ID: id, Email: newEmail}, []string{"email"}).Return(nil)
+
+	// Act
+	err := service.UpdateUser(id, "New-Email@Example.com", nil, false)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_UpdateUser_Attributes(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockAttributeService := &MockAttributeService{}
+	service := &service{repo: mockRepo, attributeService: mockAttributeService}
+
+	id := 1
+	current := map[string]any{"department": "sales"}
+	patch := map[string]any{"department": "engineering", "locale": "en-US"}
+	merged := map[string]any{"department": "engineering", "locale": "en-US"}
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "email@example.com", Attributes: current}, nil)
+	mockAttributeService.On("Merge", current, patch).Return(merged, nil)
+	mockRepo.On("Update", &User{ID: id, Email: "email@example.com", Attributes: merged}, []string{"attributes"}).Return(nil)
 
 	// Act
-	err := service.UpdateUser(id, newEmail)
+	err := service.UpdateUser(id, "", patch, false)
 
 	// Assert
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockAttributeService.AssertExpectations(t)
+}
+func TestService_UpdateUser_InvalidAttributes(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockAttributeService := &MockAttributeService{}
+	service := &service{repo: mockRepo, attributeService: mockAttributeService}
+
+	id := 1
+	patch := map[string]any{"unknown": "value"}
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "email@example.com"}, nil)
+	mockAttributeService.On("Merge", map[string]any(nil), patch).Return(nil, apperror.New(apperror.CodeInvalidUserAttributes, "invalid user attributes"))
+
+	// Act
+	err := service.UpdateUser(id, "", patch, false)
+
+	// Assert
+	assert.Equal(t, apperror.CodeInvalidUserAttributes, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockAttributeService.AssertExpectations(t)
 }
 func TestService_UpdateUser_EmailAlreadyExists(t *testing.T) {
 	// Arrange
@@ -354,7 +572,7 @@ func TestService_UpdateUser_EmailAlreadyExists(t *testing.T) {
 	mockRepo.On("GetByEmail", newEmail).Return(&User{Email: newEmail}, nil)
 
 	// Act
-	err := service.UpdateUser(id, newEmail)
+	err := service.UpdateUser(id, newEmail, nil, false)
 
 	// Assert
 	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
@@ -372,7 +590,7 @@ func TestService_UpdateUser_NotFound(t *testing.T) {
 	mockRepo.On("GetByID", id).Return(nil, nil)
 
 	// Act
-	err := service.UpdateUser(id, newEmail)
+	err := service.UpdateUser(id, newEmail, nil, false)
 
 	// Assert
 	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
@@ -389,33 +607,116 @@ func TestService_UpdateUser_UpdateError(t *testing.T) {
 
 	mockRepo.On("GetByEmail", newEmail).Return(nil, nil)
 	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: oldEmail}, nil)
-	mockRepo.On("Update", &User{ID: id, Email: newEmail}).Return(assert.AnError)
+	mockRepo.On("Update", &User{ID: id, Email: newEmail}, []string{"email"}).Return(assert.AnError)
 
 	// Act
-	err := service.UpdateUser(id, newEmail)
+	err := service.UpdateUser(id, newEmail, nil, false)
 
 	// Assert
 	assert.Equal(t, assert.AnError, err)
 	mockRepo.AssertExpectations(t)
 }
+func TestService_UpdateUser_PrivilegedTarget(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	newEmail := "new-email@example.com"
+
+	mockRepo.On("GetByEmail", newEmail).Return(nil, nil)
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "old-email@example.com", Roles: []string{"admin"}}, nil)
+
+	// Act
+	err := service.UpdateUser(id, newEmail, nil, false)
+
+	// Assert
+	assert.Equal(t, apperror.CodePermissionDenied, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+func TestService_UpdateUser_PrivilegedTargetAllowed(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	newEmail := "new-email@example.com"
+
+	mockRepo.On("GetByEmail", newEmail).Return(nil, nil)
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "old-email@example.com", Roles: []string{"admin"}}, nil)
+	mockRepo.On("Update", &User{ID: id, Email: newEmail, Roles: []string{"admin"}}, []string{"email"}).Return(nil)
+
+	// Act
+	err := service.UpdateUser(id, newEmail, nil, true)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_UpdateUser_PrivilegedTargetAttributes(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockAttributeService := &MockAttributeService{}
+	service := &service{repo: mockRepo, attributeService: mockAttributeService}
+
+	id := 1
+	patch := map[string]any{"department": "engineering"}
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "email@example.com", Roles: []string{"admin"}}, nil)
+
+	// Act
+	err := service.UpdateUser(id, "", patch, false)
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, apperror.CodePermissionDenied, err.(*apperror.AppError).Code)
+	mockAttributeService.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+func TestService_UpdateUser_PrivilegedTargetAttributesAllowed(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockAttributeService := &MockAttributeService{}
+	service := &service{repo: mockRepo, attributeService: mockAttributeService}
+
+	id := 1
+	patch := map[string]any{"department": "engineering"}
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Email: "email@example.com", Roles: []string{"admin"}}, nil)
+	mockAttributeService.On("Merge", map[string]any(nil), patch).Return(patch, nil)
+	mockRepo.On("Update", &User{ID: id, Email: "email@example.com", Roles: []string{"admin"}, Attributes: patch}, []string{"attributes"}).Return(nil)
+
+	// Act
+	err := service.UpdateUser(id, "", patch, true)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockAttributeService.AssertExpectations(t)
+}
 
 func TestService_DeleteUser_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
 	service := &service{repo: mockRepo}
 
 	id := 1
 
 	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
 	mockRepo.On("SoftDelete", id).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserDeleted, mock.Anything).Return(nil)
 
 	// Act
-	err := service.DeleteUser(id)
+	err := service.DeleteUser(id, false)
 
 	// Assert
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
 }
+
 func TestService_DeleteUser_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
@@ -426,7 +727,7 @@ func TestService_DeleteUser_NotFound(t *testing.T) {
 	mockRepo.On("GetByID", id).Return(nil, nil)
 
 	// Act
-	err := service.DeleteUser(id)
+	err := service.DeleteUser(id, false)
 
 	// Assert
 	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
@@ -441,86 +742,713 @@ func TestService_DeleteUser_SoftDeleteError(t *testing.T) {
 
 	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
 	mockRepo.On("SoftDelete", id).Return(assert.AnError)
+	mockRepo.On("Transaction").Return(&MockOutboxWriter{})
 
 	// Act
-	err := service.DeleteUser(id)
+	err := service.DeleteUser(id, false)
 
 	// Assert
 	assert.Equal(t, assert.AnError, err)
 	mockRepo.AssertExpectations(t)
 }
-
-func TestService_RecordUserLogin_Success(t *testing.T) {
+func TestService_DeleteUser_PrivilegedTarget(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
 	service := &service{repo: mockRepo}
 
 	id := 1
-	name := "John Doe"
-	picture := "https://example.com/picture.jpg"
-	loginSub := "google"
 
-	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
-	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
-		return u != nil &&
-			u.LastLoginAt != nil &&
-			u.ID == id &&
-			*u.Name == name &&
-			*u.Picture == picture &&
-			*u.LastLoginSub == loginSub &&
-			time.Since(*u.LastLoginAt) < time.Second
-	})).Return(nil)
+	mockRepo.On("GetByID", id).Return(&User{ID: id, Roles: []string{"auditor"}}, nil)
 
 	// Act
-	err := service.RecordUserLogin(id, name, picture, loginSub)
+	err := service.DeleteUser(id, false)
+
+	// Assert
+	assert.Equal(t, apperror.CodePermissionDenied, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "SoftDelete", id)
+}
+
+func TestService_GetDeletedUsers_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	users := []User{{ID: 1, DeletedAt: &time.Time{}}}
+
+	mockRepo.On("GetDeleted").Return(users, nil)
+
+	// Act
+	result, err := service.GetDeletedUsers()
 
 	// Assert
 	require.NoError(t, err)
+	assert.Equal(t, users, result)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestService_RecordUserLogin_NotFound(t *testing.T) {
+func TestService_RestoreUser_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", DeletedAt: &time.Time{}}
+
+	mockRepo.On("GetDeletedByID", user.ID).Return(user, nil)
+	mockRepo.On("GetByEmail", user.Email).Return(nil, nil)
+	mockRepo.On("Restore", user.ID).Return(nil)
+
+	// Act
+	err := service.RestoreUser(user.ID, false)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_RestoreUser_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
 	service := &service{repo: mockRepo}
 
 	id := 1
-	name := "John Doe"
-	picture := "https://example.com/picture.jpg"
-	loginSub := "google"
 
-	mockRepo.On("GetByID", id).Return(nil, nil)
+	mockRepo.On("GetDeletedByID", id).Return(nil, nil)
 
 	// Act
-	err := service.RecordUserLogin(id, name, picture, loginSub)
+	err := service.RestoreUser(id, false)
 
 	// Assert
 	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
 	mockRepo.AssertExpectations(t)
 }
-func TestService_RecordUserLogin_UpdateError(t *testing.T) {
+func TestService_RestoreUser_EmailAlreadyExists(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{}
 	service := &service{repo: mockRepo}
 
-	id := 1
-	name := "John Doe"
-	picture := "https://example.com/picture.jpg"
-	loginSub := "google"
+	user := &User{ID: 1, Email: "email@example.com", DeletedAt: &time.Time{}}
 
-	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
-	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
-		return u != nil &&
-			u.LastLoginAt != nil &&
-			u.ID == id &&
-			*u.Name == name &&
-			*u.Picture == picture &&
-			*u.LastLoginSub == loginSub &&
-			time.Since(*u.LastLoginAt) < time.Second
-	})).Return(assert.AnError)
+	mockRepo.On("GetDeletedByID", user.ID).Return(user, nil)
+	mockRepo.On("GetByEmail", user.Email).Return(&User{ID: 2, Email: user.Email}, nil)
 
 	// Act
-	err := service.RecordUserLogin(id, name, picture, loginSub)
+	err := service.RestoreUser(user.ID, false)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserEmailInUse, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Restore", user.ID)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_RestoreUser_RestoreError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", DeletedAt: &time.Time{}}
+
+	mockRepo.On("GetDeletedByID", user.ID).Return(user, nil)
+	mockRepo.On("GetByEmail", user.Email).Return(nil, nil)
+	mockRepo.On("Restore", user.ID).Return(assert.AnError)
+
+	// Act
+	err := service.RestoreUser(user.ID, false)
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_RestoreUser_PrivilegedTarget(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", Roles: []string{"admin"}, DeletedAt: &time.Time{}}
+
+	mockRepo.On("GetDeletedByID", user.ID).Return(user, nil)
+
+	// Act
+	err := service.RestoreUser(user.ID, false)
+
+	// Assert
+	assert.Equal(t, apperror.CodePermissionDenied, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Restore", user.ID)
+}
+
+func TestService_HardDeleteUser_ActiveUser(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
+	mockRepo.On("HardDelete", id).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserDeleted, mock.Anything).Return(nil)
+
+	// Act
+	err := service.HardDeleteUser(id)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_HardDeleteUser_SoftDeletedUser(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+	mockRepo.On("GetDeletedByID", id).Return(&User{ID: id, DeletedAt: &time.Time{}}, nil)
+	mockRepo.On("HardDelete", id).Return(nil)
+	mockRepo.On("Transaction").Return(&MockOutboxWriter{})
+
+	// Act
+	err := service.HardDeleteUser(id)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_HardDeleteUser_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+	mockRepo.On("GetDeletedByID", id).Return(nil, nil)
+
+	// Act
+	err := service.HardDeleteUser(id)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_PurgeDeletedUsers_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	retention := 24 * time.Hour
+
+	mockRepo.On("PurgeDeletedBefore", mock.MatchedBy(func(cutoff time.Time) bool {
+		return time.Since(cutoff.Add(retention)) < time.Second
+	})).Return(int64(2), nil)
+
+	// Act
+	count, err := service.PurgeDeletedUsers(retention)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_SetUserRoles_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", Roles: []string{"viewer"}}
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("Update", &User{ID: 1, Email: "email@example.com", Roles: []string{"admin", "viewer"}}, []string{"roles"}).Return(nil)
+
+	// Act
+	err := service.SetUserRoles(user.ID, []string{" Admin", "viewer", "admin"})
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_SetUserRoles_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+
+	// Act
+	err := service.SetUserRoles(id, []string{"admin"})
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_SuspendUser_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", Status: StatusActive}
+	reason := "policy violation"
+	until := time.Now().Add(time.Hour)
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
+		return u.Status == StatusSuspended && *u.StatusReason == reason && u.SuspendedUntil.Equal(until)
+	}), statusColumns).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserSuspended, mock.Anything).Return(nil)
+
+	// Act
+	err := service.SuspendUser(user.ID, &reason, &until)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_SuspendUser_UntilInPast(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	until := time.Now().Add(-time.Hour)
+
+	// Act
+	err := service.SuspendUser(1, nil, &until)
+
+	// Assert
+	assert.Equal(t, apperror.CodeInvalidSuspension, err.(*apperror.AppError).Code)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+func TestService_SuspendUser_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+
+	// Act
+	err := service.SuspendUser(id, nil, nil)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ReactivateUser_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	reason := "policy violation"
+	user := &User{ID: 1, Email: "email@example.com", Status: StatusSuspended, StatusReason: &reason}
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
+		return u.Status == StatusActive && u.StatusReason == nil && u.SuspendedUntil == nil
+	}), statusColumns).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserReactivated, mock.Anything).Return(nil)
+
+	// Act
+	err := service.ReactivateUser(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_ReactivateUser_Pending(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Email: "email@example.com", Status: StatusPending}
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
+		return u.Status == StatusActive
+	}), statusColumns).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserReactivated, mock.Anything).Return(nil)
+
+	// Act
+	err := service.ReactivateUser(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_ReactivateUser_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+
+	// Act
+	err := service.ReactivateUser(id)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CheckUserStatus_Active(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Status: StatusActive}
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+	// Act
+	err := service.CheckUserStatus(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_CheckUserStatus_Suspended(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	user := &User{ID: 1, Status: StatusSuspended}
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+	// Act
+	err := service.CheckUserStatus(user.ID)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserSuspended, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_CheckUserStatus_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+
+	// Act
+	err := service.CheckUserStatus(id)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotAuthorized, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUser_CheckStatus_SuspensionExpired(t *testing.T) {
+	// Arrange
+	user := &User{ID: 1, Status: StatusSuspended, SuspendedUntil: test.TimePtr(time.Now().Add(-time.Minute))}
+
+	// Act
+	err := user.CheckStatus()
+
+	// Assert
+	assert.NoError(t, err)
+}
+func TestUser_CheckStatus_Pending(t *testing.T) {
+	// Arrange
+	user := &User{ID: 1, Status: StatusPending}
+
+	// Act
+	err := user.CheckStatus()
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserPending, err.(*apperror.AppError).Code)
+}
+
+func TestService_RecordUserLogin_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	name := "John Doe"
+	picture := "https://example.com/picture.jpg"
+	loginSub := "google"
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
+		return u != nil &&
+			u.LastLoginAt != nil &&
+			u.ID == id &&
+			*u.Name == name &&
+			*u.Picture == picture &&
+			*u.LastLoginSub == loginSub &&
+			time.Since(*u.LastLoginAt) < time.Second
+	}), []string{"name", "picture", "last_login_sub", "last_login_at"}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserLogin, mock.MatchedBy(func(data *EventData) bool {
+		return data.ID == id && *data.Name == name
+	})).Return(nil)
+
+	// Act
+	err := service.RecordUserLogin(id, name, picture, loginSub)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_RecordUserLogin_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	name := "John Doe"
+	picture := "https://example.com/picture.jpg"
+	loginSub := "google"
+
+	mockRepo.On("GetByID", id).Return(nil, nil)
+
+	// Act
+	err := service.RecordUserLogin(id, name, picture, loginSub)
+
+	// Assert
+	assert.Equal(t, apperror.CodeUserNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_RecordUserLogin_UpdateError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	id := 1
+	name := "John Doe"
+	picture := "https://example.com/picture.jpg"
+	loginSub := "google"
+
+	mockRepo.On("GetByID", id).Return(&User{ID: id}, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *User) bool {
+		return u != nil &&
+			u.LastLoginAt != nil &&
+			u.ID == id &&
+			*u.Name == name &&
+			*u.Picture == picture &&
+			*u.LastLoginSub == loginSub &&
+			time.Since(*u.LastLoginAt) < time.Second
+	}), []string{"name", "picture", "last_login_sub", "last_login_at"}).Return(assert.AnError)
+	mockRepo.On("Transaction").Return(&MockOutboxWriter{})
+
+	// Act
+	err := service.RecordUserLogin(id, name, picture, loginSub)
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportUsers_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	name := "New User"
+	entries := []ImportEntry{
+		{Line: 2, Email: " New@Example.com ", Name: &name, Roles: []string{"Admin", " admin", ""}},
+		{Line: 3, Email: "existing@example.com"},
+		{Line: 4, Email: "not-an-email"},
+		{Line: 5, Email: "new@example.com"},
+		{Line: 6, Email: ""},
+	}
+
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, nil)
+	mockRepo.On("GetByEmail", "existing@example.com").Return(&User{ID: 1, Email: "existing@example.com"}, nil)
+	mockRepo.On("Create", &User{Email: "new@example.com", Name: &name, Roles: []string{"admin"}}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(nil)
+
+	// Act
+	results, err := service.ImportUsers(entries, false, true)
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportResult{
+		{Line: 2, Email: "new@example.com", Status: ImportStatusCreated},
+		{Line: 3, Email: "existing@example.com", Status: ImportStatusSkipped, Reason: "user email already in use"},
+		{Line: 4, Email: "not-an-email", Status: ImportStatusInvalid, Reason: "invalid email format"},
+		{Line: 5, Email: "new@example.com", Status: ImportStatusInvalid, Reason: "duplicate email in file | line: 2"},
+		{Line: 6, Email: "", Status: ImportStatusInvalid, Reason: "missing email"},
+	}
+	assert.Equal(t, expected, results)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_ImportUsers_DryRun(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	entries := []ImportEntry{{Line: 1, Email: "new@example.com"}}
+
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, nil)
+
+	// Act
+	results, err := service.ImportUsers(entries, true, false)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []ImportResult{{Line: 1, Email: "new@example.com", Status: ImportStatusValid}}, results)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_ImportUsers_RolesNotAllowed(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	entries := []ImportEntry{
+		{Line: 1, Email: "new@example.com", Roles: []string{"admin"}},
+		{Line: 2, Email: "blank@example.com", Roles: []string{" "}},
+	}
+
+	mockRepo.On("GetByEmail", "blank@example.com").Return(nil, nil)
+
+	// Act
+	results, err := service.ImportUsers(entries, true, false)
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportResult{
+		{Line: 1, Email: "new@example.com", Status: ImportStatusInvalid, Reason: "roles require the admin role"},
+		{Line: 2, Email: "blank@example.com", Status: ImportStatusValid},
+	}
+	assert.Equal(t, expected, results)
+	mockRepo.AssertNotCalled(t, "GetByEmail", "new@example.com")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportUsers_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	entries := []ImportEntry{{Line: 1, Email: "new@example.com"}}
+
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, nil)
+	mockRepo.On("Create", &User{Email: "new@example.com"}).Return(assert.AnError)
+	mockRepo.On("Transaction").Return(&MockOutboxWriter{})
+
+	// Act
+	results, err := service.ImportUsers(entries, false, false)
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	assert.Nil(t, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportUsers_Pending(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	entries := []ImportEntry{
+		{Line: 1, Email: "pending@example.com", Status: " Pending "},
+		{Line: 2, Email: "suspended@example.com", Status: StatusSuspended},
+	}
+
+	mockRepo.On("GetByEmail", "pending@example.com").Return(nil, nil)
+	mockRepo.On("Create", &User{Email: "pending@example.com", Status: StatusPending}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(nil)
+
+	// Act
+	results, err := service.ImportUsers(entries, false, false)
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportResult{
+		{Line: 1, Email: "pending@example.com", Status: ImportStatusCreated},
+		{Line: 2, Email: "suspended@example.com", Status: ImportStatusInvalid, Reason: "invalid status | status: suspended"},
+	}
+	assert.Equal(t, expected, results)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestService_ImportUsers_CreatedConcurrently(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	mockEvents := &MockOutboxWriter{}
+	service := &service{repo: mockRepo}
+
+	entries := []ImportEntry{{Line: 1, Email: "taken@example.com"}, {Line: 2, Email: "new@example.com"}}
+
+	mockRepo.On("GetByEmail", "taken@example.com").Return(nil, nil)
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, nil)
+	mockRepo.On("Create", &User{Email: "taken@example.com"}).Return(apperror.New(apperror.CodeUserEmailInUse, "user email already in use | email: taken@example.com"))
+	mockRepo.On("Create", &User{Email: "new@example.com"}).Return(nil)
+	mockRepo.On("Transaction").Return(mockEvents)
+	mockEvents.On("Write", outbox.EventUserCreated, mock.Anything).Return(nil)
+
+	// Act
+	results, err := service.ImportUsers(entries, false, false)
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportResult{
+		{Line: 1, Email: "taken@example.com", Status: ImportStatusSkipped, Reason: "user email already in use"},
+		{Line: 2, Email: "new@example.com", Status: ImportStatusCreated},
+	}
+	assert.Equal(t, expected, results)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertNumberOfCalls(t, "Write", 1)
+}
+
+func TestService_ExportUsers_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	fn := func(users []User) error { return nil }
+
+	mockRepo.On("FindInBatches", exportBatchSize, mock.Anything).Return(nil)
+
+	// Act
+	err := service.ExportUsers(fn)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_ExportUsers_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := &service{repo: mockRepo}
+
+	mockRepo.On("FindInBatches", exportBatchSize, mock.Anything).Return(assert.AnError)
+
+	// Act
+	err := service.ExportUsers(func(users []User) error { return nil })
 
 	// Assert
 	assert.Equal(t, assert.AnError, err)
@@ -0,0 +1,215 @@
+package user
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	MIMETypeCSV    = "text/csv"
+	MIMETypeNDJSON = "application/x-ndjson"
+
+	roleSeparator = ";"
+
+	// maxImportLineSize bounds an NDJSON record, well above any real user
+	maxImportLineSize = 64 << 10
+)
+
+var exportCSVHeader = []string{"id", "email", "name", "picture", "roles", "last_login_at", "created_at", "updated_at"}
+
+type importRecord struct {
+	Email  string   `json:"email"`
+	Name   *string  `json:"name"`
+	Roles  []string `json:"roles"`
+	Status Status   `json:"status"`
+}
+
+func splitRoles(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, roleSeparator)
+}
+
+func decodeImport(contentType string, r io.Reader) ([]ImportEntry, error) {
+	switch contentType {
+	case MIMETypeCSV:
+		return decodeCSVImport(r)
+	case MIMETypeNDJSON, "application/jsonl":
+		return decodeNDJSONImport(r)
+	default:
+		return nil, apperror.New(apperror.CodeUnsupportedImportFormat, "unsupported import format | content type: "+contentType)
+	}
+}
+
+func decodeCSVImport(r io.Reader) ([]ImportEntry, error) {
+	// spreadsheets such as Excel start their UTF-8 exports with a byte order
+	// mark, which would otherwise become part of the first column name
+	br := bufio.NewReader(r)
+	if bom, _, err := br.ReadRune(); err == nil && bom != '\ufeff' {
+		_ = br.UnreadRune()
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, apperror.New(apperror.CodeInvalidImportFile, "missing csv header")
+	}
+	if err != nil {
+		return nil, apperror.New(apperror.CodeInvalidImportFile, "failed to read csv header | "+err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	emailIndex, ok := columns["email"]
+	if !ok {
+		return nil, apperror.New(apperror.CodeInvalidImportFile, "missing email column in csv header")
+	}
+	nameIndex, hasName := columns["name"]
+	rolesIndex, hasRoles := columns["roles"]
+	statusIndex, hasStatus := columns["status"]
+
+	field := func(record []string, index int) string {
+		if index < len(record) {
+			return strings.TrimSpace(record[index])
+		}
+		return ""
+	}
+
+	var entries []ImportEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, apperror.New(apperror.CodeInvalidImportFile, "failed to read csv record | "+err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		entry := ImportEntry{Line: line, Email: field(record, emailIndex)}
+		if hasName {
+			if name := field(record, nameIndex); name != "" {
+				entry.Name = &name
+			}
+		}
+		if hasRoles {
+			entry.Roles = splitRoles(field(record, rolesIndex))
+		}
+		if hasStatus {
+			entry.Status = Status(field(record, statusIndex))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func decodeNDJSONImport(r io.Reader) ([]ImportEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLineSize)
+
+	var entries []ImportEntry
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record importRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, apperror.New(apperror.CodeInvalidImportFile, "failed to parse ndjson record | line: "+strconv.Itoa(line)+" | "+err.Error())
+		}
+		entries = append(entries, ImportEntry{Line: line, Email: record.Email, Name: record.Name, Roles: record.Roles, Status: record.Status})
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, apperror.New(apperror.CodeInvalidImportFile, "ndjson record too long | line: "+strconv.Itoa(line+1)+" | max bytes: "+strconv.Itoa(maxImportLineSize))
+		}
+		return nil, apperror.New(apperror.CodeInvalidImportFile, "failed to read ndjson | "+err.Error())
+	}
+	return entries, nil
+}
+
+type exportEncoder interface {
+	Encode(u *User) error
+	Flush() error
+}
+
+func newExportEncoder(format string, w io.Writer) exportEncoder {
+	if format == FormatNDJSON {
+		return &ndjsonExportEncoder{encoder: json.NewEncoder(w)}
+	}
+	return &csvExportEncoder{writer: csv.NewWriter(w)}
+}
+
+type csvExportEncoder struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvExportEncoder) Encode(u *User) error {
+	if !e.headerWritten {
+		if err := e.writer.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	res := newUserResponse(u)
+	return e.writer.Write([]string{
+		strconv.Itoa(res.ID),
+		res.Email,
+		optional(res.Name),
+		optional(res.Picture),
+		strings.Join(res.Roles, roleSeparator),
+		optional(res.LastLoginAt),
+		res.CreatedAt,
+		res.UpdatedAt,
+	})
+}
+
+func (e *csvExportEncoder) Flush() error {
+	if !e.headerWritten {
+		if err := e.writer.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExportEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportEncoder) Encode(u *User) error {
+	return e.encoder.Encode(newUserResponse(u))
+}
+
+func (e *ndjsonExportEncoder) Flush() error {
+	return nil
+}
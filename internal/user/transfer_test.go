@@ -0,0 +1,180 @@
+package user
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer_decodeImport_CSV(t *testing.T) {
+	// Arrange
+	payload := "Name,Email,Roles,Status\n" +
+		"User One,user1@example.com,admin;viewer,\n" +
+		",user2@example.com,,pending\n" +
+		"User Three\n"
+
+	// Act
+	entries, err := decodeImport(MIMETypeCSV, strings.NewReader(payload))
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportEntry{
+		{Line: 2, Email: "user1@example.com", Name: test.StringPtr("User One"), Roles: []string{"admin", "viewer"}},
+		{Line: 3, Email: "user2@example.com", Status: StatusPending},
+		{Line: 4, Email: "", Name: test.StringPtr("User Three")},
+	}
+	assert.Equal(t, expected, entries)
+}
+func TestTransfer_decodeImport_CSVByteOrderMark(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"unquoted", "\ufeffEmail,Name\n"},
+		{"quoted", "\ufeff\"Email\",\"Name\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			payload := tt.header + "user1@example.com,User One\n"
+
+			// Act
+			entries, err := decodeImport(MIMETypeCSV, strings.NewReader(payload))
+
+			// Assert
+			require.NoError(t, err)
+			expected := []ImportEntry{{Line: 2, Email: "user1@example.com", Name: test.StringPtr("User One")}}
+			assert.Equal(t, expected, entries)
+		})
+	}
+}
+func TestTransfer_decodeImport_NDJSON(t *testing.T) {
+	// Arrange
+	payload := `{"email":"user1@example.com","name":"User One","roles":["admin"]}` + "\n" +
+		"\n" +
+		`{"email":"user2@example.com","status":"pending"}` + "\n"
+
+	// Act
+	entries, err := decodeImport(MIMETypeNDJSON, strings.NewReader(payload))
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportEntry{
+		{Line: 1, Email: "user1@example.com", Name: test.StringPtr("User One"), Roles: []string{"admin"}},
+		{Line: 3, Email: "user2@example.com", Status: StatusPending},
+	}
+	assert.Equal(t, expected, entries)
+}
+func TestTransfer_decodeImport_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		payload     string
+		code        string
+	}{
+		{
+			name:        "unsupported content type",
+			contentType: "application/json",
+			payload:     `[]`,
+			code:        apperror.CodeUnsupportedImportFormat,
+		},
+		{
+			name:        "empty csv",
+			contentType: MIMETypeCSV,
+			payload:     "",
+			code:        apperror.CodeInvalidImportFile,
+		},
+		{
+			name:        "csv without email column",
+			contentType: MIMETypeCSV,
+			payload:     "name,roles\nUser,admin\n",
+			code:        apperror.CodeInvalidImportFile,
+		},
+		{
+			name:        "malformed csv",
+			contentType: MIMETypeCSV,
+			payload:     "email\n\"user@example.com\n",
+			code:        apperror.CodeInvalidImportFile,
+		},
+		{
+			name:        "malformed ndjson",
+			contentType: MIMETypeNDJSON,
+			payload:     "{\"email\":\n",
+			code:        apperror.CodeInvalidImportFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			entries, err := decodeImport(tt.contentType, strings.NewReader(tt.payload))
+
+			// Assert
+			assert.Nil(t, entries)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, err.(*apperror.AppError).Code)
+		})
+	}
+}
+
+func TestTransfer_decodeImport_NDJSONLineTooLong(t *testing.T) {
+	// Arrange
+	payload := `{"email":"user1@example.com"}` + "\n" + `{"email":"` + strings.Repeat("a", maxImportLineSize) + `"}` + "\n"
+
+	// Act
+	entries, err := decodeImport(MIMETypeNDJSON, strings.NewReader(payload))
+
+	// Assert
+	assert.Nil(t, entries)
+	require.Error(t, err)
+	appErr := err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidImportFile, appErr.Code)
+	assert.Equal(t, "ndjson record too long | line: 2 | max bytes: 65536", appErr.Message)
+}
+
+func TestTransfer_newExportEncoder_EmptyCSV(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	encoder := newExportEncoder(FormatCSV, &buf)
+
+	// Act
+	err := encoder.Flush()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "id,email,name,picture,roles,last_login_at,created_at,updated_at\n", buf.String())
+}
+func TestTransfer_newExportEncoder_CSVRoundTrip(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	encoder := newExportEncoder(FormatCSV, &buf)
+	user := &User{
+		ID:        1,
+		Name:      test.StringPtr("Doe, Jane"),
+		Email:     "jane@example.com",
+		Roles:     []string{"admin"},
+		CreatedAt: time.Unix(1, 0),
+		UpdatedAt: time.Unix(1, 0),
+	}
+
+	// Act
+	err := encoder.Encode(user)
+	require.NoError(t, err)
+	err = encoder.Flush()
+	require.NoError(t, err)
+	entries, err := decodeImport(MIMETypeCSV, &buf)
+
+	// Assert
+	require.NoError(t, err)
+	expected := []ImportEntry{
+		{Line: 2, Email: "jane@example.com", Name: test.StringPtr("Doe, Jane"), Roles: []string{"admin"}},
+	}
+	assert.Equal(t, expected, entries)
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type RequestURI struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type DeliveryURI struct {
+	ID         int   `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+type SubscriptionBody struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"required,min=1,dive,required,max=64"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Active      *bool    `json:"active"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=255"`
+}
+
+func (b *SubscriptionBody) toSubscription(id int) *Subscription {
+	active := true
+	if b.Active != nil {
+		active = *b.Active
+	}
+	return &Subscription{
+		ID:          id,
+		URL:         b.URL,
+		Secret:      b.Secret,
+		Events:      b.Events,
+		Description: b.Description,
+		Active:      active,
+	}
+}
+
+type GetDeliveriesQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+}
+
+type SubscriptionResponse struct {
+	ID          int      `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description *string  `json:"description"`
+	Active      bool     `json:"active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+func newSubscriptionResponse(s *Subscription) *SubscriptionResponse {
+	return &SubscriptionResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		Events:      s.Events,
+		Description: s.Description,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   s.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// CreatedSubscriptionResponse is the only response that carries the signing
+// secret.
+type CreatedSubscriptionResponse struct {
+	SubscriptionResponse
+	Secret string `json:"secret"`
+}
+
+func newCreatedSubscriptionResponse(s *Subscription) *CreatedSubscriptionResponse {
+	return &CreatedSubscriptionResponse{
+		SubscriptionResponse: *newSubscriptionResponse(s),
+		Secret:               s.Secret,
+	}
+}
+
+type DeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *string         `json:"delivered_at"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+func newDeliveryResponse(d *Delivery) *DeliveryResponse {
+	var nextAttemptAt *string
+	if d.Status == DeliveryStatusPending {
+		formatted := d.NextAttemptAt.Format(time.RFC3339)
+		nextAttemptAt = &formatted
+	}
+	var deliveredAt *string
+	if d.DeliveredAt != nil {
+		formatted := d.DeliveredAt.Format(time.RFC3339)
+		deliveredAt = &formatted
+	}
+	return &DeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    deliveredAt,
+		Payload:        d.Payload,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetSubscriptions(c *gin.Context) {
+	subscriptions, err := h.service.GetSubscriptions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	subscriptionResponses := make([]SubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		subscriptionResponses[i] = *newSubscriptionResponse(&subscription)
+	}
+
+	c.JSON(http.StatusOK, subscriptionResponses)
+}
+
+func (h *Handler) GetSubscription(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	subscription, err := h.service.GetSubscriptionByID(uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSubscriptionResponse(subscription))
+}
+
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	var body SubscriptionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	subscription := body.toSubscription(0)
+	err := h.service.CreateSubscription(subscription)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCreatedSubscriptionResponse(subscription))
+}
+
+func (h *Handler) UpdateSubscription(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var body SubscriptionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(apperror.NewBindError("body", err))
+		return
+	}
+
+	subscription := body.toSubscription(uri.ID)
+	err := h.service.UpdateSubscription(subscription)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSubscriptionResponse(subscription))
+}
+
+func (h *Handler) DeleteSubscription(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	err := h.service.DeleteSubscription(uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	var uri RequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+	var query GetDeliveriesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.NewBindError("query", err))
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(uri.ID, DeliveryStatus(query.Status))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deliveryResponses := make([]DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryResponses[i] = *newDeliveryResponse(&delivery)
+	}
+
+	c.JSON(http.StatusOK, deliveryResponses)
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	var uri DeliveryURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(apperror.NewBindError("uri", err))
+		return
+	}
+
+	delivery, err := h.service.Redeliver(uri.ID, uri.DeliveryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newDeliveryResponse(delivery))
+}
@@ -0,0 +1,360 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Publish(event outbox.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+func (m *MockService) GetSubscriptions() ([]Subscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Subscription), args.Error(1)
+}
+func (m *MockService) GetSubscriptionByID(id int) (*Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+func (m *MockService) CreateSubscription(subscription *Subscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+func (m *MockService) UpdateSubscription(subscription *Subscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+func (m *MockService) DeleteSubscription(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockService) GetDeliveries(subscriptionID int, status DeliveryStatus) ([]Delivery, error) {
+	args := m.Called(subscriptionID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Delivery), args.Error(1)
+}
+func (m *MockService) Redeliver(subscriptionID int, deliveryID int64) (*Delivery, error) {
+	args := m.Called(subscriptionID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Delivery), args.Error(1)
+}
+func (m *MockService) DeliverDue() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func TestHandler_NewHandler_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+
+	// Act
+	handler := NewHandler(mockService)
+
+	// Assert
+	assert.NotNil(t, handler)
+	assert.Equal(t, mockService, handler.service)
+}
+
+func TestHandler_GetSubscriptions_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	subscriptions := []Subscription{
+		{ID: 1, URL: "https://example.com/hook", Secret: "whsec_secret", Events: []string{outbox.EventUserCreated}, Active: true, CreatedAt: time.Unix(1, 0), UpdatedAt: time.Unix(1, 0)},
+	}
+
+	mockService.On("GetSubscriptions").Return(subscriptions, nil)
+
+	// Act
+	handler.GetSubscriptions(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_secret")
+
+	var actual []SubscriptionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []SubscriptionResponse{
+		{
+			ID:        1,
+			URL:       "https://example.com/hook",
+			Events:    []string{outbox.EventUserCreated},
+			Active:    true,
+			CreatedAt: time.Unix(1, 0).Format(time.RFC3339),
+			UpdatedAt: time.Unix(1, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetSubscriptions_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	mockService.On("GetSubscriptions").Return(nil, assert.AnError)
+
+	// Act
+	handler.GetSubscriptions(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, assert.AnError, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetSubscription_NotFound(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, _ := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	appErr := apperror.New(apperror.CodeWebhookNotFound, "webhook not found | id: 1")
+
+	mockService.On("GetSubscriptionByID", 1).Return(nil, appErr)
+
+	// Act
+	handler.GetSubscription(c)
+
+	// Assert
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, appErr, c.Errors[0].Err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateSubscription_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"url":"https://example.com/hook","events":["user.created"]}`))
+
+	mockService.On("CreateSubscription", &Subscription{URL: "https://example.com/hook", Events: []string{outbox.EventUserCreated}, Active: true}).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*Subscription).Secret = "whsec_generated"
+		}).
+		Return(nil)
+
+	// Act
+	handler.CreateSubscription(c)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var actual CreatedSubscriptionResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_generated", actual.Secret)
+	assert.Equal(t, "https://example.com/hook", actual.URL)
+	assert.True(t, actual.Active)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_CreateSubscription_InvalidBody(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"url":"not a url","events":[]}`))
+
+	// Act
+	handler.CreateSubscription(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	assert.Contains(t, appErr.Message, "request body validation failed")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_UpdateSubscription_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request.Body = io.NopCloser(bytes.NewBufferString(`{"url":"https://example.com/hook","events":["*"],"active":false}`))
+
+	mockService.On("UpdateSubscription", &Subscription{ID: 1, URL: "https://example.com/hook", Events: []string{EventAll}, Active: false}).Return(nil)
+
+	// Act
+	handler.UpdateSubscription(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_DeleteSubscription_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	mockService.On("DeleteSubscription", 1).Return(nil)
+
+	// Act
+	handler.DeleteSubscription(c)
+	c.Writer.WriteHeaderNow()
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_DeleteSubscription_InvalidURI(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	// Act
+	handler.DeleteSubscription(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+	assert.Contains(t, appErr.Message, "invalid request uri")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetDeliveries_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request.URL.RawQuery = "status=dead"
+	statusCode := http.StatusGone
+	deliveries := []Delivery{
+		{
+			ID:             2,
+			EventID:        "c0ffee",
+			EventType:      outbox.EventUserDeleted,
+			Payload:        json.RawMessage(`{"id":"c0ffee"}`),
+			Status:         DeliveryStatusDead,
+			Attempts:       8,
+			LastStatusCode: &statusCode,
+			LastError:      test.StringPtr("unexpected response status | status: 410"),
+			CreatedAt:      time.Unix(1, 0),
+		},
+	}
+
+	mockService.On("GetDeliveries", 1, DeliveryStatusDead).Return(deliveries, nil)
+
+	// Act
+	handler.GetDeliveries(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual []DeliveryResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []DeliveryResponse{
+		{
+			ID:             2,
+			EventID:        "c0ffee",
+			EventType:      outbox.EventUserDeleted,
+			Status:         "dead",
+			Attempts:       8,
+			LastStatusCode: &statusCode,
+			LastError:      test.StringPtr("unexpected response status | status: 410"),
+			Payload:        json.RawMessage(`{"id":"c0ffee"}`),
+			CreatedAt:      time.Unix(1, 0).Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+	mockService.AssertExpectations(t)
+}
+func TestHandler_GetDeliveries_InvalidStatus(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request.URL.RawQuery = "status=failed"
+
+	// Act
+	handler.GetDeliveries(c)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, c.Errors, 1)
+	appErr := c.Errors[0].Err.(*apperror.AppError)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	assert.Contains(t, appErr.Message, "request query validation failed")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Redeliver_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	handler := NewHandler(mockService)
+	c, w := test.SetupContext()
+
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "delivery_id", Value: "2"}}
+	delivery := &Delivery{ID: 2, Status: DeliveryStatusPending, NextAttemptAt: time.Unix(1, 0), Payload: json.RawMessage(`{}`)}
+
+	mockService.On("Redeliver", 1, int64(2)).Return(delivery, nil)
+
+	// Act
+	handler.Redeliver(c)
+
+	// Assert
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var actual DeliveryResponse
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", actual.Status)
+	require.NotNil(t, actual.NextAttemptAt)
+	assert.Equal(t, time.Unix(1, 0).Format(time.RFC3339), *actual.NextAttemptAt)
+	mockService.AssertExpectations(t)
+}
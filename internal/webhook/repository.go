@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusDead      DeliveryStatus = "dead"
+)
+
+type Subscription struct {
+	ID          int       `gorm:"primaryKey;autoIncrement"`
+	URL         string    `gorm:"type:varchar(2048);not null"`
+	Secret      string    `gorm:"type:varchar(255);not null"`
+	Events      []string  `gorm:"type:jsonb;serializer:json;not null"`
+	Description *string   `gorm:"type:varchar(255)"`
+	Active      bool      `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"type:timestamptz;not null"`
+	UpdatedAt   time.Time `gorm:"type:timestamptz;not null"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+type Delivery struct {
+	ID             int64           `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID int             `gorm:"not null;index;uniqueIndex:idx_webhook_deliveries_subscription_event"`
+	Subscription   *Subscription   `gorm:"constraint:OnDelete:CASCADE"`
+	EventID        string          `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_subscription_event"`
+	EventType      string          `gorm:"type:varchar(64);not null"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null"`
+	Status         DeliveryStatus  `gorm:"type:varchar(16);not null;index"`
+	Attempts       int             `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time       `gorm:"type:timestamptz;not null;index"`
+	LastStatusCode *int
+	LastError      *string    `gorm:"type:text"`
+	DeliveredAt    *time.Time `gorm:"type:timestamptz"`
+	CreatedAt      time.Time  `gorm:"type:timestamptz;not null"`
+	UpdatedAt      time.Time  `gorm:"type:timestamptz;not null"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+type Repository interface {
+	GetSubscriptions() ([]Subscription, error)
+	GetSubscriptionByID(id int) (*Subscription, error)
+	GetActiveSubscriptions() ([]Subscription, error)
+	CreateSubscription(subscription *Subscription) error
+	UpdateSubscription(subscription *Subscription) error
+	DeleteSubscription(id int) error
+	GetDeliveries(subscriptionID int, status DeliveryStatus, limit int) ([]Delivery, error)
+	GetDeliveryByID(subscriptionID int, id int64) (*Delivery, error)
+	CreateDeliveries(deliveries []Delivery) error
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	UpdateDelivery(delivery *Delivery) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetSubscriptions() ([]Subscription, error) {
+	var subscriptions []Subscription
+	err := r.db.Order("id").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *repository) GetSubscriptionByID(id int) (*Subscription, error) {
+	var subscription Subscription
+	err := r.db.First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *repository) GetActiveSubscriptions() ([]Subscription, error) {
+	var subscriptions []Subscription
+	err := r.db.Where("active").Order("id").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *repository) CreateSubscription(subscription *Subscription) error {
+	return r.db.Create(subscription).Error
+}
+
+func (r *repository) UpdateSubscription(subscription *Subscription) error {
+	return r.db.Save(subscription).Error
+}
+
+func (r *repository) DeleteSubscription(id int) error {
+	return r.db.Delete(&Subscription{}, id).Error
+}
+
+// GetDeliveries returns the newest deliveries of a subscription first. An
+// empty status returns deliveries in every state.
+func (r *repository) GetDeliveries(subscriptionID int, status DeliveryStatus, limit int) ([]Delivery, error) {
+	query := r.db.Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []Delivery
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *repository) GetDeliveryByID(subscriptionID int, id int64) (*Delivery, error) {
+	var delivery Delivery
+	err := r.db.Where("subscription_id = ?", subscriptionID).First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// CreateDeliveries skips deliveries of an event a subscription already has,
+// so that an event published twice is still sent once.
+func (r *repository) CreateDeliveries(deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries locks pending deliveries whose next attempt is due and
+// pushes their next attempt back by the lease, so that other instances skip
+// them while this one is sending. A crashed sender simply lets the lease run
+// out and the delivery is picked up again.
+func (r *repository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	var ids []int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Delivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", DeliveryStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&Delivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	// the subscription is loaded after the claim so it reflects the latest secret and URL
+	var deliveries []Delivery
+	err = r.db.Preload("Subscription").Where("id IN ?", ids).Order("id").Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *repository) UpdateDelivery(delivery *Delivery) error {
+	return r.db.Omit("Subscription").Save(delivery).Error
+}
@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/db"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/test"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+var d *gorm.DB
+
+func TestMain(m *testing.M) {
+	// Setup
+	dbURL, closeDB, err := test.SetupPostgresql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d, err = db.NewDatabase(&config.Config{DatabaseURL: dbURL}, noop.NewTracerProvider())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = d.AutoMigrate(&Subscription{}, &Delivery{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run
+	code := m.Run()
+
+	// Teardown
+	closeDB()
+
+	os.Exit(code)
+}
+
+func createSubscription(t *testing.T, repo Repository, active bool, events ...string) *Subscription {
+	subscription := &Subscription{URL: "https://example.com/hook", Secret: "whsec_secret", Events: events, Active: active}
+	err := repo.CreateSubscription(subscription)
+	require.NoError(t, err)
+	return subscription
+}
+
+func newDelivery(subscriptionID int, status DeliveryStatus, nextAttemptAt time.Time) Delivery {
+	return Delivery{
+		SubscriptionID: subscriptionID,
+		EventID:        uuid.New().String(),
+		EventType:      outbox.EventUserCreated,
+		Payload:        json.RawMessage(`{"type":"user.created"}`),
+		Status:         status,
+		NextAttemptAt:  nextAttemptAt,
+	}
+}
+
+func TestRepository_NewRepository_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+
+	// Act
+	repo := NewRepository(d)
+
+	// Assert
+	assert.NotNil(t, repo)
+	assert.IsType(t, &repository{}, repo)
+}
+
+func TestRepository_CreateSubscription_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	subscription := createSubscription(t, repo, false, outbox.EventUserCreated, outbox.EventUserLogin)
+
+	// Assert
+	saved, err := repo.GetSubscriptionByID(subscription.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, []string{outbox.EventUserCreated, outbox.EventUserLogin}, saved.Events)
+	assert.False(t, saved.Active)
+	assert.WithinDuration(t, time.Now(), saved.CreatedAt, time.Second)
+}
+
+func TestRepository_GetSubscriptionByID_NotFound(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	// Act
+	subscription, err := repo.GetSubscriptionByID(1)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, subscription)
+}
+
+func TestRepository_GetActiveSubscriptions_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	active := createSubscription(t, repo, true, EventAll)
+	createSubscription(t, repo, false, EventAll)
+
+	// Act
+	subscriptions, err := repo.GetActiveSubscriptions()
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, active.ID, subscriptions[0].ID)
+}
+
+func TestRepository_DeleteSubscription_CascadesDeliveries(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	subscription := createSubscription(t, repo, true, EventAll)
+	err = repo.CreateDeliveries([]Delivery{newDelivery(subscription.ID, DeliveryStatusPending, time.Now())})
+	require.NoError(t, err)
+
+	// Act
+	err = repo.DeleteSubscription(subscription.ID)
+
+	// Assert
+	require.NoError(t, err)
+	var count int64
+	d.Model(&Delivery{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestRepository_CreateDeliveries_SkipsDuplicateEvent(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	subscription := createSubscription(t, repo, true, EventAll)
+	delivery := newDelivery(subscription.ID, DeliveryStatusPending, time.Now())
+	err = repo.CreateDeliveries([]Delivery{delivery})
+	require.NoError(t, err)
+
+	// Act
+	err = repo.CreateDeliveries([]Delivery{delivery})
+
+	// Assert
+	require.NoError(t, err)
+	deliveries, err := repo.GetDeliveries(subscription.ID, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+}
+
+func TestRepository_GetDeliveries_FilterByStatus(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	subscription := createSubscription(t, repo, true, EventAll)
+	other := createSubscription(t, repo, true, EventAll)
+	err = repo.CreateDeliveries([]Delivery{
+		newDelivery(subscription.ID, DeliveryStatusDead, time.Now()),
+		newDelivery(subscription.ID, DeliveryStatusSucceeded, time.Now()),
+		newDelivery(subscription.ID, DeliveryStatusDead, time.Now()),
+		newDelivery(other.ID, DeliveryStatusDead, time.Now()),
+	})
+	require.NoError(t, err)
+
+	// Act
+	all, err := repo.GetDeliveries(subscription.ID, "", 10)
+	require.NoError(t, err)
+	dead, err := repo.GetDeliveries(subscription.ID, DeliveryStatusDead, 10)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, all, 3)
+	assert.Equal(t, int64(3), all[0].ID)
+	require.Len(t, dead, 2)
+	assert.Equal(t, []int64{3, 1}, []int64{dead[0].ID, dead[1].ID})
+}
+
+func TestRepository_GetDeliveryByID_OtherSubscription(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	subscription := createSubscription(t, repo, true, EventAll)
+	other := createSubscription(t, repo, true, EventAll)
+	err = repo.CreateDeliveries([]Delivery{newDelivery(subscription.ID, DeliveryStatusDead, time.Now())})
+	require.NoError(t, err)
+
+	// Act
+	delivery, err := repo.GetDeliveryByID(other.ID, 1)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, delivery)
+}
+
+func TestRepository_ClaimDueDeliveries_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	now := time.Now()
+	subscription := createSubscription(t, repo, true, EventAll)
+	err = repo.CreateDeliveries([]Delivery{
+		newDelivery(subscription.ID, DeliveryStatusPending, now.Add(-time.Minute)),
+		newDelivery(subscription.ID, DeliveryStatusPending, now.Add(time.Minute)),
+		newDelivery(subscription.ID, DeliveryStatusDead, now.Add(-time.Minute)),
+	})
+	require.NoError(t, err)
+
+	// Act
+	claimed, err := repo.ClaimDueDeliveries(now, time.Minute, 10)
+	require.NoError(t, err)
+	again, err := repo.ClaimDueDeliveries(now, time.Minute, 10)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, claimed, 1)
+	assert.Equal(t, int64(1), claimed[0].ID)
+	require.NotNil(t, claimed[0].Subscription)
+	assert.Equal(t, subscription.Secret, claimed[0].Subscription.Secret)
+	assert.WithinDuration(t, now.Add(time.Minute), claimed[0].NextAttemptAt, time.Second)
+	assert.Empty(t, again)
+}
+
+func TestRepository_UpdateDelivery_Success(t *testing.T) {
+	// Arrange
+	err := test.CleanupTables(d)
+	require.NoError(t, err)
+	repo := NewRepository(d)
+
+	subscription := createSubscription(t, repo, true, EventAll)
+	err = repo.CreateDeliveries([]Delivery{newDelivery(subscription.ID, DeliveryStatusPending, time.Now())})
+	require.NoError(t, err)
+	claimed, err := repo.ClaimDueDeliveries(time.Now(), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	delivery := &claimed[0]
+	delivery.Status = DeliveryStatusSucceeded
+	delivery.Attempts = 1
+	delivery.DeliveredAt = test.TimePtr(time.Now())
+
+	// Act
+	err = repo.UpdateDelivery(delivery)
+
+	// Assert
+	require.NoError(t, err)
+	saved, err := repo.GetDeliveryByID(subscription.ID, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryStatusSucceeded, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.NotNil(t, saved.DeliveredAt)
+}
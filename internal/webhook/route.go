@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"github.com/sninjo/vera-identity-service/internal/middleware"
+	"github.com/sninjo/vera-identity-service/internal/role"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *Handler, authMiddleware middleware.AuthMiddleware) {
+	g := r.Group("/webhooks")
+	g.Use(gin.HandlerFunc(authMiddleware), middleware.RequireRole(role.Admin))
+	{
+		g.GET("", handler.GetSubscriptions)
+		g.POST("", handler.CreateSubscription)
+		g.GET("/:id", handler.GetSubscription)
+		g.PUT("/:id", handler.UpdateSubscription)
+		g.DELETE("/:id", handler.DeleteSubscription)
+		g.GET("/:id/deliveries", handler.GetDeliveries)
+		g.POST("/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+	}
+}
@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// EventAll subscribes to every event type, including ones added later.
+	EventAll = "*"
+
+	SignatureHeader = "X-Vera-Signature"
+	EventHeader     = "X-Vera-Event"
+	EventIDHeader   = "X-Vera-Event-ID"
+	DeliveryHeader  = "X-Vera-Delivery"
+
+	deliveryTimeout      = 10 * time.Second
+	deliveryLease        = time.Minute
+	deliveryBatchSize    = 20
+	deliveryHistoryLimit = 100
+	maxBackoff           = 6 * time.Hour
+	minSecretLength      = 16
+)
+
+type Service interface {
+	Publish(event outbox.Event) error
+	GetSubscriptions() ([]Subscription, error)
+	GetSubscriptionByID(id int) (*Subscription, error)
+	CreateSubscription(subscription *Subscription) error
+	UpdateSubscription(subscription *Subscription) error
+	DeleteSubscription(id int) error
+	GetDeliveries(subscriptionID int, status DeliveryStatus) ([]Delivery, error)
+	Redeliver(subscriptionID int, deliveryID int64) (*Delivery, error)
+	DeliverDue() (int, error)
+}
+
+type service struct {
+	repo        Repository
+	logger      *zap.Logger
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewService(config *config.Config, repo Repository, logger *zap.Logger) Service {
+	return &service{
+		repo:        repo,
+		logger:      logger,
+		client:      &http.Client{Timeout: deliveryTimeout},
+		maxAttempts: config.WebhookMaxAttempts,
+		backoff:     config.WebhookBackoff,
+	}
+}
+
+// Sign returns the signature of a payload sent at the given unix time, in the
+// format of the X-Vera-Signature header. Receivers recompute it with their
+// copy of the secret and compare in constant time.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func validateSubscription(subscription *Subscription) error {
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperror.New(apperror.CodeInvalidWebhook, "webhook url must be an absolute http(s) url | url: "+subscription.URL)
+	}
+	if len(subscription.Events) == 0 {
+		return apperror.New(apperror.CodeInvalidWebhook, "webhook must subscribe to at least one event")
+	}
+	for _, event := range subscription.Events {
+		if event != EventAll && !slices.Contains(outbox.EventTypes, event) {
+			return apperror.New(apperror.CodeInvalidWebhook, "unknown webhook event | event: "+event)
+		}
+	}
+	if subscription.Secret != "" && len(subscription.Secret) < minSecretLength {
+		return apperror.New(apperror.CodeInvalidWebhook, "webhook secret must be at least "+strconv.Itoa(minSecretLength)+" characters")
+	}
+	return nil
+}
+
+func (s *service) GetSubscriptions() ([]Subscription, error) {
+	return s.repo.GetSubscriptions()
+}
+
+func (s *service) GetSubscriptionByID(id int) (*Subscription, error) {
+	subscription, err := s.repo.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, apperror.New(apperror.CodeWebhookNotFound, "webhook not found | id: "+strconv.Itoa(id))
+	}
+	return subscription, nil
+}
+
+// CreateSubscription generates a secret when none is given. The caller sees
+// the secret on the subscription once; it is never returned again.
+func (s *service) CreateSubscription(subscription *Subscription) error {
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+
+	return s.repo.CreateSubscription(subscription)
+}
+
+// UpdateSubscription replaces the settings of an existing subscription and
+// keeps its secret unless a new one is given.
+func (s *service) UpdateSubscription(subscription *Subscription) error {
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	existing, err := s.GetSubscriptionByID(subscription.ID)
+	if err != nil {
+		return err
+	}
+
+	existing.URL = subscription.URL
+	existing.Events = subscription.Events
+	existing.Description = subscription.Description
+	existing.Active = subscription.Active
+	if subscription.Secret != "" {
+		existing.Secret = subscription.Secret
+	}
+	if err := s.repo.UpdateSubscription(existing); err != nil {
+		return err
+	}
+
+	*subscription = *existing
+	return nil
+}
+
+func (s *service) DeleteSubscription(id int) error {
+	if _, err := s.GetSubscriptionByID(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+func (s *service) GetDeliveries(subscriptionID int, status DeliveryStatus) ([]Delivery, error) {
+	if _, err := s.GetSubscriptionByID(subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(subscriptionID, status, deliveryHistoryLimit)
+}
+
+// Redeliver queues a delivery again with a fresh attempt budget, typically to
+// replay a dead delivery once the receiver has been fixed.
+func (s *service) Redeliver(subscriptionID int, deliveryID int64) (*Delivery, error) {
+	delivery, err := s.repo.GetDeliveryByID(subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, apperror.New(
+			apperror.CodeWebhookDeliveryNotFound,
+			"webhook delivery not found | webhook id: "+strconv.Itoa(subscriptionID)+" | delivery id: "+strconv.FormatInt(deliveryID, 10),
+		)
+	}
+
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Publish queues a delivery of an outbox event for every active subscription
+// that listens to it. The event ID is the idempotency key: publishing the same
+// event again does not queue it twice.
+func (s *service) Publish(event outbox.Event) error {
+	subscriptions, err := s.repo.GetActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	var deliveries []Delivery
+	for _, subscription := range subscriptions {
+		if !slices.Contains(subscription.Events, event.Type) && !slices.Contains(subscription.Events, EventAll) {
+			continue
+		}
+		deliveries = append(deliveries, Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Status:         DeliveryStatusPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = payload
+	}
+	return s.repo.CreateDeliveries(deliveries)
+}
+
+// DeliverDue sends one batch of due deliveries concurrently and returns how
+// many were attempted.
+func (s *service) DeliverDue() (int, error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(time.Now(), deliveryLease, deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *Delivery) {
+			defer wg.Done()
+			s.deliver(delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+func (s *service) deliver(delivery *Delivery) {
+	now := time.Now()
+	if delivery.Subscription == nil || !delivery.Subscription.Active {
+		// a disabled webhook is not retried; its deliveries can be replayed once it is enabled again
+		message := "webhook disabled"
+		delivery.Status = DeliveryStatusDead
+		delivery.LastError = &message
+	} else if statusCode, err := s.send(delivery); err == nil {
+		delivery.Attempts++
+		delivery.LastStatusCode = statusCode
+		delivery.Status = DeliveryStatusSucceeded
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.Attempts++
+		delivery.LastStatusCode = statusCode
+		message := err.Error()
+		delivery.LastError = &message
+		if delivery.Attempts >= s.maxAttempts {
+			delivery.Status = DeliveryStatusDead
+		} else {
+			delivery.NextAttemptAt = now.Add(s.retryDelay(delivery.Attempts))
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		s.logger.Error("failed to update webhook delivery", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+// retryDelay doubles the configured backoff after every failed attempt.
+func (s *service) retryDelay(attempts int) time.Duration {
+	delay := s.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+func (s *service) send(delivery *Delivery) (*int, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.Subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vera-identity-service")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Subscription.Secret, time.Now().Unix(), delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &res.StatusCode, errors.New("unexpected response status | status: " + strconv.Itoa(res.StatusCode))
+	}
+	return &res.StatusCode, nil
+}
@@ -0,0 +1,544 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/config"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetSubscriptions() ([]Subscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Subscription), args.Error(1)
+}
+func (m *MockRepository) GetSubscriptionByID(id int) (*Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+func (m *MockRepository) GetActiveSubscriptions() ([]Subscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Subscription), args.Error(1)
+}
+func (m *MockRepository) CreateSubscription(subscription *Subscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+func (m *MockRepository) UpdateSubscription(subscription *Subscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+func (m *MockRepository) DeleteSubscription(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockRepository) GetDeliveries(subscriptionID int, status DeliveryStatus, limit int) ([]Delivery, error) {
+	args := m.Called(subscriptionID, status, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Delivery), args.Error(1)
+}
+func (m *MockRepository) GetDeliveryByID(subscriptionID int, id int64) (*Delivery, error) {
+	args := m.Called(subscriptionID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Delivery), args.Error(1)
+}
+func (m *MockRepository) CreateDeliveries(deliveries []Delivery) error {
+	args := m.Called(deliveries)
+	return args.Error(0)
+}
+func (m *MockRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	args := m.Called(now, lease, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Delivery), args.Error(1)
+}
+func (m *MockRepository) UpdateDelivery(delivery *Delivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func newTestService(repo Repository) *service {
+	return NewService(&config.Config{WebhookMaxAttempts: 3, WebhookBackoff: time.Second}, repo, zap.NewNop()).(*service)
+}
+
+func TestService_NewService_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	config := &config.Config{WebhookMaxAttempts: 5, WebhookBackoff: time.Minute}
+
+	// Act
+	s := NewService(config, mockRepo, zap.NewNop())
+
+	// Assert
+	assert.IsType(t, &service{}, s)
+	assert.Equal(t, mockRepo, s.(*service).repo)
+	assert.Equal(t, 5, s.(*service).maxAttempts)
+	assert.Equal(t, time.Minute, s.(*service).backoff)
+	assert.Equal(t, deliveryTimeout, s.(*service).client.Timeout)
+}
+
+func TestService_Sign_Success(t *testing.T) {
+	// Act
+	signature := Sign("mock-secret", 1700000000, []byte(`{"id":"1"}`))
+
+	// Assert
+	assert.Equal(t, "t=1700000000,v1=66b2840041f2667d068842e8311ddd9d80eb2d1acee882be17f1a2048488a4a4", signature)
+}
+
+func TestService_CreateSubscription_GeneratesSecret(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	subscription := &Subscription{URL: "https://example.com/hook", Events: []string{outbox.EventUserCreated}, Active: true}
+
+	mockRepo.On("CreateSubscription", subscription).Return(nil)
+
+	// Act
+	err := service.CreateSubscription(subscription)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(subscription.Secret, "whsec_"))
+	assert.Len(t, subscription.Secret, len("whsec_")+64)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_CreateSubscription_KeepsGivenSecret(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	subscription := &Subscription{URL: "http://localhost:9000", Events: []string{EventAll}, Secret: "0123456789abcdef"}
+
+	mockRepo.On("CreateSubscription", subscription).Return(nil)
+
+	// Act
+	err := service.CreateSubscription(subscription)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", subscription.Secret)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_CreateSubscription_Invalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription *Subscription
+	}{
+		{"relative url", &Subscription{URL: "/hook", Events: []string{outbox.EventUserCreated}}},
+		{"unsupported scheme", &Subscription{URL: "ftp://example.com", Events: []string{outbox.EventUserCreated}}},
+		{"no events", &Subscription{URL: "https://example.com"}},
+		{"unknown event", &Subscription{URL: "https://example.com", Events: []string{"user.renamed"}}},
+		{"short secret", &Subscription{URL: "https://example.com", Events: []string{outbox.EventUserCreated}, Secret: "short"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRepo := &MockRepository{}
+			service := newTestService(mockRepo)
+
+			// Act
+			err := service.CreateSubscription(tt.subscription)
+
+			// Assert
+			assert.Equal(t, apperror.CodeInvalidWebhook, err.(*apperror.AppError).Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_UpdateSubscription_KeepsSecret(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	existing := &Subscription{ID: 1, URL: "https://old.example.com", Secret: "whsec_existing", Events: []string{outbox.EventUserCreated}, Active: true, CreatedAt: time.Unix(1, 0)}
+	subscription := &Subscription{ID: 1, URL: "https://new.example.com", Events: []string{outbox.EventUserDeleted}, Active: false}
+
+	mockRepo.On("GetSubscriptionByID", 1).Return(existing, nil)
+	mockRepo.On("UpdateSubscription", existing).Return(nil)
+
+	// Act
+	err := service.UpdateSubscription(subscription)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "https://new.example.com", subscription.URL)
+	assert.Equal(t, []string{outbox.EventUserDeleted}, subscription.Events)
+	assert.False(t, subscription.Active)
+	assert.Equal(t, "whsec_existing", subscription.Secret)
+	assert.Equal(t, time.Unix(1, 0), subscription.CreatedAt)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_UpdateSubscription_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetSubscriptionByID", 1).Return(nil, nil)
+
+	// Act
+	err := service.UpdateSubscription(&Subscription{ID: 1, URL: "https://example.com", Events: []string{EventAll}})
+
+	// Assert
+	assert.Equal(t, apperror.CodeWebhookNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_DeleteSubscription_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetSubscriptionByID", 1).Return(&Subscription{ID: 1}, nil)
+	mockRepo.On("DeleteSubscription", 1).Return(nil)
+
+	// Act
+	err := service.DeleteSubscription(1)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeleteSubscription_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetSubscriptionByID", 1).Return(nil, nil)
+
+	// Act
+	err := service.DeleteSubscription(1)
+
+	// Assert
+	assert.Equal(t, apperror.CodeWebhookNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetDeliveries_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	deliveries := []Delivery{{ID: 2, SubscriptionID: 1, Status: DeliveryStatusDead}}
+
+	mockRepo.On("GetSubscriptionByID", 1).Return(&Subscription{ID: 1}, nil)
+	mockRepo.On("GetDeliveries", 1, DeliveryStatusDead, deliveryHistoryLimit).Return(deliveries, nil)
+
+	// Act
+	actual, err := service.GetDeliveries(1, DeliveryStatusDead)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, deliveries, actual)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Redeliver_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	delivery := &Delivery{ID: 2, SubscriptionID: 1, Status: DeliveryStatusDead, Attempts: 3}
+
+	mockRepo.On("GetDeliveryByID", 1, int64(2)).Return(delivery, nil)
+	mockRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Act
+	actual, err := service.Redeliver(1, 2)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryStatusPending, actual.Status)
+	assert.Equal(t, 0, actual.Attempts)
+	assert.WithinDuration(t, time.Now(), actual.NextAttemptAt, time.Second)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_Redeliver_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetDeliveryByID", 1, int64(2)).Return(nil, nil)
+
+	// Act
+	_, err := service.Redeliver(1, 2)
+
+	// Assert
+	assert.Equal(t, apperror.CodeWebhookDeliveryNotFound, err.(*apperror.AppError).Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Publish_QueuesMatchingSubscriptions(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	subscriptions := []Subscription{
+		{ID: 1, Events: []string{outbox.EventUserCreated}},
+		{ID: 2, Events: []string{outbox.EventUserDeleted}},
+		{ID: 3, Events: []string{EventAll}},
+	}
+	event := outbox.Event{
+		ID:        "0b6f3c1e-8d5a-4b8e-9f3a-2d1c0e9b8a7f",
+		Type:      outbox.EventUserCreated,
+		CreatedAt: time.Now(),
+		Data:      json.RawMessage(`{"id":7}`),
+	}
+
+	var queued []Delivery
+	mockRepo.On("GetActiveSubscriptions").Return(subscriptions, nil)
+	mockRepo.On("CreateDeliveries", mock.Anything).Run(func(args mock.Arguments) {
+		queued = args.Get(0).([]Delivery)
+	}).Return(nil)
+
+	// Act
+	err := service.Publish(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	assert.Equal(t, 1, queued[0].SubscriptionID)
+	assert.Equal(t, 3, queued[1].SubscriptionID)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(queued[0].Payload, &payload))
+	assert.Equal(t, event.ID, payload["id"])
+	assert.Equal(t, outbox.EventUserCreated, payload["type"])
+	assert.Equal(t, map[string]any{"id": float64(7)}, payload["data"])
+	for _, delivery := range queued {
+		assert.Equal(t, event.ID, delivery.EventID)
+		assert.Equal(t, outbox.EventUserCreated, delivery.EventType)
+		assert.Equal(t, DeliveryStatusPending, delivery.Status)
+	}
+	mockRepo.AssertExpectations(t)
+}
+func TestService_Publish_NoSubscribers(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetActiveSubscriptions").Return([]Subscription{{ID: 1, Events: []string{outbox.EventUserLogin}}}, nil)
+
+	// Act
+	err := service.Publish(outbox.Event{Type: outbox.EventUserCreated})
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateDeliveries", mock.Anything)
+}
+func TestService_Publish_RepositoryError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("GetActiveSubscriptions").Return(nil, assert.AnError)
+
+	// Act
+	err := service.Publish(outbox.Event{Type: outbox.EventUserCreated})
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_DeliverDue_Success(t *testing.T) {
+	// Arrange
+	var received *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	payload := []byte(`{"id":"c0ffee","type":"user.created"}`)
+	deliveries := []Delivery{{
+		ID:             9,
+		SubscriptionID: 1,
+		Subscription:   &Subscription{ID: 1, URL: receiver.URL, Secret: "mock-secret-value", Active: true},
+		EventID:        "c0ffee",
+		EventType:      outbox.EventUserCreated,
+		Payload:        payload,
+		Status:         DeliveryStatusPending,
+	}}
+
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, deliveryLease, deliveryBatchSize).Return(deliveries, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything).Return(nil)
+
+	// Act
+	count, err := service.DeliverDue()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NotNil(t, received)
+	assert.Equal(t, payload, receivedBody)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, outbox.EventUserCreated, received.Header.Get(EventHeader))
+	assert.Equal(t, "c0ffee", received.Header.Get(EventIDHeader))
+	assert.Equal(t, "9", received.Header.Get(DeliveryHeader))
+
+	signature := received.Header.Get(SignatureHeader)
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign("mock-secret-value", timestamp, payload), signature)
+
+	delivery := mockRepo.Calls[1].Arguments.Get(0).(*Delivery)
+	assert.Equal(t, DeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, *delivery.LastStatusCode)
+	assert.NotNil(t, delivery.DeliveredAt)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeliverDue_RetryWithBackoff(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	deliveries := []Delivery{{
+		ID:           9,
+		Subscription: &Subscription{URL: receiver.URL, Secret: "mock-secret-value", Active: true},
+		Status:       DeliveryStatusPending,
+		Attempts:     1,
+	}}
+
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, deliveryLease, deliveryBatchSize).Return(deliveries, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything).Return(nil)
+
+	// Act
+	_, err := service.DeliverDue()
+
+	// Assert
+	require.NoError(t, err)
+	delivery := mockRepo.Calls[1].Arguments.Get(0).(*Delivery)
+	assert.Equal(t, DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.LastStatusCode)
+	assert.Contains(t, *delivery.LastError, "500")
+	// the second failed attempt waits twice the base backoff
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), delivery.NextAttemptAt, 500*time.Millisecond)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeliverDue_DeadLetter(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer receiver.Close()
+
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	deliveries := []Delivery{{
+		ID:           9,
+		Subscription: &Subscription{URL: receiver.URL, Secret: "mock-secret-value", Active: true},
+		Status:       DeliveryStatusPending,
+		Attempts:     2,
+	}}
+
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, deliveryLease, deliveryBatchSize).Return(deliveries, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything).Return(nil)
+
+	// Act
+	_, err := service.DeliverDue()
+
+	// Assert
+	require.NoError(t, err)
+	delivery := mockRepo.Calls[1].Arguments.Get(0).(*Delivery)
+	assert.Equal(t, DeliveryStatusDead, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeliverDue_DisabledSubscription(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	deliveries := []Delivery{{ID: 9, Subscription: &Subscription{URL: "http://127.0.0.1:1", Active: false}, Status: DeliveryStatusPending}}
+
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, deliveryLease, deliveryBatchSize).Return(deliveries, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything).Return(nil)
+
+	// Act
+	_, err := service.DeliverDue()
+
+	// Assert
+	require.NoError(t, err)
+	delivery := mockRepo.Calls[1].Arguments.Get(0).(*Delivery)
+	assert.Equal(t, DeliveryStatusDead, delivery.Status)
+	assert.Equal(t, 0, delivery.Attempts)
+	assert.Equal(t, "webhook disabled", *delivery.LastError)
+	mockRepo.AssertExpectations(t)
+}
+func TestService_DeliverDue_ClaimError(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{}
+	service := newTestService(mockRepo)
+
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, deliveryLease, deliveryBatchSize).Return(nil, assert.AnError)
+
+	// Act
+	count, err := service.DeliverDue()
+
+	// Assert
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, 0, count)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_retryDelay_Capped(t *testing.T) {
+	// Arrange
+	service := &service{backoff: time.Minute}
+
+	// Act
+	first := service.retryDelay(1)
+	third := service.retryDelay(3)
+	last := service.retryDelay(20)
+
+	// Assert
+	assert.Equal(t, time.Minute, first)
+	assert.Equal(t, 4*time.Minute, third)
+	assert.Equal(t, maxBackoff, last)
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// Worker sends queued webhook deliveries and retries failed ones once their
+// backoff has passed.
+type Worker struct {
+	service  Service
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+func NewWorker(config *config.Config, service Service, logger *zap.Logger) *Worker {
+	return &Worker{
+		service:  service,
+		logger:   logger,
+		interval: config.WebhookPollInterval,
+	}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		w.logger.Info("webhook worker disabled")
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver drains the due deliveries batch by batch so a backlog does not have
+// to wait one interval per batch.
+func (w *Worker) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		count, err := w.service.DeliverDue()
+		if err != nil {
+			w.logger.Error("failed to deliver webhooks", zap.Error(err))
+			return
+		}
+		if count < deliveryBatchSize {
+			return
+		}
+	}
+}
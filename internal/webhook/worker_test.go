@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sninjo/vera-identity-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestWorker_NewWorker_Success(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	config := &config.Config{WebhookPollInterval: time.Second}
+	logger := zap.NewNop()
+
+	// Act
+	worker := NewWorker(config, mockService, logger)
+
+	// Assert
+	assert.Equal(t, mockService, worker.service)
+	assert.Equal(t, logger, worker.logger)
+	assert.Equal(t, time.Second, worker.interval)
+}
+
+func TestWorker_Run_Disabled(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	worker := NewWorker(&config.Config{}, mockService, zap.NewNop())
+
+	// Act
+	worker.Run(context.Background())
+
+	// Assert
+	mockService.AssertNotCalled(t, "DeliverDue")
+}
+func TestWorker_Run_DrainsFullBatches(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	worker := NewWorker(&config.Config{WebhookPollInterval: time.Hour}, mockService, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockService.On("DeliverDue").Return(deliveryBatchSize, nil).Twice()
+	mockService.On("DeliverDue").Return(1, nil).Once().Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(done)
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("webhook worker did not stop after the context was canceled")
+	}
+	mockService.AssertNumberOfCalls(t, "DeliverDue", 3)
+}
+func TestWorker_Run_ServiceError(t *testing.T) {
+	// Arrange
+	mockService := &MockService{}
+	worker := NewWorker(&config.Config{WebhookPollInterval: time.Hour}, mockService, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockService.On("DeliverDue").Return(0, assert.AnError).Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	// Act
+	worker.Run(ctx)
+
+	// Assert
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,3 @@
+-- Fails if two active users already share an email ignoring case; resolve
+-- those duplicates (soft-delete or rename) before applying.
+CREATE UNIQUE INDEX idx_users_email_lower ON users (lower(email)) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+  ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active',
+  ADD COLUMN status_reason VARCHAR(255),
+  ADD COLUMN suspended_until TIMESTAMPTZ;
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN attributes JSONB;
+
+CREATE TABLE attribute_definitions (
+  key VARCHAR(64) PRIMARY KEY,
+  type VARCHAR(16) NOT NULL,
+  description VARCHAR(255),
+  enum JSONB,
+  max_length INTEGER,
+  claim VARCHAR(64),
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_attribute_definitions_claim ON attribute_definitions (claim);
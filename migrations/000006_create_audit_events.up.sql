@@ -0,0 +1,18 @@
+CREATE TABLE audit_events (
+  id BIGSERIAL PRIMARY KEY,
+  type VARCHAR(64) NOT NULL,
+  outcome VARCHAR(16) NOT NULL,
+  reason VARCHAR(255),
+  actor_id INTEGER,
+  target_id INTEGER,
+  email VARCHAR(255),
+  ip VARCHAR(64) NOT NULL,
+  user_agent VARCHAR(512) NOT NULL,
+  metadata JSONB,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_audit_events_type ON audit_events(type);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_target_id ON audit_events(target_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
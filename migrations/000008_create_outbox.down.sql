@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_event;
+
+DROP TABLE IF EXISTS outbox;
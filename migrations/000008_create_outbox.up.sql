@@ -0,0 +1,15 @@
+CREATE TABLE outbox (
+  id BIGSERIAL PRIMARY KEY,
+  idempotency_key UUID NOT NULL,
+  type VARCHAR(64) NOT NULL,
+  data JSONB NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL,
+  last_error TEXT,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_outbox_idempotency_key ON outbox(idempotency_key);
+CREATE INDEX idx_outbox_next_attempt_at ON outbox(next_attempt_at);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_subscription_event ON webhook_deliveries(subscription_id, event_id);
@@ -0,0 +1,12 @@
+CREATE TABLE login_lockouts (
+  id SERIAL PRIMARY KEY,
+  kind VARCHAR(16) NOT NULL,
+  value VARCHAR(255) NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0,
+  locked_until TIMESTAMPTZ,
+  last_failure_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_login_lockouts_kind_value ON login_lockouts(kind, value);
+CREATE INDEX idx_login_lockouts_locked_until ON login_lockouts(locked_until);
@@ -0,0 +1,12 @@
+CREATE TABLE service_clients (
+  id SERIAL PRIMARY KEY,
+  client_id VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  secret_hash VARCHAR(64) NOT NULL,
+  scopes JSONB NOT NULL,
+  last_used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_service_clients_client_id ON service_clients(client_id);
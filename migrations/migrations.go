@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations, which are applied with the
+// migrate CLI (see the Makefile), so that the service can tell whether its
+// database is up to date.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -2,20 +2,31 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/sninjo/vera-identity-service/internal/app"
+	"github.com/sninjo/vera-identity-service/internal/apperror"
+	"github.com/sninjo/vera-identity-service/internal/attribute"
+	"github.com/sninjo/vera-identity-service/internal/audit"
 	"github.com/sninjo/vera-identity-service/internal/auth"
+	"github.com/sninjo/vera-identity-service/internal/client"
+	"github.com/sninjo/vera-identity-service/internal/lockout"
+	"github.com/sninjo/vera-identity-service/internal/outbox"
+	"github.com/sninjo/vera-identity-service/internal/pat"
 	"github.com/sninjo/vera-identity-service/internal/user"
+	"github.com/sninjo/vera-identity-service/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -48,6 +59,17 @@ func TestMain(m *testing.M) {
 		"ACCESS_TOKEN_SECRET":  "mock-access-token-secret",
 		"REFRESH_TOKEN_TTL":    "2h",
 		"REFRESH_TOKEN_SECRET": "mock-refresh-token-secret",
+
+		"OUTBOX_POLL_INTERVAL":  "50ms",
+		"WEBHOOK_POLL_INTERVAL": "50ms",
+
+		"RATE_LIMITS": "callback:ip=20/1m,refresh:ip=3/1m,verify:ip=600/1m,verify:user=300/1m",
+
+		"LOCKOUT_THRESHOLD": "3",
+
+		"INTROSPECTION_CLIENTS": "mock-gateway:mock-gateway-secret",
+
+		"FORWARD_AUTH_RETURN_HOSTS": ".example.com",
 	}
 	for key, value := range envs {
 		err = os.Setenv(key, value)
@@ -61,7 +83,7 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 
-	err = a.DB.AutoMigrate(&user.User{})
+	err = a.DB.AutoMigrate(&user.User{}, &attribute.Definition{}, &audit.Event{}, &webhook.Subscription{}, &webhook.Delivery{}, &outbox.Message{}, &lockout.Lockout{}, &client.Client{}, &pat.Token{})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -149,12 +171,14 @@ func TestAPI_AuthCallback_Success(t *testing.T) {
 	// Act
 	req, err := createTestRequest("GET", "/auth/callback?code="+oauthAPI.AuthorizationCode, nil, "")
 	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "login-1234")
 
 	w := httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
 
 	// Assert
 	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "login-1234", oauthAPI.LastRequestID)
 
 	location := w.Header().Get("Location")
 	u, err := url.Parse(location)
@@ -221,7 +245,7 @@ func TestAPI_AuthRefresh_Success(t *testing.T) {
 	}
 	err = a.DB.Create(&user).Error
 	require.NoError(t, err)
-	refreshToken, err := a.AuthService.NewRefreshToken(user.ID)
+	refreshToken, err := a.AuthService.NewRefreshToken(user.ID, "session-1")
 	require.NoError(t, err)
 
 	// Act
@@ -256,21 +280,88 @@ func TestAPI_AuthRefresh_Success(t *testing.T) {
 			IssuedAt:  actualClaims.IssuedAt,
 			ExpiresAt: actualClaims.ExpiresAt,
 		},
-		Name:    *user.Name,
-		Email:   user.Email,
-		Picture: *user.Picture,
+		Name:      *user.Name,
+		Email:     user.Email,
+		Picture:   *user.Picture,
+		SessionID: "session-1",
 	}
 	assert.Equal(t, expectedClaims, actualClaims)
 	assert.WithinDuration(t, time.Now(), actualClaims.IssuedAt.Time, time.Second)
 	assert.WithinDuration(t, time.Now().Add(a.Config.AccessTokenTTL), actualClaims.ExpiresAt.Time, time.Second)
 }
 
+func TestAPI_AuthRefresh_RateLimited(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	refresh := func() *httptest.ResponseRecorder {
+		req, err := createTestRequest("POST", "/auth/refresh", nil, "")
+		require.NoError(t, err)
+		req.RemoteAddr = "198.51.100.7:4321"
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "guessed-token"})
+
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		return w
+	}
+	for range 3 {
+		w := refresh()
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// Act
+	w := refresh()
+
+	// Assert
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeRateLimited)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+
+	var count int64
+	err = a.DB.Model(&audit.Event{}).Where("type = ?", audit.EventRefresh).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestAPI_AuthRefresh_RateLimitedForwardedFor(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	refresh := func(i int) *httptest.ResponseRecorder {
+		req, err := createTestRequest("POST", "/auth/refresh", nil, "")
+		require.NoError(t, err)
+		req.RemoteAddr = "198.51.100.8:4321"
+		// no proxy is trusted, so a new forwarded IP must not get a new bucket
+		req.Header.Set("X-Forwarded-For", "203.0.113."+strconv.Itoa(i))
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "guessed-token"})
+
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		return w
+	}
+	for i := range 3 {
+		w := refresh(i)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// Act
+	w := refresh(3)
+
+	// Assert
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeRateLimited)
+}
+
 func TestAPI_AuthVerify_Success(t *testing.T) {
 	// Arrange
 	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "")
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
 	require.NoError(t, err)
 
 	// Act
@@ -284,219 +375,1771 @@ func TestAPI_AuthVerify_Success(t *testing.T) {
 	require.Equal(t, http.StatusNoContent, w.Code)
 }
 
-func TestAPI_UsersGet_Success(t *testing.T) {
+func TestAPI_AuthVerify_ForwardAuth(t *testing.T) {
 	// Arrange
 	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	user1 := user.User{
-		ID:           1,
-		Name:         StringPtr("name1"),
-		Email:        "user1@example.com",
-		Picture:      StringPtr("https://example.com/picture1.jpg"),
-		LastLoginSub: StringPtr("sub1"),
-		LastLoginAt:  nil,
-		CreatedAt:    time.Unix(1, 0),
-		UpdatedAt:    time.Unix(1, 0),
-	}
-	user2 := user.User{
-		ID:           2,
-		Name:         StringPtr("name2"),
-		Email:        "user2@example.com",
-		Picture:      StringPtr("https://example.com/picture2.jpg"),
-		LastLoginSub: StringPtr("sub2"),
-		LastLoginAt:  TimePtr(time.Unix(2, 0)),
-		CreatedAt:    time.Unix(2, 0),
-		UpdatedAt:    time.Unix(2, 0),
-	}
-	err = a.DB.Create(&user1).Error
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "user@example.com", "", []string{"ops"}, nil)
 	require.NoError(t, err)
-	err = a.DB.Create(&user2).Error
+
+	// Act
+	req, err := http.NewRequest("GET", "/auth/verify?roles=admin,ops", nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: accessToken})
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1", w.Header().Get(auth.HeaderUserID))
+	assert.Equal(t, "user@example.com", w.Header().Get(auth.HeaderEmail))
+	assert.Equal(t, "ops", w.Header().Get(auth.HeaderRoles))
+}
+
+func TestAPI_AuthVerify_ForwardAuthMissingRole(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
 	require.NoError(t, err)
-	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "")
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", []string{"ops"}, nil)
 	require.NoError(t, err)
 
 	// Act
-	req, err := createTestRequest("GET", "/users", nil, accessToken)
+	req, err := http.NewRequest("GET", "/auth/verify?roles=admin", nil)
 	require.NoError(t, err)
+	req.Header.Set("Accept", "text/html")
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: accessToken})
 
 	w := httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
 
 	// Assert
-	fmt.Println(w.Body.String())
-	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get(auth.HeaderUserID))
+}
 
-	var actual []user.UserResponse
-	err = json.Unmarshal(w.Body.Bytes(), &actual)
+func TestAPI_AuthVerify_ForwardAuthRedirectsBrowser(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
 	require.NoError(t, err)
-	expected := []user.UserResponse{
-		{
-			ID:          user1.ID,
-			Name:        user1.Name,
-			Email:       user1.Email,
-			Picture:     user1.Picture,
-			LastLoginAt: nil,
-			CreatedAt:   user1.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   user1.UpdatedAt.Format(time.RFC3339),
-		},
-		{
-			ID:          user2.ID,
-			Name:        user2.Name,
-			Email:       user2.Email,
-			Picture:     user2.Picture,
-			LastLoginAt: StringPtr(user2.LastLoginAt.Format(time.RFC3339)),
-			CreatedAt:   user2.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   user2.UpdatedAt.Format(time.RFC3339),
-		},
-	}
-	assert.Equal(t, expected, actual)
+
+	// Act
+	req, err := http.NewRequest("GET", "/auth/verify", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "grafana.example.com")
+	req.Header.Set("X-Forwarded-Uri", "/d/1")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, a.Config.BaseURL+"/auth/login?return_to="+url.QueryEscape("https://grafana.example.com/d/1"), w.Header().Get("Location"))
 }
 
-func TestAPI_UsersPost_Success(t *testing.T) {
+func TestAPI_OAuth2Introspect_Success(t *testing.T) {
 	// Arrange
 	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "")
+	user := user.User{ID: 1, Email: "user@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&user).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(user.ID, "session-1", "", user.Email, "", user.Roles, nil)
 	require.NoError(t, err)
 
 	// Act
-	body := user.RequestBody{
-		Email: "new@example.com",
-	}
-	req, err := createTestRequest("POST", "/users", body, accessToken)
+	req, err := http.NewRequest("POST", "/oauth2/introspect", strings.NewReader(url.Values{"token": {accessToken}}.Encode()))
 	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("mock-gateway", "mock-gateway-secret")
 
 	w := httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
 
 	// Assert
-	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, http.StatusOK, w.Code)
 
-	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	var resp auth.IntrospectionResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	w = httptest.NewRecorder()
-	a.Router.ServeHTTP(w, req)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "1", resp.Subject)
+	assert.Equal(t, []string{"admin"}, resp.Roles)
+	assert.Equal(t, "session-1", resp.SessionID)
+}
 
-	var resp []user.UserResponse
-	err = json.Unmarshal(w.Body.Bytes(), &resp)
+func TestAPI_OAuth2Introspect_UserSuspended(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
 	require.NoError(t, err)
-	actual := resp[0]
-	expected := user.UserResponse{
-		ID:          1,
-		Name:        nil,
-		Email:       body.Email,
-		Picture:     nil,
-		LastLoginAt: nil,
-		CreatedAt:   actual.CreatedAt,
-		UpdatedAt:   actual.UpdatedAt,
-	}
-	assert.Equal(t, expected, actual)
-	actualCreatedAt, err := time.Parse(time.RFC3339, actual.CreatedAt)
+
+	user := user.User{ID: 1, Email: "user@example.com", Status: user.StatusSuspended}
+	err = a.DB.Create(&user).Error
 	require.NoError(t, err)
-	actualUpdatedAt, err := time.Parse(time.RFC3339, actual.UpdatedAt)
+	accessToken, err := a.AuthService.NewAccessToken(user.ID, "", "", user.Email, "", nil, nil)
 	require.NoError(t, err)
-	assert.WithinDuration(t, time.Now(), actualCreatedAt, time.Second)
-	assert.WithinDuration(t, time.Now(), actualUpdatedAt, time.Second)
+
+	// Act
+	req, err := http.NewRequest("POST", "/oauth2/introspect", strings.NewReader(url.Values{"token": {accessToken}}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("mock-gateway", "mock-gateway-secret")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
 }
 
-func TestAPI_UsersPatch_Success(t *testing.T) {
+func TestAPI_OAuth2Introspect_InvalidClient(t *testing.T) {
 	// Arrange
 	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	existingUser := user.User{
-		ID:           1,
-		Name:         StringPtr("name1"),
-		Email:        "user1@example.com",
-		Picture:      StringPtr("https://example.com/picture1.jpg"),
-		LastLoginSub: StringPtr("sub1"),
-		LastLoginAt:  nil,
-		CreatedAt:    time.Unix(1, 0),
-		UpdatedAt:    time.Unix(1, 0),
-	}
-	err = a.DB.Create(&existingUser).Error
+	// Act
+	req, err := http.NewRequest("POST", "/oauth2/introspect", strings.NewReader("token=anything"))
 	require.NoError(t, err)
-	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("mock-gateway", "wrong-secret")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInvalidClient)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAPI_OAuth2Token_ClientCredentials(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	// Act
-	body := user.RequestBody{
-		Email: "new@example.com",
-	}
-	req, err := createTestRequest("PATCH", "/users/1", body, accessToken)
+	admin := user.User{ID: 1, Email: "admin@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&admin).Error
+	require.NoError(t, err)
+	adminToken, err := a.AuthService.NewAccessToken(admin.ID, "", "", admin.Email, "", admin.Roles, nil)
 	require.NoError(t, err)
 
+	req, err := createTestRequest("POST", "/clients", map[string]any{"name": "Batch", "scopes": []string{user.ScopeUsersRead}}, adminToken)
+	require.NoError(t, err)
 	w := httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+
+	// Act
+	req, err = http.NewRequest("POST", "/oauth2/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(created.ClientID, created.ClientSecret)
+
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
 
 	// Assert
-	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, http.StatusOK, w.Code)
+	var token client.TokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &token)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, 3600, token.ExpiresIn)
+	assert.Equal(t, user.ScopeUsersRead, token.Scope)
 
-	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	// the token reads users, but may not change them
+	req, err = createTestRequest("GET", "/users", nil, token.AccessToken)
 	require.NoError(t, err)
 	w = httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	var resp []user.UserResponse
-	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	req, err = createTestRequest("POST", "/users", map[string]any{"email": "user@example.com"}, token.AccessToken)
 	require.NoError(t, err)
-	actual := resp[0]
-	expected := user.UserResponse{
-		ID:          existingUser.ID,
-		Name:        existingUser.Name,
-		Email:       body.Email,
-		Picture:     existingUser.Picture,
-		LastLoginAt: nil,
-		CreatedAt:   existingUser.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   actual.UpdatedAt,
-	}
-	assert.Equal(t, expected, actual)
-	actualUpdatedAt, err := time.Parse(time.RFC3339, actual.UpdatedAt)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInsufficientScope)
+
+	req, err = createTestRequest("GET", "/clients", nil, token.AccessToken)
 	require.NoError(t, err)
-	assert.WithinDuration(t, time.Now(), actualUpdatedAt, time.Second)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var event audit.Event
+	err = a.DB.Where("type = ?", audit.EventClientToken).Take(&event).Error
+	require.NoError(t, err)
+	assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+	assert.Equal(t, created.ClientID, event.Metadata["client_id"])
 }
 
-func TestAPI_UsersDelete_Success(t *testing.T) {
+func TestAPI_OAuth2Token_InvalidClient(t *testing.T) {
 	// Arrange
 	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	existingUser := user.User{
-		ID:           1,
-		Name:         StringPtr("name1"),
-		Email:        "user1@example.com",
-		Picture:      StringPtr("https://example.com/picture1.jpg"),
-		LastLoginSub: StringPtr("sub1"),
-		LastLoginAt:  nil,
-		CreatedAt:    time.Unix(1, 0),
-		UpdatedAt:    time.Unix(1, 0),
-	}
-	err = a.DB.Create(&existingUser).Error
+	// Act
+	req, err := http.NewRequest("POST", "/oauth2/token", strings.NewReader("grant_type=client_credentials"))
 	require.NoError(t, err)
-	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("svc_unknown", "csec_secret")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInvalidClient)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAPI_Clients_DeletedClient(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
 	require.NoError(t, err)
 
-	// Act
-	req, err := createTestRequest("DELETE", "/users/1", nil, accessToken)
+	admin := user.User{ID: 1, Email: "admin@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&admin).Error
+	require.NoError(t, err)
+	adminToken, err := a.AuthService.NewAccessToken(admin.ID, "", "", admin.Email, "", admin.Roles, nil)
 	require.NoError(t, err)
 
+	req, err := createTestRequest("POST", "/clients", map[string]any{"name": "Batch", "scopes": []string{user.ScopeUsersRead}}, adminToken)
+	require.NoError(t, err)
 	w := httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+	token, err := a.AuthService.NewClientAccessToken(created.ClientID, []string{user.ScopeUsersRead})
+	require.NoError(t, err)
 
-	// Assert
+	introspect := func() auth.IntrospectionResponse {
+		req, err := http.NewRequest("POST", "/oauth2/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(created.ClientID, created.ClientSecret)
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp auth.IntrospectionResponse
+		err = json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// registered clients introspect like the configured ones
+	assert.True(t, introspect().Active)
+
+	// Act
+	req, err = createTestRequest("DELETE", "/clients/"+created.ClientID, nil, adminToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
 	require.Equal(t, http.StatusNoContent, w.Code)
 
-	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	// Assert
+	req, err = createTestRequest("GET", "/users", nil, token)
 	require.NoError(t, err)
 	w = httptest.NewRecorder()
 	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInvalidClient)
 
-	var resp []user.UserResponse
-	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	req, err = http.NewRequest("POST", "/oauth2/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
 	require.NoError(t, err)
-	assert.Empty(t, resp)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("mock-gateway", "mock-gateway-secret")
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+}
+
+func TestAPI_MeTokens_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	admin := user.User{ID: 1, Email: "admin@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&admin).Error
+	require.NoError(t, err)
+	adminToken, err := a.AuthService.NewAccessToken(admin.ID, "", "", admin.Email, "", admin.Roles, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]any{"name": "CLI", "scopes": []string{user.ScopeUsersRead}, "expires_at": time.Now().Add(time.Hour).Format(time.RFC3339)}
+	req, err := createTestRequest("POST", "/me/tokens", body, adminToken)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created pat.CreatedTokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, "pat_"))
+
+	// the token reads users, but may neither change them nor use the admin role
+	req, err = createTestRequest("GET", "/users", nil, created.Token)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, err = createTestRequest("POST", "/users", map[string]any{"email": "user@example.com"}, created.Token)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInsufficientScope)
+
+	req, err = createTestRequest("GET", "/audit-events", nil, created.Token)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// nor manage tokens, which needs a login
+	req, err = createTestRequest("POST", "/me/tokens", body, created.Token)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req, err = createTestRequest("GET", "/me/tokens", nil, adminToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var tokens []pat.TokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &tokens)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, created.ID, tokens[0].ID)
+	assert.NotNil(t, tokens[0].LastUsedAt)
+	assert.NotContains(t, w.Body.String(), created.Token)
+
+	var event audit.Event
+	err = a.DB.Where("type = ?", audit.EventTokenCreate).Take(&event).Error
+	require.NoError(t, err)
+	require.NotNil(t, event.TargetID)
+	assert.Equal(t, admin.ID, *event.TargetID)
+}
+
+func TestAPI_MeTokensDelete_Revoked(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	u := user.User{ID: 1, Email: "user@example.com"}
+	err = a.DB.Create(&u).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(u.ID, "", "", u.Email, "", nil, nil)
+	require.NoError(t, err)
+
+	body := map[string]any{"name": "CLI", "scopes": []string{user.ScopeUsersRead}, "expires_at": time.Now().Add(time.Hour).Format(time.RFC3339)}
+	req, err := createTestRequest("POST", "/me/tokens", body, accessToken)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created pat.CreatedTokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+
+	// Act
+	req, err = createTestRequest("DELETE", "/me/tokens/"+strconv.Itoa(created.ID), nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req, err = createTestRequest("GET", "/users", nil, created.Token)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), apperror.CodeInvalidAccessToken)
+}
+
+func TestAPI_OAuth2Introspect_PersonalAccessToken(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	u := user.User{ID: 1, Email: "user@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&u).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(u.ID, "", "", u.Email, "", u.Roles, nil)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	body := map[string]any{"name": "CLI", "scopes": []string{user.ScopeUsersRead}, "expires_at": expiresAt.Format(time.RFC3339)}
+	req, err := createTestRequest("POST", "/me/tokens", body, accessToken)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created pat.CreatedTokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	require.NoError(t, err)
+
+	introspect := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/oauth2/introspect", strings.NewReader(url.Values{"token": {created.Token}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("mock-gateway", "mock-gateway-secret")
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	// Act
+	w = introspect()
+
+	// Assert
+	var resp auth.IntrospectionResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "1", resp.Subject)
+	assert.Equal(t, user.ScopeUsersRead, resp.Scope)
+	assert.Empty(t, resp.Roles)
+	assert.Equal(t, expiresAt.Unix(), resp.ExpiresAt)
+
+	// a revoked token is inactive
+	req, err = createTestRequest("DELETE", "/me/tokens/"+strconv.Itoa(created.ID), nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	assert.JSONEq(t, `{"active":false}`, introspect().Body.String())
+}
+
+func TestAPI_UsersGet_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	user1 := user.User{
+		ID:           1,
+		Name:         StringPtr("name1"),
+		Email:        "user1@example.com",
+		Picture:      StringPtr("https://example.com/picture1.jpg"),
+		LastLoginSub: StringPtr("sub1"),
+		LastLoginAt:  nil,
+		CreatedAt:    time.Unix(1, 0),
+		UpdatedAt:    time.Unix(1, 0),
+	}
+	user2 := user.User{
+		ID:           2,
+		Name:         StringPtr("name2"),
+		Email:        "user2@example.com",
+		Picture:      StringPtr("https://example.com/picture2.jpg"),
+		LastLoginSub: StringPtr("sub2"),
+		LastLoginAt:  TimePtr(time.Unix(2, 0)),
+		CreatedAt:    time.Unix(2, 0),
+		UpdatedAt:    time.Unix(2, 0),
+	}
+	err = a.DB.Create(&user1).Error
+	require.NoError(t, err)
+	err = a.DB.Create(&user2).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("GET", "/users", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	fmt.Println(w.Body.String())
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var actual []user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	expected := []user.UserResponse{
+		{
+			ID:          user1.ID,
+			Name:        user1.Name,
+			Email:       user1.Email,
+			Picture:     user1.Picture,
+			Status:      "active",
+			LastLoginAt: nil,
+			CreatedAt:   user1.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:   user1.UpdatedAt.Format(time.RFC3339),
+		},
+		{
+			ID:          user2.ID,
+			Name:        user2.Name,
+			Email:       user2.Email,
+			Picture:     user2.Picture,
+			Status:      "active",
+			LastLoginAt: StringPtr(user2.LastLoginAt.Format(time.RFC3339)),
+			CreatedAt:   user2.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:   user2.UpdatedAt.Format(time.RFC3339),
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestAPI_UsersPost_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := user.RequestBody{
+		Email: "new@example.com",
+	}
+	req, err := createTestRequest("POST", "/users", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	var resp []user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	actual := resp[0]
+	expected := user.UserResponse{
+		ID:          1,
+		Name:        nil,
+		Email:       body.Email,
+		Picture:     nil,
+		Status:      "active",
+		LastLoginAt: nil,
+		CreatedAt:   actual.CreatedAt,
+		UpdatedAt:   actual.UpdatedAt,
+	}
+	assert.Equal(t, expected, actual)
+	actualCreatedAt, err := time.Parse(time.RFC3339, actual.CreatedAt)
+	require.NoError(t, err)
+	actualUpdatedAt, err := time.Parse(time.RFC3339, actual.UpdatedAt)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), actualCreatedAt, time.Second)
+	assert.WithinDuration(t, time.Now(), actualUpdatedAt, time.Second)
+}
+
+func TestAPI_UsersPost_EmailInUseIgnoringCase(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := user.RequestBody{
+		Email: "User@Example.com",
+	}
+	req, err := createTestRequest("POST", "/users", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "409_01_009")
+}
+
+func TestAPI_UsersPost_InvalidRequestProblem(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users", map[string]any{}, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	assert.Equal(t, "/errors/400_01_029", problem["type"])
+	assert.Equal(t, "Validation failed", problem["title"])
+	assert.Equal(t, float64(http.StatusBadRequest), problem["status"])
+	assert.Equal(t, "request body validation failed | fields: email", problem["detail"])
+	assert.Equal(t, "/users", problem["instance"])
+	assert.Equal(t, "400_01_029", problem["code"])
+	assert.NotEmpty(t, problem["request_id"])
+	assert.Equal(t, []any{map[string]any{"field": "email", "rule": "email", "message": "email must be a valid email address"}}, problem["errors"])
+}
+func TestAPI_UsersPost_InvalidRequestLegacy(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users", map[string]any{}, accessToken)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var response map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "400_01_029", response["code"])
+	assert.Contains(t, response["message"], "request body validation failed")
+	assert.NotEmpty(t, response["timestamp"])
+	assert.NotContains(t, response, "type")
+}
+func TestAPI_UsersPost_InvalidRequestLocalized(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users", map[string]any{"email": "not-an-email"}, accessToken)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "zh-TW,zh;q=0.9,en;q=0.8")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var problem map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	assert.Equal(t, "email 必須是有效的電子郵件地址", problem["errors"].([]any)[0].(map[string]any)["message"])
+}
+
+func TestAPI_UnknownRoute_NotFound(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/unknown", nil, "")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "404_01_028")
+}
+
+func TestAPI_RequestID_Propagated(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/unknown", nil, "")
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "gateway-1234")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "gateway-1234", w.Header().Get("X-Request-ID"))
+
+	var problem map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	assert.Equal(t, "gateway-1234", problem["request_id"])
+}
+
+func TestAPI_Livez_Success(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/livez", nil, "")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestAPI_Readyz_Success(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/readyz", nil, "")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response["status"])
+	checks, ok := response["checks"].(map[string]any)
+	require.True(t, ok)
+	assert.Contains(t, checks, "database")
+	assert.Contains(t, checks, "keys")
+}
+
+func TestAPI_RequestID_FromTraceparent(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/healthz", nil, "")
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get("X-Request-ID"))
+}
+
+func TestAPI_Metrics_Success(t *testing.T) {
+	// Arrange
+	req, err := createTestRequest("GET", "/unknown", nil, "")
+	require.NoError(t, err)
+	a.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Act
+	req, err = createTestRequest("GET", "/metrics", nil, "")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `vera_identity_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.Contains(t, w.Body.String(), `go_sql_open_connections{db_name="identity"}`)
+}
+
+func TestAPI_ErrorsGet_Success(t *testing.T) {
+	// Act
+	req, err := createTestRequest("GET", "/errors/409_01_009", nil, "")
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "zh-TW")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "zh-Hant", w.Header().Get("Content-Language"))
+
+	var response map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "user_email_in_use", response["id"])
+	assert.Equal(t, "電子郵件已被使用", response["title"])
+}
+
+func TestAPI_UsersPatch_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{
+		ID:           1,
+		Name:         StringPtr("name1"),
+		Email:        "user1@example.com",
+		Picture:      StringPtr("https://example.com/picture1.jpg"),
+		LastLoginSub: StringPtr("sub1"),
+		LastLoginAt:  nil,
+		CreatedAt:    time.Unix(1, 0),
+		UpdatedAt:    time.Unix(1, 0),
+	}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := user.RequestBody{
+		Email: "new@example.com",
+	}
+	req, err := createTestRequest("PATCH", "/users/1", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	var resp []user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	actual := resp[0]
+	expected := user.UserResponse{
+		ID:          existingUser.ID,
+		Name:        existingUser.Name,
+		Email:       body.Email,
+		Picture:     existingUser.Picture,
+		Status:      "active",
+		LastLoginAt: nil,
+		CreatedAt:   existingUser.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   actual.UpdatedAt,
+	}
+	assert.Equal(t, expected, actual)
+	actualUpdatedAt, err := time.Parse(time.RFC3339, actual.UpdatedAt)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), actualUpdatedAt, time.Second)
+}
+
+func TestAPI_UsersDelete_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{
+		ID:           1,
+		Name:         StringPtr("name1"),
+		Email:        "user1@example.com",
+		Picture:      StringPtr("https://example.com/picture1.jpg"),
+		LastLoginSub: StringPtr("sub1"),
+		LastLoginAt:  nil,
+		CreatedAt:    time.Unix(1, 0),
+		UpdatedAt:    time.Unix(1, 0),
+	}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("DELETE", "/users/1", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	var resp []user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	assert.Empty(t, resp)
+}
+
+func TestAPI_UsersImport_RolesRequireAdmin(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "existing@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	payload := "email,name,roles\n" +
+		"new@example.com,New User,\n" +
+		"me@example.com,Me,admin\n" +
+		"existing@example.com,,\n" +
+		"not-an-email,,\n"
+	req, err := createTestRequest("POST", "/users/import", nil, accessToken)
+	require.NoError(t, err)
+	req.Body = io.NopCloser(strings.NewReader(payload))
+	req.Header.Set("Content-Type", "text/csv")
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var report user.ImportReportResponse
+	err = json.Unmarshal(w.Body.Bytes(), &report)
+	require.NoError(t, err)
+	assert.Equal(t, user.ImportReportResponse{
+		DryRun:  false,
+		Total:   4,
+		Created: 1,
+		Skipped: 1,
+		Invalid: 2,
+		Rows: []user.ImportRowResponse{
+			{Line: 2, Email: "new@example.com", Status: "created"},
+			{Line: 3, Email: "me@example.com", Status: "invalid", Reason: "roles require the admin role"},
+			{Line: 4, Email: "existing@example.com", Status: "skipped", Reason: "user email already in use"},
+			{Line: 5, Email: "not-an-email", Status: "invalid", Reason: "invalid email format"},
+		},
+	}, report)
+
+	var created user.User
+	err = a.DB.Where("email = ?", "new@example.com").First(&created).Error
+	require.NoError(t, err)
+	assert.Equal(t, "New User", *created.Name)
+	assert.Empty(t, created.Roles)
+
+	// the caller is no admin, so the row with roles is not imported
+	var count int64
+	err = a.DB.Model(&user.User{}).Where("email = ?", "me@example.com").Count(&count).Error
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestAPI_UsersExport_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{
+		ID:        1,
+		Name:      StringPtr("name1"),
+		Email:     "user1@example.com",
+		Roles:     []string{"admin"},
+		CreatedAt: time.Unix(1, 0),
+		UpdatedAt: time.Unix(1, 0),
+	}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("GET", "/users/export?format=ndjson", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var actual user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &actual)
+	require.NoError(t, err)
+	assert.Equal(t, existingUser.Email, actual.Email)
+	assert.Equal(t, existingUser.Roles, actual.Roles)
+}
+
+func TestAPI_UsersRestore_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	deletedUser := user.User{
+		ID:        1,
+		Email:     "user1@example.com",
+		DeletedAt: TimePtr(time.Unix(1, 0)),
+	}
+	err = a.DB.Create(&deletedUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users/1/restore", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req, err = createTestRequest("GET", "/users", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	var resp []user.UserResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp, 1)
+	assert.Equal(t, deletedUser.Email, resp[0].Email)
+}
+func TestAPI_UsersRestore_EmailInUse(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	deletedUser := user.User{ID: 1, Email: "user1@example.com", DeletedAt: TimePtr(time.Unix(1, 0))}
+	err = a.DB.Create(&deletedUser).Error
+	require.NoError(t, err)
+	activeUser := user.User{ID: 2, Email: "user1@example.com"}
+	err = a.DB.Create(&activeUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users/1/restore", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "409_01_009")
+}
+
+func TestAPI_UsersHardDelete_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	deletedUser := user.User{ID: 1, Email: "user1@example.com", DeletedAt: TimePtr(time.Unix(1, 0))}
+	err = a.DB.Create(&deletedUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("DELETE", "/users/1?hard=true", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	count := int64(0)
+	err = a.DB.Model(&user.User{}).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+func TestAPI_UsersHardDelete_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("DELETE", "/users/1?hard=true", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+}
+
+func TestAPI_UsersDelete_PrivilegedTarget(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("DELETE", "/users/1", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+
+	var count int64
+	err = a.DB.Model(&user.User{}).Where("id = ?", 1).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAPI_UsersPatch_PrivilegedTarget(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com", Roles: []string{"admin"}}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]string{"email": "attacker@example.com"}
+	req, err := createTestRequest("PATCH", "/users/1", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+
+	var actual user.User
+	err = a.DB.First(&actual, 1).Error
+	require.NoError(t, err)
+	assert.Equal(t, "user1@example.com", actual.Email)
+}
+
+func TestAPI_UsersRestore_PrivilegedTarget(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com", Roles: []string{"admin"}, DeletedAt: TimePtr(time.Unix(1, 0))}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users/1/restore", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+}
+
+func TestAPI_UsersSuspend_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+	refreshToken, err := a.AuthService.NewRefreshToken(existingUser.ID, "")
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]string{"reason": "policy violation"}
+	req, err := createTestRequest("POST", "/users/1/suspend", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	suspendedUser := user.User{}
+	err = a.DB.First(&suspendedUser, existingUser.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusSuspended, suspendedUser.Status)
+	assert.Equal(t, StringPtr("policy violation"), suspendedUser.StatusReason)
+
+	req, err = createTestRequest("POST", "/auth/refresh", nil, "")
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_015")
+}
+func TestAPI_UsersSuspend_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users/1/suspend", map[string]string{}, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+}
+func TestAPI_UsersReactivate_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	suspendedUser := user.User{ID: 1, Email: "user1@example.com", Status: user.StatusSuspended, StatusReason: StringPtr("policy violation")}
+	err = a.DB.Create(&suspendedUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/users/1/reactivate", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	reactivatedUser := user.User{}
+	err = a.DB.First(&reactivatedUser, suspendedUser.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusActive, reactivatedUser.Status)
+	assert.Nil(t, reactivatedUser.StatusReason)
+}
+
+func TestAPI_AttributesPost_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]any{"key": "department", "type": "string", "enum": []string{"engineering", "sales"}, "claim": "dept"}
+	req, err := createTestRequest("POST", "/attributes", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	req, err = createTestRequest("GET", "/attributes", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	var resp []attribute.DefinitionResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp, 1)
+	assert.Equal(t, "department", resp[0].Key)
+	assert.Equal(t, []string{"engineering", "sales"}, resp[0].Enum)
+	assert.Equal(t, StringPtr("dept"), resp[0].Claim)
+}
+func TestAPI_AttributesPost_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]any{"key": "department", "type": "string"}
+	req, err := createTestRequest("POST", "/attributes", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
+}
+func TestAPI_UsersPatch_Attributes(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	err = a.DB.Create(&[]attribute.Definition{
+		{Key: "department", Type: attribute.TypeString, Claim: StringPtr("dept")},
+		{Key: "employee_id", Type: attribute.TypeString},
+	}).Error
+	require.NoError(t, err)
+	existingUser := user.User{
+		ID:      1,
+		Name:    StringPtr("name"),
+		Email:   "user@example.com",
+		Picture: StringPtr("https://example.com/picture.jpg"),
+	}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]any{"attributes": map[string]any{"department": "engineering", "employee_id": "E-1"}}
+	req, err := createTestRequest("PATCH", "/users/1", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	updatedUser := user.User{}
+	err = a.DB.First(&updatedUser, existingUser.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, existingUser.Email, updatedUser.Email)
+	assert.Equal(t, map[string]any{"department": "engineering", "employee_id": "E-1"}, updatedUser.Attributes)
+
+	refreshToken, err := a.AuthService.NewRefreshToken(existingUser.ID, "")
+	require.NoError(t, err)
+	req, err = createTestRequest("POST", "/auth/refresh", nil, "")
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp auth.TokenResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	claims, err := a.AuthService.ParseAccessToken(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"dept": "engineering"}, claims.Attributes)
+}
+func TestAPI_UsersPatch_InvalidAttributes(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	body := map[string]any{"attributes": map[string]any{"department": "engineering"}}
+	req, err := createTestRequest("PATCH", "/users/1", body, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "400_01_018")
+}
+
+func TestAPI_UsersRolesPut_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("PUT", "/users/1/roles", map[string]any{"roles": []string{"Viewer"}}, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	updatedUser := user.User{}
+	err = a.DB.First(&updatedUser, existingUser.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, updatedUser.Roles)
+
+	event := audit.Event{}
+	err = a.DB.Where("type = ?", audit.EventUserRolesChange).First(&event).Error
+	require.NoError(t, err)
+	assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+	assert.Equal(t, 2, *event.ActorID)
+	assert.Equal(t, 1, *event.TargetID)
+	assert.Equal(t, map[string]any{"roles": []any{"Viewer"}}, event.Metadata)
+}
+
+func TestAPI_UsersRolesPut_LongUserAgent(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	existingUser := user.User{ID: 1, Email: "user1@example.com"}
+	err = a.DB.Create(&existingUser).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("PUT", "/users/1/roles", map[string]any{"roles": []string{"viewer"}}, accessToken)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", strings.Repeat("a", 2000))
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	event := audit.Event{}
+	err = a.DB.Where("type = ?", audit.EventUserRolesChange).First(&event).Error
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 512), event.UserAgent)
+}
+
+func TestAPI_AuditEventsGet_LoginFailure(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	oauthAPI := SetupOAuthAPI()
+	a.Config.OAuth2.Endpoint = oauth2.Endpoint{
+		TokenURL: oauthAPI.URL + "/token",
+	}
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	req, err := createTestRequest("GET", "/auth/callback?code="+oauthAPI.AuthorizationCode, nil, "")
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// Act
+	req, err = createTestRequest("GET", "/audit-events?type=auth.login&outcome=failure", nil, accessToken)
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp audit.EventPageResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, StringPtr("403_01_011"), resp.Events[0].Reason)
+	assert.Equal(t, StringPtr(oauthAPI.IDTokenClaims.Email), resp.Events[0].Email)
+	assert.Nil(t, resp.Events[0].ActorID)
+	assert.Nil(t, resp.NextCursor)
+}
+func TestAPI_AuditEventsGet_Pagination(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = a.DB.Create(&audit.Event{Type: audit.EventRefresh, Outcome: audit.OutcomeSuccess, CreatedAt: time.Unix(int64(i), 0)}).Error
+		require.NoError(t, err)
+	}
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	var ids []int64
+	cursor := ""
+	for {
+		req, err := createTestRequest("GET", "/audit-events?limit=2&cursor="+cursor, nil, accessToken)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp audit.EventPageResponse
+		err = json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		for _, event := range resp.Events {
+			ids = append(ids, event.ID)
+		}
+		if resp.NextCursor == nil {
+			break
+		}
+		cursor = *resp.NextCursor
+	}
+
+	// Assert
+	assert.Equal(t, []int64{3, 2, 1}, ids)
+}
+func TestAPI_AuditEventsGet_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("GET", "/audit-events", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAPI_AuthCallback_LockedOut(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	oauthAPI := SetupOAuthAPI()
+	a.Config.OAuth2.Endpoint = oauth2.Endpoint{
+		TokenURL: oauthAPI.URL + "/token",
+	}
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		req, err := createTestRequest("GET", "/auth/callback?code="+oauthAPI.AuthorizationCode, nil, "")
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		a.Router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+	}
+
+	// Act
+	req, err := createTestRequest("GET", "/auth/callback?code="+oauthAPI.AuthorizationCode, nil, "")
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusLocked, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	req, err = createTestRequest("GET", "/lockouts", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var lockouts []lockout.LockoutResponse
+	err = json.Unmarshal(w.Body.Bytes(), &lockouts)
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, lockout.KindEmail, lockouts[0].Kind)
+	assert.Equal(t, strings.ToLower(oauthAPI.IDTokenClaims.Email), lockouts[0].Value)
+	assert.Equal(t, 3, lockouts[0].Failures)
+
+	req, err = createTestRequest("GET", "/audit-events?type=auth.lockout", nil, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp audit.EventPageResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, StringPtr(oauthAPI.IDTokenClaims.Email), resp.Events[0].Email)
+}
+
+func TestAPI_LockoutsDelete_Success(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	lockedUntil := time.Now().Add(time.Hour)
+	err = a.DB.Create(&lockout.Lockout{Kind: lockout.KindEmail, Value: "user@example.com", Failures: 5, LockedUntil: &lockedUntil, LastFailureAt: time.Now(), CreatedAt: time.Now()}).Error
+	require.NoError(t, err)
+	accessToken, err := a.AuthService.NewAccessToken(2, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("DELETE", "/lockouts/email/User@Example.com", nil, accessToken)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	var count int64
+	err = a.DB.Model(&lockout.Lockout{}).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	var event audit.Event
+	err = a.DB.Where("type = ?", audit.EventLoginUnlock).Take(&event).Error
+	require.NoError(t, err)
+	assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+	assert.Equal(t, StringPtr("User@Example.com"), event.Email)
+}
+
+func TestAPI_LockoutsGet_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("GET", "/lockouts", nil, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAPI_Webhooks_DeliverUserCreated(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", []string{"admin"}, nil)
+	require.NoError(t, err)
+
+	req, err := createTestRequest("POST", "/webhooks", map[string]any{"url": receiver.URL, "events": []string{outbox.EventUserCreated}}, accessToken)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var subscription map[string]any
+	err = json.Unmarshal(w.Body.Bytes(), &subscription)
+	require.NoError(t, err)
+	secret := subscription["secret"].(string)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.OutboxDispatcher.Run(ctx)
+	go a.WebhookWorker.Run(ctx)
+
+	// Act
+	req, err = createTestRequest("POST", "/users", map[string]any{"email": "new@example.com"}, accessToken)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	// Assert
+	var r received
+	select {
+	case r = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	assert.Equal(t, outbox.EventUserCreated, r.header.Get(webhook.EventHeader))
+
+	signature := r.header.Get(webhook.SignatureHeader)
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign(secret, timestamp, r.body), signature)
+
+	var event map[string]any
+	err = json.Unmarshal(r.body, &event)
+	require.NoError(t, err)
+	assert.Equal(t, outbox.EventUserCreated, event["type"])
+	assert.Equal(t, "new@example.com", event["data"].(map[string]any)["email"])
+
+	assert.Eventually(t, func() bool {
+		delivery := webhook.Delivery{}
+		err := a.DB.First(&delivery).Error
+		return err == nil && delivery.Status == webhook.DeliveryStatusSucceeded
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestAPI_WebhooksPost_PermissionDenied(t *testing.T) {
+	// Arrange
+	err := CleanupTables(a.DB)
+	require.NoError(t, err)
+
+	accessToken, err := a.AuthService.NewAccessToken(1, "", "", "", "", nil, nil)
+	require.NoError(t, err)
+
+	// Act
+	req, err := createTestRequest("POST", "/webhooks", map[string]any{"url": "https://example.com", "events": []string{"*"}}, accessToken)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "403_01_014")
 }
 
 func TestAPI_AllURLs_Unauthorized(t *testing.T) {
@@ -507,8 +2150,31 @@ func TestAPI_AllURLs_Unauthorized(t *testing.T) {
 		{"POST", "/auth/verify"},
 		{"GET", "/users"},
 		{"POST", "/users"},
+		{"POST", "/users/import"},
+		{"GET", "/users/export"},
 		{"PATCH", "/users/1"},
 		{"DELETE", "/users/1"},
+		{"POST", "/users/1/restore"},
+		{"POST", "/users/1/suspend"},
+		{"POST", "/users/1/reactivate"},
+		{"GET", "/attributes"},
+		{"POST", "/attributes"},
+		{"PUT", "/attributes/locale"},
+		{"DELETE", "/attributes/locale"},
+		{"PUT", "/users/1/roles"},
+		{"GET", "/audit-events"},
+		{"GET", "/webhooks"},
+		{"POST", "/webhooks"},
+		{"GET", "/webhooks/1"},
+		{"PUT", "/webhooks/1"},
+		{"DELETE", "/webhooks/1"},
+		{"GET", "/webhooks/1/deliveries"},
+		{"POST", "/webhooks/1/deliveries/1/redeliver"},
+		{"GET", "/lockouts"},
+		{"DELETE", "/lockouts/email/user@example.com"},
+		{"GET", "/me/tokens"},
+		{"POST", "/me/tokens"},
+		{"DELETE", "/me/tokens/1"},
 	}
 
 	for _, tt := range tests {
@@ -530,6 +2196,7 @@ func TestAPI_AllURLs_Unauthorized(t *testing.T) {
 			// Assert
 			assert.Equal(t, http.StatusUnauthorized, w.Code)
 			assert.Contains(t, w.Body.String(), "invalid access token")
+			assert.NotContains(t, w.Body.String(), token)
 		})
 	}
 }
@@ -18,6 +18,9 @@ type OAuthAPI struct {
 	URL               string
 	AuthorizationCode string
 	IDTokenClaims     *IDTokenClaims
+	// X-Request-ID and traceparent headers of the last token request
+	LastRequestID   string
+	LastTraceparent string
 }
 
 func SetupOAuthAPI() *OAuthAPI {
@@ -29,8 +32,15 @@ func SetupOAuthAPI() *OAuthAPI {
 		Picture: "https://example.com/picture.png",
 	}
 
+	oauthAPI := &OAuthAPI{
+		AuthorizationCode: expectedCode,
+		IDTokenClaims:     claims,
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		oauthAPI.LastRequestID = r.Header.Get("X-Request-ID")
+		oauthAPI.LastTraceparent = r.Header.Get("traceparent")
 		err := r.ParseForm()
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -60,9 +70,6 @@ func SetupOAuthAPI() *OAuthAPI {
 	})
 
 	server := httptest.NewServer(mux)
-	return &OAuthAPI{
-		URL:               server.URL,
-		AuthorizationCode: expectedCode,
-		IDTokenClaims:     claims,
-	}
+	oauthAPI.URL = server.URL
+	return oauthAPI
 }